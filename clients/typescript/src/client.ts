@@ -0,0 +1,152 @@
+import type { Request, Response, StreamChunk } from "./types.js";
+
+export * from "./types.js";
+
+/** Error returned by the flow server or raised for a failed stream. */
+export class AgenticRAGError extends Error {
+  constructor(
+    message: string,
+    readonly status?: number,
+  ) {
+    super(message);
+    this.name = "AgenticRAGError";
+  }
+}
+
+export interface ClientOptions {
+  /** Base URL of the GenKit flow server, e.g. http://localhost:3400 */
+  baseUrl: string;
+  /** Headers sent with every request, e.g. Authorization */
+  headers?: Record<string, string>;
+  /** fetch implementation; defaults to the global fetch */
+  fetch?: typeof fetch;
+}
+
+export interface StreamOptions {
+  /**
+   * Called for every stream event (message, citation, kg-entity, verification, done, error), in
+   * sequence order and without duplicates across reconnects
+   */
+  onChunk?: (chunk: StreamChunk) => void;
+  /** Reconnect attempts after a dropped connection (default 3) */
+  maxRetries?: number;
+  signal?: AbortSignal;
+}
+
+/** Minimal client for the v1 agentic RAG flow endpoints. */
+export class AgenticRAGClient {
+  private readonly baseUrl: string;
+  private readonly headers: Record<string, string>;
+  private readonly fetchImpl: typeof fetch;
+
+  constructor(options: ClientOptions) {
+    this.baseUrl = options.baseUrl.replace(/\/+$/, "");
+    this.headers = options.headers ?? {};
+    this.fetchImpl = options.fetch ?? globalThis.fetch.bind(globalThis);
+  }
+
+  /** Answers a query and returns the full response. */
+  async query(request: Request, signal?: AbortSignal): Promise<Response> {
+    const response = await this.post("/agenticRAGV1", request, "application/json", signal);
+    const body = (await response.json()) as { result: Response };
+    return body.result;
+  }
+
+  /**
+   * Answers a query, passing answer tokens to onChunk as they are generated. A dropped connection
+   * is resumed from the last received chunk instead of restarting the pipeline.
+   */
+  async stream(request: Request, options: StreamOptions = {}): Promise<Response> {
+    const maxRetries = options.maxRetries ?? 3;
+    let resumeToken = request.resume_token ?? "";
+    let lastSequence = request.resume_after ?? 0;
+
+    for (let attempt = 0; ; attempt++) {
+      const payload: Request = resumeToken
+        ? { ...request, resume_token: resumeToken, resume_after: lastSequence }
+        : request;
+
+      try {
+        const response = await this.post("/agenticRAG", payload, "text/event-stream", options.signal);
+        for await (const event of readEvents(response)) {
+          if ("error" in event) {
+            throw new AgenticRAGError(event.error.details ?? event.error.message);
+          }
+          if ("result" in event) {
+            return event.result;
+          }
+          const chunk = event.message;
+          resumeToken = chunk.resume_token;
+          if (chunk.sequence <= lastSequence) {
+            continue;
+          }
+          lastSequence = chunk.sequence;
+          options.onChunk?.(chunk);
+          if (chunk.event === "error") {
+            throw new AgenticRAGError(chunk.error ?? "stream failed");
+          }
+          if (chunk.event === "done" && chunk.response) {
+            return chunk.response;
+          }
+        }
+        // The connection closed before the final event; treat it like a network failure and resume
+        throw new Error("stream ended before the final response");
+      } catch (error) {
+        const retryable = !(error instanceof AgenticRAGError) && !options.signal?.aborted;
+        if (!retryable || !resumeToken || attempt >= maxRetries) {
+          throw error;
+        }
+      }
+    }
+  }
+
+  private async post(path: string, data: Request, accept: string, signal?: AbortSignal): Promise<globalThis.Response> {
+    const response = await this.fetchImpl(this.baseUrl + path, {
+      method: "POST",
+      headers: { ...this.headers, "Content-Type": "application/json", Accept: accept },
+      body: JSON.stringify({ data }),
+      signal,
+    });
+    if (!response.ok) {
+      throw new AgenticRAGError(await response.text(), response.status);
+    }
+    return response;
+  }
+}
+
+type StreamEvent =
+  | { message: StreamChunk }
+  | { result: Response }
+  | { error: { status: string; message: string; details?: string } };
+
+/** Parses the server-sent events of a streaming flow response. */
+async function* readEvents(response: globalThis.Response): AsyncGenerator<StreamEvent> {
+  if (!response.body) {
+    throw new AgenticRAGError("streaming response has no body");
+  }
+  const reader = response.body.getReader();
+  const decoder = new TextDecoder();
+  let buffer = "";
+
+  for (;;) {
+    const { done, value } = await reader.read();
+    if (done) {
+      return;
+    }
+    buffer += decoder.decode(value, { stream: true });
+
+    let boundary: number;
+    while ((boundary = buffer.indexOf("\n\n")) >= 0) {
+      const event = buffer.slice(0, boundary);
+      buffer = buffer.slice(boundary + 2);
+      const data = event
+        .split("\n")
+        .filter((line) => line.startsWith("data:"))
+        .map((line) => line.slice(5).trimStart())
+        .join("\n");
+      if (data) {
+        yield JSON.parse(data) as StreamEvent;
+      }
+    }
+  }
+}
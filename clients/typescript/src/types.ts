@@ -0,0 +1,183 @@
+// Code generated by go run ./cmd/apigen. DO NOT EDIT.
+
+export const API_VERSION = "v1";
+
+export interface Blocklist {
+  document_ids?: string[];
+  source_patterns?: string[];
+  metadata?: Record<string, unknown>;
+}
+
+export interface Chunk {
+  id: string;
+  content: string;
+  document_id: string;
+  chunk_index: number;
+  start_index: number;
+  end_index: number;
+  relevance_score?: number;
+  metadata?: Record<string, unknown>;
+}
+
+export interface Citation {
+  number: number;
+  chunk_id: string;
+  document_id: string;
+  title?: string;
+  url?: string;
+  license?: string;
+  copyright?: string;
+  page?: number;
+  snippet?: string;
+  unsupported?: boolean;
+}
+
+export interface Claim {
+  text: string;
+  status: string;
+  confidence: number;
+  evidence?: string[];
+}
+
+export interface Degradation {
+  subsystem: string;
+  action: string;
+  error: string;
+}
+
+export interface Entity {
+  id: string;
+  name: string;
+  type: string;
+  properties?: Record<string, unknown>;
+  confidence: number;
+}
+
+export interface EntityCard {
+  entity: Entity;
+  definition: string;
+  relations?: Relation[];
+  key_facts?: string[];
+  sources: string[];
+}
+
+export interface FactVerification {
+  claims: Claim[];
+  overall: string;
+  metadata?: Record<string, unknown>;
+}
+
+export interface GenerationParams {
+  top_p?: number;
+  top_k?: number;
+  frequency_penalty?: number;
+  presence_penalty?: number;
+  stop_sequences?: string[];
+  safety_settings?: SafetySetting[];
+}
+
+export interface KnowledgeGraph {
+  entities: Entity[];
+  relations: Relation[];
+  metadata?: Record<string, unknown>;
+}
+
+export interface Metadata {
+  processing_time: number;
+  chunks_processed: number;
+  recursive_levels: number;
+  model_calls: number;
+  tokens_used: number;
+  degraded?: boolean;
+  cached?: boolean;
+  faq_entry_id?: string;
+  degradations?: Degradation[];
+  partial?: boolean;
+  timed_out_sources?: string[];
+}
+
+export interface Options {
+  profile?: string;
+  chunk_size?: number;
+  chunker?: string;
+  retrieval?: string;
+  max_chunks?: number;
+  recursive_depth?: number;
+  mmr_lambda?: number;
+  enable_knowledge_graph?: boolean;
+  enable_fact_verification?: boolean;
+  enable_citation_verification?: boolean;
+  temperature?: number;
+  generation?: GenerationParams;
+  persona?: string;
+  output_format?: string;
+  blocklist?: Blocklist;
+  metadata_filter?: Record<string, unknown>;
+  collections?: string[];
+  commercial_use?: boolean;
+  sign_answer?: boolean;
+  priority?: string;
+  include_embeddings?: boolean;
+  entity_mode?: boolean;
+}
+
+export interface ProcessedChunk {
+  chunk: Chunk;
+  entities?: Entity[];
+  relations?: Relation[];
+  metadata?: Record<string, unknown>;
+  embedding?: number[];
+  embedding_url?: string;
+  embedding_model?: string;
+}
+
+export interface Relation {
+  id: string;
+  subject: string;
+  predicate: string;
+  object: string;
+  properties?: Record<string, unknown>;
+  confidence: number;
+}
+
+export interface Request {
+  query: string;
+  documents?: string[];
+  user_id?: string;
+  tenant_id?: string;
+  session_id?: string;
+  checkpoint_id?: string;
+  resume_token?: string;
+  resume_after?: number;
+  filters?: Record<string, unknown>;
+  options?: Options;
+}
+
+export interface Response {
+  api_version?: string;
+  answer: string;
+  formatted_answer?: string;
+  citations?: Citation[];
+  relevant_chunks: ProcessedChunk[];
+  knowledge_graph?: KnowledgeGraph;
+  entity_card?: EntityCard;
+  fact_verification?: FactVerification;
+  processing_metadata: Metadata;
+}
+
+export interface SafetySetting {
+  category: string;
+  threshold: string;
+}
+
+export interface StreamChunk {
+  sequence: number;
+  resume_token: string;
+  event: string;
+  text?: string;
+  citation?: Citation;
+  entity?: Entity;
+  verification?: FactVerification;
+  response?: Response;
+  error?: string;
+}
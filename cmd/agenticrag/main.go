@@ -0,0 +1,419 @@
+// Command agenticrag provides command-line utilities for the agentic RAG plugin.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/plugin"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/plugins/googlegenai"
+)
+
+const usage = `Usage: agenticrag <command> [flags]
+
+Commands:
+  eval run     Score the pipeline against an eval dataset
+  eval tune    Sweep pipeline parameters against an eval dataset and write the best profile
+  cache prime  Replay the most frequent logged queries to fill a shared answer cache
+  faq generate Cluster logged queries and answer each cluster as a draft FAQ entry for review
+  monitoring generate
+               Write a Grafana dashboard and Prometheus alert rules for the exported metrics
+  doctor       Smoke test the model, embedder, vector store, and citations end to end
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the requested command
+func run(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "doctor" {
+		return runDoctor(ctx, args[1:])
+	}
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command")
+	}
+
+	switch args[0] + " " + args[1] {
+	case "eval run":
+		return runEval(ctx, args[2:])
+	case "eval tune":
+		return runTune(ctx, args[2:])
+	case "cache prime":
+		return runCachePrime(ctx, args[2:])
+	case "faq generate":
+		return runFAQGenerate(ctx, args[2:])
+	case "monitoring generate":
+		return runMonitoringGenerate(args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0]+" "+args[1])
+	}
+}
+
+// pipelineFlags configure the processor of every command that runs the pipeline
+type pipelineFlags struct {
+	model      string
+	embedder   string
+	promptsDir string
+}
+
+// register adds the pipeline flags to a flag set
+func (c *pipelineFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.model, "model", "googleai/gemini-2.5-flash", "model used by the pipeline")
+	fs.StringVar(&c.embedder, "embedder", "", "embedder (provider/name) used for similarity search")
+	fs.StringVar(&c.promptsDir, "prompts", "./prompts", "dotprompt directory")
+}
+
+// config initializes GenKit and a processor configuration from the pipeline flags
+func (c *pipelineFlags) config(ctx context.Context) (*plugin.AgenticRAGConfig, error) {
+	g, err := genkit.Init(ctx,
+		genkit.WithPlugins(&googlegenai.GoogleAI{}),
+		genkit.WithPromptDir(c.promptsDir),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GenKit: %w", err)
+	}
+
+	config := plugin.DefaultConfig()
+	config.Genkit = g
+	config.ModelName = c.model
+	config.EmbedderName = c.embedder
+	config.Prompts.Directory = c.promptsDir
+	return config, nil
+}
+
+// commonFlags are shared by the eval commands
+type commonFlags struct {
+	pipelineFlags
+	dataset string
+}
+
+// register adds the common flags to a flag set
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.dataset, "dataset", "", "path to the JSONL eval dataset (required)")
+	c.pipelineFlags.register(fs)
+}
+
+// newProcessor initializes GenKit and a processor from the common flags
+func (c *commonFlags) newProcessor(ctx context.Context) (*plugin.AgenticRAGProcessor, error) {
+	if c.dataset == "" {
+		return nil, fmt.Errorf("-dataset is required")
+	}
+	config, err := c.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return plugin.NewAgenticRAGProcessor(config), nil
+}
+
+// runEval implements "eval run"
+func runEval(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("eval run", flag.ExitOnError)
+	var common commonFlags
+	common.register(fs)
+	profile := fs.String("profile", "", "pipeline profile to evaluate")
+	export := fs.String("export", "", "write the retrieved chunks, scores, and embeddings to this Parquet file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	processor, err := common.newProcessor(ctx)
+	if err != nil {
+		return err
+	}
+	cases, err := plugin.LoadEvalDataset(common.dataset)
+	if err != nil {
+		return err
+	}
+
+	options := plugin.AgenticRAGOptions{Profile: *profile, IncludeEmbeddings: *export != "" && common.embedder != ""}
+	report, err := processor.Evaluate(ctx, cases, options)
+	if err != nil {
+		return err
+	}
+	if *export != "" {
+		if err := exportEvalChunks(*export, cases, report); err != nil {
+			return err
+		}
+	}
+	return printJSON(report)
+}
+
+// exportEvalChunks writes the chunks retrieved for each eval case to a Parquet file
+func exportEvalChunks(path string, cases []plugin.EvalCase, report *plugin.EvalReport) error {
+	records := make([]plugin.ChunkRecord, 0)
+	for i, result := range report.Cases {
+		if result.Response == nil {
+			continue
+		}
+		caseRecords, err := plugin.ChunkRecords(result.ID, cases[i].Query, result.Response)
+		if err != nil {
+			return err
+		}
+		records = append(records, caseRecords...)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	if err := plugin.WriteChunksParquet(file, records); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runTune implements "eval tune"
+func runTune(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("eval tune", flag.ExitOnError)
+	var common commonFlags
+	common.register(fs)
+	out := fs.String("out", "tuned_profile.json", "file the recommended profile is written to")
+	name := fs.String("name", "tuned", "name of the written profile")
+	gridPath := fs.String("grid", "", "JSON file with chunk_sizes, max_chunks, and recursive_depths (default: built-in grid)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	grid := plugin.DefaultTuningGrid()
+	if *gridPath != "" {
+		data, err := os.ReadFile(*gridPath)
+		if err != nil {
+			return fmt.Errorf("failed to read grid: %w", err)
+		}
+		if err := json.Unmarshal(data, &grid); err != nil {
+			return fmt.Errorf("failed to parse grid: %w", err)
+		}
+	}
+
+	processor, err := common.newProcessor(ctx)
+	if err != nil {
+		return err
+	}
+	cases, err := plugin.LoadEvalDataset(common.dataset)
+	if err != nil {
+		return err
+	}
+
+	result, err := processor.Tune(ctx, cases, grid, plugin.AgenticRAGOptions{})
+	if err != nil {
+		return err
+	}
+	if err := plugin.WriteProfile(*out, *name, result.Best); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote profile %q (score %.3f) to %s\n", *name, result.Score, *out)
+	return printJSON(result)
+}
+
+// runCachePrime implements "cache prime"
+func runCachePrime(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("cache prime", flag.ExitOnError)
+	var pipeline pipelineFlags
+	pipeline.register(fs)
+	fromLogs := fs.String("from-logs", "", "directory of audit logs written by the file audit sink (required)")
+	cacheDir := fs.String("cache-dir", "", "answer cache directory shared with the servers (required)")
+	top := fs.Int("top", 100, "number of most frequent queries to replay")
+	ttl := fs.Duration("ttl", 24*time.Hour, "how long primed answers are served")
+	requestPath := fs.String("request", "", "JSON request whose documents and options every query is replayed with (default: configured collections and options)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fromLogs == "" || *cacheDir == "" {
+		return fmt.Errorf("-from-logs and -cache-dir are required")
+	}
+
+	template, err := readRequestTemplate(*requestPath)
+	if err != nil {
+		return err
+	}
+
+	entries, err := plugin.ReadAuditLog(*fromLogs)
+	if err != nil {
+		return err
+	}
+	frequent := plugin.FrequentQueries(entries, *top)
+	queries := make([]string, len(frequent))
+	for i, frequency := range frequent {
+		queries[i] = frequency.Query
+	}
+
+	cache, err := plugin.NewFileAnswerCache(*cacheDir)
+	if err != nil {
+		return err
+	}
+	config, err := pipeline.config(ctx)
+	if err != nil {
+		return err
+	}
+	config.AnswerCache.Enabled = true
+	config.AnswerCache.TTL = *ttl
+	config.AnswerCache.Store = cache
+
+	report, err := plugin.NewAgenticRAGProcessor(config).PrimeCaches(ctx, queries, template)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "primed %d of %d queries (%d already cached, %d failed)\n", report.Primed, report.Queries, report.Cached, len(report.Failures))
+	return printJSON(report)
+}
+
+// runDoctor implements "doctor"
+func runDoctor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	var pipeline pipelineFlags
+	pipeline.register(fs)
+	requestPath := fs.String("request", "", "JSON request whose options the test query runs with (default: configured options)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	template, err := readRequestTemplate(*requestPath)
+	if err != nil {
+		return err
+	}
+	config, err := pipeline.config(ctx)
+	if err != nil {
+		return err
+	}
+
+	report := plugin.NewAgenticRAGProcessor(config).Doctor(ctx, template)
+	for _, check := range report.Checks {
+		fmt.Fprintf(os.Stderr, "%-4s  %-12s  %s\n", strings.ToUpper(check.Status), check.Name, check.Detail)
+	}
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("doctor checks failed")
+	}
+	return nil
+}
+
+// runFAQGenerate implements "faq generate"
+func runFAQGenerate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("faq generate", flag.ExitOnError)
+	var pipeline pipelineFlags
+	pipeline.register(fs)
+	fromLogs := fs.String("from-logs", "", "directory of audit logs written by the file audit sink (required)")
+	out := fs.String("out", "", "FAQ file to merge the generated drafts into (required)")
+	minFrequency := fs.Int("min-frequency", 2, "minimum times a cluster of queries was asked")
+	maxEntries := fs.Int("max-entries", 50, "maximum number of entries to generate")
+	requestPath := fs.String("request", "", "JSON request whose documents and options every question is answered with (default: configured collections and options)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *fromLogs == "" || *out == "" {
+		return fmt.Errorf("-from-logs and -out are required")
+	}
+
+	template, err := readRequestTemplate(*requestPath)
+	if err != nil {
+		return err
+	}
+	entries, err := plugin.ReadAuditLog(*fromLogs)
+	if err != nil {
+		return err
+	}
+
+	collection := &plugin.FAQCollection{}
+	if _, err := os.Stat(*out); err == nil {
+		if collection, err = plugin.LoadFAQ(*out); err != nil {
+			return err
+		}
+	}
+	config, err := pipeline.config(ctx)
+	if err != nil {
+		return err
+	}
+	generated, err := plugin.NewAgenticRAGProcessor(config).GenerateFAQ(ctx, plugin.FrequentQueries(entries, 0), plugin.FAQGenerationOptions{
+		Template:     template,
+		MinFrequency: *minFrequency,
+		MaxEntries:   *maxEntries,
+	})
+	if err != nil {
+		return err
+	}
+	collection.Merge(generated)
+	if err := collection.Save(*out); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "generated %d draft entries into %s; set status to %q to publish them\n", len(generated.Entries), *out, plugin.FAQApproved)
+	return nil
+}
+
+// runMonitoringGenerate implements "monitoring generate"
+func runMonitoringGenerate(args []string) error {
+	fs := flag.NewFlagSet("monitoring generate", flag.ExitOnError)
+	out := fs.String("out", ".", "directory the dashboard and alert rules are written to")
+	namespace := fs.String("namespace", "agentic_rag", "metrics namespace configured in metrics.namespace")
+	slo := fs.Float64("slo", 0.995, "availability objective for the error budget burn alerts")
+	errorRate := fs.Float64("error-rate", 0.05, "ratio of failed requests that fires the error rate alert")
+	latency := fs.Duration("latency-p95", 10*time.Second, "p95 request latency that fires the latency alert")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	options := plugin.MonitoringOptions{
+		Namespace:  *namespace,
+		SLOTarget:  *slo,
+		ErrorRate:  *errorRate,
+		LatencyP95: *latency,
+	}
+	dashboard, err := plugin.GrafanaDashboard(options)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", *out, err)
+	}
+	for name, data := range map[string][]byte{
+		"grafana-dashboard.json": dashboard,
+		"prometheus-alerts.yml":  plugin.PrometheusAlertRules(options),
+	} {
+		path := filepath.Join(*out, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+	}
+	return nil
+}
+
+// readRequestTemplate reads the request whose documents and options replayed queries run with
+func readRequestTemplate(path string) (plugin.AgenticRAGRequest, error) {
+	var template plugin.AgenticRAGRequest
+	if path == "" {
+		return template, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return template, fmt.Errorf("failed to read request: %w", err)
+	}
+	if err := json.Unmarshal(data, &template); err != nil {
+		return template, fmt.Errorf("failed to parse request: %w", err)
+	}
+	return template, nil
+}
+
+// printJSON writes a value to stdout as indented JSON
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
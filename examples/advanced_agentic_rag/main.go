@@ -18,7 +18,7 @@ func main() {
 	config := &plugin.AgenticRAGConfig{
 		ModelName: "googleai/gemini-2.5-flash",
 		Processing: plugin.ProcessingConfig{
-			DefaultChunkSize:      800, // Smaller chunks for better precision
+			DefaultChunkSize:      200, // Smaller chunks (in model tokens) for better precision
 			DefaultMaxChunks:      25,  // More chunks for comprehensive analysis
 			DefaultRecursiveDepth: 4,   // Deeper recursive analysis
 			RespectSentences:      true,
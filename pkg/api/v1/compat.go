@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Version is the API version written to the api_version field of v1 payloads
+const Version = "v1"
+
+// DecodeRequest decodes a request payload, including payloads written before versioning
+func DecodeRequest(data []byte) (*Request, error) {
+	var request Request
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("failed to decode v1 request: %w", err)
+	}
+	return &request, nil
+}
+
+// DecodeResponse decodes a response payload. Payloads without an api_version were written by
+// the unversioned API, whose encoding v1 is a superset of, and are stamped as v1.
+func DecodeResponse(data []byte) (*Response, error) {
+	var response Response
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode v1 response: %w", err)
+	}
+	switch response.APIVersion {
+	case "", Version:
+		response.APIVersion = Version
+	default:
+		return nil, fmt.Errorf("unsupported API version %q", response.APIVersion)
+	}
+	return &response, nil
+}
+
+// marshalWithExtra encodes value and merges in extra fields that it does not define itself
+func marshalWithExtra(value interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, raw := range extra {
+		if _, defined := fields[key]; !defined {
+			fields[key] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// unmarshalWithExtra decodes data into target and returns the fields target does not define
+func unmarshalWithExtra(data []byte, target interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, target); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range jsonFieldNames(reflect.TypeOf(target).Elem()) {
+		delete(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// jsonFieldNames returns the JSON names of the encoded fields of a struct type
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
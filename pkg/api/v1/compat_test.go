@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// roundTrip decodes a payload with decode and re-encodes it indented
+func roundTrip(t *testing.T, data []byte, decode func([]byte) (interface{}, error)) []byte {
+	t.Helper()
+	decoded, err := decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.MarshalIndent(decoded, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(encoded, '\n')
+}
+
+func decodeRequest(data []byte) (interface{}, error) {
+	return DecodeRequest(data)
+}
+
+func decodeResponse(data []byte) (interface{}, error) {
+	return DecodeResponse(data)
+}
+
+// TestGoldenRoundTrip decodes each payload in testdata and checks its re-encoding against the
+// golden file. The golden files are the v1 wire format: a change to them breaks stored payloads.
+func TestGoldenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func([]byte) (interface{}, error)
+	}{
+		{"request_full", decodeRequest},
+		{"request_minimal", decodeRequest},
+		{"response_full", decodeResponse},
+		{"response_unversioned", decodeResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", tt.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := roundTrip(t, input, tt.decode)
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("re-encoded payload differs from %s:\n%s", golden, got)
+			}
+
+			// The golden encoding is a fixed point, so repeated round trips keep payloads unchanged
+			if again := roundTrip(t, want, tt.decode); !bytes.Equal(again, want) {
+				t.Errorf("round trip of %s is not stable:\n%s", golden, again)
+			}
+		})
+	}
+}
+
+// TestUnknownFieldsPreserved checks that fields added by newer releases are kept in Extra at each
+// level that preserves them, rather than rejected or dropped
+func TestUnknownFieldsPreserved(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "request_full.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := DecodeRequest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExtra(t, request.Extra, "future_field", `["added","by","a","newer","release"]`)
+	assertExtra(t, request.Options.Extra, "future_option", `{"enabled":true}`)
+
+	data, err = os.ReadFile(filepath.Join("testdata", "response_full.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := DecodeResponse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExtra(t, response.Extra, "future_field", `{"nested":["value"]}`)
+	assertExtra(t, response.ProcessingMetadata.Extra, "future_metric", `7`)
+}
+
+// assertExtra checks that extra holds exactly the one field with the given compact encoding
+func assertExtra(t *testing.T, extra map[string]json.RawMessage, key, want string) {
+	t.Helper()
+	if len(extra) != 1 {
+		t.Fatalf("extra = %v, want only %s", extra, key)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, extra[key]); err != nil {
+		t.Fatal(err)
+	}
+	if compact.String() != want {
+		t.Errorf("extra[%s] = %s, want %s", key, compact.String(), want)
+	}
+}
+
+// TestOmittedOptionalFields checks that payloads without optional fields decode to zero values
+// and that zero values are not encoded
+func TestOmittedOptionalFields(t *testing.T) {
+	request, err := DecodeRequest([]byte(`{"query":"q"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*request, Request{Query: "q"}) {
+		t.Errorf("DecodeRequest() = %+v, want only the query", *request)
+	}
+
+	response, err := DecodeResponse([]byte(`{"answer":"a","relevant_chunks":null,"processing_metadata":{"processing_time":1000}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Response{APIVersion: Version, Answer: "a", ProcessingMetadata: Metadata{ProcessingTime: time.Microsecond}}
+	if !reflect.DeepEqual(*response, want) {
+		t.Errorf("DecodeResponse() = %+v, want %+v", *response, want)
+	}
+
+	encoded, err := json.Marshal(Response{APIVersion: Version, Answer: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEncoded := `{"api_version":"v1","answer":"a","relevant_chunks":null,"processing_metadata":{"processing_time":0,"chunks_processed":0,"recursive_levels":0,"model_calls":0,"tokens_used":0}}`
+	if string(encoded) != wantEncoded {
+		t.Errorf("json.Marshal() = %s, want %s", encoded, wantEncoded)
+	}
+}
+
+func TestDecodeResponseRejectsUnknownVersion(t *testing.T) {
+	if _, err := DecodeResponse([]byte(`{"api_version":"v2","answer":"a"}`)); err == nil {
+		t.Error("DecodeResponse() accepted api_version v2")
+	}
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Flow endpoints served by the GenKit flow server for the v1 API
+const (
+	QueryPath  = "/agenticRAGV1" // Request in, Response out
+	StreamPath = "/agenticRAG"   // Request in, StreamChunk events then the final Response
+)
+
+// Schemas returns the JSON Schemas of the v1 payload types by type name. References between them
+// use the "#/$defs/" prefix.
+func Schemas() map[string]*jsonschema.Schema {
+	reflector := &jsonschema.Reflector{AllowAdditionalProperties: true}
+
+	schemas := make(map[string]*jsonschema.Schema)
+	for _, value := range []any{&Request{}, &Response{}, &StreamChunk{}} {
+		for name, schema := range reflector.Reflect(value).Definitions {
+			schemas[name] = schema
+		}
+	}
+	return schemas
+}
+
+// OpenAPI returns the OpenAPI 3.1 description of the v1 flow endpoints. Schemas are reflected
+// from the Go types so the published spec cannot drift from the payloads the server accepts.
+func OpenAPI() ([]byte, error) {
+	schemas := Schemas()
+
+	ref := func(name string) map[string]any {
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	envelope := func(field string, schema map[string]any) map[string]any {
+		return map[string]any{
+			"type":       "object",
+			"properties": map[string]any{field: schema},
+			"required":   []string{field},
+		}
+	}
+	body := map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": envelope("data", ref("Request"))}},
+	}
+
+	spec := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Agentic RAG API",
+			"version":     Version,
+			"description": "GenKit flow endpoints of the agentic RAG plugin. Request bodies wrap the payload in \"data\"; JSON responses wrap it in \"result\".",
+		},
+		"paths": map[string]any{
+			QueryPath: map[string]any{
+				"post": map[string]any{
+					"operationId": "query",
+					"summary":     "Answer a query",
+					"requestBody": body,
+					"responses": map[string]any{
+						"200": map[string]any{
+							"description": "The answer",
+							"content":     map[string]any{"application/json": map[string]any{"schema": envelope("result", ref("Response"))}},
+						},
+					},
+				},
+			},
+			StreamPath: map[string]any{
+				"post": map[string]any{
+					"operationId": "stream",
+					"summary":     "Answer a query, streaming answer tokens as server-sent events",
+					"description": "Send Accept: text/event-stream. Each event is {\"message\": StreamChunk} until a final {\"result\": Response} or {\"error\": ...}. " +
+						"Resend the request with resume_token and resume_after to continue an interrupted stream.",
+					"requestBody": body,
+					"responses": map[string]any{
+						"200": map[string]any{
+							"description": "Server-sent events carrying stream chunks and the final response",
+							"content": map[string]any{"text/event-stream": map[string]any{"schema": map[string]any{
+								"oneOf": []any{envelope("message", ref("StreamChunk")), envelope("result", ref("Response"))},
+							}}},
+						},
+					},
+				},
+			},
+		},
+		"components": map[string]any{"schemas": schemas},
+	}
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	data = bytes.ReplaceAll(data, []byte(`"#/$defs/`), []byte(`"#/components/schemas/`))
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,113 @@
+// Package v1 defines the stable request and response payloads of the agentic RAG API.
+//
+// Fields are only ever added to these types; existing JSON names and encodings do not change
+// within v1. Unknown fields are preserved on decode and re-encoded unchanged, so payloads written
+// by newer releases survive a round trip through older ones.
+package v1
+
+import "encoding/json"
+
+// Request is a query against the agentic RAG pipeline
+type Request struct {
+	Query        string                 `json:"query"`
+	Documents    []string               `json:"documents,omitempty"` // URLs, file paths, or raw text
+	UserID       string                 `json:"user_id,omitempty"`
+	TenantID     string                 `json:"tenant_id,omitempty"`
+	SessionID    string                 `json:"session_id,omitempty"`
+	CheckpointID string                 `json:"checkpoint_id,omitempty"` // Saves intermediate state under this ID and resumes from it if present
+	ResumeToken  string                 `json:"resume_token,omitempty"`  // Continues an interrupted answer stream
+	ResumeAfter  int                    `json:"resume_after,omitempty"`  // Sequence number of the last stream chunk received
+	Filters      map[string]interface{} `json:"filters,omitempty"`       // Metadata filters: a value, a list of allowed values, or an object of gt/gte/lt/lte bounds
+	Options      Options                `json:"options,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Fields not defined by v1, preserved across round trips
+}
+
+// Options contains per-request processing options
+type Options struct {
+	Profile                    string                 `json:"profile,omitempty"`
+	ChunkSize                  int                    `json:"chunk_size,omitempty"`
+	Chunker                    string                 `json:"chunker,omitempty"`
+	Retrieval                  string                 `json:"retrieval,omitempty"`
+	MaxChunks                  int                    `json:"max_chunks,omitempty"`
+	RecursiveDepth             int                    `json:"recursive_depth,omitempty"`
+	MMRLambda                  *float64               `json:"mmr_lambda,omitempty"`
+	EnableKnowledgeGraph       bool                   `json:"enable_knowledge_graph,omitempty"`
+	EnableFactVerification     bool                   `json:"enable_fact_verification,omitempty"`
+	EnableCitationVerification bool                   `json:"enable_citation_verification,omitempty"`
+	Temperature                float32                `json:"temperature,omitempty"`
+	Generation                 *GenerationParams      `json:"generation,omitempty"`
+	Persona                    string                 `json:"persona,omitempty"`
+	OutputFormat               string                 `json:"output_format,omitempty"`
+	Blocklist                  *Blocklist             `json:"blocklist,omitempty"`
+	MetadataFilter             map[string]interface{} `json:"metadata_filter,omitempty"`
+	Collections                []string               `json:"collections,omitempty"`
+	CommercialUse              bool                   `json:"commercial_use,omitempty"`
+	SignAnswer                 bool                   `json:"sign_answer,omitempty"`
+	Priority                   string                 `json:"priority,omitempty"`
+	IncludeEmbeddings          bool                   `json:"include_embeddings,omitempty"`
+	EntityMode                 bool                   `json:"entity_mode,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Fields not defined by v1, preserved across round trips
+}
+
+// GenerationParams contains advanced sampling parameters passed through to the model provider
+type GenerationParams struct {
+	TopP             float64         `json:"top_p,omitempty"`
+	TopK             int             `json:"top_k,omitempty"`
+	FrequencyPenalty float64         `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64         `json:"presence_penalty,omitempty"`
+	StopSequences    []string        `json:"stop_sequences,omitempty"`
+	SafetySettings   []SafetySetting `json:"safety_settings,omitempty"`
+}
+
+// SafetySetting sets the blocking threshold for a harm category
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// Blocklist excludes documents from retrieval and citation
+type Blocklist struct {
+	DocumentIDs    []string               `json:"document_ids,omitempty"`
+	SourcePatterns []string               `json:"source_patterns,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the request together with its preserved extra fields
+func (r Request) MarshalJSON() ([]byte, error) {
+	type plain Request
+	return marshalWithExtra(plain(r), r.Extra)
+}
+
+// UnmarshalJSON decodes the request, keeping fields not defined by v1 in Extra
+func (r *Request) UnmarshalJSON(data []byte) error {
+	type plain Request
+	var decoded plain
+	extra, err := unmarshalWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*r = Request(decoded)
+	r.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the options together with their preserved extra fields
+func (o Options) MarshalJSON() ([]byte, error) {
+	type plain Options
+	return marshalWithExtra(plain(o), o.Extra)
+}
+
+// UnmarshalJSON decodes the options, keeping fields not defined by v1 in Extra
+func (o *Options) UnmarshalJSON(data []byte) error {
+	type plain Options
+	var decoded plain
+	extra, err := unmarshalWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*o = Options(decoded)
+	o.Extra = extra
+	return nil
+}
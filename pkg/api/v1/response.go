@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Response is the answer produced for a Request
+type Response struct {
+	APIVersion         string            `json:"api_version,omitempty"` // Version of the payload; empty for payloads written before versioning
+	Answer             string            `json:"answer"`
+	FormattedAnswer    string            `json:"formatted_answer,omitempty"`
+	Citations          []Citation        `json:"citations,omitempty"`
+	RelevantChunks     []ProcessedChunk  `json:"relevant_chunks"`
+	KnowledgeGraph     *KnowledgeGraph   `json:"knowledge_graph,omitempty"`
+	EntityCard         *EntityCard       `json:"entity_card,omitempty"`
+	FactVerification   *FactVerification `json:"fact_verification,omitempty"`
+	ProcessingMetadata Metadata          `json:"processing_metadata"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Fields not defined by v1, preserved across round trips
+}
+
+// StreamChunk is one event of a streamed answer; see docs/streaming-protocol.md
+type StreamChunk struct {
+	Sequence     int               `json:"sequence"`     // Position in the stream, starting at 1
+	ResumeToken  string            `json:"resume_token"` // Reconnect with this token and the last sequence to continue
+	Event        string            `json:"event"`        // message, citation, kg-entity, verification, done, or error
+	Text         string            `json:"text,omitempty"`
+	Citation     *Citation         `json:"citation,omitempty"`
+	Entity       *Entity           `json:"entity,omitempty"`
+	Verification *FactVerification `json:"verification,omitempty"`
+	Response     *Response         `json:"response,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// Citation links a numbered source reference in the answer to the chunk it came from
+type Citation struct {
+	Number      int    `json:"number"`
+	ChunkID     string `json:"chunk_id"`
+	DocumentID  string `json:"document_id"`
+	Title       string `json:"title,omitempty"`
+	URL         string `json:"url,omitempty"`
+	License     string `json:"license,omitempty"`
+	Copyright   string `json:"copyright,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	Snippet     string `json:"snippet,omitempty"`
+	Unsupported bool   `json:"unsupported,omitempty"`
+}
+
+// Chunk is a scored piece of a source document
+type Chunk struct {
+	ID             string                 `json:"id"`
+	Content        string                 `json:"content"`
+	DocumentID     string                 `json:"document_id"`
+	ChunkIndex     int                    `json:"chunk_index"`
+	StartIndex     int                    `json:"start_index"`
+	EndIndex       int                    `json:"end_index"`
+	RelevanceScore float64                `json:"relevance_score,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ProcessedChunk is a chunk used to generate the answer
+type ProcessedChunk struct {
+	Chunk          Chunk                  `json:"chunk"`
+	Entities       []Entity               `json:"entities,omitempty"`
+	Relations      []Relation             `json:"relations,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Embedding      []float32              `json:"embedding,omitempty"`
+	EmbeddingURL   string                 `json:"embedding_url,omitempty"`
+	EmbeddingModel string                 `json:"embedding_model,omitempty"`
+}
+
+// Entity is an entity extracted from the chunks
+type Entity struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	Confidence float64                `json:"confidence"`
+}
+
+// Relation is a relationship between two entities
+type Relation struct {
+	ID         string                 `json:"id"`
+	Subject    string                 `json:"subject"`
+	Predicate  string                 `json:"predicate"`
+	Object     string                 `json:"object"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	Confidence float64                `json:"confidence"`
+}
+
+// KnowledgeGraph is the graph built from the relevant chunks
+type KnowledgeGraph struct {
+	Entities  []Entity               `json:"entities"`
+	Relations []Relation             `json:"relations"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// EntityCard is an answer about a single knowledge graph entity
+type EntityCard struct {
+	Entity     Entity     `json:"entity"`
+	Definition string     `json:"definition"`
+	Relations  []Relation `json:"relations,omitempty"`
+	KeyFacts   []string   `json:"key_facts,omitempty"`
+	Sources    []string   `json:"sources"` // IDs of the chunks the card is built from
+}
+
+// FactVerification holds the verification results for the answer's claims
+type FactVerification struct {
+	Claims   []Claim                `json:"claims"`
+	Overall  string                 `json:"overall"` // "verified", "partially_verified", "unverified"
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Claim is a factual claim and its verification status
+type Claim struct {
+	Text       string   `json:"text"`
+	Status     string   `json:"status"` // "verified", "refuted", "inconclusive"
+	Confidence float64  `json:"confidence"`
+	Evidence   []string `json:"evidence,omitempty"`
+}
+
+// Degradation records a subsystem failure the request continued past, e.g. a vector store outage
+// answered from an in-memory fallback
+type Degradation struct {
+	Subsystem string `json:"subsystem"`
+	Action    string `json:"action"`
+	Error     string `json:"error"`
+}
+
+// Metadata describes how the response was produced
+type Metadata struct {
+	ProcessingTime  time.Duration `json:"processing_time"` // Encoded as nanoseconds
+	ChunksProcessed int           `json:"chunks_processed"`
+	RecursiveLevels int           `json:"recursive_levels"`
+	ModelCalls      int           `json:"model_calls"`
+	TokensUsed      int           `json:"tokens_used"`
+	Degraded        bool          `json:"degraded,omitempty"`
+	Cached          bool          `json:"cached,omitempty"`
+	FAQEntryID      string        `json:"faq_entry_id,omitempty"` // FAQ entry the answer was served from
+	Degradations    []Degradation `json:"degradations,omitempty"` // Subsystem failures the request continued past
+	Partial         bool          `json:"partial,omitempty"`      // Set when sources timed out and the answer uses the rest
+	TimedOutSources []string      `json:"timed_out_sources,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Fields not defined by v1, preserved across round trips
+}
+
+// MarshalJSON encodes the response together with its preserved extra fields
+func (r Response) MarshalJSON() ([]byte, error) {
+	type plain Response
+	return marshalWithExtra(plain(r), r.Extra)
+}
+
+// UnmarshalJSON decodes the response, keeping fields not defined by v1 in Extra
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type plain Response
+	var decoded plain
+	extra, err := unmarshalWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*r = Response(decoded)
+	r.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the metadata together with its preserved extra fields
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	type plain Metadata
+	return marshalWithExtra(plain(m), m.Extra)
+}
+
+// UnmarshalJSON decodes the metadata, keeping fields not defined by v1 in Extra
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	type plain Metadata
+	var decoded plain
+	extra, err := unmarshalWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*m = Metadata(decoded)
+	m.Extra = extra
+	return nil
+}
@@ -0,0 +1,91 @@
+// Package concurrency provides bounded goroutine groups that turn panics into errors, so a failing
+// or panicking task cancels its siblings instead of crashing the process or leaking them.
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// PanicError is the error a recovered panic is returned as
+type PanicError struct {
+	Value any    // Value passed to panic
+	Stack []byte // Stack of the panicking goroutine
+}
+
+// Error describes the panic value
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover runs fn, returning a panic in it as a *PanicError
+func Recover(fn func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &PanicError{Value: value, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Group runs tasks in goroutines, at most limit at once. The first task to fail or panic cancels
+// the group's context, and Wait returns its error.
+type Group struct {
+	group *errgroup.Group
+}
+
+// WithContext returns a group running at most limit tasks at once, or any number when limit is
+// not positive, and the context its tasks should use
+func WithContext(ctx context.Context, limit int) (*Group, context.Context) {
+	group, ctx := errgroup.WithContext(ctx)
+	if limit > 0 {
+		group.SetLimit(limit)
+	}
+	return &Group{group: group}, ctx
+}
+
+// Go runs fn in a goroutine once fewer than limit tasks are running, blocking until then
+func (g *Group) Go(fn func() error) {
+	g.group.Go(func() error {
+		return Recover(fn)
+	})
+}
+
+// Wait blocks until every task returned and returns the first error
+func (g *Group) Wait() error {
+	return g.group.Wait()
+}
+
+// Map runs fn on every item, at most limit at once, and returns the results in item order. The
+// first error cancels the remaining calls and is returned.
+func Map[T, R any](ctx context.Context, items []T, limit int, fn func(ctx context.Context, index int, item T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	group, ctx := WithContext(ctx, limit)
+	for i, item := range items {
+		group.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result, err := fn(ctx, i, item)
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Go runs fn in a goroutine and passes its error, or its panic as a *PanicError, to done
+func Go(fn func() error, done func(error)) {
+	go func() {
+		done(Recover(fn))
+	}()
+}
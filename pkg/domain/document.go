@@ -0,0 +1,10 @@
+// Package domain defines the core types and ports shared by the agentic RAG pipeline and its adapters.
+package domain
+
+// Document represents a document to be processed
+type Document struct {
+	ID       string                 `json:"id"`
+	Content  string                 `json:"content"`
+	Source   string                 `json:"source"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
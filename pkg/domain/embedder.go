@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// Embedder embeds texts as vectors for similarity search
+type Embedder interface {
+	// Embed returns one embedding per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
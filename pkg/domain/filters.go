@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Filters restricts retrieval to records whose metadata matches every entry. A scalar matches an
+// equal value or a list containing it, a list matches any of its values, and an object of range
+// operators ("gt", "gte", "lt", "lte") matches numbers or strings, such as RFC 3339 dates, within
+// the range. Records missing a filtered key never match.
+type Filters map[string]interface{}
+
+// filterOperators lists the supported range operators
+var filterOperators = map[string]bool{"gt": true, "gte": true, "lt": true, "lte": true}
+
+// Validate checks that every range filter uses supported operators on numbers or strings
+func (f Filters) Validate() error {
+	for key, value := range f {
+		ranges, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if len(ranges) == 0 {
+			return fmt.Errorf("filter %q has no operators", key)
+		}
+		for operator, bound := range ranges {
+			if !filterOperators[operator] {
+				return fmt.Errorf("filter %q has unsupported operator %q", key, operator)
+			}
+			if _, ok := orderedValue(bound); !ok {
+				return fmt.Errorf("filter %q bound %q must be a number or string", key, operator)
+			}
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the metadata satisfies every filter
+func (f Filters) Matches(metadata map[string]interface{}) bool {
+	for key, want := range f {
+		got, ok := metadata[key]
+		if !ok || !matchesFilter(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesFilter matches one metadata value, which may itself be a list such as tags
+func matchesFilter(got, want interface{}) bool {
+	if values, ok := listValues(got); ok {
+		for _, value := range values {
+			if matchesFilter(value, want) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ranges, ok := want.(map[string]interface{}); ok {
+		for operator, bound := range ranges {
+			order, ok := compareValues(got, bound)
+			if !ok {
+				return false
+			}
+			switch operator {
+			case "gt":
+				ok = order > 0
+			case "gte":
+				ok = order >= 0
+			case "lt":
+				ok = order < 0
+			case "lte":
+				ok = order <= 0
+			default:
+				ok = false
+			}
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	if options, ok := listValues(want); ok {
+		for _, option := range options {
+			if matchesFilter(got, option) {
+				return true
+			}
+		}
+		return false
+	}
+	if order, ok := compareValues(got, want); ok {
+		return order == 0
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// listValues returns the elements of a slice value
+func listValues(value interface{}) ([]interface{}, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	values := make([]interface{}, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
+
+// compareValues orders two numbers or two strings; times compare as RFC 3339 strings
+func compareValues(a, b interface{}) (int, bool) {
+	x, ok := orderedValue(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := orderedValue(b)
+	if !ok {
+		return 0, false
+	}
+	switch x := x.(type) {
+	case float64:
+		y, ok := y.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		y, ok := y.(string)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// orderedValue converts numbers to float64 and times to RFC 3339 strings
+func orderedValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"sync"
+)
+
+// DocumentLoader loads documents from the sources it recognizes
+type DocumentLoader interface {
+	CanLoad(source string) bool
+	Load(ctx context.Context, source string) ([]Document, error)
+}
+
+// LoaderRegistry holds named document loaders, tried in registration order
+type LoaderRegistry struct {
+	mu      sync.RWMutex
+	names   []string
+	loaders map[string]DocumentLoader
+}
+
+// NewLoaderRegistry creates an empty loader registry
+func NewLoaderRegistry() *LoaderRegistry {
+	return &LoaderRegistry{loaders: make(map[string]DocumentLoader)}
+}
+
+// Register adds a loader under a name; registering an existing name replaces it in place
+func (r *LoaderRegistry) Register(name string, loader DocumentLoader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.loaders[name]; !exists {
+		r.names = append(r.names, name)
+	}
+	r.loaders[name] = loader
+}
+
+// Unregister removes the named loader
+func (r *LoaderRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.loaders[name]; !exists {
+		return
+	}
+	delete(r.loaders, name)
+	for i, existing := range r.names {
+		if existing == name {
+			r.names = append(r.names[:i], r.names[i+1:]...)
+			break
+		}
+	}
+}
+
+// Names returns the registered loader names in the order they are tried
+func (r *LoaderRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Find returns the first registered loader that can load the source
+func (r *LoaderRegistry) Find(source string) (DocumentLoader, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, name := range r.names {
+		if loader := r.loaders[name]; loader.CanLoad(source) {
+			return loader, true
+		}
+	}
+	return nil, false
+}
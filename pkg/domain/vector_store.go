@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// VectorRecord is an embedded piece of content kept in a vector store
+type VectorRecord struct {
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content"`
+	Embedding []float32              `json:"embedding,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at,omitzero"` // When the source was last updated, if known
+	ExpiresAt time.Time              `json:"expires_at,omitzero"` // When Prune may remove the record; zero never expires
+}
+
+// SearchResult is a record matched by a similarity search
+type SearchResult struct {
+	Record VectorRecord `json:"record"`
+	Score  float64      `json:"score"` // Cosine similarity to the query embedding for Search; the store's own relevance for HybridSearch
+}
+
+// VectorStore stores embedded records and searches them by similarity
+type VectorStore interface {
+	// Store inserts records, replacing existing records with the same ID
+	Store(ctx context.Context, records []VectorRecord) error
+	// Search returns the k records most similar to the embedding whose metadata matches the
+	// filters, most similar first; nil filters match every record
+	Search(ctx context.Context, embedding []float32, k int, filters Filters) ([]SearchResult, error)
+	// Delete removes the records with the given IDs; unknown IDs are ignored
+	Delete(ctx context.Context, ids []string) error
+	// Prune removes the records whose expiry has passed and returns how many it removed. Expired
+	// records are still searched until pruned.
+	Prune(ctx context.Context) (int, error)
+	// WithCollection returns a view of the store scoped to a named collection, a logical index
+	// whose records are independent of other collections'. The empty name is the default collection.
+	WithCollection(collection string) VectorStore
+}
+
+// HybridSearcher is implemented by vector stores that rank records by keyword and vector
+// similarity together, so hybrid retrieval needs no separate keyword index
+type HybridSearcher interface {
+	// HybridSearch returns the k records whose content and embedding best match the query text
+	// and embedding and whose metadata matches the filters, best first. Scores are the store's
+	// fused relevance, which only orders one result set and is not a cosine similarity.
+	HybridSearch(ctx context.Context, query string, embedding []float32, k int, filters Filters) ([]SearchResult, error)
+}
+
+// EmbeddingBinding identifies the embedding space of a collection's records
+type EmbeddingBinding struct {
+	Embedder   string `json:"embedder"`   // Embedder model in "provider/name" form
+	Dimensions int    `json:"dimensions"` // Embedding dimensions
+}
+
+// EmbeddingBinder is implemented by vector stores that keep each collection's embedding binding
+// in collection metadata, so embeddings from different models are never compared. Their Store and
+// Search return an *EmbeddingMismatchError for embeddings of other dimensions than the binding's,
+// or from another embedder than the binding's when the context declares one with
+// WithEmbeddingBinding. A declared embedder binds a collection without a binding.
+type EmbeddingBinder interface {
+	// BindEmbedding binds a collection without a binding to the given one and returns the
+	// collection's binding, which differs from the given one when the collection was bound before
+	BindEmbedding(ctx context.Context, binding EmbeddingBinding) (EmbeddingBinding, error)
+}
+
+// embeddingBindingContextKey is the context key of the declared embedding binding
+type embeddingBindingContextKey struct{}
+
+// WithEmbeddingBinding returns a context declaring the embedder and dimensions of the embeddings
+// stored and searched with it
+func WithEmbeddingBinding(ctx context.Context, binding EmbeddingBinding) context.Context {
+	return context.WithValue(ctx, embeddingBindingContextKey{}, binding)
+}
+
+// EmbeddingBindingFrom returns the embedding binding the context declares, if any
+func EmbeddingBindingFrom(ctx context.Context) (EmbeddingBinding, bool) {
+	binding, ok := ctx.Value(embeddingBindingContextKey{}).(EmbeddingBinding)
+	return binding, ok
+}
+
+// EmbeddingMismatchError is returned when embeddings from one embedder are stored in or searched
+// against a collection bound to another embedder or dimensions
+type EmbeddingMismatchError struct {
+	Bound     EmbeddingBinding
+	Requested EmbeddingBinding
+}
+
+func (e *EmbeddingMismatchError) Error() string {
+	return fmt.Sprintf("collection is bound to embedder %s with %d dimensions, not %s with %d dimensions",
+		e.Bound.Embedder, e.Bound.Dimensions, e.Requested.Embedder, e.Requested.Dimensions)
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler returns an HTTP handler for operators inspecting a deployment. It serves
+// GET /flows, /tools, and /prompts, GET / with all three, GET /canary with the canary rollout state,
+// GET /diagnostics with a runtime snapshot, and, with admin.pprof set, the /debug/pprof/ profiles.
+// Mount it behind the deployment's own authentication or set admin.token_env, e.g.
+// mux.Handle("/admin/", http.StripPrefix("/admin", handler)). /diagnostics and /debug/pprof/ always
+// require the admin token and are refused while none is configured.
+func (p *AgenticRAGProcessor) AdminHandler() http.Handler {
+	protect := func(handler http.Handler) http.Handler { return handler }
+	if p.config.Admin.TokenEnv != "" {
+		protect = p.requireAdminToken
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /flows", protect(adminEndpoint(p.ListRegisteredFlows)))
+	mux.Handle("GET /tools", protect(adminEndpoint(p.ListRegisteredTools)))
+	mux.Handle("GET /prompts", protect(adminEndpoint(p.ListRegisteredPrompts)))
+	mux.Handle("GET /canary", protect(adminEndpoint(p.CanaryStatus)))
+	mux.Handle("GET /diagnostics", p.requireAdminToken(adminEndpoint(p.Diagnostics)))
+	if p.config.Admin.Pprof {
+		registerPprof(mux, p.requireAdminToken)
+	}
+	mux.Handle("GET /{$}", protect(adminEndpoint(func() (map[string][]ActionDescriptor, error) {
+		registered := make(map[string][]ActionDescriptor, 3)
+		for name, list := range map[string]func() ([]ActionDescriptor, error){
+			"flows":   p.ListRegisteredFlows,
+			"tools":   p.ListRegisteredTools,
+			"prompts": p.ListRegisteredPrompts,
+		} {
+			descriptors, err := list()
+			if err != nil {
+				return nil, err
+			}
+			registered[name] = descriptors
+		}
+		return registered, nil
+	})))
+	return mux
+}
+
+// adminEndpoint serves the result of fn as JSON
+func adminEndpoint[T any](fn func() (T, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := fn()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
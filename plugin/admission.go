@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Request priorities used for admission control
+const (
+	PriorityInteractive = "interactive"
+	PriorityBatch       = "batch"
+)
+
+// ErrOverloaded is returned when a request is shed because the admission queue is full
+var ErrOverloaded = errors.New("agentic RAG processor overloaded")
+
+// AdmissionConfig contains configuration for bounded admission and load shedding
+type AdmissionConfig struct {
+	Enabled            bool          `json:"enabled"`
+	MaxConcurrent      int           `json:"max_concurrent"`       // Requests processed at once
+	MaxQueueDepth      int           `json:"max_queue_depth"`      // Requests allowed to wait for a slot
+	BatchShedThreshold float64       `json:"batch_shed_threshold"` // Queue fill ratio at which batch requests are rejected
+	DegradeThreshold   float64       `json:"degrade_threshold"`    // Queue fill ratio at which admitted requests run a cheaper pipeline
+	QueueTimeout       time.Duration `json:"queue_timeout"`        // Maximum time a request waits for a slot
+	DegradeProfile     string        `json:"degrade_profile"`      // Pipeline profile used for degraded requests
+}
+
+// admissionController is a priority semaphore; interactive waiters are always served before batch waiters
+type admissionController struct {
+	mu      sync.Mutex
+	cfg     AdmissionConfig
+	metrics *Metrics
+	running int
+	waiting map[string]*list.List // priority -> queue of chan struct{}
+}
+
+// newAdmissionController creates an admission controller for the configuration
+func newAdmissionController(cfg AdmissionConfig, metrics *Metrics) *admissionController {
+	return &admissionController{
+		cfg:     cfg,
+		metrics: metrics,
+		waiting: map[string]*list.List{
+			PriorityInteractive: list.New(),
+			PriorityBatch:       list.New(),
+		},
+	}
+}
+
+// depth returns the number of waiting requests; the caller must hold the lock
+func (a *admissionController) depth() int {
+	return a.waiting[PriorityInteractive].Len() + a.waiting[PriorityBatch].Len()
+}
+
+// acquire admits a request, returning a release function and whether the request should degrade
+func (a *admissionController) acquire(ctx context.Context, priority string) (func(), bool, error) {
+	if priority != PriorityBatch {
+		priority = PriorityInteractive
+	}
+
+	a.mu.Lock()
+	depth := a.depth()
+	if a.running < a.cfg.MaxConcurrent && depth == 0 {
+		a.running++
+		a.mu.Unlock()
+		return a.release, false, nil
+	}
+
+	fill := 1.0
+	if a.cfg.MaxQueueDepth > 0 {
+		fill = float64(depth) / float64(a.cfg.MaxQueueDepth)
+	}
+	if depth >= a.cfg.MaxQueueDepth || (priority == PriorityBatch && fill >= a.cfg.BatchShedThreshold) {
+		a.mu.Unlock()
+		a.metrics.IncCounter("agentic_rag_requests_shed_total", 1)
+		return nil, false, fmt.Errorf("%w: %d requests queued, %s request rejected", ErrOverloaded, depth, priority)
+	}
+	degraded := fill >= a.cfg.DegradeThreshold
+
+	ready := make(chan struct{})
+	element := a.waiting[priority].PushBack(ready)
+	a.metrics.SetGauge("agentic_rag_queue_depth", float64(a.depth()))
+	a.mu.Unlock()
+
+	timeout := a.cfg.QueueTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return a.release, degraded, nil
+	case <-ctx.Done():
+		return nil, false, a.abandon(priority, element, ready, ctx.Err())
+	case <-timer.C:
+		a.metrics.IncCounter("agentic_rag_requests_shed_total", 1)
+		return nil, false, a.abandon(priority, element, ready, fmt.Errorf("%w: timed out waiting %s for a slot", ErrOverloaded, timeout))
+	}
+}
+
+// abandon removes a waiter that gave up, handing its slot on if it was granted concurrently
+func (a *admissionController) abandon(priority string, element *list.Element, ready chan struct{}, err error) error {
+	a.mu.Lock()
+	select {
+	case <-ready:
+		a.mu.Unlock()
+		a.release()
+	default:
+		a.waiting[priority].Remove(element)
+		a.metrics.SetGauge("agentic_rag_queue_depth", float64(a.depth()))
+		a.mu.Unlock()
+	}
+	return err
+}
+
+// release frees a slot, passing it directly to the highest-priority waiter
+func (a *admissionController) release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, priority := range []string{PriorityInteractive, PriorityBatch} {
+		if front := a.waiting[priority].Front(); front != nil {
+			a.waiting[priority].Remove(front)
+			close(front.Value.(chan struct{}))
+			a.metrics.SetGauge("agentic_rag_queue_depth", float64(a.depth()))
+			return
+		}
+	}
+	a.running--
+}
+
+// degradeOptions switches a request to a cheaper pipeline while the processor is under load
+func (p *AgenticRAGProcessor) degradeOptions(options AgenticRAGOptions) AgenticRAGOptions {
+	if profile, ok := p.config.Profiles[p.config.Admission.DegradeProfile]; ok {
+		options = profile.apply(options, true)
+		options.Profile = p.config.Admission.DegradeProfile
+		return options
+	}
+
+	options.RecursiveDepth = 1
+	if options.MaxChunks > 10 {
+		options.MaxChunks = 10
+	}
+	options.EnableKnowledgeGraph = false
+	options.EnableFactVerification = false
+	options.EnableCitationVerification = false
+	return options
+}
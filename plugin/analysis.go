@@ -0,0 +1,253 @@
+package plugin
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// LanguageAnalyzerConfig describes how text in a single language is analyzed
+type LanguageAnalyzerConfig struct {
+	SentencePattern string   `json:"sentence_pattern,omitempty"` // Regex used to split sentences
+	Stopwords       []string `json:"stopwords,omitempty"`        // Words ignored during lexical scoring
+	Stemmer         string   `json:"stemmer,omitempty"`          // Stemmer name ("none" or a language code with built-in suffix rules)
+	EmbedderName    string   `json:"embedder_name,omitempty"`    // Embedding model override for this language
+}
+
+// AnalysisConfig contains multilingual analysis configuration
+type AnalysisConfig struct {
+	DetectLanguage  bool                              `json:"detect_language"`     // Whether to detect document and query languages
+	DefaultLanguage string                            `json:"default_language"`    // Language used when detection is disabled or inconclusive
+	Languages       map[string]LanguageAnalyzerConfig `json:"languages,omitempty"` // Per-language overrides keyed by ISO 639-1 code
+}
+
+// languageAnalyzer is the compiled form of a LanguageAnalyzerConfig
+type languageAnalyzer struct {
+	language     string
+	sentenceExpr *regexp.Regexp
+	stopwords    map[string]struct{}
+	suffixes     []string
+	embedderName string
+}
+
+// defaultSentencePattern is used for languages that separate sentences with whitespace
+const defaultSentencePattern = `[.!?]+\s+`
+
+// builtinSentencePatterns covers scripts that do not put whitespace after terminators
+var builtinSentencePatterns = map[string]string{
+	"zh": `[。！？]+\s*|[.!?]+\s+`,
+	"ja": `[。！？]+\s*|[.!?]+\s+`,
+	"ar": `[.!?؟]+\s+`,
+	"hi": `[।.!?]+\s+`,
+}
+
+// builtinStopwords contains small, high-frequency stopword lists used for both scoring and detection
+var builtinStopwords = map[string][]string{
+	"en": {"the", "a", "an", "and", "or", "of", "to", "in", "is", "are", "was", "were", "for", "on", "with", "that", "this", "it", "as", "by", "what", "how", "be"},
+	"es": {"el", "la", "los", "las", "un", "una", "y", "o", "de", "del", "en", "es", "son", "que", "por", "para", "con", "como", "se", "al"},
+	"fr": {"le", "la", "les", "un", "une", "et", "ou", "de", "des", "du", "en", "est", "sont", "que", "pour", "avec", "dans", "ce", "qui", "au"},
+	"de": {"der", "die", "das", "ein", "eine", "und", "oder", "von", "zu", "in", "ist", "sind", "mit", "für", "auf", "den", "dem", "nicht", "wie", "was"},
+	"pt": {"o", "a", "os", "as", "um", "uma", "e", "ou", "de", "do", "da", "em", "é", "são", "que", "para", "com", "por", "no", "na"},
+	"it": {"il", "lo", "la", "i", "gli", "le", "un", "una", "e", "o", "di", "del", "in", "è", "sono", "che", "per", "con", "come", "non"},
+}
+
+// builtinSuffixes are light stemming rules, longest suffix first
+var builtinSuffixes = map[string][]string{
+	"en": {"ational", "ization", "fulness", "ousness", "iveness", "ingly", "ement", "ments", "ness", "ment", "ing", "ies", "ed", "ly", "es", "s"},
+	"es": {"amientos", "imientos", "aciones", "amiento", "imiento", "ación", "mente", "ando", "iendo", "idad", "es", "os", "as", "s"},
+	"fr": {"issements", "issement", "ations", "ation", "ement", "ments", "ment", "ités", "ité", "es", "s", "e"},
+	"de": {"ungen", "heit", "keit", "lich", "isch", "ung", "en", "er", "es", "e", "n", "s"},
+	"pt": {"amentos", "imentos", "ações", "amento", "imento", "ação", "mente", "idade", "es", "os", "as", "s"},
+	"it": {"amenti", "imenti", "azioni", "amento", "imento", "azione", "mente", "ità", "i", "e", "o", "a"},
+}
+
+// newLanguageAnalyzer compiles the analyzer for the given language, layering config overrides on built-ins
+func newLanguageAnalyzer(language string, cfg LanguageAnalyzerConfig) *languageAnalyzer {
+	pattern := cfg.SentencePattern
+	if pattern == "" {
+		pattern = builtinSentencePatterns[language]
+	}
+	if pattern == "" {
+		pattern = defaultSentencePattern
+	}
+	expr, err := regexp.Compile(pattern)
+	if err != nil {
+		expr = regexp.MustCompile(defaultSentencePattern)
+	}
+
+	words := cfg.Stopwords
+	if words == nil {
+		words = builtinStopwords[language]
+	}
+	stopwords := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		stopwords[strings.ToLower(word)] = struct{}{}
+	}
+
+	stemmer := cfg.Stemmer
+	if stemmer == "" {
+		stemmer = language
+	}
+
+	return &languageAnalyzer{
+		language:     language,
+		sentenceExpr: expr,
+		stopwords:    stopwords,
+		suffixes:     builtinSuffixes[stemmer],
+		embedderName: cfg.EmbedderName,
+	}
+}
+
+// analyzerFor returns the analyzer for a language, caching compiled analyzers on the processor
+func (p *AgenticRAGProcessor) analyzerFor(language string) *languageAnalyzer {
+	if language == "" {
+		language = p.config.Analysis.DefaultLanguage
+	}
+
+	p.analyzersMu.Lock()
+	defer p.analyzersMu.Unlock()
+
+	if analyzer, ok := p.analyzers[language]; ok {
+		return analyzer
+	}
+	analyzer := newLanguageAnalyzer(language, p.config.Analysis.Languages[language])
+	p.analyzers[language] = analyzer
+	return analyzer
+}
+
+// detectLanguage guesses the language of a text from its script and stopword frequency
+func (p *AgenticRAGProcessor) detectLanguage(text string) string {
+	if !p.config.Analysis.DetectLanguage {
+		return p.config.Analysis.DefaultLanguage
+	}
+
+	if language := detectScript(text); language != "" {
+		return language
+	}
+
+	counts := make(map[string]int)
+	for _, token := range tokenize(text) {
+		for language := range p.candidateLanguages() {
+			if _, ok := p.analyzerFor(language).stopwords[token]; ok {
+				counts[language]++
+			}
+		}
+	}
+
+	best, bestCount := p.config.Analysis.DefaultLanguage, 0
+	for language, count := range counts {
+		if count > bestCount || (count == bestCount && language < best) {
+			best, bestCount = language, count
+		}
+	}
+	return best
+}
+
+// candidateLanguages returns every language with either a built-in or configured analyzer
+func (p *AgenticRAGProcessor) candidateLanguages() map[string]struct{} {
+	languages := make(map[string]struct{}, len(builtinStopwords)+len(p.config.Analysis.Languages))
+	for language := range builtinStopwords {
+		languages[language] = struct{}{}
+	}
+	for language := range p.config.Analysis.Languages {
+		languages[language] = struct{}{}
+	}
+	return languages
+}
+
+// detectScript identifies languages that can be recognised from their writing system alone
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		}
+	}
+
+	// Kana is a stronger Japanese signal than Han characters, which both languages share
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+
+	best, bestCount := "", 0
+	for language, count := range counts {
+		if count > bestCount {
+			best, bestCount = language, count
+		}
+	}
+	return best
+}
+
+// splitSentences splits text into trimmed, non-empty sentences
+func (a *languageAnalyzer) splitSentences(text string) []string {
+	spans := a.sentenceSpans(text)
+	result := make([]string, len(spans))
+	for i, span := range spans {
+		result[i] = text[span[0]:span[1]]
+	}
+	return result
+}
+
+// sentenceSpans returns the byte spans of the trimmed, non-empty sentences of text
+func (a *languageAnalyzer) sentenceSpans(text string) [][2]int {
+	separators := a.sentenceExpr.FindAllStringIndex(text, -1)
+	spans := make([][2]int, 0, len(separators)+1)
+	add := func(start, end int) {
+		segment := text[start:end]
+		trimmed := strings.TrimLeftFunc(segment, unicode.IsSpace)
+		start += len(segment) - len(trimmed)
+		trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+		if trimmed != "" {
+			spans = append(spans, [2]int{start, start + len(trimmed)})
+		}
+	}
+
+	start := 0
+	for _, separator := range separators {
+		add(start, separator[0])
+		start = separator[1]
+	}
+	add(start, len(text))
+	return spans
+}
+
+// terms tokenizes text, drops stopwords and applies the stemmer
+func (a *languageAnalyzer) terms(text string) []string {
+	tokens := tokenize(text)
+	terms := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if _, ok := a.stopwords[token]; ok {
+			continue
+		}
+		terms = append(terms, a.stem(token))
+	}
+	return terms
+}
+
+// stem strips the longest matching suffix while keeping a minimal stem length
+func (a *languageAnalyzer) stem(token string) string {
+	for _, suffix := range a.suffixes {
+		if strings.HasSuffix(token, suffix) && len([]rune(token))-len([]rune(suffix)) >= 3 {
+			return strings.TrimSuffix(token, suffix)
+		}
+	}
+	return token
+}
+
+// tokenize lowercases text and splits it on anything that is not a letter or digit
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
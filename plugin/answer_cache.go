@@ -0,0 +1,236 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// AnswerCacheConfig contains configuration for caching complete answers
+type AnswerCacheConfig struct {
+	Enabled    bool          `json:"enabled"`
+	TTL        time.Duration `json:"ttl"`         // How long a cached answer is served; 0 keeps answers until evicted
+	MaxEntries int           `json:"max_entries"` // Entries kept by the in-memory cache
+	Store      AnswerCache   `json:"-"`           // Shared cache backend; an in-memory cache is used when unset (not serialized)
+}
+
+// AnswerCache stores responses by request key
+type AnswerCache interface {
+	Get(ctx context.Context, key string) (*AgenticRAGResponse, error) // Returns nil without error on a miss
+	Set(ctx context.Context, key string, response *AgenticRAGResponse, ttl time.Duration) error
+}
+
+// answerCache returns the configured answer cache, creating the in-memory cache on first use
+func (p *AgenticRAGProcessor) answerCache() AnswerCache {
+	if p.config.AnswerCache.Store != nil {
+		return p.config.AnswerCache.Store
+	}
+	p.answersOnce.Do(func() {
+		p.answers = NewMemoryAnswerCache(p.config.AnswerCache.MaxEntries)
+	})
+	return p.answers
+}
+
+// answerCacheKey identifies the answer to a request, or returns false for requests that must not be
+// served from the cache: resumed, checkpointed, and signed requests, and conversation turns
+func (p *AgenticRAGProcessor) answerCacheKey(ctx context.Context, request AgenticRAGRequest) (string, bool) {
+	if !p.config.AnswerCache.Enabled || request.ResumeToken != "" || request.CheckpointID != "" || request.SessionID != "" || request.Options.SignAnswer {
+		return "", false
+	}
+	data, err := json.Marshal(struct {
+		Model     string            `json:"model"`
+		Query     string            `json:"query"`
+		Documents []string          `json:"documents"`
+		TenantID  string            `json:"tenant_id"`
+		Filters   domain.Filters    `json:"filters"`
+		Options   AgenticRAGOptions `json:"options"`
+	}{p.modelName(ctx), request.Query, request.Documents, request.TenantID, request.Filters, request.Options})
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// cachedAnswer returns a cached response for the request, if any
+func (p *AgenticRAGProcessor) cachedAnswer(ctx context.Context, key string) *AgenticRAGResponse {
+	response, err := p.answerCache().Get(ctx, key)
+	if err != nil || response == nil {
+		p.config.Metrics.IncCounterWith("agentic_rag_answer_cache_misses_total", metricLabels(ctx), 1)
+		return nil
+	}
+	p.config.Metrics.IncCounterWith("agentic_rag_answer_cache_hits_total", metricLabels(ctx), 1)
+	response.ProcessingMetadata.Cached = true
+	return response
+}
+
+// cacheAnswer stores a response; cache failures never fail the request
+func (p *AgenticRAGProcessor) cacheAnswer(ctx context.Context, key string, response *AgenticRAGResponse) {
+	if err := p.answerCache().Set(ctx, key, response, p.config.AnswerCache.TTL); err != nil {
+		p.config.Metrics.IncCounter("agentic_rag_answer_cache_failures_total", 1)
+	}
+}
+
+// MemoryAnswerCache keeps answers in memory, evicting the oldest entry when full
+type MemoryAnswerCache struct {
+	mu      sync.Mutex
+	maxSize int
+	entries map[string]cachedResponse
+	order   []string // Keys in insertion order
+}
+
+// cachedResponse is a response held until it expires
+type cachedResponse struct {
+	response  *AgenticRAGResponse
+	expiresAt time.Time // Zero when the entry never expires
+}
+
+// NewMemoryAnswerCache creates an in-memory cache holding up to maxSize answers
+func NewMemoryAnswerCache(maxSize int) *MemoryAnswerCache {
+	if maxSize <= 0 {
+		maxSize = 1000
+	}
+	return &MemoryAnswerCache{maxSize: maxSize, entries: make(map[string]cachedResponse)}
+}
+
+// Get returns a copy of the cached response for key
+func (c *MemoryAnswerCache) Get(ctx context.Context, key string) (*AgenticRAGResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, nil
+	}
+	response := *entry.response
+	return &response, nil
+}
+
+// Set stores a copy of the response
+func (c *MemoryAnswerCache) Set(ctx context.Context, key string, response *AgenticRAGResponse, ttl time.Duration) error {
+	entry := cachedResponse{response: response}
+	stored := *response
+	entry.response = &stored
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = entry
+
+	// Evict the oldest entries, skipping keys already removed on expiry
+	for len(c.entries) > c.maxSize && len(c.order) > 0 {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	if len(c.order) > 2*c.maxSize {
+		order := make([]string, 0, len(c.entries))
+		for _, key := range c.order {
+			if _, ok := c.entries[key]; ok {
+				order = append(order, key)
+			}
+		}
+		c.order = order
+	}
+	return nil
+}
+
+// Len returns the number of cached answers, including expired ones not yet evicted
+func (c *MemoryAnswerCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// FileAnswerCache stores answers as JSON files in a directory, so separate processes sharing the
+// directory (e.g. a cache priming job and the servers it warms) share the cache
+type FileAnswerCache struct {
+	dir string
+}
+
+// fileAnswer is the on-disk form of a cached answer
+type fileAnswer struct {
+	Response  *AgenticRAGResponse `json:"response"`
+	ExpiresAt time.Time           `json:"expires_at,omitempty"`
+}
+
+// NewFileAnswerCache creates a file cache in dir
+func NewFileAnswerCache(dir string) (*FileAnswerCache, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create answer cache directory: %w", err)
+	}
+	return &FileAnswerCache{dir: dir}, nil
+}
+
+// Get reads the cached response for key
+func (c *FileAnswerCache) Get(ctx context.Context, key string) (*AgenticRAGResponse, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached answer: %w", err)
+	}
+
+	var entry fileAnswer
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse cached answer: %w", err)
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		os.Remove(c.path(key))
+		return nil, nil
+	}
+	return entry.Response, nil
+}
+
+// Set writes the response, replacing the file atomically so readers never see partial entries
+func (c *FileAnswerCache) Set(ctx context.Context, key string, response *AgenticRAGResponse, ttl time.Duration) error {
+	entry := fileAnswer{Response: response}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached answer: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "answer-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cached answer: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cached answer: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cached answer: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to store cached answer: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the answer for key
+func (c *FileAnswerCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/api/v1"
+)
+
+// RequestFromV1 converts a v1 request into the pipeline's request type
+func RequestFromV1(request v1.Request) (AgenticRAGRequest, error) {
+	var converted AgenticRAGRequest
+	if err := convertJSON(request, &converted); err != nil {
+		return AgenticRAGRequest{}, fmt.Errorf("failed to convert v1 request: %w", err)
+	}
+	return converted, nil
+}
+
+// ResponseToV1 converts a pipeline response into a v1 response. Fields outside the v1
+// contract are carried in Extra so they still appear in the encoded payload.
+func ResponseToV1(response *AgenticRAGResponse) (*v1.Response, error) {
+	var converted v1.Response
+	if err := convertJSON(response, &converted); err != nil {
+		return nil, fmt.Errorf("failed to convert response to v1: %w", err)
+	}
+	converted.APIVersion = v1.Version
+	return &converted, nil
+}
+
+// ProcessV1 runs the pipeline for a v1 request
+func (p *AgenticRAGProcessor) ProcessV1(ctx context.Context, request v1.Request) (*v1.Response, error) {
+	converted, err := RequestFromV1(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.Process(ctx, converted)
+	if err != nil {
+		return nil, err
+	}
+	return ResponseToV1(response)
+}
+
+// convertJSON copies value into target through its JSON encoding
+func convertJSON(value, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,406 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source prefixes of the Atlassian connectors
+const (
+	confluenceSourcePrefix = "confluence:" // confluence:<base URL>?space=KEY
+	jiraSourcePrefix       = "jira:"       // jira:<base URL>?project=KEY
+)
+
+// AtlassianConfig contains configuration for the Confluence and Jira connectors
+type AtlassianConfig struct {
+	Email    string `json:"email,omitempty"` // Atlassian Cloud account used with the API token; empty sends the token as a bearer personal access token
+	TokenEnv string `json:"token_env"`       // Environment variable holding the API token
+	MaxItems int    `json:"max_items"`       // Maximum pages or issues fetched per sync
+}
+
+// AtlassianLoader loads Confluence spaces and Jira projects. Sources name the site and the space
+// or project, e.g. "confluence:https://acme.atlassian.net/wiki?space=ENG" or
+// "jira:https://acme.atlassian.net?project=SUP". Pages and their comments, and issues with their
+// comments, become documents whose metadata records the space or project and the read
+// restrictions, for filtering by permission. With LoaderConfig.SyncDir set, later loads only fetch
+// what changed since the previous one.
+type AtlassianLoader struct {
+	config LoaderConfig
+	client *http.Client
+}
+
+// NewAtlassianLoader creates a Confluence and Jira loader
+func NewAtlassianLoader(config LoaderConfig) *AtlassianLoader {
+	return &AtlassianLoader{config: config, client: http.DefaultClient}
+}
+
+// CanLoad reports whether the source is a Confluence space or Jira project
+func (l *AtlassianLoader) CanLoad(source string) bool {
+	for _, prefix := range []string{confluenceSourcePrefix, jiraSourcePrefix} {
+		if rest, ok := strings.CutPrefix(source, prefix); ok {
+			return isHTTPURL(rest)
+		}
+	}
+	return false
+}
+
+// Load syncs the space or project and returns all of its documents
+func (l *AtlassianLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	if rest, ok := strings.CutPrefix(source, confluenceSourcePrefix); ok {
+		base, key, err := atlassianSource(rest, "space")
+		if err != nil {
+			return nil, err
+		}
+		return syncConnectorSource(ctx, l.config.SyncDir, source, func(ctx context.Context, since time.Time) ([]syncedItem, error) {
+			return l.fetchConfluence(ctx, base, key, since)
+		})
+	}
+	rest := strings.TrimPrefix(source, jiraSourcePrefix)
+	base, key, err := atlassianSource(rest, "project")
+	if err != nil {
+		return nil, err
+	}
+	return syncConnectorSource(ctx, l.config.SyncDir, source, func(ctx context.Context, since time.Time) ([]syncedItem, error) {
+		return l.fetchJira(ctx, base, key, since)
+	})
+}
+
+// atlassianSource splits a connector URL into the site's base URL and the key query parameter
+func atlassianSource(source, parameter string) (string, string, error) {
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid source URL: %w", err)
+	}
+	key := parsed.Query().Get(parameter)
+	if key == "" {
+		return "", "", fmt.Errorf("source URL needs a %s parameter", parameter)
+	}
+	parsed.RawQuery, parsed.Fragment = "", ""
+	return strings.TrimSuffix(parsed.String(), "/"), key, nil
+}
+
+// confluenceContent is a page or comment returned by the Confluence content search API
+type confluenceContent struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	Space struct {
+		Key string `json:"key"`
+	} `json:"space"`
+	Version struct {
+		When string `json:"when"`
+		By   struct {
+			DisplayName string `json:"displayName"`
+		} `json:"by"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Container struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"container"`
+	Restrictions struct {
+		Read struct {
+			Restrictions struct {
+				User struct {
+					Results []struct {
+						AccountID string `json:"accountId"`
+						Username  string `json:"username"`
+					} `json:"results"`
+				} `json:"user"`
+				Group struct {
+					Results []struct {
+						Name string `json:"name"`
+					} `json:"results"`
+				} `json:"group"`
+			} `json:"restrictions"`
+		} `json:"read"`
+	} `json:"restrictions"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// fetchConfluence searches the space's pages and comments updated since the cursor
+func (l *AtlassianLoader) fetchConfluence(ctx context.Context, base, space string, since time.Time) ([]syncedItem, error) {
+	cql := fmt.Sprintf(`space = %q AND type IN (page, comment)`, space)
+	if !since.IsZero() {
+		cql += fmt.Sprintf(` AND lastmodified >= %q`, atlassianSince(since, "2006-01-02 15:04"))
+	}
+	cql += " ORDER BY lastmodified ASC"
+
+	items := make([]syncedItem, 0)
+	for start := 0; ; {
+		query := url.Values{
+			"cql":    {cql},
+			"start":  {strconv.Itoa(start)},
+			"limit":  {"50"},
+			"expand": {"body.storage,version,space,container,restrictions.read.restrictions.user,restrictions.read.restrictions.group"},
+		}
+		var page struct {
+			Results []confluenceContent `json:"results"`
+			Size    int                 `json:"size"`
+			Links   struct {
+				Next string `json:"next"`
+			} `json:"_links"`
+		}
+		if err := l.getJSON(ctx, base+"/rest/api/content/search?"+query.Encode(), &page); err != nil {
+			return nil, fmt.Errorf("failed to search Confluence: %w", err)
+		}
+		for _, content := range page.Results {
+			item, err := confluenceItem(base, content)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		start += len(page.Results)
+		if page.Links.Next == "" || len(page.Results) == 0 || l.reachedMaxItems(len(items)) {
+			break
+		}
+	}
+	return items, nil
+}
+
+// confluenceItem converts a page or comment into a document with its read restrictions
+func confluenceItem(base string, content confluenceContent) (syncedItem, error) {
+	text, _, sections, err := extractHTMLText(content.Body.Storage.Value)
+	if err != nil {
+		return syncedItem{}, fmt.Errorf("failed to parse Confluence content %s: %w", content.ID, err)
+	}
+	updatedAt, _ := time.Parse(time.RFC3339, content.Version.When)
+
+	users := make([]string, 0)
+	for _, user := range content.Restrictions.Read.Restrictions.User.Results {
+		if user.AccountID != "" {
+			users = append(users, user.AccountID)
+		} else {
+			users = append(users, user.Username)
+		}
+	}
+	groups := make([]string, 0)
+	for _, group := range content.Restrictions.Read.Restrictions.Group.Results {
+		groups = append(groups, group.Name)
+	}
+
+	title := content.Title
+	metadata := map[string]interface{}{
+		"content_type":    "text/html",
+		"confluence_id":   content.ID,
+		"confluence_type": content.Type,
+		"space":           content.Space.Key,
+		"author":          content.Version.By.DisplayName,
+		"allowed_users":   users,  // Empty when only space permissions apply
+		"allowed_groups":  groups, // Empty when only space permissions apply
+	}
+	if content.Type == "comment" && content.Container.ID != "" {
+		metadata["page_id"] = content.Container.ID
+		if title == "" || strings.HasPrefix(title, "Re:") {
+			title = "Comment on " + content.Container.Title
+		}
+	}
+	metadata["title"] = title
+	if !updatedAt.IsZero() {
+		metadata["updated_at"] = updatedAt
+	}
+	if content.Links.WebUI != "" {
+		metadata["url"] = base + content.Links.WebUI
+	}
+	if len(sections) > 0 {
+		metadata[sectionsMetadataKey] = sections
+	}
+
+	source := fmt.Sprintf("%s/pages/%s", base, content.ID)
+	if link, ok := metadata["url"].(string); ok {
+		source = link
+	}
+	return syncedItem{
+		id:        content.ID,
+		updatedAt: updatedAt,
+		document:  Document{Content: text, Source: source, Metadata: metadata},
+	}, nil
+}
+
+// jiraIssue is an issue returned by the Jira search API
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string   `json:"summary"`
+		Description string   `json:"description"`
+		Created     string   `json:"created"`
+		Updated     string   `json:"updated"`
+		Labels      []string `json:"labels"`
+		Status      struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Reporter struct {
+			DisplayName string `json:"displayName"`
+		} `json:"reporter"`
+		Security *struct {
+			Name string `json:"name"`
+		} `json:"security"`
+		Comment struct {
+			Comments []struct {
+				Author struct {
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+				Body    string `json:"body"`
+				Created string `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// jiraTimeLayout is the timestamp format of the Jira REST API
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// fetchJira searches the project's issues updated since the cursor
+func (l *AtlassianLoader) fetchJira(ctx context.Context, base, project string, since time.Time) ([]syncedItem, error) {
+	jql := fmt.Sprintf(`project = %q`, project)
+	if !since.IsZero() {
+		jql += fmt.Sprintf(` AND updated >= %q`, atlassianSince(since, "2006/01/02 15:04"))
+	}
+	jql += " ORDER BY updated ASC"
+
+	items := make([]syncedItem, 0)
+	for start := 0; ; {
+		query := url.Values{
+			"jql":        {jql},
+			"startAt":    {strconv.Itoa(start)},
+			"maxResults": {"50"},
+			"fields":     {"summary,description,created,updated,labels,status,issuetype,project,reporter,security,comment"},
+		}
+		var page struct {
+			Total  int         `json:"total"`
+			Issues []jiraIssue `json:"issues"`
+		}
+		if err := l.getJSON(ctx, base+"/rest/api/2/search?"+query.Encode(), &page); err != nil {
+			return nil, fmt.Errorf("failed to search Jira: %w", err)
+		}
+		for _, issue := range page.Issues {
+			items = append(items, jiraItem(base, issue))
+		}
+		start += len(page.Issues)
+		if start >= page.Total || len(page.Issues) == 0 || l.reachedMaxItems(len(items)) {
+			break
+		}
+	}
+	return items, nil
+}
+
+// jiraItem converts an issue and its comments into a document with its project and security level
+func jiraItem(base string, issue jiraIssue) syncedItem {
+	fields := issue.Fields
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("%s: %s\n\n", issue.Key, fields.Summary))
+	if fields.Description != "" {
+		content.WriteString(fields.Description + "\n\n")
+	}
+	for _, comment := range fields.Comment.Comments {
+		date := comment.Created
+		if created, err := time.Parse(jiraTimeLayout, comment.Created); err == nil {
+			date = created.Format("2006-01-02")
+		}
+		content.WriteString(fmt.Sprintf("Comment by %s on %s:\n%s\n\n", comment.Author.DisplayName, date, comment.Body))
+	}
+
+	updatedAt, _ := time.Parse(jiraTimeLayout, fields.Updated)
+	metadata := map[string]interface{}{
+		"content_type": "text/plain",
+		"title":        fmt.Sprintf("%s: %s", issue.Key, fields.Summary),
+		"url":          base + "/browse/" + issue.Key,
+		"issue_key":    issue.Key,
+		"project":      fields.Project.Key,
+		"issue_type":   fields.IssueType.Name,
+		"status":       fields.Status.Name,
+		"reporter":     fields.Reporter.DisplayName,
+	}
+	if len(fields.Labels) > 0 {
+		metadata["labels"] = fields.Labels
+	}
+	if fields.Security != nil {
+		metadata["security_level"] = fields.Security.Name
+	}
+	if created, err := time.Parse(jiraTimeLayout, fields.Created); err == nil {
+		metadata["published_at"] = created
+	}
+	if !updatedAt.IsZero() {
+		metadata["updated_at"] = updatedAt
+	}
+	return syncedItem{
+		id:        issue.Key,
+		updatedAt: updatedAt,
+		document:  Document{Content: strings.TrimSpace(content.String()), Source: base + "/browse/" + issue.Key, Metadata: metadata},
+	}
+}
+
+// atlassianSince formats a sync cursor for CQL and JQL, which compare minutes in the account's
+// time zone. The cursor is moved back by the largest negative UTC offset so no zone skips updates;
+// items fetched again are merged by ID.
+func atlassianSince(since time.Time, layout string) string {
+	return since.UTC().Add(-12 * time.Hour).Format(layout)
+}
+
+// reachedMaxItems reports whether a sync fetched the configured maximum of items
+func (l *AtlassianLoader) reachedMaxItems(count int) bool {
+	return l.config.Atlassian.MaxItems > 0 && count >= l.config.Atlassian.MaxItems
+}
+
+// getJSON fetches an authenticated API URL and decodes its JSON response
+func (l *AtlassianLoader) getJSON(ctx context.Context, apiURL string, out interface{}) error {
+	timeout := l.config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if l.config.UserAgent != "" {
+		req.Header.Set("User-Agent", l.config.UserAgent)
+	}
+	if token := os.Getenv(l.config.Atlassian.TokenEnv); token != "" {
+		if l.config.Atlassian.Email != "" {
+			req.SetBasicAuth(l.config.Atlassian.Email, token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	body, err := readLimited(resp.Body, l.config.MaxBytes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
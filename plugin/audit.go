@@ -0,0 +1,360 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditConfig contains configuration for the model interaction audit log
+type AuditConfig struct {
+	Enabled     bool          `json:"enabled"`
+	Retention   time.Duration `json:"retention"`     // Entries older than this are pruned; 0 keeps everything
+	FailOnError bool          `json:"fail_on_error"` // Fail the request when an entry cannot be written
+	Sinks       []AuditSink   `json:"-"`             // Destinations for audit entries (not serialized)
+}
+
+// AuditEntry records a single request to the agentic RAG pipeline
+type AuditEntry struct {
+	ID             string        `json:"id"`
+	Timestamp      time.Time     `json:"timestamp"`
+	UserID         string        `json:"user_id,omitempty"`
+	Query          string        `json:"query"`
+	DocumentIDs    []string      `json:"document_ids,omitempty"`
+	ChunkIDs       []string      `json:"chunk_ids,omitempty"`
+	Model          string        `json:"model"`
+	Prompts        []string      `json:"prompts,omitempty"`
+	Persona        string        `json:"persona,omitempty"`
+	Route          string        `json:"route,omitempty"`
+	Answer         string        `json:"answer,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	ProcessingTime time.Duration `json:"processing_time"`
+}
+
+// AuditSink receives audit entries; implementations must only ever append
+type AuditSink interface {
+	Write(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditPruner is implemented by sinks that can enforce a retention policy
+type AuditPruner interface {
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// auditPruneInterval limits how often retention is enforced
+const auditPruneInterval = time.Hour
+
+// audit writes an entry for a completed or failed request to every sink
+func (p *AgenticRAGProcessor) audit(ctx context.Context, request AgenticRAGRequest, response *AgenticRAGResponse, processErr error, startTime time.Time) error {
+	cfg := p.config.Audit
+	if !cfg.Enabled || len(cfg.Sinks) == 0 {
+		return nil
+	}
+
+	entry := AuditEntry{
+		ID:             newAuditID(),
+		Timestamp:      startTime,
+		UserID:         request.UserID,
+		Query:          request.Query,
+		Model:          p.modelName(ctx),
+		Persona:        request.Options.Persona,
+		ProcessingTime: time.Since(startTime),
+	}
+	if processErr != nil {
+		entry.Error = processErr.Error()
+	}
+	if response != nil {
+		entry.Answer = response.Answer
+		seen := make(map[string]bool)
+		for _, chunk := range response.RelevantChunks {
+			entry.ChunkIDs = append(entry.ChunkIDs, chunk.Chunk.ID)
+			if !seen[chunk.Chunk.DocumentID] {
+				seen[chunk.Chunk.DocumentID] = true
+				entry.DocumentIDs = append(entry.DocumentIDs, chunk.Chunk.DocumentID)
+			}
+		}
+		if routing := response.ProcessingMetadata.Routing; routing != nil {
+			entry.Route = routing.Route
+		}
+		if entry.Route != RouteDirect {
+			entry.Prompts = p.pipelinePrompts()
+		}
+	}
+
+	var writeErr error
+	for _, sink := range cfg.Sinks {
+		if err := sink.Write(ctx, entry); err != nil && writeErr == nil {
+			writeErr = fmt.Errorf("failed to write audit entry: %w", err)
+		}
+	}
+	if writeErr != nil {
+		p.config.Metrics.IncCounter("agentic_rag_audit_failures_total", 1)
+	}
+
+	p.pruneAuditLog(ctx)
+	return writeErr
+}
+
+// pruneAuditLog enforces the retention policy at most once per prune interval
+func (p *AgenticRAGProcessor) pruneAuditLog(ctx context.Context) {
+	retention := p.config.Audit.Retention
+	if retention <= 0 {
+		return
+	}
+
+	p.auditMu.Lock()
+	if time.Since(p.lastAuditPrune) < auditPruneInterval {
+		p.auditMu.Unlock()
+		return
+	}
+	p.lastAuditPrune = time.Now()
+	p.auditMu.Unlock()
+
+	before := time.Now().Add(-retention)
+	for _, sink := range p.config.Audit.Sinks {
+		if pruner, ok := sink.(AuditPruner); ok {
+			if err := pruner.Prune(ctx, before); err != nil {
+				p.config.Metrics.IncCounter("agentic_rag_audit_failures_total", 1)
+			}
+		}
+	}
+}
+
+// modelName returns the name of the model serving the request
+func (p *AgenticRAGProcessor) modelName(ctx context.Context) string {
+	if endpoint := endpointFromContext(ctx); endpoint != nil {
+		if endpoint.Model != nil {
+			return endpoint.Model.Name()
+		}
+		return endpoint.ModelName
+	}
+	if p.config.Model != nil {
+		return p.config.Model.Name()
+	}
+	return p.config.ModelName
+}
+
+// pipelinePrompts returns the names of the prompts used to retrieve and answer
+func (p *AgenticRAGProcessor) pipelinePrompts() []string {
+	return []string{
+		p.resolvedPromptName(p.config.Prompts.RelevanceScoringPrompt, "relevance_scoring"),
+		p.resolvedPromptName(p.config.Prompts.ResponseGenerationPrompt, "response_generation"),
+	}
+}
+
+// resolvedPromptName returns the prompt name including any configured variant
+func (p *AgenticRAGProcessor) resolvedPromptName(name, key string) string {
+	if variant, exists := p.config.Prompts.Variants[key]; exists {
+		return fmt.Sprintf("%s.%s", name, variant)
+	}
+	return name
+}
+
+// newAuditID returns a random identifier for an audit entry
+func newAuditID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// FileAuditSink appends entries as JSON lines to one file per UTC day
+type FileAuditSink struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileAuditSink creates a file sink writing to dir
+func NewFileAuditSink(dir string) (*FileAuditSink, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	return &FileAuditSink{dir: dir}, nil
+}
+
+// auditFilePrefix and auditFileSuffix frame the date in audit file names
+const (
+	auditFilePrefix = "audit-"
+	auditFileSuffix = ".jsonl"
+)
+
+// Write appends the entry to the file for its day
+func (s *FileAuditSink) Write(ctx context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := auditFilePrefix + entry.Timestamp.UTC().Format("2006-01-02") + auditFileSuffix
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes daily files that ended before the cutoff
+func (s *FileAuditSink) Prune(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list audit directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, auditFilePrefix) || !strings.HasSuffix(name, auditFileSuffix) {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", strings.TrimSuffix(strings.TrimPrefix(name, auditFilePrefix), auditFileSuffix))
+		if err != nil || !day.Add(24*time.Hour).Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+			return fmt.Errorf("failed to remove audit file %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SQLAuditSink stores entries in a SQL table, e.g. a Turso/libSQL database opened with its database/sql driver
+type SQLAuditSink struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLAuditSink creates the audit table if needed and returns a sink writing to it
+func NewSQLAuditSink(ctx context.Context, db *sql.DB, table string) (*SQLAuditSink, error) {
+	if table == "" {
+		table = "audit_log"
+	}
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id TEXT PRIMARY KEY,
+		timestamp INTEGER NOT NULL,
+		user_id TEXT,
+		entry TEXT NOT NULL
+	)`, table)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return nil, fmt.Errorf("failed to create audit table: %w", err)
+	}
+	return &SQLAuditSink{db: db, table: table}, nil
+}
+
+// Write inserts the entry
+func (s *SQLAuditSink) Write(ctx context.Context, entry AuditEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (id, timestamp, user_id, entry) VALUES (?, ?, ?, ?)", s.table)
+	if _, err := s.db.ExecContext(ctx, query, entry.ID, entry.Timestamp.UnixNano(), entry.UserID, string(payload)); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes entries recorded before the cutoff
+func (s *SQLAuditSink) Prune(ctx context.Context, before time.Time) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE timestamp < ?", s.table)
+	if _, err := s.db.ExecContext(ctx, query, before.UnixNano()); err != nil {
+		return fmt.Errorf("failed to prune audit entries: %w", err)
+	}
+	return nil
+}
+
+// OTLPAuditSink exports entries as OpenTelemetry log records over OTLP/HTTP JSON
+type OTLPAuditSink struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPAuditSink creates a sink posting to an OTLP logs endpoint (e.g. http://collector:4318/v1/logs)
+func NewOTLPAuditSink(endpoint, serviceName string, headers map[string]string) *OTLPAuditSink {
+	if serviceName == "" {
+		serviceName = "genkit-agentic-rag"
+	}
+	return &OTLPAuditSink{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Write exports the entry as a single log record
+func (s *OTLPAuditSink) Write(ctx context.Context, entry AuditEntry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	stringValue := func(value string) map[string]any {
+		return map[string]any{"stringValue": value}
+	}
+	payload := map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []any{map[string]any{"key": "service.name", "value": stringValue(s.serviceName)}},
+			},
+			"scopeLogs": []any{map[string]any{
+				"scope": map[string]any{"name": "agentic-rag-audit"},
+				"logRecords": []any{map[string]any{
+					"timeUnixNano": strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+					"severityText": "INFO",
+					"body":         stringValue(string(body)),
+					"attributes": []any{
+						map[string]any{"key": "audit.id", "value": stringValue(entry.ID)},
+						map[string]any{"key": "enduser.id", "value": stringValue(entry.UserID)},
+						map[string]any{"key": "gen_ai.request.model", "value": stringValue(entry.Model)},
+					},
+				}},
+			}},
+		}},
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal OTLP payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export audit entry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import "fmt"
+
+// BlocklistConfig excludes content from retrieval and citation without removing it from the corpus
+type BlocklistConfig struct {
+	DocumentIDs    []string               `json:"document_ids,omitempty"`
+	SourcePatterns []string               `json:"source_patterns,omitempty"` // Glob patterns ("*" matches anything) on document source
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`        // Documents whose metadata value equals the given value are excluded
+}
+
+// blocks reports whether the blocklist excludes the document
+func (b *BlocklistConfig) blocks(doc Document) bool {
+	if b == nil {
+		return false
+	}
+	for _, id := range b.DocumentIDs {
+		if id == doc.ID {
+			return true
+		}
+	}
+	for _, pattern := range b.SourcePatterns {
+		if globMatch(pattern, doc.Source) {
+			return true
+		}
+	}
+	for key, blocked := range b.Metadata {
+		if value, ok := doc.Metadata[key]; ok && fmt.Sprint(value) == fmt.Sprint(blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterBlockedDocuments removes documents excluded by the global or per-request blocklist
+func (p *AgenticRAGProcessor) filterBlockedDocuments(documents []Document, requestBlocklist *BlocklistConfig) ([]Document, int) {
+	filtered := make([]Document, 0, len(documents))
+	for _, doc := range documents {
+		if p.config.Blocklist.blocks(doc) || requestBlocklist.blocks(doc) {
+			continue
+		}
+		filtered = append(filtered, doc)
+	}
+	return filtered, len(documents) - len(filtered)
+}
+
+// filterDocumentsByMetadata keeps documents whose metadata matches every filter key.
+// A list value matches when the metadata equals any of its elements.
+func filterDocumentsByMetadata(documents []Document, filter map[string]interface{}) ([]Document, int) {
+	if len(filter) == 0 {
+		return documents, 0
+	}
+
+	filtered := make([]Document, 0, len(documents))
+	for _, doc := range documents {
+		matches := true
+		for key, want := range filter {
+			value, ok := doc.Metadata[key]
+			if !ok || !metadataValueMatches(value, want) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, doc)
+		}
+	}
+	return filtered, len(documents) - len(filtered)
+}
+
+// metadataValueMatches compares a metadata value with a filter value by their text form
+func metadataValueMatches(value, want interface{}) bool {
+	if options, ok := want.([]interface{}); ok {
+		for _, option := range options {
+			if fmt.Sprint(value) == fmt.Sprint(option) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(value) == fmt.Sprint(want)
+}
@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// QueryFrequency is a historical query and how often it was asked
+type QueryFrequency struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+// CachePrimingReport summarizes a cache priming run
+type CachePrimingReport struct {
+	Queries  int               `json:"queries"`
+	Primed   int               `json:"primed"`
+	Cached   int               `json:"cached"`             // Queries whose answer was already cached
+	Failures map[string]string `json:"failures,omitempty"` // Query -> error
+}
+
+// ReadAuditLog reads every entry written by a FileAuditSink to dir
+func ReadAuditLog(dir string) ([]AuditEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, auditFilePrefix+"*"+auditFileSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit files: %w", err)
+	}
+	sort.Strings(paths)
+
+	entries := make([]AuditEntry, 0)
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit file: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var entry AuditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to parse audit entry in %s: %w", filepath.Base(path), err)
+			}
+			entries = append(entries, entry)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit file %s: %w", filepath.Base(path), err)
+		}
+	}
+	return entries, nil
+}
+
+// FrequentQueries returns the n most frequent successfully answered queries, most frequent first.
+// Queries differing only in case and surrounding whitespace are counted together under their most
+// common spelling.
+func FrequentQueries(entries []AuditEntry, n int) []QueryFrequency {
+	counts := make(map[string]int)
+	spellings := make(map[string]map[string]int)
+	for _, entry := range entries {
+		query := strings.TrimSpace(entry.Query)
+		if entry.Error != "" || query == "" {
+			continue
+		}
+		key := strings.ToLower(query)
+		counts[key]++
+		if spellings[key] == nil {
+			spellings[key] = make(map[string]int)
+		}
+		spellings[key][query]++
+	}
+
+	frequencies := make([]QueryFrequency, 0, len(counts))
+	for key, count := range counts {
+		best, bestCount := "", 0
+		for spelling, spellingCount := range spellings[key] {
+			if spellingCount > bestCount || (spellingCount == bestCount && spelling < best) {
+				best, bestCount = spelling, spellingCount
+			}
+		}
+		frequencies = append(frequencies, QueryFrequency{Query: best, Count: count})
+	}
+	sort.Slice(frequencies, func(i, j int) bool {
+		if frequencies[i].Count != frequencies[j].Count {
+			return frequencies[i].Count > frequencies[j].Count
+		}
+		return frequencies[i].Query < frequencies[j].Query
+	})
+	if n > 0 && len(frequencies) > n {
+		frequencies = frequencies[:n]
+	}
+	return frequencies
+}
+
+// PrimeCaches replays queries against the current corpus, using template for the documents and
+// options, so their embeddings and answers are cached before live traffic arrives. Failed queries
+// are reported rather than stopping the run.
+func (p *AgenticRAGProcessor) PrimeCaches(ctx context.Context, queries []string, template AgenticRAGRequest) (*CachePrimingReport, error) {
+	report := &CachePrimingReport{Queries: len(queries), Failures: make(map[string]string)}
+
+	// Query embeddings are shared by similarity search, few-shot selection, and vector retrieval
+	groups := make(map[string][]string)
+	for _, query := range queries {
+		if embedderName := p.embedderNameFor(p.detectLanguage(query)); embedderName != "" {
+			groups[embedderName] = append(groups[embedderName], query)
+		}
+	}
+	for embedderName, group := range groups {
+		if _, err := p.cachedEmbeddings(ctx, embedderName, group); err != nil {
+			for _, query := range group {
+				report.Failures[query] = fmt.Sprintf("failed to embed query: %v", err)
+			}
+		}
+	}
+
+	for _, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		request := template
+		request.Query = query
+		response, err := p.Process(ctx, request)
+		if err != nil {
+			report.Failures[query] = err.Error()
+			continue
+		}
+		if response.ProcessingMetadata.Cached {
+			report.Cached++
+			continue
+		}
+		report.Primed++
+	}
+	p.config.Metrics.IncCounter("agentic_rag_cache_primed_queries_total", float64(report.Primed))
+	return report, nil
+}
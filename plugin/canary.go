@@ -0,0 +1,307 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Canary variants
+const (
+	CanaryBaseline  = "baseline"
+	CanaryCandidate = "candidate"
+)
+
+// maxCanaryAssignments bounds the response assignments remembered for feedback
+const maxCanaryAssignments = 10000
+
+// CanaryConfig contains configuration for rolling out a candidate configuration to a share of traffic
+type CanaryConfig struct {
+	Enabled              bool              `json:"enabled"`
+	Candidate            *AgenticRAGConfig `json:"-"`                       // Candidate configuration, e.g. a new prompt variant or model (not serialized)
+	Percent              float64           `json:"percent"`                 // Share of requests served by the candidate, 0-100
+	MinSamples           int               `json:"min_samples"`             // Observations per variant before a metric can trigger rollback
+	MaxConfidenceDrop    float64           `json:"max_confidence_drop"`     // Roll back when candidate confidence trails the baseline by more than this
+	MaxGroundednessDrop  float64           `json:"max_groundedness_drop"`   // Roll back when candidate groundedness trails the baseline by more than this
+	MaxFeedbackDrop      float64           `json:"max_feedback_drop"`       // Roll back when candidate user feedback trails the baseline by more than this
+	MaxErrorRateIncrease float64           `json:"max_error_rate_increase"` // Roll back when the candidate error rate exceeds the baseline by more than this
+}
+
+// CanaryAssignment identifies the variant that served a response, for attaching user feedback
+type CanaryAssignment struct {
+	ID      string `json:"id"`
+	Variant string `json:"variant"`
+}
+
+// CanaryFeedback is a user rating of a canary-served response
+type CanaryFeedback struct {
+	ID    string  `json:"id"`    // CanaryAssignment ID from the response metadata
+	Score float64 `json:"score"` // 0 (bad) to 1 (good)
+}
+
+// CanaryVariantStats summarizes the quality proxies observed for a variant
+type CanaryVariantStats struct {
+	Requests         int     `json:"requests"`
+	Errors           int     `json:"errors"`
+	ErrorRate        float64 `json:"error_rate"`
+	MeanConfidence   float64 `json:"mean_confidence"`
+	MeanGroundedness float64 `json:"mean_groundedness"`
+	FeedbackCount    int     `json:"feedback_count"`
+	MeanFeedback     float64 `json:"mean_feedback"`
+	confidenceSum    float64
+	groundednessSum  float64
+	feedbackSum      float64
+}
+
+// CanaryStatus reports the rollout state and the per-variant quality proxies
+type CanaryStatus struct {
+	Percent        float64            `json:"percent"` // 0 once rolled back
+	RolledBack     bool               `json:"rolled_back"`
+	RollbackReason string             `json:"rollback_reason,omitempty"`
+	RolledBackAt   time.Time          `json:"rolled_back_at,omitempty"`
+	Baseline       CanaryVariantStats `json:"baseline"`
+	Candidate      CanaryVariantStats `json:"candidate"`
+}
+
+// canaryController splits traffic between the baseline processor and the candidate and rolls back on regressions
+type canaryController struct {
+	cfg       CanaryConfig
+	baseline  *AgenticRAGProcessor
+	candidate *AgenticRAGProcessor
+
+	mu          sync.Mutex
+	stats       map[string]*CanaryVariantStats
+	assignments map[string]string
+	order       []string
+	status      CanaryStatus
+}
+
+// newCanaryController creates the controller; the candidate never runs a canary of its own
+func newCanaryController(cfg CanaryConfig, baseline *AgenticRAGProcessor) *canaryController {
+	candidateConfig := *cfg.Candidate
+	candidateConfig.Canary = CanaryConfig{}
+	return &canaryController{
+		cfg:       cfg,
+		baseline:  baseline,
+		candidate: NewAgenticRAGProcessor(&candidateConfig),
+		stats: map[string]*CanaryVariantStats{
+			CanaryBaseline:  {},
+			CanaryCandidate: {},
+		},
+		assignments: make(map[string]string),
+		status:      CanaryStatus{Percent: cfg.Percent},
+	}
+}
+
+// process serves the request with the assigned variant and records its quality proxies
+func (c *canaryController) process(ctx context.Context, request AgenticRAGRequest) (*AgenticRAGResponse, error) {
+	variant := c.assign(request)
+	processor := c.baseline
+	if variant == CanaryCandidate {
+		processor = c.candidate
+	}
+
+	response, err := processor.serve(ctx, request)
+	id := c.observe(variant, response, err)
+	if response != nil {
+		response.ProcessingMetadata.Canary = &CanaryAssignment{ID: id, Variant: variant}
+	}
+	return response, err
+}
+
+// assign picks the variant for a request; requests from the same user or session stick to one variant
+func (c *canaryController) assign(request AgenticRAGRequest) string {
+	c.mu.Lock()
+	percent := c.status.Percent
+	c.mu.Unlock()
+	if percent <= 0 {
+		return CanaryBaseline
+	}
+
+	key := request.UserID
+	if key == "" {
+		key = request.SessionID
+	}
+	var bucket float64
+	if key != "" {
+		hash := fnv.New32a()
+		hash.Write([]byte(key))
+		bucket = float64(hash.Sum32()%10000) / 100
+	} else {
+		bucket = rand.Float64() * 100
+	}
+	if bucket < percent {
+		return CanaryCandidate
+	}
+	return CanaryBaseline
+}
+
+// observe records a response's quality proxies and returns the assignment ID for feedback
+func (c *canaryController) observe(variant string, response *AgenticRAGResponse, err error) string {
+	id := newAuditID()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats[variant]
+	stats.Requests++
+	if err != nil {
+		stats.Errors++
+	} else {
+		stats.confidenceSum += responseConfidence(response)
+		stats.groundednessSum += responseGroundedness(response)
+	}
+	c.baseline.config.Metrics.IncCounter(fmt.Sprintf("agentic_rag_canary_%s_requests_total", variant), 1)
+
+	c.assignments[id] = variant
+	c.order = append(c.order, id)
+	if len(c.order) > maxCanaryAssignments {
+		delete(c.assignments, c.order[0])
+		c.order = c.order[1:]
+	}
+
+	c.evaluate()
+	return id
+}
+
+// recordFeedback attributes a user rating to the variant that served the response
+func (c *canaryController) recordFeedback(feedback CanaryFeedback) error {
+	if feedback.Score < 0 || feedback.Score > 1 {
+		return fmt.Errorf("feedback score must be between 0 and 1")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	variant, ok := c.assignments[feedback.ID]
+	if !ok {
+		return fmt.Errorf("unknown canary response %q", feedback.ID)
+	}
+	delete(c.assignments, feedback.ID)
+
+	stats := c.stats[variant]
+	stats.FeedbackCount++
+	stats.feedbackSum += feedback.Score
+	c.evaluate()
+	return nil
+}
+
+// evaluate rolls the canary back when a candidate quality proxy regresses beyond its threshold.
+// The caller must hold c.mu.
+func (c *canaryController) evaluate() {
+	if c.status.RolledBack {
+		return
+	}
+	base, cand := c.stats[CanaryBaseline], c.stats[CanaryCandidate]
+	minSamples := max(c.cfg.MinSamples, 1)
+
+	reason := ""
+	if base.Requests >= minSamples && cand.Requests >= minSamples {
+		baseSuccesses, candSuccesses := float64(base.Requests-base.Errors), float64(cand.Requests-cand.Errors)
+		errorRateIncrease := float64(cand.Errors)/float64(cand.Requests) - float64(base.Errors)/float64(base.Requests)
+		switch {
+		case c.cfg.MaxErrorRateIncrease > 0 && errorRateIncrease > c.cfg.MaxErrorRateIncrease:
+			reason = fmt.Sprintf("error rate increased by %.3f", errorRateIncrease)
+		case baseSuccesses > 0 && candSuccesses > 0 && c.cfg.MaxConfidenceDrop > 0 &&
+			base.confidenceSum/baseSuccesses-cand.confidenceSum/candSuccesses > c.cfg.MaxConfidenceDrop:
+			reason = fmt.Sprintf("confidence dropped by %.3f", base.confidenceSum/baseSuccesses-cand.confidenceSum/candSuccesses)
+		case baseSuccesses > 0 && candSuccesses > 0 && c.cfg.MaxGroundednessDrop > 0 &&
+			base.groundednessSum/baseSuccesses-cand.groundednessSum/candSuccesses > c.cfg.MaxGroundednessDrop:
+			reason = fmt.Sprintf("groundedness dropped by %.3f", base.groundednessSum/baseSuccesses-cand.groundednessSum/candSuccesses)
+		}
+	}
+	if reason == "" && base.FeedbackCount >= minSamples && cand.FeedbackCount >= minSamples && c.cfg.MaxFeedbackDrop > 0 {
+		if drop := base.feedbackSum/float64(base.FeedbackCount) - cand.feedbackSum/float64(cand.FeedbackCount); drop > c.cfg.MaxFeedbackDrop {
+			reason = fmt.Sprintf("user feedback dropped by %.3f", drop)
+		}
+	}
+	if reason == "" {
+		return
+	}
+
+	c.status.RolledBack = true
+	c.status.RollbackReason = reason
+	c.status.RolledBackAt = time.Now()
+	c.status.Percent = 0
+	c.baseline.config.Metrics.IncCounter("agentic_rag_canary_rollbacks_total", 1)
+}
+
+// snapshot returns the current rollout status
+func (c *canaryController) snapshot() CanaryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status
+	variantStats := func(stats *CanaryVariantStats) CanaryVariantStats {
+		summary := *stats
+		if summary.Requests > 0 {
+			summary.ErrorRate = float64(summary.Errors) / float64(summary.Requests)
+		}
+		if successes := summary.Requests - summary.Errors; successes > 0 {
+			summary.MeanConfidence = summary.confidenceSum / float64(successes)
+			summary.MeanGroundedness = summary.groundednessSum / float64(successes)
+		}
+		if summary.FeedbackCount > 0 {
+			summary.MeanFeedback = summary.feedbackSum / float64(summary.FeedbackCount)
+		}
+		return summary
+	}
+	status.Baseline = variantStats(c.stats[CanaryBaseline])
+	status.Candidate = variantStats(c.stats[CanaryCandidate])
+	return status
+}
+
+// responseConfidence is the mean claim confidence when facts were verified, else the mean relevance of the answer's chunks
+func responseConfidence(response *AgenticRAGResponse) float64 {
+	if response.FactVerification != nil && len(response.FactVerification.Claims) > 0 {
+		total := 0.0
+		for _, claim := range response.FactVerification.Claims {
+			total += claim.Confidence
+		}
+		return total / float64(len(response.FactVerification.Claims))
+	}
+	if len(response.RelevantChunks) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, chunk := range response.RelevantChunks {
+		total += chunk.Chunk.RelevanceScore
+	}
+	return total / float64(len(response.RelevantChunks))
+}
+
+// responseGroundedness is the share of verified citations that are supported, or whether the answer cites anything when citations were not verified
+func responseGroundedness(response *AgenticRAGResponse) float64 {
+	if len(response.CitationChecks) > 0 {
+		supported := 0
+		for _, check := range response.CitationChecks {
+			if check.Supported {
+				supported++
+			}
+		}
+		return float64(supported) / float64(len(response.CitationChecks))
+	}
+	if len(response.Citations) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// RecordCanaryFeedback attributes a user rating to the canary variant that served a response
+func (p *AgenticRAGProcessor) RecordCanaryFeedback(feedback CanaryFeedback) error {
+	if p.canary == nil {
+		return fmt.Errorf("no canary is running")
+	}
+	return p.canary.recordFeedback(feedback)
+}
+
+// CanaryStatus returns the canary rollout state
+func (p *AgenticRAGProcessor) CanaryStatus() (CanaryStatus, error) {
+	if p.canary == nil {
+		return CanaryStatus{}, fmt.Errorf("no canary is running")
+	}
+	return p.canary.snapshot(), nil
+}
@@ -0,0 +1,214 @@
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Pipeline stages after which state is checkpointed, in execution order
+const (
+	StageStarted = "started"
+	StageLoaded  = "loaded"  // Documents loaded, filtered, and the query normalized
+	StageChunked = "chunked" // Documents chunked
+	StageScored  = "scored"  // Relevant chunks selected
+	StageRefined = "refined" // Recursive refinement finished
+)
+
+// pipelineStages orders the checkpointed stages
+var pipelineStages = []string{StageStarted, StageLoaded, StageChunked, StageScored, StageRefined}
+
+// PipelineState is the serializable intermediate state of a request, saved after each stage
+type PipelineState struct {
+	ID                 string                     `json:"id"`
+	Stage              string                     `json:"stage"`
+	Request            AgenticRAGRequest          `json:"request"` // Request with resolved options
+	UpdatedAt          time.Time                  `json:"updated_at"`
+	Routing            *RoutingDecision           `json:"routing,omitempty"`
+	CollectionRouting  *CollectionRoutingDecision `json:"collection_routing,omitempty"`
+	Documents          []Document                 `json:"documents,omitempty"`
+	SecretFindings     []SecretFinding            `json:"secret_findings,omitempty"`
+	ExcludedDocuments  int                        `json:"excluded_documents,omitempty"`
+	TimedOutSources    []string                   `json:"timed_out_sources,omitempty"`
+	QueryNormalization *QueryNormalization        `json:"query_normalization,omitempty"`
+	Chunks             []DocumentChunk            `json:"chunks,omitempty"`
+	ParentChunks       []DocumentChunk            `json:"parent_chunks,omitempty"` // Parents of Chunks in small-to-big retrieval
+	RelevantChunks     []DocumentChunk            `json:"relevant_chunks,omitempty"`
+	FinalChunks        []DocumentChunk            `json:"final_chunks,omitempty"`
+	RecursiveLevels    int                        `json:"recursive_levels,omitempty"`
+}
+
+// reached reports whether the state has completed the given stage
+func (s *PipelineState) reached(stage string) bool {
+	current, target := -1, -1
+	for i, name := range pipelineStages {
+		if name == s.Stage {
+			current = i
+		}
+		if name == stage {
+			target = i
+		}
+	}
+	return current >= target
+}
+
+// CheckpointStore persists pipeline state so long-running requests survive a process restart
+type CheckpointStore interface {
+	Save(ctx context.Context, state *PipelineState) error
+	Load(ctx context.Context, id string) (*PipelineState, error) // Returns nil without error when no checkpoint exists
+	Delete(ctx context.Context, id string) error
+}
+
+// loadCheckpoint returns the saved state for the request's checkpoint, or fresh state when there
+// is none. Checkpoint IDs are caller-supplied, so a checkpoint saved for another tenant or query is
+// rejected rather than resumed; the request keeps its own options, and only Resume continues with
+// the stored request.
+func (p *AgenticRAGProcessor) loadCheckpoint(ctx context.Context, request AgenticRAGRequest) (*PipelineState, error) {
+	fresh := &PipelineState{ID: request.CheckpointID, Stage: StageStarted, Request: request}
+	if p.config.Checkpoints == nil || request.CheckpointID == "" {
+		return fresh, nil
+	}
+
+	state, err := p.config.Checkpoints.Load(ctx, request.CheckpointID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint %s: %w", request.CheckpointID, err)
+	}
+	if state == nil {
+		return fresh, nil
+	}
+	if state.Request.TenantID != request.TenantID || state.Request.Query != request.Query {
+		return nil, fmt.Errorf("checkpoint %s belongs to another request", request.CheckpointID)
+	}
+	for i := range state.Documents {
+		if err := restoreDocumentMetadata(&state.Documents[i]); err != nil {
+			return nil, fmt.Errorf("failed to restore checkpoint %s: %w", request.CheckpointID, err)
+		}
+	}
+	p.config.Metrics.IncCounter("agentic_rag_checkpoints_resumed_total", 1)
+	return state, nil
+}
+
+// saveCheckpoint records that the state completed a stage
+func (p *AgenticRAGProcessor) saveCheckpoint(ctx context.Context, state *PipelineState, stage string) error {
+	state.Stage = stage
+	if p.config.Checkpoints == nil || state.ID == "" {
+		return nil
+	}
+	state.UpdatedAt = time.Now()
+	if err := p.config.Checkpoints.Save(ctx, state); err != nil {
+		return fmt.Errorf("failed to save checkpoint %s: %w", state.ID, err)
+	}
+	return nil
+}
+
+// clearCheckpoint removes the checkpoint of a finished request
+func (p *AgenticRAGProcessor) clearCheckpoint(ctx context.Context, state *PipelineState) error {
+	if p.config.Checkpoints == nil || state.ID == "" {
+		return nil
+	}
+	if err := p.config.Checkpoints.Delete(ctx, state.ID); err != nil {
+		return fmt.Errorf("failed to delete checkpoint %s: %w", state.ID, err)
+	}
+	return nil
+}
+
+// Resume continues a checkpointed request from its last completed stage
+func (p *AgenticRAGProcessor) Resume(ctx context.Context, checkpointID string) (*AgenticRAGResponse, error) {
+	if p.config.Checkpoints == nil {
+		return nil, fmt.Errorf("no checkpoint store configured")
+	}
+	state, err := p.config.Checkpoints.Load(ctx, checkpointID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint %s: %w", checkpointID, err)
+	}
+	if state == nil {
+		return nil, fmt.Errorf("checkpoint %s not found", checkpointID)
+	}
+	return p.Process(ctx, state.Request)
+}
+
+// restoreDocumentMetadata re-types metadata values that chunking reads as Go types after a JSON round trip
+func restoreDocumentMetadata(doc *Document) error {
+	if raw, ok := doc.Metadata[sectionsMetadataKey]; ok {
+		var sections []DocumentSection
+		if err := convertJSON(raw, &sections); err != nil {
+			return err
+		}
+		doc.Metadata[sectionsMetadataKey] = sections
+	}
+	if raw, ok := doc.Metadata[transcriptSegmentsMetadataKey]; ok {
+		var segments []TranscriptSegment
+		if err := convertJSON(raw, &segments); err != nil {
+			return err
+		}
+		doc.Metadata[transcriptSegmentsMetadataKey] = segments
+	}
+	return nil
+}
+
+// SQLCheckpointStore stores pipeline state in a SQL table, e.g. a Turso/libSQL database opened with its database/sql driver
+type SQLCheckpointStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLCheckpointStore creates the checkpoint table if needed
+func NewSQLCheckpointStore(ctx context.Context, db *sql.DB, table string) (*SQLCheckpointStore, error) {
+	if table == "" {
+		table = "pipeline_checkpoints"
+	}
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id TEXT PRIMARY KEY,
+		stage TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		state TEXT NOT NULL
+	)`, table)
+	if _, err := db.ExecContext(ctx, statement); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint table: %w", err)
+	}
+	return &SQLCheckpointStore{db: db, table: table}, nil
+}
+
+// Save inserts or replaces the checkpoint
+func (s *SQLCheckpointStore) Save(ctx context.Context, state *PipelineState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (id, stage, updated_at, state) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET stage = excluded.stage, updated_at = excluded.updated_at, state = excluded.state`, s.table)
+	if _, err := s.db.ExecContext(ctx, query, state.ID, state.Stage, state.UpdatedAt.UnixNano(), string(data)); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load reads a checkpoint
+func (s *SQLCheckpointStore) Load(ctx context.Context, id string) (*PipelineState, error) {
+	var data string
+	query := fmt.Sprintf("SELECT state FROM %s WHERE id = ?", s.table)
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var state PipelineState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return &state, nil
+}
+
+// Delete removes a checkpoint
+func (s *SQLCheckpointStore) Delete(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", s.table)
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
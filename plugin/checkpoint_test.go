@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+)
+
+// memoryCheckpointStore keeps checkpoints in a map
+type memoryCheckpointStore map[string]*PipelineState
+
+func (s memoryCheckpointStore) Save(ctx context.Context, state *PipelineState) error {
+	s[state.ID] = state
+	return nil
+}
+
+func (s memoryCheckpointStore) Load(ctx context.Context, id string) (*PipelineState, error) {
+	return s[id], nil
+}
+
+func (s memoryCheckpointStore) Delete(ctx context.Context, id string) error {
+	delete(s, id)
+	return nil
+}
+
+func TestLoadCheckpointRejectsOtherRequests(t *testing.T) {
+	config := DefaultConfig()
+	store := memoryCheckpointStore{}
+	config.Checkpoints = store
+	p := NewAgenticRAGProcessor(config)
+
+	saved := AgenticRAGRequest{Query: "quarterly revenue", TenantID: "tenant-a", CheckpointID: "cp-1"}
+	store["cp-1"] = &PipelineState{ID: "cp-1", Stage: StageChunked, Request: saved}
+
+	tests := []struct {
+		name    string
+		request AgenticRAGRequest
+		wantErr bool
+	}{
+		{"same tenant and query", saved, false},
+		{"other tenant", AgenticRAGRequest{Query: saved.Query, TenantID: "tenant-b", CheckpointID: "cp-1"}, true},
+		{"other query", AgenticRAGRequest{Query: "headcount", TenantID: saved.TenantID, CheckpointID: "cp-1"}, true},
+		{"unknown checkpoint", AgenticRAGRequest{Query: "headcount", TenantID: "tenant-b", CheckpointID: "cp-2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := p.loadCheckpoint(context.Background(), tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadCheckpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && state.Request.TenantID != tt.request.TenantID {
+				t.Errorf("loadCheckpoint() returned state of tenant %q", state.Request.TenantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package plugin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChunkEmbeddingConfig contains configuration for returning chunk embeddings in responses
+type ChunkEmbeddingConfig struct {
+	EmbedderName string         `json:"embedder_name,omitempty"` // Embedder override; defaults to the per-language embedder
+	Store        EmbeddingStore `json:"-"`                       // When set, responses carry URLs to stored embeddings instead of vectors
+}
+
+// EmbeddingStore persists chunk embeddings and returns a URL clients can fetch them from
+type EmbeddingStore interface {
+	Put(ctx context.Context, key string, embedding []float32) (string, error)
+}
+
+// attachEmbeddings embeds the response chunks, inlining the vectors or storing them behind URLs
+func (p *AgenticRAGProcessor) attachEmbeddings(ctx context.Context, chunks []ProcessedChunk) error {
+	// Chunks are embedded in one batch per embedder, since languages may use different embedders
+	groups := make(map[string][]int)
+	for i, processed := range chunks {
+		embedderName := p.config.ChunkEmbeddings.EmbedderName
+		if embedderName == "" {
+			embedderName = p.embedderNameFor(chunkLanguage(processed.Chunk))
+		}
+		if embedderName == "" {
+			return fmt.Errorf("no embedder configured")
+		}
+		groups[embedderName] = append(groups[embedderName], i)
+	}
+
+	for embedderName, indexes := range groups {
+		texts := make([]string, len(indexes))
+		for j, index := range indexes {
+			texts[j] = chunks[index].Chunk.Content
+		}
+		embeddings, err := p.embedTexts(ctx, embedderName, texts)
+		if err != nil {
+			return err
+		}
+
+		for j, index := range indexes {
+			chunks[index].EmbeddingModel = embedderName
+			if p.config.ChunkEmbeddings.Store == nil {
+				chunks[index].Embedding = embeddings[j]
+				continue
+			}
+			location, err := p.config.ChunkEmbeddings.Store.Put(ctx, chunks[index].Chunk.ID, embeddings[j])
+			if err != nil {
+				return fmt.Errorf("failed to store embedding for %s: %w", chunks[index].Chunk.ID, err)
+			}
+			chunks[index].EmbeddingURL = location
+		}
+	}
+	return nil
+}
+
+// MemoryEmbeddingStore keeps embeddings in memory and serves them over HTTP behind expiring signed URLs
+type MemoryEmbeddingStore struct {
+	mu         sync.Mutex
+	baseURL    string
+	secret     []byte
+	ttl        time.Duration
+	embeddings map[string]storedEmbedding
+}
+
+// storedEmbedding is an embedding held until it expires
+type storedEmbedding struct {
+	vector    []float32
+	expiresAt time.Time
+}
+
+// NewMemoryEmbeddingStore creates a store whose URLs start with baseURL and are valid for ttl
+func NewMemoryEmbeddingStore(baseURL string, secret []byte, ttl time.Duration) *MemoryEmbeddingStore {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &MemoryEmbeddingStore{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		secret:     secret,
+		ttl:        ttl,
+		embeddings: make(map[string]storedEmbedding),
+	}
+}
+
+// Put stores an embedding and returns its signed URL
+func (s *MemoryEmbeddingStore) Put(ctx context.Context, key string, embedding []float32) (string, error) {
+	if len(s.secret) == 0 {
+		return "", fmt.Errorf("embedding store requires a signing secret")
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	id := fmt.Sprintf("%s_%d", key, expiresAt.UnixNano())
+
+	s.mu.Lock()
+	s.pruneLocked()
+	s.embeddings[id] = storedEmbedding{vector: embedding, expiresAt: expiresAt}
+	s.mu.Unlock()
+
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(id, expires)}}
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, url.PathEscape(id), query.Encode()), nil
+}
+
+// ServeHTTP returns the embedding named by the last path segment when the URL signature is valid
+func (s *MemoryEmbeddingStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+	if err != nil {
+		http.Error(w, "invalid embedding id", http.StatusBadRequest)
+		return
+	}
+
+	expires := r.URL.Query().Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(s.sign(id, expires))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	if time.Now().After(time.Unix(unix, 0)) {
+		http.Error(w, "link expired", http.StatusGone)
+		return
+	}
+
+	s.mu.Lock()
+	stored, ok := s.embeddings[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "embedding": stored.vector})
+}
+
+// sign returns the hex HMAC-SHA256 signature of an embedding ID and expiry
+func (s *MemoryEmbeddingStore) sign(id, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id + "|" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// pruneLocked drops expired embeddings; the caller must hold mu
+func (s *MemoryEmbeddingStore) pruneLocked() {
+	now := time.Now()
+	for id, stored := range s.embeddings {
+		if now.After(stored.expiresAt) {
+			delete(s.embeddings, id)
+		}
+	}
+}
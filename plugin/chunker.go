@@ -0,0 +1,306 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Built-in chunkers
+const (
+	ChunkerSentence  = "sentence"  // Sentences packed by character count
+	ChunkerToken     = "token"     // Sentences packed by model token count
+	ChunkerSemantic  = "semantic"  // Sentences packed by token count and split where the topic shifts
+	ChunkerMarkdown  = "markdown"  // Heading sections, whole code blocks and tables, then sentences (default)
+	ChunkerRecursive = "recursive" // Paragraphs, then lines, sentences, and words, using configurable separators
+)
+
+// Chunker splits a document into at most maxChunks chunks of at most chunkSize each
+type Chunker interface {
+	Chunk(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error)
+}
+
+// ChunkerFunc adapts a function to the Chunker interface
+type ChunkerFunc func(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error)
+
+// Chunk calls the function
+func (f ChunkerFunc) Chunk(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	return f(ctx, doc, chunkSize, maxChunks)
+}
+
+// chunkerFor returns the named chunker, falling back to the configured default.
+// Chunkers registered in the config take precedence over the built-in ones.
+func (p *AgenticRAGProcessor) chunkerFor(name string) (Chunker, error) {
+	if name == "" {
+		name = p.config.Processing.Chunker
+	}
+	if name == "" {
+		name = ChunkerMarkdown
+	}
+	if chunker, ok := p.config.Chunkers[name]; ok {
+		return chunker, nil
+	}
+
+	switch name {
+	case ChunkerSentence:
+		return ChunkerFunc(p.chunkSentences), nil
+	case ChunkerToken:
+		return ChunkerFunc(p.chunkTokens), nil
+	case ChunkerSemantic:
+		return ChunkerFunc(p.chunkSemantic), nil
+	case ChunkerMarkdown:
+		return ChunkerFunc(p.chunkMarkdown), nil
+	case ChunkerRecursive:
+		return ChunkerFunc(p.chunkRecursive), nil
+	default:
+		return nil, fmt.Errorf("unknown chunker %q", name)
+	}
+}
+
+// chunkDocumentWith chunks a document with the named chunker
+func (p *AgenticRAGProcessor) chunkDocumentWith(ctx context.Context, name string, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	chunker, err := p.chunkerFor(name)
+	if err != nil {
+		return nil, err
+	}
+	return chunker.Chunk(ctx, doc, chunkSize, maxChunks)
+}
+
+// chunkSentences packs sentences into chunks measured in characters
+func (p *AgenticRAGProcessor) chunkSentences(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	measure := func(text string) int { return len(text) }
+	units := p.sentenceUnits(doc, chunkSize, measure)
+	return p.packUnits(ctx, doc, units, nil, chunkSize, maxChunks, measure), nil
+}
+
+// chunkTokens packs sentences into chunks measured in model tokens
+func (p *AgenticRAGProcessor) chunkTokens(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	measure := p.tokenizer().CountTokens
+	units := p.sentenceUnits(doc, chunkSize, measure)
+	return p.packUnits(ctx, doc, units, nil, chunkSize, maxChunks, measure), nil
+}
+
+// chunkSemantic packs sentences into chunks measured in model tokens, splitting where the topic shifts
+func (p *AgenticRAGProcessor) chunkSemantic(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	measure := p.tokenizer().CountTokens
+	units := p.sentenceUnits(doc, chunkSize, measure)
+	breaks := p.topicBreaks(ctx, unitTexts(units), documentLanguage(doc))
+	return p.packUnits(ctx, doc, units, breaks, chunkSize, maxChunks, measure), nil
+}
+
+// chunkMarkdown chunks heading sections separately, keeping code blocks and tables whole, and
+// splits on topic shifts when semantic chunking is enabled
+func (p *AgenticRAGProcessor) chunkMarkdown(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	// Structured documents are split on their sections before sentences
+	if sections := documentSections(doc); len(sections) > 0 {
+		return p.chunkSections(ctx, doc, sections, chunkSize, maxChunks)
+	}
+
+	// Chunk sizes are measured in model tokens unless configured in characters
+	language := documentLanguage(doc)
+	measure := p.chunkMeasure()
+	units := p.chunkUnits(doc.Content, language, chunkSize, measure)
+	breaks := p.semanticBreaks(ctx, unitTexts(units), language)
+	return p.packUnits(ctx, doc, units, breaks, chunkSize, maxChunks, measure), nil
+}
+
+// sentenceUnits splits the document into sentences that each fit in a chunk
+func (p *AgenticRAGProcessor) sentenceUnits(doc Document, chunkSize int, measure func(string) int) []chunkUnit {
+	return p.sentenceUnitsIn(doc.Content, 0, documentLanguage(doc), chunkSize, measure)
+}
+
+// sentenceUnitsIn splits text, found at offset base of the chunked content, into sentence units
+// that each fit in a chunk. Oversized sentences are broken up and each piece located in the text.
+func (p *AgenticRAGProcessor) sentenceUnitsIn(text string, base int, language string, chunkSize int, measure func(string) int) []chunkUnit {
+	units := make([]chunkUnit, 0)
+	for _, span := range p.analyzerFor(language).sentenceSpans(text) {
+		sentence := text[span[0]:span[1]]
+		if chunkSize <= 0 || measure(sentence) <= chunkSize {
+			units = append(units, chunkUnit{text: sentence, start: base + span[0], end: base + span[1]})
+			continue
+		}
+		from := span[0]
+		for _, piece := range splitOversized([]string{sentence}, chunkSize, measure) {
+			start, end, ok := locateText(text, piece, from, span[1])
+			if !ok {
+				start, end = from, from
+			}
+			units = append(units, chunkUnit{text: piece, start: base + start, end: base + end})
+			from = end
+		}
+	}
+	return units
+}
+
+// locateText finds the span of source[from:limit] covering the words of text in order. Words that
+// do not occur are skipped, so text rejoined with different whitespace or punctuation still maps
+// back to the source.
+func locateText(source, text string, from, limit int) (int, int, bool) {
+	limit = min(limit, len(source))
+	start, end := -1, from
+	for _, word := range strings.Fields(text) {
+		if end >= limit {
+			break
+		}
+		index := strings.Index(source[end:limit], word)
+		if index < 0 {
+			continue
+		}
+		if start < 0 {
+			start = end + index
+		}
+		end += index + len(word)
+	}
+	if start < 0 {
+		return from, from, false
+	}
+	return start, end, true
+}
+
+// unitTexts returns the text of each unit
+func unitTexts(units []chunkUnit) []string {
+	texts := make([]string, len(units))
+	for i, unit := range units {
+		texts[i] = unit.text
+	}
+	return texts
+}
+
+// packUnits packs units into chunks of at most chunkSize as measured by measure, also starting a
+// chunk wherever breaks is set; code blocks and tables keep their syntax. Each unit is measured
+// once and chunk content is built in place, so packing stays linear in the document size.
+func (p *AgenticRAGProcessor) packUnits(ctx context.Context, doc Document, units []chunkUnit, breaks []bool, chunkSize, maxChunks int, measure func(string) int) []DocumentChunk {
+	chunks := make([]DocumentChunk, 0)
+
+	var current strings.Builder
+	currentSize := 0
+	currentStart, currentEnd := 0, 0 // Span of the current chunk's units in the document
+	currentHasCode := false
+	currentIsTable := false
+	chunkIndex := 0
+
+	emit := func() {
+		chunk := DocumentChunk{
+			ID:         doc.ID + "_chunk_" + strconv.Itoa(chunkIndex),
+			Content:    strings.TrimSpace(current.String()),
+			DocumentID: doc.ID,
+			ChunkIndex: chunkIndex,
+			StartIndex: currentStart,
+			EndIndex:   currentEnd,
+			Metadata:   newChunkMetadata(doc),
+		}
+		if currentHasCode {
+			chunk.Metadata["has_code"] = true
+		}
+		if currentIsTable {
+			chunk.Metadata["table"] = true
+		}
+		chunks = append(chunks, chunk)
+
+		// Size the next chunk's buffer like this one; the finished content keeps its own buffer
+		previousLen := current.Len()
+		current = strings.Builder{}
+		current.Grow(previousLen)
+	}
+
+	// appendUnit writes a unit, placing code blocks and tables on their own lines so their syntax stays valid
+	appendUnit := func(unit chunkUnit) {
+		if unit.code || unit.table {
+			current.WriteByte('\n')
+			current.WriteString(unit.text)
+			current.WriteByte('\n')
+		} else {
+			current.WriteString(unit.text)
+			current.WriteByte(' ')
+		}
+	}
+
+	for i, unit := range units {
+		unitSize := measure(unit.text)
+
+		// If adding this sentence would exceed chunk size or start a new topic, finalize current chunk;
+		// tables always form a chunk of their own
+		startsChunk := currentSize+unitSize > chunkSize || (breaks != nil && breaks[i]) || unit.table || currentIsTable
+		if startsChunk && current.Len() > 0 {
+			emit()
+
+			// Start new chunk
+			chunkIndex++
+			currentStart, currentEnd = unit.start, unit.end
+			appendUnit(unit)
+			currentSize = measure(current.String())
+			currentHasCode = unit.code
+			currentIsTable = unit.table
+
+			// Stop if we've reached max chunks
+			if len(chunks) >= maxChunks {
+				break
+			}
+		} else {
+			if current.Len() == 0 {
+				currentStart = unit.start
+			}
+			currentEnd = unit.end
+			before := current.Len()
+			appendUnit(unit)
+			currentSize += measure(current.String()[before:])
+			currentHasCode = currentHasCode || unit.code
+			currentIsTable = unit.table
+		}
+	}
+
+	// Add final chunk if it has content
+	if current.Len() > 0 && len(chunks) < maxChunks {
+		emit()
+	}
+
+	// Table chunks carry a prose summary for matching queries against their contents
+	for i := range chunks {
+		if chunks[i].Metadata["table"] == true {
+			chunks[i].Metadata["table_summary"] = p.summarizeTable(ctx, chunks[i].Content)
+		}
+	}
+
+	// Transcript chunks carry the time span of the recording they cover
+	if segments, ok := doc.Metadata[transcriptSegmentsMetadataKey].([]TranscriptSegment); ok {
+		annotateChunkTimestamps(chunks, segments)
+	}
+
+	return chunks
+}
+
+// chunkSections chunks each heading section separately so no chunk spans two sections
+func (p *AgenticRAGProcessor) chunkSections(ctx context.Context, doc Document, sections []DocumentSection, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	chunks := make([]DocumentChunk, 0)
+	for _, section := range sections {
+		if len(chunks) >= maxChunks {
+			break
+		}
+		if section.Start < 0 || section.End > len(doc.Content) || section.Start >= section.End {
+			continue
+		}
+
+		sectionDoc := doc
+		sectionDoc.Content = doc.Content[section.Start:section.End]
+		sectionDoc.Metadata = newChunkMetadata(doc)
+		if section.Level > 0 {
+			sectionDoc.Metadata["section"] = section.Heading
+			sectionDoc.Metadata["heading_path"] = strings.Join(section.Path, " > ")
+			sectionDoc.Metadata["section_level"] = section.Level
+		}
+
+		sectionChunks, err := p.chunkMarkdown(ctx, sectionDoc, chunkSize, maxChunks-len(chunks))
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range sectionChunks {
+			chunk.ChunkIndex = len(chunks)
+			chunk.ID = fmt.Sprintf("%s_chunk_%d", doc.ID, chunk.ChunkIndex)
+			chunk.StartIndex += section.Start
+			chunk.EndIndex += section.Start
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
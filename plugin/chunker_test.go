@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// benchmarkDocument builds a prose document of about size bytes, with sentences of varied length
+// and a paragraph break every few sentences
+func benchmarkDocument(size int) Document {
+	var content strings.Builder
+	content.Grow(size + 256)
+	for i := 0; content.Len() < size; i++ {
+		content.WriteString("Sentence ")
+		content.WriteString(strconv.Itoa(i))
+		content.WriteString(" describes the retrieval pipeline")
+		for j := 0; j < i%7; j++ {
+			content.WriteString(" and its chunking of long documents")
+		}
+		content.WriteString(". ")
+		if i%5 == 4 {
+			content.WriteString("\n\n")
+		}
+	}
+	return Document{ID: "bench", Content: content.String(), Source: "bench.txt"}
+}
+
+// benchmarkChunker runs the named chunker over multi-MB documents without a chunk limit
+func benchmarkChunker(b *testing.B, name string) {
+	p := NewAgenticRAGProcessor(DefaultConfig())
+	for _, size := range []int{1 << 20, 4 << 20} {
+		doc := benchmarkDocument(size)
+		b.Run(strconv.Itoa(size>>20)+"MB", func(b *testing.B) {
+			b.SetBytes(int64(len(doc.Content)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.chunkDocumentWith(context.Background(), name, doc, 256, math.MaxInt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkChunkSentence(b *testing.B) {
+	benchmarkChunker(b, ChunkerSentence)
+}
+
+func BenchmarkChunkToken(b *testing.B) {
+	benchmarkChunker(b, ChunkerToken)
+}
+
+func BenchmarkChunkMarkdown(b *testing.B) {
+	benchmarkChunker(b, ChunkerMarkdown)
+}
+
+func BenchmarkChunkRecursive(b *testing.B) {
+	benchmarkChunker(b, ChunkerRecursive)
+}
+
+func BenchmarkChunkSplitSentences(b *testing.B) {
+	p := NewAgenticRAGProcessor(DefaultConfig())
+	doc := benchmarkDocument(4 << 20)
+	b.SetBytes(int64(len(doc.Content)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.splitIntoSentences(doc.Content, "")
+	}
+}
+
+// TestChunkCoversLargeDocument checks that chunking a multi-MB document stays within the chunk size
+// and keeps chunk spans ordered, so the benchmarks measure complete, correct runs
+func TestChunkCoversLargeDocument(t *testing.T) {
+	p := NewAgenticRAGProcessor(DefaultConfig())
+	doc := benchmarkDocument(1 << 20)
+	chunks, err := p.chunkDocumentWith(context.Background(), ChunkerSentence, doc, 256, math.MaxInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < len(doc.Content)/256 {
+		t.Fatalf("got %d chunks for %d bytes", len(chunks), len(doc.Content))
+	}
+	end := 0
+	for _, chunk := range chunks {
+		if len(chunk.Content) > 256 {
+			t.Fatalf("chunk %d has %d bytes", chunk.ChunkIndex, len(chunk.Content))
+		}
+		if chunk.StartIndex < end {
+			t.Fatalf("chunk %d starts at %d before the previous end %d", chunk.ChunkIndex, chunk.StartIndex, end)
+		}
+		end = chunk.EndIndex
+	}
+}
@@ -0,0 +1,186 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// Actions taken for citations that are not supported by the cited chunk
+const (
+	CitationActionFlag = "flag" // Keep the citation and mark it unsupported
+	CitationActionDrop = "drop" // Remove the citation marker and the citation
+)
+
+// CitationVerificationConfig contains configuration for inline citation verification
+type CitationVerificationConfig struct {
+	Enabled         bool    `json:"enabled"`
+	UseLLM          bool    `json:"use_llm"`           // Use the model for entailment; lexical overlap is used otherwise or on failure
+	MinSupportScore float64 `json:"min_support_score"` // Minimum entailment score for a citation to count as supported
+	Action          string  `json:"action"`            // CitationActionFlag or CitationActionDrop
+}
+
+// CitationCheck records whether a cited chunk supports the sentence that cites it
+type CitationCheck struct {
+	Sentence  string  `json:"sentence"`
+	Number    int     `json:"number"`
+	ChunkID   string  `json:"chunk_id"`
+	Supported bool    `json:"supported"`
+	Score     float64 `json:"score"`
+	Method    string  `json:"method"` // "llm" or "lexical"
+}
+
+// verifyCitations checks every citation marker against its cited chunk and applies the configured action
+func (p *AgenticRAGProcessor) verifyCitations(ctx context.Context, answer string, chunks []DocumentChunk) (string, []CitationCheck) {
+	cfg := p.config.CitationVerification
+	checks := make([]CitationCheck, 0)
+
+	language := p.detectLanguage(answer)
+	for _, sentence := range p.splitIntoSentences(answer, language) {
+		for _, match := range citationPattern.FindAllStringSubmatch(sentence, -1) {
+			number, err := strconv.Atoi(match[1] + match[2])
+			if err != nil || number < 1 || number > len(chunks) {
+				continue
+			}
+			checks = append(checks, CitationCheck{
+				Sentence: strings.TrimSpace(citationPattern.ReplaceAllString(sentence, "")),
+				Number:   number,
+				ChunkID:  chunks[number-1].ID,
+			})
+		}
+	}
+	if len(checks) == 0 {
+		return answer, checks
+	}
+
+	scored := false
+	if cfg.UseLLM {
+		scored = p.scoreCitationsWithLLM(ctx, checks, chunks) == nil
+	}
+	if !scored {
+		for i := range checks {
+			checks[i].Score = p.calculateRelevanceScoreForLanguage(checks[i].Sentence, chunks[checks[i].Number-1].Content, language)
+			checks[i].Method = "lexical"
+		}
+	}
+
+	unsupported := make(map[int]bool)
+	supported := make(map[int]bool)
+	for i := range checks {
+		checks[i].Supported = checks[i].Score >= cfg.MinSupportScore
+		if checks[i].Supported {
+			supported[checks[i].Number] = true
+		} else {
+			unsupported[checks[i].Number] = true
+		}
+	}
+
+	if cfg.Action == CitationActionDrop {
+		answer = dropUnsupportedCitations(answer, unsupported, supported)
+	}
+	return answer, checks
+}
+
+// scoreCitationsWithLLM asks the model whether each cited chunk entails its sentence
+func (p *AgenticRAGProcessor) scoreCitationsWithLLM(ctx context.Context, checks []CitationCheck, chunks []DocumentChunk) error {
+	var builder strings.Builder
+	for i, check := range checks {
+		builder.WriteString(fmt.Sprintf("[%d]\nStatement: %s\nPassage: %s\n\n", i, check.Sentence, chunks[check.Number-1].Content))
+	}
+
+	prompt := fmt.Sprintf(`You are an expert fact-checker. For each numbered pair below, decide whether the passage supports (entails) the statement.
+
+%s
+Respond with a JSON array where each element has "index" (the pair number) and "score" (0.0 = not supported, 1.0 = fully supported).
+
+Example: [{"index": 0, "score": 0.9}, {"index": 1, "score": 0.1}]`, builder.String())
+
+	responseText, err := p.generateText(ctx, prompt, 0.0, 1000)
+	if err != nil {
+		return err
+	}
+
+	var scores []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(responseText)), &scores); err != nil {
+		return fmt.Errorf("failed to parse citation verification response: %w", err)
+	}
+	if len(scores) != len(checks) {
+		return fmt.Errorf("citation verification returned %d scores for %d citations", len(scores), len(checks))
+	}
+
+	for _, score := range scores {
+		if score.Index < 0 || score.Index >= len(checks) {
+			return fmt.Errorf("citation verification returned invalid index %d", score.Index)
+		}
+		checks[score.Index].Score = score.Score
+		checks[score.Index].Method = "llm"
+	}
+	return nil
+}
+
+// dropUnsupportedCitations removes bracketed markers for citations that no sentence supports.
+// Prose mentions such as "According to Source 2" are left in place and only flagged.
+func dropUnsupportedCitations(answer string, unsupported, supported map[int]bool) string {
+	return citationPattern.ReplaceAllStringFunc(answer, func(marker string) string {
+		match := citationPattern.FindStringSubmatch(marker)
+		if match[2] == "" {
+			return marker
+		}
+		number, err := strconv.Atoi(match[2])
+		if err != nil || !unsupported[number] || supported[number] {
+			return marker
+		}
+		return ""
+	})
+}
+
+// flagCitations marks citations whose supporting checks all failed
+func flagCitations(citations []Citation, checks []CitationCheck) {
+	supported := make(map[int]bool)
+	checked := make(map[int]bool)
+	for _, check := range checks {
+		checked[check.Number] = true
+		if check.Supported {
+			supported[check.Number] = true
+		}
+	}
+	for i := range citations {
+		citations[i].Unsupported = checked[citations[i].Number] && !supported[citations[i].Number]
+	}
+}
+
+// generateText runs a plain-text generation with the configured model
+func (p *AgenticRAGProcessor) generateText(ctx context.Context, prompt string, temperature float64, maxTokens int) (string, error) {
+	response, err := genkit.Generate(ctx, p.config.Genkit,
+		p.modelOption(ctx),
+		p.modelMetricsOption(ctx),
+		ai.WithPrompt(prompt),
+		ai.WithConfig(&ai.GenerationCommonConfig{
+			Temperature:     temperature,
+			MaxOutputTokens: maxTokens,
+		}),
+	)
+	if err != nil {
+		return "", err
+	}
+	return response.Text(), nil
+}
+
+// extractJSON strips Markdown code fences that models often wrap around JSON output
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,179 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CollectionConfig describes a named document collection that queries can be routed to
+type CollectionConfig struct {
+	Description string   `json:"description"`        // What the collection covers, used for routing
+	Keywords    []string `json:"keywords,omitempty"` // Topic keywords that strengthen routing
+	Documents   []string `json:"documents"`          // Document sources (URLs, file paths, or raw text)
+}
+
+// CollectionRoutingConfig contains configuration for routing queries to named collections
+type CollectionRoutingConfig struct {
+	Enabled         bool    `json:"enabled"`
+	UseLLM          bool    `json:"use_llm"`           // Ask the model when no embedder is available
+	FanOutThreshold float64 `json:"fan_out_threshold"` // Below this confidence, query several collections
+	MaxCollections  int     `json:"max_collections"`   // Maximum collections queried when fanning out
+}
+
+// CollectionRoutingDecision records which collections were searched for a query
+type CollectionRoutingDecision struct {
+	Collections []string           `json:"collections"`
+	Scores      map[string]float64 `json:"scores,omitempty"`
+	Confidence  float64            `json:"confidence"`
+	Method      string             `json:"method"` // "explicit", "embedding", "llm", or "lexical"
+	FannedOut   bool               `json:"fanned_out,omitempty"`
+}
+
+// collectionSources resolves the collections to search and returns their document sources
+func (p *AgenticRAGProcessor) collectionSources(ctx context.Context, query string, requested []string) ([]string, *CollectionRoutingDecision, error) {
+	var decision *CollectionRoutingDecision
+	if len(requested) > 0 {
+		decision = &CollectionRoutingDecision{Collections: requested, Confidence: 1.0, Method: "explicit"}
+	} else {
+		decision = p.routeCollections(ctx, query)
+	}
+
+	sources := make([]string, 0)
+	for _, name := range decision.Collections {
+		collection, ok := p.config.Collections[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown collection %q", name)
+		}
+		sources = append(sources, collection.Documents...)
+	}
+	return sources, decision, nil
+}
+
+// corpusDocuments loads every document in the configured collections, in collection name order
+func (p *AgenticRAGProcessor) corpusDocuments(ctx context.Context) ([]Document, error) {
+	names := make([]string, 0, len(p.config.Collections))
+	for name := range p.config.Collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sources := make([]string, 0)
+	for _, name := range names {
+		sources = append(sources, p.config.Collections[name].Documents...)
+	}
+
+	documents, timedOut, err := p.loadDocuments(ctx, sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load corpus documents: %w", err)
+	}
+	if len(timedOut) > 0 {
+		return nil, fmt.Errorf("failed to load corpus documents: %d sources timed out", len(timedOut))
+	}
+	return documents, nil
+}
+
+// routeCollections picks the collection(s) most likely to answer the query
+func (p *AgenticRAGProcessor) routeCollections(ctx context.Context, query string) *CollectionRoutingDecision {
+	cfg := p.config.CollectionRouting
+
+	names := make([]string, 0, len(p.config.Collections))
+	for name := range p.config.Collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scores, method := p.scoreCollections(ctx, query, names)
+	sort.SliceStable(names, func(i, j int) bool {
+		return scores[names[i]] > scores[names[j]]
+	})
+
+	// Confidence is how clearly the best collection beats the runner-up
+	confidence := 1.0
+	if len(names) > 1 {
+		top, second := scores[names[0]], scores[names[1]]
+		confidence = 0
+		if top > 0 {
+			confidence = 1 - second/top
+		}
+	}
+
+	decision := &CollectionRoutingDecision{
+		Collections: names[:1],
+		Scores:      scores,
+		Confidence:  confidence,
+		Method:      method,
+	}
+	if confidence < cfg.FanOutThreshold && len(names) > 1 {
+		limit := cfg.MaxCollections
+		if limit <= 0 || limit > len(names) {
+			limit = len(names)
+		}
+		decision.Collections = names[:limit]
+		decision.FannedOut = true
+	}
+	return decision
+}
+
+// scoreCollections scores each collection against the query, preferring embeddings, then the model, then term overlap
+func (p *AgenticRAGProcessor) scoreCollections(ctx context.Context, query string, names []string) (map[string]float64, string) {
+	profiles := make([]string, len(names))
+	for i, name := range names {
+		collection := p.config.Collections[name]
+		profiles[i] = strings.TrimSpace(fmt.Sprintf("%s: %s %s", name, collection.Description, strings.Join(collection.Keywords, " ")))
+	}
+
+	if embedderName := p.embedderNameFor(p.detectLanguage(query)); embedderName != "" {
+		embeddings, err := p.embedTexts(ctx, embedderName, append([]string{query}, profiles...))
+		if err == nil {
+			scores := make(map[string]float64, len(names))
+			for i, name := range names {
+				scores[name] = cosineSimilarity(embeddings[0], embeddings[i+1])
+			}
+			return scores, "embedding"
+		}
+	}
+
+	if p.config.CollectionRouting.UseLLM {
+		if scores, err := p.scoreCollectionsWithLLM(ctx, query, names, profiles); err == nil {
+			return scores, "llm"
+		}
+	}
+
+	scores := make(map[string]float64, len(names))
+	for i, name := range names {
+		scores[name] = p.calculateRelevanceScore(query, profiles[i])
+	}
+	return scores, "lexical"
+}
+
+// scoreCollectionsWithLLM asks the model how likely each collection is to answer the query
+func (p *AgenticRAGProcessor) scoreCollectionsWithLLM(ctx context.Context, query string, names, profiles []string) (map[string]float64, error) {
+	prompt := fmt.Sprintf(`You are routing a question to the document collections most likely to answer it.
+
+Question: %s
+
+Collections:
+%s
+Respond with a JSON object mapping each collection name to the probability (0.0-1.0) that it contains the answer.
+
+Example: {"billing": 0.8, "api-docs": 0.2}`, query, "- "+strings.Join(profiles, "\n- ")+"\n")
+
+	responseText, err := p.generateText(ctx, prompt, 0.0, 500)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed map[string]float64
+	if err := json.Unmarshal([]byte(extractJSON(responseText)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse collection routing response: %w", err)
+	}
+
+	scores := make(map[string]float64, len(names))
+	for _, name := range names {
+		scores[name] = parsed[name]
+	}
+	return scores, nil
+}
@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Answer diff operations
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// AnswerDiffOp is a run of words kept, inserted, or deleted between the two answers
+type AnswerDiffOp struct {
+	Op   string `json:"op"` // DiffEqual, DiffInsert, or DiffDelete
+	Text string `json:"text"`
+}
+
+// CitationChange records a chunk cited by only one of the two answers
+type CitationChange struct {
+	Change     string `json:"change"` // "added" or "removed"
+	ChunkID    string `json:"chunk_id"`
+	DocumentID string `json:"document_id"`
+	Title      string `json:"title,omitempty"`
+}
+
+// ScoreMovement records how a retrieved chunk's relevance and rank moved; ranks are 1-based and 0 when absent
+type ScoreMovement struct {
+	ChunkID        string  `json:"chunk_id"`
+	BaselineScore  float64 `json:"baseline_score"`
+	CandidateScore float64 `json:"candidate_score"`
+	Delta          float64 `json:"delta"`
+	BaselineRank   int     `json:"baseline_rank"`
+	CandidateRank  int     `json:"candidate_rank"`
+}
+
+// CostDelta compares the resources the two runs used
+type CostDelta struct {
+	BaselineTokens      int           `json:"baseline_tokens"`
+	CandidateTokens     int           `json:"candidate_tokens"`
+	TokensDelta         int           `json:"tokens_delta"`
+	BaselineModelCalls  int           `json:"baseline_model_calls"`
+	CandidateModelCalls int           `json:"candidate_model_calls"`
+	ModelCallsDelta     int           `json:"model_calls_delta"`
+	LatencyDelta        time.Duration `json:"latency_delta"`
+}
+
+// ConfigComparison is the structured difference between the responses of two pipeline configurations
+type ConfigComparison struct {
+	Baseline        *AgenticRAGResponse `json:"baseline"`
+	Candidate       *AgenticRAGResponse `json:"candidate"`
+	AnswerChanged   bool                `json:"answer_changed"`
+	AnswerDiff      []AnswerDiffOp      `json:"answer_diff,omitempty"`
+	CitationChanges []CitationChange    `json:"citation_changes,omitempty"`
+	ScoreMovements  []ScoreMovement     `json:"score_movements,omitempty"` // Largest movements first
+	Cost            CostDelta           `json:"cost"`
+}
+
+// CompareConfigs runs the request under a baseline and a candidate configuration and diffs the results
+func CompareConfigs(ctx context.Context, request AgenticRAGRequest, baseline, candidate *AgenticRAGConfig) (*ConfigComparison, error) {
+	// Comparison runs are not recorded in the caller's session or resumed from its checkpoint
+	request.SessionID = ""
+	request.CheckpointID = ""
+
+	baselineResponse, err := NewAgenticRAGProcessor(baseline).serve(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run baseline configuration: %w", err)
+	}
+	candidateResponse, err := NewAgenticRAGProcessor(candidate).serve(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run candidate configuration: %w", err)
+	}
+	return CompareResponses(baselineResponse, candidateResponse), nil
+}
+
+// CompareResponses diffs two responses to the same request
+func CompareResponses(baseline, candidate *AgenticRAGResponse) *ConfigComparison {
+	comparison := &ConfigComparison{
+		Baseline:        baseline,
+		Candidate:       candidate,
+		AnswerChanged:   baseline.Answer != candidate.Answer,
+		CitationChanges: diffCitations(baseline.Citations, candidate.Citations),
+		ScoreMovements:  diffScores(baseline.RelevantChunks, candidate.RelevantChunks),
+		Cost: CostDelta{
+			BaselineTokens:      baseline.ProcessingMetadata.TokensUsed,
+			CandidateTokens:     candidate.ProcessingMetadata.TokensUsed,
+			TokensDelta:         candidate.ProcessingMetadata.TokensUsed - baseline.ProcessingMetadata.TokensUsed,
+			BaselineModelCalls:  baseline.ProcessingMetadata.ModelCalls,
+			CandidateModelCalls: candidate.ProcessingMetadata.ModelCalls,
+			ModelCallsDelta:     candidate.ProcessingMetadata.ModelCalls - baseline.ProcessingMetadata.ModelCalls,
+			LatencyDelta:        candidate.ProcessingMetadata.ProcessingTime - baseline.ProcessingMetadata.ProcessingTime,
+		},
+	}
+	if comparison.AnswerChanged {
+		comparison.AnswerDiff = diffWords(baseline.Answer, candidate.Answer)
+	}
+	return comparison
+}
+
+// diffWords computes a word-level diff of two texts from their longest common subsequence
+func diffWords(a, b string) []AnswerDiffOp {
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+
+	// lcs[i][j] is the common subsequence length of wordsA[i:] and wordsB[j:]
+	lcs := make([][]int, len(wordsA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(wordsB)+1)
+	}
+	for i := len(wordsA) - 1; i >= 0; i-- {
+		for j := len(wordsB) - 1; j >= 0; j-- {
+			if wordsA[i] == wordsB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]AnswerDiffOp, 0)
+	add := func(op, word string) {
+		if last := len(ops) - 1; last >= 0 && ops[last].Op == op {
+			ops[last].Text += " " + word
+			return
+		}
+		ops = append(ops, AnswerDiffOp{Op: op, Text: word})
+	}
+
+	i, j := 0, 0
+	for i < len(wordsA) && j < len(wordsB) {
+		switch {
+		case wordsA[i] == wordsB[j]:
+			add(DiffEqual, wordsA[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(DiffDelete, wordsA[i])
+			i++
+		default:
+			add(DiffInsert, wordsB[j])
+			j++
+		}
+	}
+	for ; i < len(wordsA); i++ {
+		add(DiffDelete, wordsA[i])
+	}
+	for ; j < len(wordsB); j++ {
+		add(DiffInsert, wordsB[j])
+	}
+	return ops
+}
+
+// diffCitations returns the chunks cited by only one of the answers
+func diffCitations(baseline, candidate []Citation) []CitationChange {
+	cited := func(citations []Citation) map[string]Citation {
+		byChunk := make(map[string]Citation, len(citations))
+		for _, citation := range citations {
+			byChunk[citation.ChunkID] = citation
+		}
+		return byChunk
+	}
+	baselineCited, candidateCited := cited(baseline), cited(candidate)
+
+	changes := make([]CitationChange, 0)
+	for _, citation := range baseline {
+		if _, ok := candidateCited[citation.ChunkID]; !ok {
+			changes = append(changes, CitationChange{Change: "removed", ChunkID: citation.ChunkID, DocumentID: citation.DocumentID, Title: citation.Title})
+		}
+	}
+	for _, citation := range candidate {
+		if _, ok := baselineCited[citation.ChunkID]; !ok {
+			changes = append(changes, CitationChange{Change: "added", ChunkID: citation.ChunkID, DocumentID: citation.DocumentID, Title: citation.Title})
+		}
+	}
+	return changes
+}
+
+// diffScores returns the relevance and rank movements of chunks retrieved by either run
+func diffScores(baseline, candidate []ProcessedChunk) []ScoreMovement {
+	movements := make(map[string]*ScoreMovement)
+	order := make([]string, 0)
+	movement := func(chunkID string) *ScoreMovement {
+		if _, ok := movements[chunkID]; !ok {
+			movements[chunkID] = &ScoreMovement{ChunkID: chunkID}
+			order = append(order, chunkID)
+		}
+		return movements[chunkID]
+	}
+
+	for i, chunk := range baseline {
+		m := movement(chunk.Chunk.ID)
+		m.BaselineScore = chunk.Chunk.RelevanceScore
+		m.BaselineRank = i + 1
+	}
+	for i, chunk := range candidate {
+		m := movement(chunk.Chunk.ID)
+		m.CandidateScore = chunk.Chunk.RelevanceScore
+		m.CandidateRank = i + 1
+	}
+
+	result := make([]ScoreMovement, 0)
+	for _, chunkID := range order {
+		m := movements[chunkID]
+		m.Delta = m.CandidateScore - m.BaselineScore
+		if m.Delta != 0 || m.BaselineRank != m.CandidateRank {
+			result = append(result, *m)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return math.Abs(result[i].Delta) > math.Abs(result[j].Delta)
+	})
+	return result
+}
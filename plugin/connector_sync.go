@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// syncState is the documents of an incrementally synced connector source and how far it got
+type syncState struct {
+	Cursor    time.Time           `json:"cursor"`    // Latest update time seen; the next sync fetches items updated since
+	Documents map[string]Document `json:"documents"` // By the item's ID in the source system
+}
+
+// syncedItem is a document fetched from a connector with its ID and update time in the source system
+type syncedItem struct {
+	id        string
+	updatedAt time.Time
+	document  Document
+}
+
+// syncConnectorSource returns every document of a connector source. With a sync directory
+// configured only items updated since the saved cursor are fetched and merged into the saved
+// documents; otherwise everything is fetched. Fetching from the zero time means a full sync.
+// Items deleted in the source stay in the saved documents until the sync state is removed.
+func syncConnectorSource(ctx context.Context, syncDir, source string, fetch func(ctx context.Context, since time.Time) ([]syncedItem, error)) ([]Document, error) {
+	state := &syncState{Documents: make(map[string]Document)}
+	path := ""
+	if syncDir != "" {
+		sum := sha256.Sum256([]byte(source))
+		path = filepath.Join(syncDir, hex.EncodeToString(sum[:12])+".json")
+		loaded, err := loadSyncState(path)
+		if err != nil {
+			return nil, err
+		}
+		if loaded != nil {
+			state = loaded
+		}
+	}
+
+	items, err := fetch(ctx, state.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		state.Documents[item.id] = item.document
+		if item.updatedAt.After(state.Cursor) {
+			state.Cursor = item.updatedAt
+		}
+	}
+	if path != "" {
+		if err := saveSyncState(path, state); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]string, 0, len(state.Documents))
+	for id := range state.Documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	docs := make([]Document, len(ids))
+	for i, id := range ids {
+		docs[i] = state.Documents[id]
+	}
+	return docs, nil
+}
+
+// loadSyncState reads saved sync state, returning nil when the source was never synced
+func loadSyncState(path string) (*syncState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	if state.Documents == nil {
+		state.Documents = make(map[string]Document)
+	}
+	for id, doc := range state.Documents {
+		if err := restoreDocumentMetadata(&doc); err != nil {
+			return nil, fmt.Errorf("failed to restore synced document %s: %w", id, err)
+		}
+	}
+	return &state, nil
+}
+
+// saveSyncState writes sync state atomically
+func saveSyncState(path string, state *syncState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "sync-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
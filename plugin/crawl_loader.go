@@ -0,0 +1,316 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// crawlSourcePrefix marks a source as a seed URL to crawl rather than a single page to fetch
+const crawlSourcePrefix = "crawl:"
+
+// CrawlConfig contains configuration for crawling from a seed URL
+type CrawlConfig struct {
+	MaxDepth       int           `json:"max_depth"`                 // Link hops followed from the seed page
+	MaxPages       int           `json:"max_pages"`                 // Maximum pages fetched per crawl
+	SameHost       bool          `json:"same_host"`                 // Only follow links on the seed's host
+	AllowedDomains []string      `json:"allowed_domains,omitempty"` // Domains (and their subdomains) links may lead to
+	PathPrefix     string        `json:"path_prefix,omitempty"`     // Only follow links whose path starts with this prefix
+	RespectRobots  bool          `json:"respect_robots"`            // Skip URLs disallowed by robots.txt
+	Delay          time.Duration `json:"delay"`                     // Pause between requests
+}
+
+// CrawlLoader crawls pages reachable from a "crawl:<url>" seed and loads each as a document
+type CrawlLoader struct {
+	config LoaderConfig
+	urls   *URLLoader
+
+	robotsMu sync.Mutex
+	robots   map[string]*robotsRules
+}
+
+// NewCrawlLoader creates a crawling loader; PDF pages are handed to the PDF loader when one is given
+func NewCrawlLoader(config LoaderConfig, pdf *PDFLoader) *CrawlLoader {
+	return &CrawlLoader{
+		config: config,
+		urls:   NewURLLoader(config, pdf),
+		robots: make(map[string]*robotsRules),
+	}
+}
+
+// CanLoad reports whether the source is a crawl seed
+func (l *CrawlLoader) CanLoad(source string) bool {
+	return strings.HasPrefix(source, crawlSourcePrefix) && isHTTPURL(strings.TrimPrefix(source, crawlSourcePrefix))
+}
+
+// crawlTarget is a queued URL and its link distance from the seed
+type crawlTarget struct {
+	url   string
+	depth int
+}
+
+// Load crawls breadth-first from the seed within the depth, page, domain, and robots.txt limits.
+// Pages after the seed that fail to load are skipped.
+func (l *CrawlLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	cfg := l.config.Crawl
+	seed, err := url.Parse(strings.TrimPrefix(source, crawlSourcePrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed URL: %w", err)
+	}
+	seed.Fragment = ""
+
+	maxPages := cfg.MaxPages
+	if maxPages <= 0 {
+		maxPages = 50
+	}
+
+	queue := []crawlTarget{{url: seed.String()}}
+	visited := map[string]bool{seed.String(): true}
+	docs := make([]Document, 0)
+	fetched := 0
+
+	for len(queue) > 0 && fetched < maxPages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		target := queue[0]
+		queue = queue[1:]
+
+		if cfg.RespectRobots && !l.allowedByRobots(ctx, target.url) {
+			if target.depth == 0 {
+				return nil, fmt.Errorf("seed URL disallowed by robots.txt")
+			}
+			continue
+		}
+		if fetched > 0 && cfg.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(cfg.Delay):
+			}
+		}
+
+		fetched++
+		page, err := l.urls.fetch(ctx, target.url)
+		if err == nil {
+			var pageDocs []Document
+			if pageDocs, err = l.urls.documents(ctx, page); err == nil {
+				for i := range pageDocs {
+					pageDocs[i].Metadata["crawl_seed"] = seed.String()
+					pageDocs[i].Metadata["crawl_depth"] = target.depth
+				}
+				docs = append(docs, pageDocs...)
+			}
+		}
+		if err != nil {
+			if target.depth == 0 {
+				return nil, err
+			}
+			continue
+		}
+
+		if target.depth >= cfg.MaxDepth || page.mediaType != "text/html" {
+			continue
+		}
+		for _, link := range extractLinks(page.url, page.body) {
+			if !visited[link] && l.followable(seed, link) {
+				visited[link] = true
+				queue = append(queue, crawlTarget{url: link, depth: target.depth + 1})
+			}
+		}
+	}
+	return docs, nil
+}
+
+// followable reports whether a discovered link is within the crawl's domain and path limits
+func (l *CrawlLoader) followable(seed *url.URL, link string) bool {
+	cfg := l.config.Crawl
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	if cfg.SameHost && host != strings.ToLower(seed.Hostname()) {
+		return false
+	}
+	if len(cfg.AllowedDomains) > 0 {
+		allowed := false
+		for _, domain := range cfg.AllowedDomains {
+			domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return cfg.PathPrefix == "" || strings.HasPrefix(parsed.Path, cfg.PathPrefix)
+}
+
+// extractLinks returns the absolute http(s) links of an HTML page without fragments
+func extractLinks(base string, body []byte) []string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	links := make([]string, 0)
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "base" {
+			for _, attr := range node.Attr {
+				if attr.Key == "href" {
+					if parsed, err := baseURL.Parse(attr.Val); err == nil {
+						baseURL = parsed
+					}
+				}
+			}
+		}
+		if node.Type == html.ElementNode && node.Data == "a" {
+			href, nofollow := "", false
+			for _, attr := range node.Attr {
+				switch attr.Key {
+				case "href":
+					href = strings.TrimSpace(attr.Val)
+				case "rel":
+					nofollow = strings.Contains(strings.ToLower(attr.Val), "nofollow")
+				}
+			}
+			if href != "" && !nofollow {
+				if resolved, err := baseURL.Parse(href); err == nil {
+					resolved.Fragment = ""
+					if resolved.Scheme == "http" || resolved.Scheme == "https" {
+						links = append(links, resolved.String())
+					}
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return links
+}
+
+// robotsRules are the Allow/Disallow path prefixes that apply to this crawler
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// allows reports whether a path may be fetched; the longest matching rule wins and Allow wins ties
+func (r *robotsRules) allows(path string) bool {
+	longestAllow, longestDisallow := -1, -1
+	for _, prefix := range r.allow {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longestAllow {
+			longestAllow = len(prefix)
+		}
+	}
+	for _, prefix := range r.disallow {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longestDisallow {
+			longestDisallow = len(prefix)
+		}
+	}
+	return longestDisallow < 0 || longestAllow >= longestDisallow
+}
+
+// allowedByRobots checks a URL against its host's robots.txt, fetched once per host.
+// A missing or unreadable robots.txt allows everything.
+func (l *CrawlLoader) allowedByRobots(ctx context.Context, target string) bool {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	origin := parsed.Scheme + "://" + parsed.Host
+
+	l.robotsMu.Lock()
+	rules, ok := l.robots[origin]
+	l.robotsMu.Unlock()
+	if !ok {
+		rules = &robotsRules{}
+		if page, err := l.urls.fetch(ctx, origin+"/robots.txt"); err == nil {
+			rules = parseRobots(page.body, l.config.UserAgent)
+		}
+		l.robotsMu.Lock()
+		l.robots[origin] = rules
+		l.robotsMu.Unlock()
+	}
+
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return rules.allows(path)
+}
+
+// parseRobots extracts the rules of the group matching the user agent, falling back to the "*" group
+func parseRobots(body []byte, userAgent string) *robotsRules {
+	agent := strings.ToLower(userAgent)
+	specific, wildcard := &robotsRules{}, &robotsRules{}
+	var current []*robotsRules
+	matchedSpecific := false
+	inAgents := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			if name == "*" {
+				current = append(current, wildcard)
+			} else if agent != "" && strings.Contains(agent, name) {
+				current = append(current, specific)
+				matchedSpecific = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+			for _, rules := range current {
+				if key == "allow" {
+					rules.allow = append(rules.allow, value)
+				} else {
+					rules.disallow = append(rules.disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if matchedSpecific {
+		return specific
+	}
+	return wildcard
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CredibilityConfig contains configuration for source credibility weighting
+type CredibilityConfig struct {
+	Enabled       bool               `json:"enabled"`
+	DefaultWeight float64            `json:"default_weight"`           // Weight for sources without an explicit weight
+	SourceWeights map[string]float64 `json:"source_weights,omitempty"` // Glob patterns ("*" matches anything) on document source
+}
+
+// credibilityMetadataKey is the document/chunk metadata key holding the trust weight
+const credibilityMetadataKey = "credibility"
+
+// sourceCredibility returns the trust weight for a document, preferring explicit metadata
+func (p *AgenticRAGProcessor) sourceCredibility(doc Document) float64 {
+	if weight, ok := metadataFloat(doc.Metadata, credibilityMetadataKey); ok {
+		return weight
+	}
+
+	// The most specific (longest) matching pattern wins
+	patterns := make([]string, 0, len(p.config.Credibility.SourceWeights))
+	for pattern := range p.config.Credibility.SourceWeights {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if globMatch(pattern, doc.Source) {
+			return p.config.Credibility.SourceWeights[pattern]
+		}
+	}
+
+	return p.defaultCredibility()
+}
+
+// defaultCredibility returns the configured default weight, treating unset as fully trusted
+func (p *AgenticRAGProcessor) defaultCredibility() float64 {
+	if p.config.Credibility.DefaultWeight > 0 {
+		return p.config.Credibility.DefaultWeight
+	}
+	return 1.0
+}
+
+// chunkCredibility returns the trust weight carried in chunk metadata
+func (p *AgenticRAGProcessor) chunkCredibility(chunk DocumentChunk) float64 {
+	if weight, ok := metadataFloat(chunk.Metadata, credibilityMetadataKey); ok {
+		return weight
+	}
+	return p.defaultCredibility()
+}
+
+// metadataFloat reads a numeric value from metadata
+func metadataFloat(metadata map[string]interface{}, key string) (float64, bool) {
+	switch value := metadata[key].(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	default:
+		return 0, false
+	}
+}
+
+// globMatch reports whether s matches pattern, where "*" matches any sequence of characters
+func globMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr, err := regexp.Compile(fmt.Sprintf("^%s$", strings.Join(parts, ".*")))
+	if err != nil {
+		return false
+	}
+	return expr.MatchString(s)
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Subsystems covered by the degradation policy
+const (
+	SubsystemVectorStore      = "vector_store"      // Vector store used by embedding and hybrid retrieval
+	SubsystemKnowledgeGraph   = "knowledge_graph"   // Knowledge graph extraction
+	SubsystemFactVerification = "fact_verification" // Fact verification of the answer
+	SubsystemReranker         = "reranker"          // Reranking stage before generation
+)
+
+// Degradation actions
+const (
+	DegradeFail     = "fail"     // Fail the request (default)
+	DegradeFallback = "fallback" // Continue with a fallback implementation
+	DegradeSkip     = "skip"     // Continue without the subsystem's output
+)
+
+// FactVerificationSkipped is the overall status of fact verification skipped after a failure
+const FactVerificationSkipped = "skipped"
+
+// degradationActions is the action other than failing that each subsystem supports:
+// vector search falls back to in-memory search over the request's chunks, the knowledge graph is
+// omitted, fact verification is reported as skipped, and chunks keep their retrieval order when
+// reranking fails
+var degradationActions = map[string]string{
+	SubsystemVectorStore:      DegradeFallback,
+	SubsystemKnowledgeGraph:   DegradeSkip,
+	SubsystemFactVerification: DegradeSkip,
+	SubsystemReranker:         DegradeSkip,
+}
+
+// DegradationConfig maps each subsystem to the action taken when it fails
+type DegradationConfig struct {
+	Policies map[string]string `json:"policies"` // Subsystem -> action; subsystems without a policy fail the request
+}
+
+// DegradationEvent records a subsystem failure the request continued past
+type DegradationEvent struct {
+	Subsystem string `json:"subsystem"`
+	Action    string `json:"action"`
+	Error     string `json:"error"`
+}
+
+// degradationRecorder collects the degradation events of one request
+type degradationRecorder struct {
+	mu     sync.Mutex
+	events []DegradationEvent
+}
+
+type degradationContextKey struct{}
+
+// withDegradationRecorder returns a context whose subsystem failures are recorded for the response
+func withDegradationRecorder(ctx context.Context) (context.Context, *degradationRecorder) {
+	recorder := &degradationRecorder{}
+	return context.WithValue(ctx, degradationContextKey{}, recorder), recorder
+}
+
+// list returns the recorded events
+func (r *degradationRecorder) list() []DegradationEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]DegradationEvent(nil), r.events...)
+}
+
+// degrade applies the policy for a failed subsystem. It returns the error when the request should
+// fail, and otherwise records the degradation and returns nil so the caller continues as the
+// subsystem's action describes.
+func (p *AgenticRAGProcessor) degrade(ctx context.Context, subsystem string, err error) error {
+	action := p.config.Degradation.Policies[subsystem]
+	if action == "" || action == DegradeFail {
+		return err
+	}
+	if action != degradationActions[subsystem] {
+		return fmt.Errorf("unsupported degradation action %q for %s: %w", action, subsystem, err)
+	}
+
+	if recorder, ok := ctx.Value(degradationContextKey{}).(*degradationRecorder); ok {
+		recorder.mu.Lock()
+		recorder.events = append(recorder.events, DegradationEvent{Subsystem: subsystem, Action: action, Error: err.Error()})
+		recorder.mu.Unlock()
+	}
+	p.config.Metrics.IncCounter(fmt.Sprintf("agentic_rag_degraded_%s_total", subsystem), 1)
+	return nil
+}
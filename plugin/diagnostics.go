@@ -0,0 +1,184 @@
+package plugin
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// AdminConfig contains configuration for the admin endpoints served by AdminHandler
+type AdminConfig struct {
+	TokenEnv string `json:"token_env,omitempty"` // Environment variable holding the bearer token every admin endpoint requires; empty leaves authentication to the deployment and refuses /diagnostics and /debug/pprof/
+	Pprof    bool   `json:"pprof"`               // Serve runtime profiles under /debug/pprof/
+}
+
+// Diagnostics is a point-in-time snapshot of the processor's runtime state for troubleshooting
+type Diagnostics struct {
+	Time           time.Time                     `json:"time"`
+	Goroutines     int                           `json:"goroutines"`
+	HeapAllocBytes uint64                        `json:"heap_alloc_bytes"`
+	HeapObjects    uint64                        `json:"heap_objects"`
+	GCCycles       uint32                        `json:"gc_cycles"`
+	Caches         map[string]int                `json:"caches"` // Entries per in-memory cache
+	Admission      AdmissionDiagnostics          `json:"admission"`
+	VectorStores   map[string]VectorStorePool    `json:"vector_stores,omitempty"` // Connection pools of the opened named stores
+	Providers      []ProviderEndpointDiagnostics `json:"providers,omitempty"`
+}
+
+// AdmissionDiagnostics is the state of admission control
+type AdmissionDiagnostics struct {
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+}
+
+// VectorStorePool is the connection pool of a database-backed vector store
+type VectorStorePool struct {
+	OpenConnections int           `json:"open_connections"`
+	InUse           int           `json:"in_use"`
+	Idle            int           `json:"idle"`
+	WaitCount       int64         `json:"wait_count"`
+	WaitDuration    time.Duration `json:"wait_duration"`
+	Pruned          int64         `json:"pruned,omitempty"` // Expired records pruned, for stores counting them
+}
+
+// ProviderEndpointDiagnostics is a registered model endpoint with its call outcomes, which are
+// counted while metrics are enabled
+type ProviderEndpointDiagnostics struct {
+	Name      string  `json:"name"`
+	Provider  string  `json:"provider"`
+	Region    string  `json:"region"`
+	ModelName string  `json:"model_name"`
+	Requests  float64 `json:"requests"`
+	Errors    float64 `json:"errors"`
+}
+
+// Diagnostics returns a snapshot of goroutines, memory, cache sizes, admission, vector store
+// connection pools, and model endpoints
+func (p *AgenticRAGProcessor) Diagnostics() (*Diagnostics, error) {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	diagnostics := &Diagnostics{
+		Time:           time.Now(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: memory.HeapAlloc,
+		HeapObjects:    memory.HeapObjects,
+		GCCycles:       memory.NumGC,
+		Caches:         make(map[string]int),
+		VectorStores:   make(map[string]VectorStorePool),
+	}
+
+	p.embeddings.mu.Lock()
+	if p.embeddings.vectors != nil {
+		diagnostics.Caches["embeddings"] = p.embeddings.vectors.len()
+	}
+	p.embeddings.mu.Unlock()
+	if cache, ok := p.answerCache().(*MemoryAnswerCache); ok {
+		diagnostics.Caches["answers"] = cache.Len()
+	}
+	diagnostics.Caches["faq"] = len(p.faqEntries())
+	p.streams.mu.Lock()
+	diagnostics.Caches["streams"] = len(p.streams.streams)
+	p.streams.mu.Unlock()
+
+	p.admission.mu.Lock()
+	diagnostics.Admission = AdmissionDiagnostics{Running: p.admission.running, Queued: p.admission.depth()}
+	p.admission.mu.Unlock()
+
+	p.vectorStoresMu.Lock()
+	for name, store := range p.vectorStores {
+		if cached, ok := store.(interface{ CacheStats() VectorStoreCacheStats }); ok {
+			diagnostics.Caches["vector_store:"+name] = cached.CacheStats().Records
+		}
+		if wrapped, ok := store.(interface{ Unwrap() domain.VectorStore }); ok {
+			store = wrapped.Unwrap()
+		}
+		var stats sql.DBStats
+		var pruned int64
+		switch pooled := store.(type) {
+		case interface{ Stats() sql.DBStats }:
+			stats = pooled.Stats()
+		case *TursoVectorStore:
+			tursoStats := pooled.Stats()
+			stats, pruned = tursoStats.DBStats, tursoStats.Pruned
+		default:
+			continue
+		}
+		diagnostics.VectorStores[name] = VectorStorePool{
+			OpenConnections: stats.OpenConnections,
+			InUse:           stats.InUse,
+			Idle:            stats.Idle,
+			WaitCount:       stats.WaitCount,
+			WaitDuration:    stats.WaitDuration,
+			Pruned:          pruned,
+		}
+	}
+	p.vectorStoresMu.Unlock()
+
+	if p.config.Providers != nil {
+		calls := make(map[string][2]float64)
+		for _, sample := range p.config.Metrics.Snapshot() {
+			if sample.Name != "agentic_rag_model_requests_total" {
+				continue
+			}
+			counts := calls[sample.Labels["model"]]
+			counts[0] += sample.Value
+			if sample.Labels["status"] == "error" {
+				counts[1] += sample.Value
+			}
+			calls[sample.Labels["model"]] = counts
+		}
+		for _, endpoint := range p.config.Providers.Endpoints() {
+			modelName := endpoint.ModelName
+			if endpoint.Model != nil {
+				modelName = endpoint.Model.Name()
+			}
+			diagnostics.Providers = append(diagnostics.Providers, ProviderEndpointDiagnostics{
+				Name:      endpoint.Name,
+				Provider:  endpoint.Provider,
+				Region:    endpoint.Region,
+				ModelName: modelName,
+				Requests:  calls[modelName][0],
+				Errors:    calls[modelName][1],
+			})
+		}
+	}
+	return diagnostics, nil
+}
+
+// registerPprof serves the runtime profiles under /debug/pprof/, each wrapped by protect
+func registerPprof(mux *http.ServeMux, protect func(http.Handler) http.Handler) {
+	mux.Handle("GET /debug/pprof/", protect(http.HandlerFunc(pprof.Index)))
+	mux.Handle("GET /debug/pprof/cmdline", protect(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("GET /debug/pprof/profile", protect(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("GET /debug/pprof/symbol", protect(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("GET /debug/pprof/trace", protect(http.HandlerFunc(pprof.Trace)))
+}
+
+// requireAdminToken rejects requests without the configured admin bearer token. It fails closed:
+// without admin.token_env, or with the variable empty, every request is refused.
+func (p *AgenticRAGProcessor) requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if p.config.Admin.TokenEnv != "" {
+			token = os.Getenv(p.config.Admin.TokenEnv)
+		}
+		if token == "" {
+			http.Error(w, "admin token not configured", http.StatusForbidden)
+			return
+		}
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// DoctorReport is the outcome of an end-to-end smoke test of a deployment
+type DoctorReport struct {
+	Passed bool          `json:"passed"`
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// DoctorCheck is the outcome of one smoke test step
+type DoctorCheck struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"` // "pass", "fail", or "skip"
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Doctor smoke tests the deployment: it validates the configuration, calls the model, embedder,
+// and default vector store, answers a query about a tiny test document, and checks that the
+// answer's citations point back at that document. Steps whose prerequisites failed are skipped.
+func (p *AgenticRAGProcessor) Doctor(ctx context.Context, template AgenticRAGRequest) *DoctorReport {
+	report := &DoctorReport{Passed: true}
+	run := func(name string, ready bool, check func() (string, error)) bool {
+		result := DoctorCheck{Name: name, Status: "skip"}
+		if ready {
+			startTime := time.Now()
+			detail, err := check()
+			result.Duration = time.Since(startTime)
+			result.Status, result.Detail = "pass", detail
+			if err != nil {
+				result.Status, result.Detail = "fail", err.Error()
+				report.Passed = false
+			}
+		}
+		report.Checks = append(report.Checks, result)
+		return result.Status == "pass"
+	}
+
+	codeWord, err := doctorCodeWord()
+	if err != nil {
+		codeWord = fmt.Sprintf("DOCTOR-%d", time.Now().UnixNano())
+	}
+
+	configured := run("config", true, p.validateConfig)
+	modelReady := run("model", configured, func() (string, error) {
+		text, err := p.generateText(ctx, "Reply with the single word OK.", 0, 16)
+		if err != nil {
+			return "", fmt.Errorf("failed to call model %s: %w", p.modelName(ctx), err)
+		}
+		if strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("model %s returned an empty response", p.modelName(ctx))
+		}
+		return p.modelName(ctx), nil
+	})
+
+	var embedding []float32
+	embedderName := p.config.VectorRetrieval.EmbedderName
+	if embedderName == "" {
+		embedderName = p.config.EmbedderName
+	}
+	embedderReady := run("embedder", configured && embedderName != "", func() (string, error) {
+		embeddings, err := p.embedTexts(ctx, embedderName, []string{codeWord})
+		if err != nil {
+			return "", err
+		}
+		embedding = embeddings[0]
+		return fmt.Sprintf("%s, %d dimensions", embedderName, len(embedding)), nil
+	})
+
+	store, storeErr := p.defaultVectorStore(ctx)
+	run("vector_store", configured && embedderReady && (store != nil || storeErr != nil), func() (string, error) {
+		if storeErr != nil {
+			return "", storeErr
+		}
+		return roundTripVectorStore(withEmbedder(ctx, embedderName, len(embedding)), store, codeWord, embedding)
+	})
+
+	var response *AgenticRAGResponse
+	answered := run("query", modelReady, func() (string, error) {
+		request := template
+		request.Query = "What is the code word of the agenticrag doctor test document?"
+		request.Documents = []string{"agenticrag doctor test document. The code word of this test document is " + codeWord + "."}
+		var err error
+		response, err = p.Process(ctx, request)
+		if err != nil {
+			return "", fmt.Errorf("failed to process test query: %w", err)
+		}
+		if !strings.Contains(response.Answer, codeWord) {
+			return "", fmt.Errorf("answer does not contain the test document's code word: %q", truncateText(response.Answer, 200))
+		}
+		return fmt.Sprintf("answered in %s", response.ProcessingMetadata.ProcessingTime), nil
+	})
+
+	run("citations", answered, func() (string, error) {
+		return checkCitationRoundTrip(response, codeWord)
+	})
+	return report
+}
+
+// validateConfig checks that the configured model, embedder, and vector stores can be resolved
+func (p *AgenticRAGProcessor) validateConfig() (string, error) {
+	if p.config.Genkit == nil {
+		return "", fmt.Errorf("GenKit instance not provided in config")
+	}
+	if p.config.Model == nil {
+		provider, name, ok := strings.Cut(p.config.ModelName, "/")
+		if !ok {
+			return "", fmt.Errorf("model name %q must be in provider/name form", p.config.ModelName)
+		}
+		if genkit.LookupModel(p.config.Genkit, provider, name) == nil {
+			return "", fmt.Errorf("model %q not found", p.config.ModelName)
+		}
+	}
+	if p.config.EmbedderName != "" {
+		provider, name, ok := strings.Cut(p.config.EmbedderName, "/")
+		if !ok || genkit.LookupEmbedder(p.config.Genkit, provider, name) == nil {
+			return "", fmt.Errorf("embedder %q not found", p.config.EmbedderName)
+		}
+	}
+	if name := p.config.VectorStores.Default; name != "" && p.config.VectorStore == nil {
+		if _, ok := p.config.VectorStores.Stores[name]; !ok {
+			return "", fmt.Errorf("default vector store %q is not configured", name)
+		}
+	}
+	for name, store := range p.config.VectorStores.Stores {
+		switch store.Type {
+		case VectorStorePgVector, VectorStoreMemory, VectorStoreTurso, VectorStoreLocal, VectorStoreWeaviate, VectorStoreMilvus, VectorStoreElasticsearch:
+		default:
+			return "", fmt.Errorf("vector store %q has unsupported type %q", name, store.Type)
+		}
+	}
+	return fmt.Sprintf("model %s", p.modelName(context.Background())), nil
+}
+
+// roundTripVectorStore stores a probe record, finds it again, and deletes it
+func roundTripVectorStore(ctx context.Context, store domain.VectorStore, codeWord string, embedding []float32) (string, error) {
+	record := domain.VectorRecord{
+		ID:        "doctor-" + strings.ToLower(codeWord),
+		Content:   codeWord,
+		Embedding: embedding,
+		Metadata:  map[string]interface{}{"doctor_probe": codeWord},
+		UpdatedAt: time.Now(),
+	}
+	if err := store.Store(ctx, []domain.VectorRecord{record}); err != nil {
+		return "", fmt.Errorf("failed to store probe record: %w", err)
+	}
+	results, searchErr := store.Search(ctx, embedding, 1, domain.Filters{"doctor_probe": codeWord})
+	if err := store.Delete(ctx, []string{record.ID}); err != nil {
+		return "", fmt.Errorf("failed to delete probe record: %w", err)
+	}
+	if searchErr != nil {
+		return "", fmt.Errorf("failed to search for probe record: %w", searchErr)
+	}
+	if len(results) == 0 || results[0].Record.ID != record.ID {
+		return "", fmt.Errorf("search did not return the stored probe record")
+	}
+	return fmt.Sprintf("stored, found, and deleted a probe record (score %.3f)", results[0].Score), nil
+}
+
+// checkCitationRoundTrip checks that the answer cites the test document through chunks the
+// response returned
+func checkCitationRoundTrip(response *AgenticRAGResponse, codeWord string) (string, error) {
+	if len(response.Citations) == 0 {
+		return "", fmt.Errorf("answer has no citations")
+	}
+	chunks := make(map[string]DocumentChunk, len(response.RelevantChunks))
+	for _, processed := range response.RelevantChunks {
+		chunks[processed.Chunk.ID] = processed.Chunk
+	}
+	for _, citation := range response.Citations {
+		chunk, ok := chunks[citation.ChunkID]
+		if !ok {
+			return "", fmt.Errorf("citation [%d] refers to chunk %q, which is not among the relevant chunks", citation.Number, citation.ChunkID)
+		}
+		if citation.DocumentID != chunk.DocumentID {
+			return "", fmt.Errorf("citation [%d] names document %q, but its chunk belongs to %q", citation.Number, citation.DocumentID, chunk.DocumentID)
+		}
+		if strings.Contains(chunk.Content, codeWord) {
+			return fmt.Sprintf("%d citations, [%d] cites the test document", len(response.Citations), citation.Number), nil
+		}
+	}
+	return "", fmt.Errorf("no citation points at the test document")
+}
+
+// doctorCodeWord returns a random word the smoke test's answer must repeat from the test document
+func doctorCodeWord() (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return "DOCTOR-" + strings.ToUpper(hex.EncodeToString(random)), nil
+}
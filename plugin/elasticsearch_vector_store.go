@@ -0,0 +1,508 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// Search engines supported by ElasticsearchVectorStore
+const (
+	SearchEngineElasticsearch = "elasticsearch" // Elasticsearch 8, dense_vector fields and the knn search option (default)
+	SearchEngineOpenSearch    = "opensearch"    // OpenSearch 2, knn_vector fields with the Lucene engine and the knn query
+)
+
+// ElasticsearchConfig contains configuration for an Elasticsearch or OpenSearch vector store
+type ElasticsearchConfig struct {
+	URL         string        `json:"url"`                    // Base URL of the cluster, e.g. http://localhost:9200
+	Engine      string        `json:"engine"`                 // SearchEngineElasticsearch or SearchEngineOpenSearch
+	Index       string        `json:"index"`                  // Index holding the records (default: vector_records)
+	Dimensions  int           `json:"dimensions"`             // Embedding dimensions; required by OpenSearch to create the index
+	APIKeyEnv   string        `json:"api_key_env,omitempty"`  // Environment variable holding an Elasticsearch API key
+	Username    string        `json:"username,omitempty"`     // User of basic authentication
+	PasswordEnv string        `json:"password_env,omitempty"` // Environment variable holding the basic authentication password
+	VectorBoost float64       `json:"vector_boost"`           // Weight of the kNN score against the BM25 score in hybrid search (default: 1)
+	Timeout     time.Duration `json:"timeout"`                // Timeout of each request (default: 30s)
+}
+
+// ElasticsearchVectorStore stores records as documents of an Elasticsearch or OpenSearch index with a
+// cosine kNN vector field and a BM25-analyzed content field, so one backend serves vector, keyword,
+// and hybrid search. Metadata strings are mapped as keywords, and filters run as term and range
+// queries on them.
+type ElasticsearchVectorStore struct {
+	client *http.Client
+	config ElasticsearchConfig
+	schema *schemaInit
+	guard  *embeddingGuard
+}
+
+// elasticsearchIndexName matches the index names the store accepts
+var elasticsearchIndexName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NewElasticsearchVectorStore creates the store's index unless it already exists
+func NewElasticsearchVectorStore(ctx context.Context, config ElasticsearchConfig) (*ElasticsearchVectorStore, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("elasticsearch URL is required")
+	}
+	if config.Engine == "" {
+		config.Engine = SearchEngineElasticsearch
+	}
+	if config.Index == "" {
+		config.Index = "vector_records"
+	}
+	if config.VectorBoost <= 0 {
+		config.VectorBoost = 1
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	if config.Engine != SearchEngineElasticsearch && config.Engine != SearchEngineOpenSearch {
+		return nil, fmt.Errorf("unsupported search engine %q", config.Engine)
+	}
+	if !elasticsearchIndexName.MatchString(config.Index) {
+		return nil, fmt.Errorf("invalid index name %q", config.Index)
+	}
+
+	store := &ElasticsearchVectorStore{client: &http.Client{Timeout: config.Timeout}, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+	if err := store.schema.ensure(ctx, store.bootstrap); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// WithCollection returns a view of the store keeping the collection's records in their own index,
+// named after the base index and the collection and created on first use. Index names are
+// lowercase, so collections with uppercase letters are rejected rather than folded onto another
+// collection's index.
+func (s *ElasticsearchVectorStore) WithCollection(collection string) domain.VectorStore {
+	index, err := collectionTable(s.config.Index, collection)
+	if err != nil {
+		return invalidVectorStore{err: err}
+	}
+	if !elasticsearchIndexName.MatchString(index) {
+		return invalidVectorStore{err: fmt.Errorf("invalid elasticsearch collection %q: index names must be lowercase", collection)}
+	}
+	config := s.config
+	config.Index = index
+	return &ElasticsearchVectorStore{client: s.client, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+}
+
+// bootstrap creates the index with the record mappings unless it exists
+func (s *ElasticsearchVectorStore) bootstrap(ctx context.Context) error {
+	status, err := s.do(ctx, http.MethodHead, "/"+s.config.Index, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check index %s: %w", s.config.Index, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	embedding := map[string]interface{}{"type": "dense_vector", "index": true, "similarity": "cosine"}
+	if s.config.Dimensions > 0 {
+		embedding["dims"] = s.config.Dimensions
+	}
+	settings := map[string]interface{}{}
+	if s.config.Engine == SearchEngineOpenSearch {
+		if s.config.Dimensions <= 0 {
+			return fmt.Errorf("dimensions are required to create OpenSearch index %s", s.config.Index)
+		}
+		embedding = map[string]interface{}{
+			"type":      "knn_vector",
+			"dimension": s.config.Dimensions,
+			"method":    map[string]interface{}{"name": "hnsw", "space_type": "cosinesimil", "engine": "lucene"},
+		}
+		settings["index"] = map[string]interface{}{"knn": true}
+	}
+	body := map[string]interface{}{
+		"settings": settings,
+		"mappings": map[string]interface{}{
+			// Dates in metadata stay keywords so range filters compare them as RFC 3339 strings
+			"date_detection": false,
+			"dynamic_templates": []map[string]interface{}{
+				{"metadata_strings": map[string]interface{}{
+					"path_match":         "metadata.*",
+					"match_mapping_type": "string",
+					"mapping":            map[string]interface{}{"type": "keyword"},
+				}},
+			},
+			"properties": map[string]interface{}{
+				"content":    map[string]interface{}{"type": "text"},
+				"embedding":  embedding,
+				"metadata":   map[string]interface{}{"type": "object"},
+				"updated_at": map[string]interface{}{"type": "date"},
+				"expires_at": map[string]interface{}{"type": "date"},
+			},
+		},
+	}
+
+	var failure struct {
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	status, err = s.do(ctx, http.MethodPut, "/"+s.config.Index, body, &failure)
+	if err != nil {
+		return fmt.Errorf("failed to create index %s: %w", s.config.Index, err)
+	}
+	if status != http.StatusOK && failure.Error.Type != "resource_already_exists_exception" {
+		return fmt.Errorf("failed to create index %s: status %d %s", s.config.Index, status, failure.Error.Type)
+	}
+	return nil
+}
+
+// BindEmbedding binds the index to the embedding binding unless it is already bound, keeping the
+// binding in the index mapping's _meta
+func (s *ElasticsearchVectorStore) BindEmbedding(ctx context.Context, binding domain.EmbeddingBinding) (domain.EmbeddingBinding, error) {
+	bound, ok, err := s.embeddingBinding(ctx)
+	if err != nil || ok {
+		return bound, err
+	}
+
+	body := map[string]interface{}{"_meta": map[string]interface{}{"embedding": binding}}
+	status, err := s.do(ctx, http.MethodPut, "/"+s.config.Index+"/_mapping", body, nil)
+	if err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: %w", err)
+	}
+	if status != http.StatusOK {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: status %d", status)
+	}
+	return binding, nil
+}
+
+// embeddingBinding returns the index's binding, if it is bound
+func (s *ElasticsearchVectorStore) embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return domain.EmbeddingBinding{}, false, err
+	}
+	var mappings map[string]struct {
+		Mappings struct {
+			Meta struct {
+				Embedding *domain.EmbeddingBinding `json:"embedding"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+	status, err := s.do(ctx, http.MethodGet, "/"+s.config.Index+"/_mapping", nil, &mappings)
+	if err != nil {
+		return domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: %w", err)
+	}
+	if status != http.StatusOK {
+		return domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: status %d", status)
+	}
+	for _, index := range mappings {
+		if bound := index.Mappings.Meta.Embedding; bound != nil {
+			return *bound, true, nil
+		}
+	}
+	return domain.EmbeddingBinding{}, false, nil
+}
+
+// Store indexes records in one bulk request, replacing existing records with the same ID, and
+// waits for them to become searchable
+func (s *ElasticsearchVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return err
+	}
+	if err := s.guard.checkRecords(ctx, s, records); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, record := range records {
+		metadata := make(map[string]interface{}, len(record.Metadata))
+		for key, value := range record.Metadata {
+			metadata[key] = pgFilterValue(value)
+		}
+		document := map[string]interface{}{
+			"content":   record.Content,
+			"embedding": record.Embedding,
+			"metadata":  metadata,
+		}
+		if !record.UpdatedAt.IsZero() {
+			document["updated_at"] = record.UpdatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		if !record.ExpiresAt.IsZero() {
+			document["expires_at"] = record.ExpiresAt.UTC().Format(time.RFC3339Nano)
+		}
+		action := map[string]interface{}{"index": map[string]string{"_index": s.config.Index, "_id": record.ID}}
+		if err := encoder.Encode(action); err != nil {
+			return fmt.Errorf("failed to encode record %s: %w", record.ID, err)
+		}
+		if err := encoder.Encode(document); err != nil {
+			return fmt.Errorf("failed to encode record %s: %w", record.ID, err)
+		}
+	}
+
+	var response struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string `json:"_id"`
+			Error *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	status, err := s.do(ctx, http.MethodPost, "/_bulk?refresh=wait_for", body.Bytes(), &response)
+	if err != nil {
+		return fmt.Errorf("failed to store records: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to store records: status %d", status)
+	}
+	if response.Errors {
+		for _, item := range response.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					return fmt.Errorf("failed to store record %s: %s: %s", result.ID, result.Error.Type, result.Error.Reason)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Search returns the k nearest records matching the filters, scored by cosine similarity
+func (s *ElasticsearchVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	return s.search(ctx, "", embedding, k, filters)
+}
+
+// HybridSearch returns the k records ranking best by the sum of their BM25 score for the query and
+// their kNN score, weighted by the configured vector boost, in a single search request. Scores are
+// these sums rather than cosine similarities.
+func (s *ElasticsearchVectorStore) HybridSearch(ctx context.Context, query string, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	return s.search(ctx, query, embedding, k, filters)
+}
+
+// search runs a kNN search, combined with a BM25 match on the content for a non-empty query
+func (s *ElasticsearchVectorStore) search(ctx context.Context, query string, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return nil, err
+	}
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	filter, err := elasticsearchFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	body := s.searchBody(query, embedding, k, filter)
+
+	var response struct {
+		Hits struct {
+			Hits []struct {
+				ID     string  `json:"_id"`
+				Score  float64 `json:"_score"`
+				Source struct {
+					Content   string                 `json:"content"`
+					Metadata  map[string]interface{} `json:"metadata"`
+					UpdatedAt time.Time              `json:"updated_at"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	status, err := s.do(ctx, http.MethodPost, "/"+s.config.Index+"/_search", body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search records: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to search records: status %d", status)
+	}
+
+	results := make([]domain.SearchResult, len(response.Hits.Hits))
+	for i, hit := range response.Hits.Hits {
+		score := hit.Score
+		if query == "" {
+			// Cosine kNN scores are (1 + cosine) / 2
+			score = 2*hit.Score - 1
+		}
+		results[i] = domain.SearchResult{
+			Record: domain.VectorRecord{
+				ID:        hit.ID,
+				Content:   hit.Source.Content,
+				Metadata:  hit.Source.Metadata,
+				UpdatedAt: hit.Source.UpdatedAt,
+			},
+			Score: score,
+		}
+	}
+	return results, nil
+}
+
+// searchBody builds the engine's search request. Elasticsearch sums the scores of the top-level
+// knn option and query; OpenSearch sums the should clauses of a bool query.
+func (s *ElasticsearchVectorStore) searchBody(query string, embedding []float32, k int, filter []interface{}) map[string]interface{} {
+	match := map[string]interface{}{"match": map[string]interface{}{"content": map[string]interface{}{"query": query}}}
+	body := map[string]interface{}{
+		"size":    k,
+		"_source": []string{"content", "metadata", "updated_at"},
+	}
+
+	if s.config.Engine == SearchEngineOpenSearch {
+		knn := map[string]interface{}{"vector": embedding, "k": k, "boost": s.config.VectorBoost}
+		if len(filter) > 0 {
+			knn["filter"] = map[string]interface{}{"bool": map[string]interface{}{"filter": filter}}
+		}
+		clauses := []interface{}{map[string]interface{}{"knn": map[string]interface{}{"embedding": knn}}}
+		if query != "" {
+			clauses = append(clauses, match)
+		}
+		body["query"] = map[string]interface{}{"bool": map[string]interface{}{"should": clauses, "filter": filter, "minimum_should_match": 1}}
+		return body
+	}
+
+	knn := map[string]interface{}{
+		"field":          "embedding",
+		"query_vector":   embedding,
+		"k":              k,
+		"num_candidates": max(100, 10*k),
+		"boost":          s.config.VectorBoost,
+	}
+	if len(filter) > 0 {
+		knn["filter"] = filter
+	}
+	body["knn"] = knn
+	if query != "" {
+		body["query"] = map[string]interface{}{"bool": map[string]interface{}{"must": match, "filter": filter}}
+	}
+	return body
+}
+
+// Delete removes the records with the given IDs
+func (s *ElasticsearchVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return err
+	}
+	body := map[string]interface{}{"query": map[string]interface{}{"ids": map[string]interface{}{"values": ids}}}
+	status, err := s.do(ctx, http.MethodPost, "/"+s.config.Index+"/_delete_by_query?refresh=true", body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to delete records: status %d", status)
+	}
+	return nil
+}
+
+// Prune removes the records whose expiry has passed
+func (s *ElasticsearchVectorStore) Prune(ctx context.Context) (int, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return 0, err
+	}
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"range": map[string]interface{}{"expires_at": map[string]interface{}{"lte": "now"}}},
+	}
+	var response struct {
+		Deleted int `json:"deleted"`
+	}
+	status, err := s.do(ctx, http.MethodPost, "/"+s.config.Index+"/_delete_by_query?refresh=true&conflicts=proceed", body, &response)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune records: %w", err)
+	}
+	if status != http.StatusOK {
+		return 0, fmt.Errorf("failed to prune records: status %d", status)
+	}
+	return response.Deleted, nil
+}
+
+// do sends a request to the cluster and decodes the JSON response into out, returning the status
+// so callers can handle expected failures. A []byte body is sent as NDJSON, anything else as JSON.
+func (s *ElasticsearchVectorStore) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var payload io.Reader
+	contentType := "application/json"
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		payload, contentType = bytes.NewReader(body), "application/x-ndjson"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.config.URL+path, payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case s.config.APIKeyEnv != "":
+		req.Header.Set("Authorization", "ApiKey "+os.Getenv(s.config.APIKeyEnv))
+	case s.config.Username != "":
+		req.SetBasicAuth(s.config.Username, os.Getenv(s.config.PasswordEnv))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if out == nil || method == http.MethodHead {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// elasticsearchFilter translates filters into term, terms, and range queries on the metadata
+// fields. Term queries match any value of multi-valued fields, like domain.Filters.
+func elasticsearchFilter(filters domain.Filters) ([]interface{}, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := make([]interface{}, 0, len(filters))
+	for _, key := range keys {
+		field := "metadata." + key
+		switch want := filters[key].(type) {
+		case map[string]interface{}:
+			bounds := make(map[string]interface{}, len(want))
+			for operator, bound := range want {
+				bounds[operator] = pgFilterValue(bound)
+			}
+			clauses = append(clauses, map[string]interface{}{"range": map[string]interface{}{field: bounds}})
+		default:
+			options, ok := filterOptions(want)
+			if !ok {
+				clauses = append(clauses, map[string]interface{}{"term": map[string]interface{}{field: pgFilterValue(want)}})
+				continue
+			}
+			values := make([]interface{}, len(options))
+			for i, option := range options {
+				values[i] = pgFilterValue(option)
+			}
+			// An empty terms list matches nothing
+			clauses = append(clauses, map[string]interface{}{"terms": map[string]interface{}{field: values}})
+		}
+	}
+	return clauses, nil
+}
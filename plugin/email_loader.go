@@ -0,0 +1,662 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// EmailConfig contains configuration for loading mailboxes from mbox files and IMAP servers
+type EmailConfig struct {
+	MaxMessages    int    `json:"max_messages"`           // Most recent messages loaded per mailbox
+	KeepQuoted     bool   `json:"keep_quoted"`            // Keep quoted replies instead of stripping them
+	KeepSignatures bool   `json:"keep_signatures"`        // Keep signatures instead of stripping them
+	PasswordEnv    string `json:"password_env,omitempty"` // Environment variable holding the IMAP password when the URL has none
+}
+
+// EmailLoader loads each message of a mailbox as a document tagged with its sender, date, and
+// thread. Sources are local .mbox files or IMAP mailbox URLs such as imaps://user@host/INBOX;
+// imap:// URLs are upgraded with STARTTLS. Quoted replies and signatures are stripped so each
+// document holds only what its sender wrote.
+type EmailLoader struct {
+	config LoaderConfig
+}
+
+// NewEmailLoader creates a mailbox loader
+func NewEmailLoader(config LoaderConfig) *EmailLoader {
+	return &EmailLoader{config: config}
+}
+
+// CanLoad reports whether the source is a local mbox file or an IMAP URL
+func (l *EmailLoader) CanLoad(source string) bool {
+	if parsed, err := url.Parse(source); err == nil && (parsed.Scheme == "imap" || parsed.Scheme == "imaps") && parsed.Host != "" {
+		return true
+	}
+	if strings.ToLower(filepath.Ext(source)) != ".mbox" {
+		return false
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load reads the mailbox's most recent messages and converts them into threaded documents
+func (l *EmailLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	var messages []rawEmail
+	var err error
+	if parsed, parseErr := url.Parse(source); parseErr == nil && (parsed.Scheme == "imap" || parsed.Scheme == "imaps") {
+		messages, err = l.fetchIMAP(ctx, parsed)
+	} else {
+		messages, err = l.readMbox(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.documents(messages), nil
+}
+
+// rawEmail is an undecoded message and the source it was read from
+type rawEmail struct {
+	source string
+	data   []byte
+}
+
+// email is a parsed message
+type email struct {
+	source    string
+	messageID string
+	inReplyTo string
+	refs      []string
+	subject   string
+	from      string
+	address   string
+	to        string
+	date      time.Time
+	body      string
+}
+
+// documents parses the messages, assigns threads, and keeps the configured number of most recent
+func (l *EmailLoader) documents(messages []rawEmail) []Document {
+	emails := make([]email, 0, len(messages))
+	for _, message := range messages {
+		parsed, err := l.parseEmail(message)
+		if err != nil {
+			continue // Malformed messages are common in exports and are skipped
+		}
+		emails = append(emails, parsed)
+	}
+	sort.SliceStable(emails, func(i, j int) bool {
+		return emails[i].date.Before(emails[j].date)
+	})
+	if max := l.config.Email.MaxMessages; max > 0 && len(emails) > max {
+		emails = emails[len(emails)-max:]
+	}
+
+	threads := emailThreads(emails)
+	positions := make(map[string]int)
+	docs := make([]Document, 0, len(emails))
+	for i, message := range emails {
+		thread := threads[i]
+		positions[thread]++
+
+		var content strings.Builder
+		content.WriteString(fmt.Sprintf("From: %s\nDate: %s\nSubject: %s\n\n", message.from, message.date.Format(time.RFC1123Z), message.subject))
+		content.WriteString(message.body)
+
+		metadata := map[string]interface{}{
+			"content_type":    "message/rfc822",
+			"title":           message.subject,
+			"from":            message.from,
+			"from_address":    message.address,
+			"message_id":      message.messageID,
+			"thread_id":       thread,
+			"thread_position": positions[thread],
+		}
+		if message.to != "" {
+			metadata["to"] = message.to
+		}
+		if message.inReplyTo != "" {
+			metadata["in_reply_to"] = message.inReplyTo
+		}
+		if !message.date.IsZero() {
+			metadata["date"] = message.date
+			metadata["updated_at"] = message.date
+		}
+		docs = append(docs, Document{Content: content.String(), Source: message.source, Metadata: metadata})
+	}
+	return docs
+}
+
+// replySubjectPrefix matches the reply and forward prefixes of a subject
+var replySubjectPrefix = regexp.MustCompile(`(?i)^\s*((re|fwd?|aw|sv|wg)\s*(\[\d+\])?\s*:\s*)+`)
+
+// emailThreads returns the thread ID of each message in date order: the root of its References,
+// else the thread of the message it replies to, else for replies without either header the
+// thread with the same subject, else its own message ID
+func emailThreads(emails []email) []string {
+	threads := make([]string, len(emails))
+	byMessage := make(map[string]string)
+	bySubject := make(map[string]string)
+	for i, message := range emails {
+		subject := strings.ToLower(strings.TrimSpace(replySubjectPrefix.ReplaceAllString(message.subject, "")))
+		isReply := replySubjectPrefix.MatchString(message.subject)
+
+		thread := message.messageID
+		switch {
+		case len(message.refs) > 0:
+			thread = message.refs[0]
+			if root, ok := byMessage[thread]; ok {
+				thread = root
+			}
+		case message.inReplyTo != "":
+			thread = message.inReplyTo
+			if root, ok := byMessage[thread]; ok {
+				thread = root
+			}
+		case isReply && bySubject[subject] != "":
+			thread = bySubject[subject]
+		}
+
+		threads[i] = thread
+		byMessage[message.messageID] = thread
+		if _, ok := bySubject[subject]; !ok && subject != "" {
+			bySubject[subject] = thread
+		}
+	}
+	return threads
+}
+
+// parseEmail decodes a message's headers and the text of its body
+func (l *EmailLoader) parseEmail(raw rawEmail) (email, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(raw.data))
+	if err != nil {
+		return email{}, fmt.Errorf("failed to parse message: %w", err)
+	}
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	header := func(name string) string {
+		value := message.Header.Get(name)
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			return strings.TrimSpace(decoded)
+		}
+		return strings.TrimSpace(value)
+	}
+
+	parsed := email{
+		source:    raw.source,
+		messageID: trimMessageID(message.Header.Get("Message-ID")),
+		inReplyTo: trimMessageID(message.Header.Get("In-Reply-To")),
+		subject:   header("Subject"),
+		from:      header("From"),
+		to:        header("To"),
+	}
+	for _, ref := range strings.Fields(message.Header.Get("References")) {
+		parsed.refs = append(parsed.refs, trimMessageID(ref))
+	}
+	if from, err := mail.ParseAddress(message.Header.Get("From")); err == nil {
+		parsed.address = strings.ToLower(from.Address)
+		if from.Name != "" {
+			parsed.from = fmt.Sprintf("%s <%s>", from.Name, from.Address)
+		} else {
+			parsed.from = from.Address
+		}
+	}
+	if date, err := message.Header.Date(); err == nil {
+		parsed.date = date
+	}
+	if parsed.messageID == "" {
+		sum := sha256.Sum256(raw.data)
+		parsed.messageID = hex.EncodeToString(sum[:16])
+	}
+
+	body, err := emailBodyText(message.Header.Get("Content-Type"), message.Header.Get("Content-Transfer-Encoding"), message.Body)
+	if err != nil {
+		return email{}, err
+	}
+	if !l.config.Email.KeepQuoted {
+		body = stripEmailQuotes(body)
+	}
+	if !l.config.Email.KeepSignatures {
+		body = stripEmailSignature(body)
+	}
+	parsed.body = strings.TrimSpace(body)
+	return parsed, nil
+}
+
+// trimMessageID removes the angle brackets around a message ID
+func trimMessageID(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
+
+// emailBodyText returns the readable text of a message body, preferring plain text parts over
+// HTML and skipping attachments
+func emailBodyText(contentType, transferEncoding string, body io.Reader) (string, error) {
+	plain, html, err := emailParts(contentType, transferEncoding, body)
+	if err != nil {
+		return "", err
+	}
+	if len(plain) > 0 {
+		return strings.Join(plain, "\n\n"), nil
+	}
+	texts := make([]string, 0, len(html))
+	for _, page := range html {
+		text, _, _, err := extractHTMLText(page)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse HTML part: %w", err)
+		}
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// emailParts collects the decoded plain text and HTML parts of a possibly multipart body
+func emailParts(contentType, transferEncoding string, body io.Reader) ([]string, []string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &lineStripper{r: body})
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var plain, html []string
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read message part: %w", err)
+			}
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			// The multipart reader already decodes quoted-printable parts
+			partPlain, partHTML, err := emailParts(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return nil, nil, err
+			}
+			plain = append(plain, partPlain...)
+			html = append(html, partHTML...)
+		}
+		return plain, html, nil
+	}
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil, nil, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	text := string(data)
+	if charset := params["charset"]; charset != "" {
+		if encoding, err := htmlindex.Get(charset); err == nil {
+			if decoded, err := encoding.NewDecoder().String(text); err == nil {
+				text = decoded
+			}
+		}
+	}
+	if mediaType == "text/html" {
+		return nil, []string{text}, nil
+	}
+	return []string{text}, nil, nil
+}
+
+// lineStripper removes line breaks from base64 content
+type lineStripper struct {
+	r io.Reader
+}
+
+// Read reads from the underlying reader, dropping CR and LF bytes
+func (s *lineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// charsetReader decodes encoded-word header text in the named charset
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// Patterns that start the quoted part of a reply
+var (
+	replyAttribution = regexp.MustCompile(`(?i)^(on\s.+wrote:|.+\sschrieb:|le\s.+a écrit\s?:)\s*$`)
+	forwardedHeader  = regexp.MustCompile(`(?i)^(-{2,}\s*(original message|forwarded message)\s*-{2,}|_{10,})\s*$`)
+	outlookHeader    = regexp.MustCompile(`(?i)^from:\s.+`)
+	outlookSent      = regexp.MustCompile(`(?i)^(sent|date):\s.+`)
+)
+
+// stripEmailQuotes removes quoted lines and everything from the first reply attribution or
+// forwarded-message header, which is the earlier message the reply quotes
+func stripEmailQuotes(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		// Attributions are often wrapped onto two lines
+		joined := trimmed
+		if i+1 < len(lines) {
+			joined = trimmed + " " + strings.TrimSpace(lines[i+1])
+		}
+		if replyAttribution.MatchString(trimmed) || (strings.HasPrefix(strings.ToLower(trimmed), "on ") && replyAttribution.MatchString(joined)) {
+			break
+		}
+		if forwardedHeader.MatchString(trimmed) {
+			break
+		}
+		if outlookHeader.MatchString(trimmed) && i+1 < len(lines) && outlookSent.MatchString(strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// mobileSignature matches the one-line signatures mail apps append
+var mobileSignature = regexp.MustCompile(`(?i)^(sent from my\s.+|get outlook for\s.+|sent from (mail|outlook) for\s.+)$`)
+
+// stripEmailSignature removes everything after the "-- " signature delimiter and mail app signatures
+func stripEmailSignature(body string) string {
+	lines := strings.Split(body, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimRight(lines[i], " \r") == "--" {
+			lines = lines[:i]
+			break
+		}
+	}
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !mobileSignature.MatchString(strings.TrimSpace(line)) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// readMbox splits an mbox file into its messages, undoing ">From " escaping
+func (l *EmailLoader) readMbox(source string) ([]rawEmail, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mbox: %w", err)
+	}
+	defer file.Close()
+
+	maxBytes := l.config.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 10 << 20
+	}
+	messages := make([]rawEmail, 0)
+	var current bytes.Buffer
+	inMessage, previousBlank := false, true
+	flush := func() {
+		if inMessage && current.Len() > 0 && int64(current.Len()) <= maxBytes {
+			messages = append(messages, rawEmail{source: source, data: append([]byte(nil), current.Bytes()...)})
+		}
+		current.Reset()
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			blank := len(bytes.TrimRight(line, "\r\n")) == 0
+			switch {
+			case previousBlank && bytes.HasPrefix(line, []byte("From ")):
+				flush()
+				inMessage = true
+			case inMessage:
+				if unescaped := bytes.TrimLeft(line, ">"); len(unescaped) < len(line) && bytes.HasPrefix(unescaped, []byte("From ")) {
+					line = line[1:]
+				}
+				if int64(current.Len()) <= maxBytes {
+					current.Write(line)
+				}
+			}
+			previousBlank = blank
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mbox: %w", err)
+		}
+	}
+	flush()
+	return messages, nil
+}
+
+// imapLiteral matches the length of a literal announced at the end of an IMAP response line
+var imapLiteral = regexp.MustCompile(`\{(\d+)\}$`)
+
+// imapConn is a minimal IMAP4rev1 client connection for reading a mailbox
+type imapConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	tag      int
+	maxBytes int64
+}
+
+// imapResponse is a response line with the literals embedded in it
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+// fetchIMAP reads the most recent messages of the URL's mailbox without marking them as seen
+func (l *EmailLoader) fetchIMAP(ctx context.Context, source *url.URL) ([]rawEmail, error) {
+	username := source.User.Username()
+	password, ok := source.User.Password()
+	if !ok && l.config.Email.PasswordEnv != "" {
+		password = os.Getenv(l.config.Email.PasswordEnv)
+	}
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("IMAP source requires a username and password")
+	}
+	mailbox := strings.TrimPrefix(source.Path, "/")
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+
+	conn, err := l.dialIMAP(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.conn.Close() })
+	defer stop()
+
+	if _, err := conn.command("LOGIN %s %s", imapQuote(username), imapQuote(password)); err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	if _, err := conn.command("EXAMINE %s", imapQuote(mailbox)); err != nil {
+		return nil, fmt.Errorf("failed to open mailbox %q: %w", mailbox, err)
+	}
+	responses, err := conn.command("UID SEARCH ALL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	uids := make([]string, 0)
+	for _, response := range responses {
+		if fields := strings.Fields(response.text); len(fields) > 2 && strings.EqualFold(fields[1], "SEARCH") {
+			uids = append(uids, fields[2:]...)
+		}
+	}
+	if max := l.config.Email.MaxMessages; max > 0 && len(uids) > max {
+		uids = uids[len(uids)-max:]
+	}
+
+	base := url.URL{Scheme: source.Scheme, Host: source.Host, Path: "/" + mailbox}
+	messages := make([]rawEmail, 0, len(uids))
+	for start := 0; start < len(uids); start += 100 {
+		batch := uids[start:min(start+100, len(uids))]
+		responses, err := conn.command("UID FETCH %s (UID BODY.PEEK[])", strings.Join(batch, ","))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch messages: %w", err)
+		}
+		for _, response := range responses {
+			if len(response.literals) == 0 || !strings.Contains(strings.ToUpper(response.text), "FETCH") {
+				continue
+			}
+			uid := ""
+			if fields := strings.Fields(strings.ToUpper(response.text)); len(fields) > 0 {
+				for i, field := range fields[:len(fields)-1] {
+					if strings.TrimLeft(field, "(") == "UID" {
+						uid = fields[i+1]
+						break
+					}
+				}
+			}
+			messages = append(messages, rawEmail{source: base.String() + ";UID=" + uid, data: response.literals[0]})
+		}
+	}
+	conn.command("LOGOUT") // The messages are already read, so a failed logout is ignored
+	return messages, nil
+}
+
+// dialIMAP connects over TLS, upgrading imap:// connections with STARTTLS
+func (l *EmailLoader) dialIMAP(ctx context.Context, source *url.URL) (*imapConn, error) {
+	host := source.Hostname()
+	port := source.Port()
+	if port == "" {
+		port = "993"
+		if source.Scheme == "imap" {
+			port = "143"
+		}
+	}
+	address := net.JoinHostPort(host, port)
+	dialer := &net.Dialer{Timeout: l.config.HTTPTimeout}
+	tlsConfig := &tls.Config{ServerName: host}
+
+	var raw net.Conn
+	var err error
+	if source.Scheme == "imaps" {
+		raw, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		raw, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	conn := &imapConn{conn: raw, reader: bufio.NewReader(raw), maxBytes: l.config.MaxBytes}
+	if conn.maxBytes <= 0 {
+		conn.maxBytes = 10 << 20
+	}
+	if _, err := conn.readResponse(); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("failed to read IMAP greeting: %w", err)
+	}
+
+	if source.Scheme == "imap" {
+		if _, err := conn.command("STARTTLS"); err != nil {
+			raw.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+		secure := tls.Client(raw, tlsConfig)
+		if err := secure.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+		conn.conn, conn.reader = secure, bufio.NewReader(secure)
+	}
+	return conn, nil
+}
+
+// command sends a tagged command and returns its untagged responses, failing unless it completes OK
+func (c *imapConn) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	responses := make([]imapResponse, 0)
+	for {
+		response, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(response.text, tag+" "); ok {
+			if !strings.HasPrefix(strings.ToUpper(rest), "OK") {
+				return nil, fmt.Errorf("IMAP server replied %q", rest)
+			}
+			return responses, nil
+		}
+		responses = append(responses, response)
+	}
+}
+
+// readResponse reads one response line, including any literals it announces
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var response imapResponse
+	var text strings.Builder
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return response, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		text.WriteString(line)
+
+		match := imapLiteral.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+		size, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || size > c.maxBytes {
+			return response, fmt.Errorf("IMAP literal of %s bytes exceeds size limit", match[1])
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.reader, literal); err != nil {
+			return response, err
+		}
+		response.literals = append(response.literals, literal)
+	}
+	response.text = text.String()
+	return response, nil
+}
+
+// imapQuote encodes a string as an IMAP quoted string
+func imapQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
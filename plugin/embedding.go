@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// registerEmbedders defines the configured embedders as GenKit embedders
+func (p *AgenticRAGPlugin) registerEmbedders(ctx context.Context, g *genkit.Genkit) error {
+	embedders := make(map[string]domain.Embedder, len(p.config.Embedders)+len(p.config.OpenAIEmbedders))
+	for name, embedder := range p.config.Embedders {
+		embedders[name] = embedder
+	}
+	for _, config := range p.config.OpenAIEmbedders {
+		embedder, err := NewOpenAIEmbedder(config)
+		if err != nil {
+			return err
+		}
+		embedders["openai/"+embedder.Name()] = embedder
+	}
+
+	for fullName, embedder := range embedders {
+		provider, name, ok := strings.Cut(fullName, "/")
+		if !ok {
+			return fmt.Errorf("embedder name %q must be in provider/name form", fullName)
+		}
+		if genkit.LookupEmbedder(g, provider, name) != nil {
+			return fmt.Errorf("embedder %q is already registered", fullName)
+		}
+		genkit.DefineEmbedder(g, provider, name, func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+			texts := make([]string, len(req.Input))
+			for i, doc := range req.Input {
+				texts[i] = documentText(doc)
+			}
+			embeddings, err := embedder.Embed(ctx, texts)
+			if err != nil {
+				return nil, err
+			}
+			response := &ai.EmbedResponse{Embeddings: make([]*ai.Embedding, len(embeddings))}
+			for i, embedding := range embeddings {
+				response.Embeddings[i] = &ai.Embedding{Embedding: embedding}
+			}
+			return response, nil
+		})
+	}
+	return nil
+}
+
+// documentText joins the text parts of a GenKit document
+func documentText(doc *ai.Document) string {
+	var text strings.Builder
+	for _, part := range doc.Content {
+		if part.IsText() {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+// embedderNameFor returns the embedder for a language, preferring the analyzer override
+func (p *AgenticRAGProcessor) embedderNameFor(language string) string {
+	if name := p.analyzerFor(language).embedderName; name != "" {
+		return name
+	}
+	return p.config.EmbedderName
+}
+
+// embedTexts embeds texts with the named Genkit embedder ("provider/name")
+func (p *AgenticRAGProcessor) embedTexts(ctx context.Context, embedderName string, texts []string) ([][]float32, error) {
+	if p.config.Genkit == nil {
+		return nil, fmt.Errorf("GenKit instance not provided in config")
+	}
+
+	provider, name, ok := strings.Cut(embedderName, "/")
+	if !ok {
+		return nil, fmt.Errorf("embedder name %q must be in provider/name form", embedderName)
+	}
+	embedder := genkit.LookupEmbedder(p.config.Genkit, provider, name)
+	if embedder == nil {
+		return nil, fmt.Errorf("embedder %q not found", embedderName)
+	}
+
+	docs := make([]*ai.Document, len(texts))
+	for i, text := range texts {
+		docs[i] = ai.DocumentFromText(text, nil)
+	}
+
+	response, err := ai.Embed(ctx, embedder, ai.WithDocs(docs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed texts: %w", err)
+	}
+	if len(response.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(response.Embeddings), len(texts))
+	}
+
+	embeddings := make([][]float32, len(response.Embeddings))
+	for i, embedding := range response.Embeddings {
+		embeddings[i] = embedding.Embedding
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// withEmbedder declares the embedder and dimensions of the embeddings stored and searched with the
+// returned context, so stores whose collection is bound to another embedder or dimensions reject
+// them with a *domain.EmbeddingMismatchError rather than comparing them, and unbound collections
+// are bound on first use
+func withEmbedder(ctx context.Context, embedderName string, dimensions int) context.Context {
+	return domain.WithEmbeddingBinding(ctx, domain.EmbeddingBinding{Embedder: embedderName, Dimensions: dimensions})
+}
+
+// embeddingBindingStore is a store keeping its collection's embedding binding
+type embeddingBindingStore interface {
+	domain.EmbeddingBinder
+	// embeddingBinding returns the collection's binding, if it is bound
+	embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error)
+}
+
+// embeddingGuard enforces a collection's embedding binding in a store's Store and Search. The
+// binding is read once per store view and again when a declared embedder meets an unbound one.
+type embeddingGuard struct {
+	mu    sync.Mutex
+	read  bool
+	bound *domain.EmbeddingBinding
+}
+
+// check rejects embeddings of the given dimensions that do not fit the collection's binding, from
+// another embedder when the context declares one, binding an unbound collection to a declared one
+func (g *embeddingGuard) check(ctx context.Context, store embeddingBindingStore, dimensions int) error {
+	declared, ok := domain.EmbeddingBindingFrom(ctx)
+	if ok && declared.Dimensions == 0 {
+		declared.Dimensions = dimensions
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.read || (g.bound == nil && ok) {
+		var bound domain.EmbeddingBinding
+		isBound := true
+		var err error
+		if ok {
+			bound, err = store.BindEmbedding(ctx, declared)
+		} else {
+			bound, isBound, err = store.embeddingBinding(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		g.read = true
+		if isBound {
+			g.bound = &bound
+		}
+	}
+	if g.bound == nil {
+		return nil
+	}
+
+	requested := domain.EmbeddingBinding{Embedder: g.bound.Embedder, Dimensions: dimensions}
+	if ok {
+		requested.Embedder = declared.Embedder
+	}
+	if requested != *g.bound {
+		return &domain.EmbeddingMismatchError{Bound: *g.bound, Requested: requested}
+	}
+	return nil
+}
+
+// checkRecords checks the embeddings of records about to be stored
+func (g *embeddingGuard) checkRecords(ctx context.Context, store embeddingBindingStore, records []domain.VectorRecord) error {
+	checked := make(map[int]bool, 1)
+	for _, record := range records {
+		if checked[len(record.Embedding)] {
+			continue
+		}
+		if err := g.check(ctx, store, len(record.Embedding)); err != nil {
+			return err
+		}
+		checked[len(record.Embedding)] = true
+	}
+	return nil
+}
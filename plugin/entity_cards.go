@@ -0,0 +1,183 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EntityCard is an answer about a single knowledge graph entity, assembled from its subgraph and
+// the chunks mentioning it
+type EntityCard struct {
+	Entity     Entity     `json:"entity"`
+	Definition string     `json:"definition"`
+	Relations  []Relation `json:"relations,omitempty"`
+	KeyFacts   []string   `json:"key_facts,omitempty"`
+	Sources    []string   `json:"sources"` // IDs of the chunks the card is built from
+}
+
+// resolveEntityCard builds a knowledge graph over the chunks matching the query's keywords and
+// returns a card for the entity the query is about, with the chunks mentioning it. It returns
+// nil when no extracted entity is named in the query, so the request falls back to generic
+// retrieval.
+func (p *AgenticRAGProcessor) resolveEntityCard(ctx context.Context, query string, chunks []DocumentChunk, maxChunks int) (*EntityCard, []DocumentChunk, error) {
+	shortlist := p.keywordSearch(query, chunks)
+	if len(shortlist) > p.vectorTopK() {
+		shortlist = shortlist[:p.vectorTopK()]
+	}
+	graph, err := p.buildKnowledgeGraph(ctx, shortlist)
+	if err != nil {
+		return nil, nil, err
+	}
+	if graph == nil {
+		return nil, nil, nil
+	}
+
+	entity, ok := queryEntity(query, graph.Entities)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	names := entityNames(entity)
+	sources := make([]DocumentChunk, 0)
+	for _, chunk := range shortlist {
+		if len(sources) < maxChunks && mentionsAny(chunk.Content, names) {
+			sources = append(sources, chunk)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, nil, nil
+	}
+
+	card := &EntityCard{Entity: entity, Relations: make([]Relation, 0)}
+	for _, relation := range graph.Relations {
+		if strings.EqualFold(relation.Subject, entity.Name) || strings.EqualFold(relation.Object, entity.Name) {
+			card.Relations = append(card.Relations, relation)
+		}
+	}
+	return card, sources, nil
+}
+
+// queryEntity returns the entity named in the query, preferring the longest name and then the
+// highest confidence
+func queryEntity(query string, entities []Entity) (Entity, bool) {
+	matches := make([]Entity, 0)
+	for _, entity := range entities {
+		for _, name := range entityNames(entity) {
+			if wordPattern(name).MatchString(query) {
+				matches = append(matches, entity)
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return Entity{}, false
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if len(matches[i].Name) != len(matches[j].Name) {
+			return len(matches[i].Name) > len(matches[j].Name)
+		}
+		return matches[i].Confidence > matches[j].Confidence
+	})
+	return matches[0], true
+}
+
+// entityNames returns the entity's name and the mentions recorded for it
+func entityNames(entity Entity) []string {
+	names := []string{entity.Name}
+	if mentions, ok := entity.Properties["mentions"].([]string); ok {
+		names = append(names, mentions...)
+	}
+	nonEmpty := names[:0]
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			nonEmpty = append(nonEmpty, name)
+		}
+	}
+	return nonEmpty
+}
+
+// mentionsAny reports whether the text names any of the names as a whole word
+func mentionsAny(text string, names []string) bool {
+	for _, name := range names {
+		if wordPattern(name).MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordPattern matches a name as a whole word, ignoring case
+func wordPattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(^|\W)` + regexp.QuoteMeta(strings.TrimSpace(name)) + `($|\W)`)
+}
+
+// generateEntityCard fills in the card's definition and key facts from its relations and the
+// numbered chunks, and returns the card rendered as the answer with citation markers
+func (p *AgenticRAGProcessor) generateEntityCard(ctx context.Context, card *EntityCard, chunks []DocumentChunk, options AgenticRAGOptions) (string, int, error) {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Write an entity card for %q", card.Entity.Name))
+	if card.Entity.Type != "" {
+		prompt.WriteString(fmt.Sprintf(" (%s)", card.Entity.Type))
+	}
+	prompt.WriteString(" using only the relations and numbered sources below.\n\n")
+	if len(card.Relations) > 0 {
+		prompt.WriteString("Relations:\n")
+		for _, relation := range card.Relations {
+			prompt.WriteString(fmt.Sprintf("- %s %s %s\n", relation.Subject, relation.Predicate, relation.Object))
+		}
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("Sources:\n")
+	for i, chunk := range chunks {
+		prompt.WriteString(fmt.Sprintf("[%d] %s\n\n", i+1, chunk.Content))
+	}
+	prompt.WriteString(`Respond with only JSON: {"definition": "one or two sentences defining the entity", "key_facts": ["a key fact"]}
+Cite the supporting sources in the definition and in every key fact with markers like [1].`)
+
+	text, err := p.generateText(ctx, prompt.String(), float64(options.Temperature), 1024)
+	if err != nil {
+		return "", 0, err
+	}
+	var parsed struct {
+		Definition string   `json:"definition"`
+		KeyFacts   []string `json:"key_facts"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(text)), &parsed); err != nil {
+		return "", 0, fmt.Errorf("failed to parse entity card: %w", err)
+	}
+	card.Definition, card.KeyFacts = parsed.Definition, parsed.KeyFacts
+	card.Sources = make([]string, len(chunks))
+	for i, chunk := range chunks {
+		card.Sources[i] = chunk.ID
+	}
+
+	answer := renderEntityCard(card)
+	return answer, len(answer), nil
+}
+
+// renderEntityCard renders the card as a Markdown answer
+func renderEntityCard(card *EntityCard) string {
+	var builder strings.Builder
+	builder.WriteString("**" + card.Entity.Name + "**")
+	if card.Entity.Type != "" {
+		builder.WriteString(" (" + card.Entity.Type + ")")
+	}
+	builder.WriteString("\n\n" + card.Definition + "\n")
+	if len(card.Relations) > 0 {
+		builder.WriteString("\nRelations:\n")
+		for _, relation := range card.Relations {
+			builder.WriteString(fmt.Sprintf("- %s %s %s\n", relation.Subject, relation.Predicate, relation.Object))
+		}
+	}
+	if len(card.KeyFacts) > 0 {
+		builder.WriteString("\nKey facts:\n")
+		for _, fact := range card.KeyFacts {
+			builder.WriteString("- " + fact + "\n")
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
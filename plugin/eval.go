@@ -0,0 +1,167 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// EvalCase is a single question with the evidence a good answer should contain
+type EvalCase struct {
+	ID              string   `json:"id"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents,omitempty"`
+	Collections     []string `json:"collections,omitempty"`
+	ExpectedAnswer  string   `json:"expected_answer,omitempty"`  // Reference answer compared by term overlap
+	ExpectedFacts   []string `json:"expected_facts,omitempty"`   // Phrases the answer must contain
+	ExpectedSources []string `json:"expected_sources,omitempty"` // Phrases the retrieved chunks must contain
+}
+
+// EvalCaseResult holds the scores for one eval case
+type EvalCaseResult struct {
+	ID             string        `json:"id"`
+	AnswerScore    float64       `json:"answer_score"`
+	RetrievalScore float64       `json:"retrieval_score"`
+	Score          float64       `json:"score"`
+	Latency        time.Duration `json:"latency"`
+	Error          string        `json:"error,omitempty"`
+
+	Response *AgenticRAGResponse `json:"-"` // Pipeline response, kept for exports (not serialized)
+}
+
+// EvalReport summarizes a run over an eval dataset
+type EvalReport struct {
+	Cases              []EvalCaseResult `json:"cases"`
+	MeanScore          float64          `json:"mean_score"`
+	MeanAnswerScore    float64          `json:"mean_answer_score"`
+	MeanRetrievalScore float64          `json:"mean_retrieval_score"`
+	MeanLatency        time.Duration    `json:"mean_latency"`
+	Failures           int              `json:"failures"`
+}
+
+// LoadEvalDataset reads eval cases from a JSONL file, one case per line
+func LoadEvalDataset(path string) ([]EvalCase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open eval dataset: %w", err)
+	}
+	defer file.Close()
+
+	cases := make([]EvalCase, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var evalCase EvalCase
+		if err := json.Unmarshal([]byte(text), &evalCase); err != nil {
+			return nil, fmt.Errorf("failed to parse eval case on line %d: %w", line, err)
+		}
+		if evalCase.ID == "" {
+			evalCase.ID = fmt.Sprintf("case_%d", line)
+		}
+		cases = append(cases, evalCase)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read eval dataset: %w", err)
+	}
+	return cases, nil
+}
+
+// Evaluate runs every case through the pipeline with the given options and scores the results
+func (p *AgenticRAGProcessor) Evaluate(ctx context.Context, cases []EvalCase, options AgenticRAGOptions) (*EvalReport, error) {
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("eval dataset is empty")
+	}
+
+	report := &EvalReport{Cases: make([]EvalCaseResult, 0, len(cases))}
+	var totalLatency time.Duration
+	for _, evalCase := range cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		caseOptions := options
+		caseOptions.Collections = evalCase.Collections
+		start := time.Now()
+		response, err := p.Process(ctx, AgenticRAGRequest{
+			Query:     evalCase.Query,
+			Documents: evalCase.Documents,
+			Options:   caseOptions,
+		})
+		result := EvalCaseResult{ID: evalCase.ID, Latency: time.Since(start)}
+		totalLatency += result.Latency
+
+		if err != nil {
+			result.Error = err.Error()
+			report.Failures++
+		} else {
+			p.scoreEvalCase(&result, evalCase, response)
+			result.Response = response
+		}
+
+		report.Cases = append(report.Cases, result)
+		report.MeanScore += result.Score
+		report.MeanAnswerScore += result.AnswerScore
+		report.MeanRetrievalScore += result.RetrievalScore
+	}
+
+	count := float64(len(cases))
+	report.MeanScore /= count
+	report.MeanAnswerScore /= count
+	report.MeanRetrievalScore /= count
+	report.MeanLatency = totalLatency / time.Duration(len(cases))
+	return report, nil
+}
+
+// scoreEvalCase scores the answer and the retrieved chunks against the case expectations
+func (p *AgenticRAGProcessor) scoreEvalCase(result *EvalCaseResult, evalCase EvalCase, response *AgenticRAGResponse) {
+	scores := make([]float64, 0, 2)
+
+	switch {
+	case len(evalCase.ExpectedFacts) > 0:
+		result.AnswerScore = phraseRecall(evalCase.ExpectedFacts, response.Answer)
+		scores = append(scores, result.AnswerScore)
+	case evalCase.ExpectedAnswer != "":
+		result.AnswerScore = p.calculateRelevanceScore(evalCase.ExpectedAnswer, response.Answer)
+		scores = append(scores, result.AnswerScore)
+	}
+
+	if len(evalCase.ExpectedSources) > 0 {
+		var retrieved strings.Builder
+		for _, chunk := range response.RelevantChunks {
+			retrieved.WriteString(chunk.Chunk.Content)
+			retrieved.WriteString("\n")
+		}
+		result.RetrievalScore = phraseRecall(evalCase.ExpectedSources, retrieved.String())
+		scores = append(scores, result.RetrievalScore)
+	}
+
+	for _, score := range scores {
+		result.Score += score
+	}
+	if len(scores) > 0 {
+		result.Score /= float64(len(scores))
+	}
+}
+
+// phraseRecall returns the fraction of phrases found in text, ignoring case
+func phraseRecall(phrases []string, text string) float64 {
+	if len(phrases) == 0 {
+		return 0
+	}
+	text = strings.ToLower(text)
+	found := 0
+	for _, phrase := range phrases {
+		if strings.Contains(text, strings.ToLower(phrase)) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(phrases))
+}
@@ -0,0 +1,103 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ChunkRecord is one retrieved chunk of a response, flattened for offline analysis
+type ChunkRecord struct {
+	RequestID      string    `json:"request_id"`
+	Query          string    `json:"query"`
+	Rank           int       `json:"rank"` // Position among the response's relevant chunks, from 0
+	ChunkID        string    `json:"chunk_id"`
+	DocumentID     string    `json:"document_id"`
+	Content        string    `json:"content"`
+	ChunkIndex     int       `json:"chunk_index"`
+	StartIndex     int       `json:"start_index"`
+	EndIndex       int       `json:"end_index"`
+	Score          float64   `json:"score"`
+	Metadata       string    `json:"metadata"` // Chunk metadata as a JSON object
+	Embedding      []float32 `json:"embedding"`
+	EmbeddingModel string    `json:"embedding_model"`
+}
+
+// ChunkRecords flattens a response's relevant chunks into records. Embeddings are only present
+// when the request set IncludeEmbeddings.
+func ChunkRecords(requestID, query string, response *AgenticRAGResponse) ([]ChunkRecord, error) {
+	records := make([]ChunkRecord, 0, len(response.RelevantChunks))
+	for rank, processed := range response.RelevantChunks {
+		chunk := processed.Chunk
+		metadata := []byte("{}")
+		if len(chunk.Metadata) > 0 {
+			encoded, err := json.Marshal(chunk.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode metadata of chunk %s: %w", chunk.ID, err)
+			}
+			metadata = encoded
+		}
+		records = append(records, ChunkRecord{
+			RequestID:      requestID,
+			Query:          query,
+			Rank:           rank,
+			ChunkID:        chunk.ID,
+			DocumentID:     chunk.DocumentID,
+			Content:        chunk.Content,
+			ChunkIndex:     chunk.ChunkIndex,
+			StartIndex:     chunk.StartIndex,
+			EndIndex:       chunk.EndIndex,
+			Score:          chunk.RelevanceScore,
+			Metadata:       string(metadata),
+			Embedding:      processed.Embedding,
+			EmbeddingModel: processed.EmbeddingModel,
+		})
+	}
+	return records, nil
+}
+
+// WriteChunksParquet writes chunk records as a Parquet file, one row per record, for analysis in
+// notebooks (pandas.read_parquet, polars, DuckDB) or as reranker training data. Embeddings are a
+// list<float> column, empty for records without one.
+func WriteChunksParquet(w io.Writer, records []ChunkRecord) error {
+	strings := func(field func(ChunkRecord) string) []string {
+		values := make([]string, len(records))
+		for i, record := range records {
+			values[i] = field(record)
+		}
+		return values
+	}
+	ints := func(field func(ChunkRecord) int) []int64 {
+		values := make([]int64, len(records))
+		for i, record := range records {
+			values[i] = int64(field(record))
+		}
+		return values
+	}
+	scores := make([]float64, len(records))
+	embeddings := make([][]float32, len(records))
+	for i, record := range records {
+		scores[i] = record.Score
+		embeddings[i] = record.Embedding
+	}
+
+	columns := []parquetColumn{
+		{name: "request_id", strings: strings(func(r ChunkRecord) string { return r.RequestID })},
+		{name: "query", strings: strings(func(r ChunkRecord) string { return r.Query })},
+		{name: "rank", int64s: ints(func(r ChunkRecord) int { return r.Rank })},
+		{name: "chunk_id", strings: strings(func(r ChunkRecord) string { return r.ChunkID })},
+		{name: "document_id", strings: strings(func(r ChunkRecord) string { return r.DocumentID })},
+		{name: "content", strings: strings(func(r ChunkRecord) string { return r.Content })},
+		{name: "chunk_index", int64s: ints(func(r ChunkRecord) int { return r.ChunkIndex })},
+		{name: "start_index", int64s: ints(func(r ChunkRecord) int { return r.StartIndex })},
+		{name: "end_index", int64s: ints(func(r ChunkRecord) int { return r.EndIndex })},
+		{name: "score", doubles: scores},
+		{name: "metadata", strings: strings(func(r ChunkRecord) string { return r.Metadata })},
+		{name: "embedding", floatLists: embeddings},
+		{name: "embedding_model", strings: strings(func(r ChunkRecord) string { return r.EmbeddingModel })},
+	}
+	if err := writeParquet(w, columns, len(records)); err != nil {
+		return fmt.Errorf("failed to write parquet: %w", err)
+	}
+	return nil
+}
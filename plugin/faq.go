@@ -0,0 +1,299 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FAQ entry review states
+const (
+	FAQDraft    = "draft"    // Generated and awaiting review; never served
+	FAQApproved = "approved" // Reviewed and served in place of the pipeline
+)
+
+// FAQConfig contains configuration for the reviewed FAQ consulted before the pipeline
+type FAQConfig struct {
+	Path string `json:"path"` // FAQ collection file written by GenerateFAQ; disabled when empty
+}
+
+// FAQEntry is a canonical question with its answer, generated from a cluster of logged queries
+type FAQEntry struct {
+	ID          string     `json:"id"`
+	Question    string     `json:"question"`           // Most frequent phrasing in the cluster
+	Variants    []string   `json:"variants,omitempty"` // Other phrasings answered by the entry
+	Frequency   int        `json:"frequency"`          // Times the cluster's queries were asked
+	Answer      string     `json:"answer"`
+	Citations   []Citation `json:"citations,omitempty"`
+	Status      string     `json:"status"` // FAQDraft or FAQApproved
+	GeneratedAt time.Time  `json:"generated_at"`
+}
+
+// FAQCollection is a set of FAQ entries, edited by reviewers between generation and publishing
+type FAQCollection struct {
+	Entries []FAQEntry `json:"entries"`
+}
+
+// FAQGenerationOptions configures a GenerateFAQ run
+type FAQGenerationOptions struct {
+	Template     AgenticRAGRequest `json:"template"`      // Documents and options every canonical question is answered with
+	MinFrequency int               `json:"min_frequency"` // Clusters asked fewer times are left out (default: 2)
+	MaxEntries   int               `json:"max_entries"`   // Most frequent clusters kept (default: 50)
+}
+
+// Similarity at which a query joins a cluster, by embedding cosine or by term overlap
+const (
+	faqEmbeddingThreshold = 0.85
+	faqTermThreshold      = 0.5
+)
+
+// LoadFAQ reads an FAQ collection file
+func LoadFAQ(path string) (*FAQCollection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FAQ: %w", err)
+	}
+	var collection FAQCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse FAQ: %w", err)
+	}
+	return &collection, nil
+}
+
+// Save writes the collection atomically, so serving processors never read a partial file
+func (c *FAQCollection) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode FAQ: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".faq-*")
+	if err != nil {
+		return fmt.Errorf("failed to create FAQ file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write FAQ: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write FAQ: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write FAQ: %w", err)
+	}
+	return nil
+}
+
+// Merge adds generated entries to the collection. Approved entries keep their reviewed answer and
+// only take the new frequency and variants; other entries are replaced by the generated ones.
+func (c *FAQCollection) Merge(generated *FAQCollection) {
+	index := make(map[string]int, len(c.Entries))
+	for i, entry := range c.Entries {
+		index[entry.ID] = i
+	}
+	for _, entry := range generated.Entries {
+		i, ok := index[entry.ID]
+		switch {
+		case !ok:
+			index[entry.ID] = len(c.Entries)
+			c.Entries = append(c.Entries, entry)
+		case c.Entries[i].Status == FAQApproved:
+			c.Entries[i].Frequency = entry.Frequency
+			c.Entries[i].Variants = entry.Variants
+		default:
+			c.Entries[i] = entry
+		}
+	}
+	sort.SliceStable(c.Entries, func(i, j int) bool {
+		return c.Entries[i].Frequency > c.Entries[j].Frequency
+	})
+}
+
+// GenerateFAQ clusters historical queries by similarity and answers the most frequent phrasing of
+// each cluster with the full pipeline. Entries are returned as drafts for review; only approved
+// entries are served. Failed questions are left out.
+func (p *AgenticRAGProcessor) GenerateFAQ(ctx context.Context, queries []QueryFrequency, options FAQGenerationOptions) (*FAQCollection, error) {
+	if options.MinFrequency <= 0 {
+		options.MinFrequency = 2
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 50
+	}
+
+	clusters := p.clusterQueries(ctx, queries)
+	collection := &FAQCollection{Entries: make([]FAQEntry, 0)}
+	ctx = context.WithValue(ctx, skipFAQContextKey{}, true)
+	for _, cluster := range clusters {
+		if len(collection.Entries) == options.MaxEntries || cluster.frequency < options.MinFrequency {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		request := options.Template
+		request.Query = cluster.queries[0].Query
+		response, err := p.Process(ctx, request)
+		if err != nil {
+			continue
+		}
+
+		variants := make([]string, 0, len(cluster.queries)-1)
+		for _, query := range cluster.queries[1:] {
+			variants = append(variants, query.Query)
+		}
+		collection.Entries = append(collection.Entries, FAQEntry{
+			ID:          faqEntryID(request.Query),
+			Question:    request.Query,
+			Variants:    variants,
+			Frequency:   cluster.frequency,
+			Answer:      response.Answer,
+			Citations:   response.Citations,
+			Status:      FAQDraft,
+			GeneratedAt: time.Now(),
+		})
+	}
+	return collection, nil
+}
+
+// queryCluster is a group of similar queries, most frequent first
+type queryCluster struct {
+	queries   []QueryFrequency
+	frequency int
+}
+
+// clusterQueries greedily assigns each query, most frequent first, to the first cluster whose
+// leading query is similar enough, comparing embeddings when an embedder is configured and
+// analyzed terms otherwise. Clusters are returned most frequent first.
+func (p *AgenticRAGProcessor) clusterQueries(ctx context.Context, queries []QueryFrequency) []queryCluster {
+	sorted := append([]QueryFrequency(nil), queries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Count > sorted[j].Count
+	})
+
+	texts := make([]string, len(sorted))
+	terms := make([][]string, len(sorted))
+	for i, query := range sorted {
+		texts[i] = query.Query
+		terms[i] = p.analyzerFor(p.detectLanguage(query.Query)).terms(query.Query)
+	}
+	similarity := func(i, j int) float64 {
+		return jaccard(terms[i], terms[j])
+	}
+	threshold := faqTermThreshold
+	if p.config.EmbedderName != "" {
+		if embeddings, err := p.cachedEmbeddings(ctx, p.config.EmbedderName, texts); err == nil {
+			similarity = func(i, j int) float64 {
+				return cosineSimilarity(embeddings[i], embeddings[j])
+			}
+			threshold = faqEmbeddingThreshold
+		}
+	}
+
+	clusters := make([]queryCluster, 0)
+	leaders := make([]int, 0) // Index in sorted of each cluster's first query
+	for i, query := range sorted {
+		joined := false
+		for c, leader := range leaders {
+			if similarity(i, leader) >= threshold {
+				clusters[c].queries = append(clusters[c].queries, query)
+				clusters[c].frequency += query.Count
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			leaders = append(leaders, i)
+			clusters = append(clusters, queryCluster{queries: []QueryFrequency{query}, frequency: query.Count})
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].frequency > clusters[j].frequency
+	})
+	return clusters
+}
+
+// skipFAQContextKey marks requests that must run the pipeline even when an FAQ entry matches
+type skipFAQContextKey struct{}
+
+// faqAnswer returns the approved FAQ entry's answer when the query exactly matches its question
+// or a variant, ignoring case, punctuation, and spacing
+func (p *AgenticRAGProcessor) faqAnswer(ctx context.Context, request AgenticRAGRequest, startTime time.Time) *AgenticRAGResponse {
+	if p.config.FAQ.Path == "" || request.ResumeToken != "" || request.CheckpointID != "" || request.SessionID != "" || request.Options.SignAnswer {
+		return nil
+	}
+	if skip, _ := ctx.Value(skipFAQContextKey{}).(bool); skip {
+		return nil
+	}
+	entry, ok := p.faqEntries()[normalizeFAQQuestion(request.Query)]
+	if !ok {
+		return nil
+	}
+
+	formatted := ""
+	if request.Options.OutputFormat != "" {
+		rendered, err := renderAnswer(request.Options.OutputFormat, entry.Answer, entry.Citations)
+		if err != nil {
+			return nil
+		}
+		formatted = rendered
+	}
+	p.config.Metrics.IncCounter("agentic_rag_faq_hits_total", 1)
+	return &AgenticRAGResponse{
+		Answer:          entry.Answer,
+		FormattedAnswer: formatted,
+		Citations:       entry.Citations,
+		RelevantChunks:  make([]ProcessedChunk, 0),
+		ProcessingMetadata: ProcessingMetadata{
+			ProcessingTime: time.Since(startTime),
+			FAQEntryID:     entry.ID,
+		},
+	}
+}
+
+// faqEntries returns the approved entries by normalized question, loading the FAQ on first use. A
+// missing or unreadable FAQ disables the shortcut.
+func (p *AgenticRAGProcessor) faqEntries() map[string]*FAQEntry {
+	p.faqOnce.Do(func() {
+		p.faq = make(map[string]*FAQEntry)
+		collection, err := LoadFAQ(p.config.FAQ.Path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				p.config.Metrics.IncCounter("agentic_rag_faq_load_errors_total", 1)
+			}
+			return
+		}
+		for i := range collection.Entries {
+			entry := &collection.Entries[i]
+			if entry.Status != FAQApproved {
+				continue
+			}
+			for _, question := range append([]string{entry.Question}, entry.Variants...) {
+				p.faq[normalizeFAQQuestion(question)] = entry
+			}
+		}
+	})
+	return p.faq
+}
+
+// normalizeFAQQuestion lowercases a question and reduces it to words separated by single spaces
+func normalizeFAQQuestion(question string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// faqEntryID identifies an entry by its normalized question, so regenerated entries keep their ID
+func faqEntryID(question string) string {
+	sum := sha256.Sum256([]byte(normalizeFAQQuestion(question)))
+	return hex.EncodeToString(sum[:8])
+}
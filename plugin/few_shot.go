@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FewShotConfig contains configuration for few-shot demonstrations in the generation prompt
+type FewShotConfig struct {
+	Enabled       bool    `json:"enabled"`
+	MaxExamples   int     `json:"max_examples"`   // Maximum number of demonstrations per request
+	MaxTokens     int     `json:"max_tokens"`     // Token budget shared by all demonstrations
+	MinSimilarity float64 `json:"min_similarity"` // Minimum query similarity for an example to be used
+}
+
+// Example is a registered (question, ideal answer) demonstration
+type Example struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// ScoredExample is an example together with its similarity to the current query
+type ScoredExample struct {
+	Example    Example `json:"example"`
+	Similarity float64 `json:"similarity"`
+}
+
+// ExampleBank stores demonstrations available for few-shot prompting
+type ExampleBank struct {
+	mu       sync.RWMutex
+	examples []Example
+	nextID   int
+}
+
+// NewExampleBank creates an empty example bank
+func NewExampleBank() *ExampleBank {
+	return &ExampleBank{}
+}
+
+// Add registers a demonstration, replacing any existing example with the same ID.
+// Examples without an ID are assigned one, which is returned.
+func (b *ExampleBank) Add(example Example) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if example.ID == "" {
+		b.nextID++
+		example.ID = fmt.Sprintf("example_%d", b.nextID)
+	}
+
+	for i, existing := range b.examples {
+		if existing.ID == example.ID {
+			b.examples[i] = example
+			return example.ID
+		}
+	}
+	b.examples = append(b.examples, example)
+	return example.ID
+}
+
+// Remove deletes the example with the given ID
+func (b *ExampleBank) Remove(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, existing := range b.examples {
+		if existing.ID == id {
+			b.examples = append(b.examples[:i], b.examples[i+1:]...)
+			return
+		}
+	}
+}
+
+// Examples returns a snapshot of all registered examples
+func (b *ExampleBank) Examples() []Example {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	examples := make([]Example, len(b.examples))
+	copy(examples, b.examples)
+	return examples
+}
+
+// selectExamples retrieves the most similar examples for a query within the token budget
+func (p *AgenticRAGProcessor) selectExamples(ctx context.Context, query string) []ScoredExample {
+	cfg := p.config.FewShot
+	if !cfg.Enabled || p.config.ExampleBank == nil {
+		return nil
+	}
+
+	examples := p.config.ExampleBank.Examples()
+	if len(examples) == 0 {
+		return nil
+	}
+
+	language := p.detectLanguage(query)
+	scored := p.scoreExamplesByEmbedding(ctx, query, language, examples)
+	if scored == nil {
+		// Lexical similarity when no embedder is configured or embedding fails
+		scored = make([]ScoredExample, len(examples))
+		for i, example := range examples {
+			scored[i] = ScoredExample{
+				Example:    example,
+				Similarity: p.calculateRelevanceScoreForLanguage(query, example.Question, language),
+			}
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Similarity > scored[j].Similarity
+	})
+
+	selected := make([]ScoredExample, 0, cfg.MaxExamples)
+	usedTokens := 0
+	for _, candidate := range scored {
+		if cfg.MaxExamples > 0 && len(selected) >= cfg.MaxExamples {
+			break
+		}
+		if candidate.Similarity < cfg.MinSimilarity {
+			break
+		}
+
+		tokens := estimateTokens(candidate.Example.Question) + estimateTokens(candidate.Example.Answer)
+		if cfg.MaxTokens > 0 && usedTokens+tokens > cfg.MaxTokens {
+			continue
+		}
+		usedTokens += tokens
+		selected = append(selected, candidate)
+	}
+
+	return selected
+}
+
+// scoreExamplesByEmbedding scores examples by embedding similarity, returning nil if embeddings are
+// unavailable. The embedder follows the query language, so example questions are embedded through
+// the embedding cache, which is keyed by embedder, rather than cached once per example.
+func (p *AgenticRAGProcessor) scoreExamplesByEmbedding(ctx context.Context, query, language string, examples []Example) []ScoredExample {
+	embedderName := p.embedderNameFor(language)
+	if embedderName == "" {
+		return nil
+	}
+
+	texts := make([]string, 0, len(examples)+1)
+	texts = append(texts, query)
+	for _, example := range examples {
+		texts = append(texts, example.Question)
+	}
+	embeddings, err := p.cachedEmbeddings(ctx, embedderName, texts)
+	if err != nil {
+		return nil
+	}
+
+	scored := make([]ScoredExample, len(examples))
+	for i, example := range examples {
+		scored[i] = ScoredExample{
+			Example:    example,
+			Similarity: cosineSimilarity(embeddings[0], embeddings[i+1]),
+		}
+	}
+	return scored
+}
+
+// estimateTokens approximates the token count of text
+func estimateTokens(text string) int {
+	return defaultTokenizer.CountTokens(text)
+}
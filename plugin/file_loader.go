@@ -0,0 +1,242 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// FileLoader loads local files, directories (recursively), and glob patterns such as "./docs/**/*.md"
+type FileLoader struct {
+	config  LoaderConfig
+	formats []DocumentLoader // Loaders for specific file formats; other text files are read as-is
+}
+
+// NewFileLoader creates a filesystem loader that hands recognized file formats to the given loaders
+func NewFileLoader(config LoaderConfig, formats ...DocumentLoader) *FileLoader {
+	return &FileLoader{config: config, formats: formats}
+}
+
+// CanLoad reports whether the source is an existing file or directory, or a glob pattern
+func (l *FileLoader) CanLoad(source string) bool {
+	if source == "" || strings.ContainsAny(source, "\n\r") {
+		return false
+	}
+	if isGlobPattern(source) {
+		// Raw text may contain "?" or "*", so globs must be a single token rooted in an existing directory
+		if strings.ContainsAny(source, " \t") {
+			return false
+		}
+		root, _ := splitGlobPattern(source)
+		info, err := os.Stat(root)
+		return err == nil && info.IsDir()
+	}
+	_, err := os.Stat(source)
+	return err == nil
+}
+
+// Load reads every matching file, skipping ignored paths and binary files found while walking
+func (l *FileLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	if !isGlobPattern(source) {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+		if !info.IsDir() {
+			return l.loadFile(ctx, source, info, false)
+		}
+	}
+
+	paths, err := l.matchPaths(source)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]Document, 0, len(paths))
+	for _, filePath := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", filePath, err)
+		}
+		loaded, err := l.loadFile(ctx, filePath, info, true)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, loaded...)
+	}
+	return docs, nil
+}
+
+// matchPaths walks a directory or the static prefix of a glob pattern and returns the matching files
+func (l *FileLoader) matchPaths(source string) ([]string, error) {
+	root, pattern := source, ""
+	if isGlobPattern(source) {
+		root, pattern = splitGlobPattern(source)
+	}
+
+	maxFiles := l.config.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 1000
+	}
+
+	paths := make([]string, 0)
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if l.ignored(rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if pattern != "" && !matchGlobPath(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return nil
+		}
+
+		paths = append(paths, filePath)
+		if len(paths) > maxFiles {
+			return fmt.Errorf("matched more than %d files", maxFiles)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	return paths, nil
+}
+
+// ignored reports whether a path relative to the walk root matches an ignore pattern.
+// Patterns without a slash match any path segment; patterns with one match the whole relative path.
+func (l *FileLoader) ignored(rel string) bool {
+	segments := strings.Split(rel, "/")
+	for _, pattern := range l.config.IgnorePatterns {
+		if strings.Contains(pattern, "/") {
+			if matchGlobPath(strings.Split(strings.Trim(pattern, "/"), "/"), segments) {
+				return true
+			}
+			continue
+		}
+		for _, segment := range segments {
+			if matched, _ := path.Match(pattern, segment); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadFile loads one file with a format loader or as plain text, adding its filesystem metadata.
+// Binary files are skipped when found by walking and rejected when named directly.
+func (l *FileLoader) loadFile(ctx context.Context, filePath string, info fs.FileInfo, walked bool) ([]Document, error) {
+	var docs []Document
+	var err error
+
+	loaded := false
+	for _, loader := range l.formats {
+		if loader.CanLoad(filePath) {
+			docs, err = loader.Load(ctx, filePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s: %w", filePath, err)
+			}
+			loaded = true
+			break
+		}
+	}
+
+	if !loaded {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		data, err := readLimited(file, l.config.MaxBytes)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+
+		contentType := sniffContentType(data)
+		if !strings.HasPrefix(contentType, "text/") && !utf8.Valid(data) {
+			if walked {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unsupported binary file %s (%s)", filePath, contentType)
+		}
+		docs = []Document{{Content: string(data), Source: filePath, Metadata: map[string]interface{}{"content_type": contentType}}}
+	}
+
+	for i := range docs {
+		if docs[i].Metadata == nil {
+			docs[i].Metadata = make(map[string]interface{})
+		}
+		docs[i].Metadata["path"] = filePath
+		docs[i].Metadata["mtime"] = info.ModTime()
+		docs[i].Metadata["size"] = info.Size()
+		if _, ok := docs[i].Metadata["updated_at"]; !ok {
+			docs[i].Metadata["updated_at"] = info.ModTime()
+		}
+	}
+	return docs, nil
+}
+
+// isGlobPattern reports whether a source contains glob metacharacters
+func isGlobPattern(source string) bool {
+	return strings.ContainsAny(source, "*?[")
+}
+
+// splitGlobPattern splits a glob into the directory to walk and the slash-separated pattern below it
+func splitGlobPattern(source string) (string, string) {
+	segments := strings.Split(filepath.ToSlash(source), "/")
+	for i, segment := range segments {
+		if isGlobPattern(segment) {
+			root := strings.Join(segments[:i], "/")
+			if root == "" {
+				root = "."
+				if i > 0 {
+					root = "/"
+				}
+			}
+			return filepath.FromSlash(root), strings.Join(segments[i:], "/")
+		}
+	}
+	return source, ""
+}
+
+// matchGlobPath matches path segments against pattern segments, where "**" matches zero or more segments
+func matchGlobPath(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlobPath(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, err := path.Match(pattern[0], segments[0]); err != nil || !matched {
+		return false
+	}
+	return matchGlobPath(pattern[1:], segments[1:])
+}
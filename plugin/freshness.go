@@ -0,0 +1,241 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FreshnessConfig contains configuration for resolving conflicts between dated sources
+type FreshnessConfig struct {
+	Enabled             bool     `json:"enabled"`
+	PreferNewer         bool     `json:"prefer_newer"`         // Demote the older chunk of a conflicting pair
+	DateMetadataKeys    []string `json:"date_metadata_keys"`   // Metadata keys checked, in order, for a source date
+	SimilarityThreshold float64  `json:"similarity_threshold"` // Minimum term overlap for two chunks to be about the same topic
+	SupersededPenalty   float64  `json:"superseded_penalty"`   // Multiplier applied to the relevance of superseded chunks
+	UseLLM              bool     `json:"use_llm"`              // Confirm conflicts with the model instead of the numeric heuristic
+}
+
+// SourceConflict records two retrieved chunks that disagree and how the conflict was resolved
+type SourceConflict struct {
+	NewerChunkID string    `json:"newer_chunk_id"`
+	OlderChunkID string    `json:"older_chunk_id"`
+	NewerDate    time.Time `json:"newer_date"`
+	OlderDate    time.Time `json:"older_date"`
+	Description  string    `json:"description,omitempty"`
+	Resolution   string    `json:"resolution"` // "prefer_newer" or "unresolved"
+}
+
+// datedChunk pairs a chunk with the date parsed from its metadata
+type datedChunk struct {
+	index int
+	date  time.Time
+}
+
+// sourceDateLayouts are the string date formats accepted in metadata
+var sourceDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", time.RFC1123, time.RFC1123Z}
+
+// chunkDate returns the source date stored in chunk metadata
+func (p *AgenticRAGProcessor) chunkDate(chunk DocumentChunk) (time.Time, bool) {
+	for _, key := range p.config.Freshness.DateMetadataKeys {
+		switch value := chunk.Metadata[key].(type) {
+		case time.Time:
+			return value, true
+		case string:
+			for _, layout := range sourceDateLayouts {
+				if parsed, err := time.Parse(layout, value); err == nil {
+					return parsed, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// resolveFreshnessConflicts detects disagreeing dated chunks, demotes superseded ones, and
+// returns the conflicts along with generation notes asking the answer to mention them
+func (p *AgenticRAGProcessor) resolveFreshnessConflicts(ctx context.Context, chunks []DocumentChunk) ([]DocumentChunk, []SourceConflict, []string) {
+	cfg := p.config.Freshness
+	if !cfg.Enabled {
+		return chunks, nil, nil
+	}
+
+	dated := make([]datedChunk, 0, len(chunks))
+	for i, chunk := range chunks {
+		if date, ok := p.chunkDate(chunk); ok {
+			dated = append(dated, datedChunk{index: i, date: date})
+		}
+	}
+
+	// Candidate pairs are topically similar chunks from different documents with different dates
+	candidates := make([][2]datedChunk, 0)
+	for i := 0; i < len(dated); i++ {
+		for j := i + 1; j < len(dated); j++ {
+			a, b := chunks[dated[i].index], chunks[dated[j].index]
+			if a.DocumentID == b.DocumentID || dated[i].date.Equal(dated[j].date) {
+				continue
+			}
+			if p.termOverlap(a, b) < cfg.SimilarityThreshold {
+				continue
+			}
+			newer, older := dated[i], dated[j]
+			if older.date.After(newer.date) {
+				newer, older = older, newer
+			}
+			candidates = append(candidates, [2]datedChunk{newer, older})
+		}
+	}
+	if len(candidates) == 0 {
+		return chunks, nil, nil
+	}
+
+	descriptions, err := p.confirmConflicts(ctx, chunks, candidates)
+	if err != nil {
+		descriptions = make([]string, len(candidates))
+		for i, pair := range candidates {
+			if numbersDiffer(chunks[pair[0].index].Content, chunks[pair[1].index].Content) {
+				descriptions[i] = "sources report different figures"
+			}
+		}
+	}
+
+	conflicts := make([]SourceConflict, 0)
+	notes := make([]string, 0)
+	superseded := make(map[int]bool)
+	for i, pair := range candidates {
+		if descriptions[i] == "" {
+			continue
+		}
+		newer, older := chunks[pair[0].index], chunks[pair[1].index]
+
+		resolution := "unresolved"
+		if cfg.PreferNewer {
+			resolution = "prefer_newer"
+			superseded[pair[1].index] = true
+			notes = append(notes, fmt.Sprintf("Source %d (dated %s) supersedes Source %d (dated %s): %s. Prefer the newer information and explicitly note what was superseded.",
+				pair[0].index+1, pair[0].date.Format("2006-01-02"), pair[1].index+1, pair[1].date.Format("2006-01-02"), descriptions[i]))
+		} else {
+			notes = append(notes, fmt.Sprintf("Source %d (dated %s) and Source %d (dated %s) disagree: %s. Present both and state their dates.",
+				pair[0].index+1, pair[0].date.Format("2006-01-02"), pair[1].index+1, pair[1].date.Format("2006-01-02"), descriptions[i]))
+		}
+
+		conflicts = append(conflicts, SourceConflict{
+			NewerChunkID: newer.ID,
+			OlderChunkID: older.ID,
+			NewerDate:    pair[0].date,
+			OlderDate:    pair[1].date,
+			Description:  descriptions[i],
+			Resolution:   resolution,
+		})
+	}
+
+	// Demote superseded chunks while keeping source numbering stable for the notes above
+	for index := range superseded {
+		penalty := cfg.SupersededPenalty
+		if penalty <= 0 {
+			penalty = 0.5
+		}
+		chunks[index].RelevanceScore *= penalty
+	}
+
+	return chunks, conflicts, notes
+}
+
+// confirmConflicts asks the model which candidate pairs actually disagree, returning a description
+// for each conflicting pair and an empty string for pairs that agree
+func (p *AgenticRAGProcessor) confirmConflicts(ctx context.Context, chunks []DocumentChunk, candidates [][2]datedChunk) ([]string, error) {
+	if !p.config.Freshness.UseLLM {
+		return nil, fmt.Errorf("LLM conflict detection disabled")
+	}
+
+	var builder strings.Builder
+	for i, pair := range candidates {
+		builder.WriteString(fmt.Sprintf("[%d]\nPassage A: %s\nPassage B: %s\n\n", i, chunks[pair[0].index].Content, chunks[pair[1].index].Content))
+	}
+
+	prompt := fmt.Sprintf(`You are an expert analyst. For each numbered pair of passages, decide whether they make contradictory factual statements about the same subject.
+
+%s
+Respond with a JSON array where each element has "index" (the pair number), "conflict" (true/false), and "description" (a short summary of the disagreement, empty if none).
+
+Example: [{"index": 0, "conflict": true, "description": "different rate limits (100 vs 500 requests per minute)"}]`, builder.String())
+
+	responseText, err := p.generateText(ctx, prompt, 0.0, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Index       int    `json:"index"`
+		Conflict    bool   `json:"conflict"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(responseText)), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse conflict detection response: %w", err)
+	}
+
+	descriptions := make([]string, len(candidates))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(candidates) || !result.Conflict {
+			continue
+		}
+		descriptions[result.Index] = result.Description
+		if descriptions[result.Index] == "" {
+			descriptions[result.Index] = "sources disagree"
+		}
+	}
+	return descriptions, nil
+}
+
+// termOverlap returns the Jaccard overlap of the analyzed terms of two chunks
+func (p *AgenticRAGProcessor) termOverlap(a, b DocumentChunk) float64 {
+	termsA := p.analyzerFor(chunkLanguage(a)).terms(a.Content)
+	termsB := p.analyzerFor(chunkLanguage(b)).terms(b.Content)
+	return jaccard(termsA, termsB)
+}
+
+// jaccard returns the Jaccard similarity of two term lists treated as sets
+func jaccard(a, b []string) float64 {
+	setA := make(map[string]struct{}, len(a))
+	for _, term := range a {
+		setA[term] = struct{}{}
+	}
+	setB := make(map[string]struct{}, len(b))
+	for _, term := range b {
+		setB[term] = struct{}{}
+	}
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for term := range setA {
+		if _, ok := setB[term]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(setA)+len(setB)-intersection)
+}
+
+// numbersDiffer reports whether two texts mention different sets of numbers
+func numbersDiffer(a, b string) bool {
+	numbers := func(text string) []string {
+		result := make([]string, 0)
+		for _, token := range tokenize(text) {
+			if hasDigit(token) {
+				result = append(result, token)
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	numbersA, numbersB := numbers(a), numbers(b)
+	if len(numbersA) == 0 || len(numbersB) == 0 {
+		return false
+	}
+	return strings.Join(numbersA, ",") != strings.Join(numbersB, ",")
+}
@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// SafetySetting sets the blocking threshold for a harm category (Gemini models only)
+type SafetySetting struct {
+	Category  string `json:"category" jsonschema_description:"Harm category, e.g. HARM_CATEGORY_DANGEROUS_CONTENT"`
+	Threshold string `json:"threshold" jsonschema_description:"Blocking threshold, e.g. BLOCK_ONLY_HIGH or BLOCK_NONE"`
+}
+
+// GenerationParams contains advanced sampling parameters passed through to the model provider.
+// Parameters a provider does not support are dropped.
+type GenerationParams struct {
+	TopP             float64         `json:"top_p,omitempty" jsonschema_description:"Nucleus sampling probability mass"`
+	TopK             int             `json:"top_k,omitempty" jsonschema_description:"Sample from the K most likely tokens"`
+	FrequencyPenalty float64         `json:"frequency_penalty,omitempty" jsonschema_description:"Penalty for tokens proportional to how often they appeared"`
+	PresencePenalty  float64         `json:"presence_penalty,omitempty" jsonschema_description:"Penalty for tokens that already appeared"`
+	StopSequences    []string        `json:"stop_sequences,omitempty" jsonschema_description:"Sequences that stop generation"`
+	SafetySettings   []SafetySetting `json:"safety_settings,omitempty" jsonschema_description:"Per-category safety thresholds (Gemini models only)"`
+}
+
+// activeProvider returns the provider serving the request's model calls, e.g. "googleai"
+func (p *AgenticRAGProcessor) activeProvider(ctx context.Context) string {
+	model, modelName := p.config.Model, p.config.ModelName
+	if endpoint := endpointFromContext(ctx); endpoint != nil {
+		if endpoint.Provider != "" {
+			return endpoint.Provider
+		}
+		model, modelName = endpoint.Model, endpoint.ModelName
+	}
+	if model != nil {
+		modelName = model.Name()
+	}
+	provider, _, _ := strings.Cut(modelName, "/")
+	return provider
+}
+
+// generationConfig builds the model config for a generation in the shape the active provider expects
+func (p *AgenticRAGProcessor) generationConfig(ctx context.Context, temperature float64, maxTokens int, params *GenerationParams) any {
+	if params == nil {
+		params = &GenerationParams{}
+	}
+
+	switch p.activeProvider(ctx) {
+	case "googleai", "vertexai":
+		// The Gemini plugins decode map configs into genai.GenerateContentConfig
+		config := map[string]any{
+			"temperature":     temperature,
+			"maxOutputTokens": maxTokens,
+		}
+		if params.TopP > 0 {
+			config["topP"] = params.TopP
+		}
+		if params.TopK > 0 {
+			config["topK"] = params.TopK
+		}
+		if params.FrequencyPenalty != 0 {
+			config["frequencyPenalty"] = params.FrequencyPenalty
+		}
+		if params.PresencePenalty != 0 {
+			config["presencePenalty"] = params.PresencePenalty
+		}
+		if len(params.StopSequences) > 0 {
+			config["stopSequences"] = params.StopSequences
+		}
+		if len(params.SafetySettings) > 0 {
+			settings := make([]map[string]any, 0, len(params.SafetySettings))
+			for _, setting := range params.SafetySettings {
+				settings = append(settings, map[string]any{"category": setting.Category, "threshold": setting.Threshold})
+			}
+			config["safetySettings"] = settings
+		}
+		return config
+	case "openai":
+		// The OpenAI-compatible plugins decode map configs into their OpenAIConfig
+		config := map[string]any{
+			"temperature":       temperature,
+			"max_output_tokens": maxTokens,
+		}
+		if params.TopP > 0 {
+			config["top_p"] = params.TopP
+		}
+		if len(params.StopSequences) > 0 {
+			config["stop_sequences"] = params.StopSequences
+		}
+		return config
+	default:
+		return &ai.GenerationCommonConfig{
+			Temperature:     temperature,
+			MaxOutputTokens: maxTokens,
+			TopP:            params.TopP,
+			TopK:            params.TopK,
+			StopSequences:   params.StopSequences,
+		}
+	}
+}
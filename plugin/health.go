@@ -0,0 +1,322 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HealthCheckConfig contains configuration for scheduled corpus health checks
+type HealthCheckConfig struct {
+	Enabled              bool          `json:"enabled"`
+	Interval             time.Duration `json:"interval"`               // Time between scheduled checks
+	SampleSize           int           `json:"sample_size"`            // Number of stored chunks sampled per check
+	TopK                 int           `json:"top_k"`                  // A synthetic query is a hit if its source chunk ranks within the top K
+	UseLLM               bool          `json:"use_llm"`                // Generate synthetic queries with the model
+	MinHitRate           float64       `json:"min_hit_rate"`           // Hit rate below this is reported as degraded
+	DriftThreshold       float64       `json:"drift_threshold"`        // Mean embedding drift above this is reported as drift
+	WebhookURLs          []string      `json:"webhook_urls,omitempty"` // Endpoints receiving the JSON health report
+	NotifyOnlyOnProblems bool          `json:"notify_only_on_problems"`
+}
+
+// CorpusHealthReport summarizes the retrieval quality and embedding stability of the corpus
+type CorpusHealthReport struct {
+	CheckedAt      time.Time `json:"checked_at"`
+	ChunksSampled  int       `json:"chunks_sampled"`
+	QueriesScored  int       `json:"queries_scored"`
+	HitRate        float64   `json:"hit_rate"`
+	MRR            float64   `json:"mrr"`
+	ScoringMethod  string    `json:"scoring_method"` // "embedding" or "lexical"
+	EmbeddingDrift float64   `json:"embedding_drift,omitempty"`
+	DriftCompared  int       `json:"drift_compared,omitempty"`
+	DimensionDrift bool      `json:"dimension_drift,omitempty"` // The embedder now produces vectors of a different size
+	Problems       []string  `json:"problems,omitempty"`
+}
+
+// healthBaseline holds reference embeddings for drift detection, keyed by chunk content hash
+type healthBaseline struct {
+	mu         sync.Mutex
+	embeddings map[string][]float32
+}
+
+// StartHealthChecks runs corpus health checks every configured interval until the context is cancelled
+func (p *AgenticRAGProcessor) StartHealthChecks(ctx context.Context) error {
+	cfg := p.config.HealthCheck
+	if !cfg.Enabled {
+		return fmt.Errorf("health checks are disabled")
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("health check interval must be positive")
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := p.CheckCorpusHealth(ctx); err != nil {
+					p.config.Metrics.IncCounter("agentic_rag_health_check_failures_total", 1)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// CheckCorpusHealth samples stored chunks, scores retrieval against synthetic queries, and measures embedding drift
+func (p *AgenticRAGProcessor) CheckCorpusHealth(ctx context.Context) (*CorpusHealthReport, error) {
+	cfg := p.config.HealthCheck
+	report := &CorpusHealthReport{CheckedAt: time.Now()}
+
+	sample, err := p.sampleCorpusChunks(ctx, cfg.SampleSize)
+	if err != nil {
+		return nil, err
+	}
+	report.ChunksSampled = len(sample)
+	if len(sample) == 0 {
+		report.Problems = append(report.Problems, "corpus is empty")
+		p.publishHealthReport(ctx, report)
+		return report, nil
+	}
+
+	queries := p.syntheticQueries(ctx, sample)
+
+	// Rank the sampled chunks for each synthetic query, preferring embeddings
+	var chunkEmbeddings [][]float32
+	embedderName := p.config.EmbedderName
+	if embedderName != "" {
+		texts := make([]string, 0, len(sample)+len(queries))
+		for _, chunk := range sample {
+			texts = append(texts, chunk.Content)
+		}
+		texts = append(texts, queries...)
+		if embeddings, err := p.embedTexts(ctx, embedderName, texts); err == nil {
+			chunkEmbeddings = embeddings[:len(sample)]
+			queryEmbeddings := embeddings[len(sample):]
+			report.ScoringMethod = "embedding"
+			p.scoreHealthQueries(report, len(sample), func(query, chunk int) float64 {
+				return cosineSimilarity(queryEmbeddings[query], chunkEmbeddings[chunk])
+			})
+		}
+	}
+	if report.ScoringMethod == "" {
+		report.ScoringMethod = "lexical"
+		p.scoreHealthQueries(report, len(sample), func(query, chunk int) float64 {
+			return p.calculateRelevanceScoreForLanguage(queries[query], sample[chunk].Content, chunkLanguage(sample[chunk]))
+		})
+	}
+	report.QueriesScored = len(queries)
+
+	if chunkEmbeddings != nil {
+		p.measureEmbeddingDrift(report, sample, chunkEmbeddings)
+	}
+
+	if report.HitRate < cfg.MinHitRate {
+		report.Problems = append(report.Problems, fmt.Sprintf("retrieval hit rate %.2f below %.2f", report.HitRate, cfg.MinHitRate))
+	}
+	if report.DimensionDrift {
+		report.Problems = append(report.Problems, "embedding dimensions changed since baseline")
+	} else if report.DriftCompared > 0 && report.EmbeddingDrift > cfg.DriftThreshold {
+		report.Problems = append(report.Problems, fmt.Sprintf("embedding drift %.3f above %.3f", report.EmbeddingDrift, cfg.DriftThreshold))
+	}
+
+	p.publishHealthReport(ctx, report)
+	return report, nil
+}
+
+// sampleCorpusChunks loads the configured collections and returns a random sample of their chunks
+func (p *AgenticRAGProcessor) sampleCorpusChunks(ctx context.Context, size int) ([]DocumentChunk, error) {
+	documents, err := p.corpusDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]DocumentChunk, 0)
+	for _, doc := range documents {
+		docChunks, err := p.chunkDocument(ctx, doc, p.config.Processing.DefaultMaxChunks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to chunk document %s: %w", doc.ID, err)
+		}
+		chunks = append(chunks, docChunks...)
+	}
+
+	rand.Shuffle(len(chunks), func(i, j int) {
+		chunks[i], chunks[j] = chunks[j], chunks[i]
+	})
+	if size > 0 && len(chunks) > size {
+		chunks = chunks[:size]
+	}
+	return chunks, nil
+}
+
+// syntheticQueries produces one query per chunk that the chunk should answer
+func (p *AgenticRAGProcessor) syntheticQueries(ctx context.Context, chunks []DocumentChunk) []string {
+	if p.config.HealthCheck.UseLLM {
+		if queries, err := p.generateSyntheticQueries(ctx, chunks); err == nil {
+			return queries
+		}
+	}
+
+	// Fall back to the chunk's leading content terms
+	queries := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		terms := p.analyzerFor(chunkLanguage(chunk)).terms(chunk.Content)
+		if len(terms) > 8 {
+			terms = terms[:8]
+		}
+		queries[i] = strings.Join(terms, " ")
+	}
+	return queries
+}
+
+// generateSyntheticQueries asks the model to write a question answered by each chunk
+func (p *AgenticRAGProcessor) generateSyntheticQueries(ctx context.Context, chunks []DocumentChunk) ([]string, error) {
+	var builder strings.Builder
+	for i, chunk := range chunks {
+		builder.WriteString(fmt.Sprintf("[%d]\n%s\n\n", i, chunk.Content))
+	}
+
+	prompt := fmt.Sprintf(`For each numbered passage below, write one realistic question a user might ask that the passage answers.
+
+%s
+Respond with a JSON array of strings, one question per passage, in order.`, builder.String())
+
+	responseText, err := p.generateText(ctx, prompt, 0.3, 2000)
+	if err != nil {
+		return nil, err
+	}
+
+	var queries []string
+	if err := json.Unmarshal([]byte(extractJSON(responseText)), &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse synthetic queries: %w", err)
+	}
+	if len(queries) != len(chunks) {
+		return nil, fmt.Errorf("model returned %d queries for %d chunks", len(queries), len(chunks))
+	}
+	return queries, nil
+}
+
+// scoreHealthQueries computes hit rate and MRR, where query i should retrieve chunk i
+func (p *AgenticRAGProcessor) scoreHealthQueries(report *CorpusHealthReport, count int, score func(query, chunk int) float64) {
+	topK := p.config.HealthCheck.TopK
+	if topK <= 0 {
+		topK = 3
+	}
+
+	hits := 0
+	reciprocalRanks := 0.0
+	for query := 0; query < count; query++ {
+		target := score(query, query)
+		rank := 1
+		for chunk := 0; chunk < count; chunk++ {
+			if chunk != query && score(query, chunk) > target {
+				rank++
+			}
+		}
+		if rank <= topK {
+			hits++
+		}
+		reciprocalRanks += 1.0 / float64(rank)
+	}
+
+	report.HitRate = float64(hits) / float64(count)
+	report.MRR = reciprocalRanks / float64(count)
+}
+
+// measureEmbeddingDrift compares fresh embeddings with the baseline recorded on earlier checks
+func (p *AgenticRAGProcessor) measureEmbeddingDrift(report *CorpusHealthReport, chunks []DocumentChunk, embeddings [][]float32) {
+	p.healthBaseline.mu.Lock()
+	defer p.healthBaseline.mu.Unlock()
+
+	if p.healthBaseline.embeddings == nil {
+		p.healthBaseline.embeddings = make(map[string][]float32)
+	}
+
+	totalDrift := 0.0
+	for i, chunk := range chunks {
+		sum := sha256.Sum256([]byte(chunk.Content))
+		key := hex.EncodeToString(sum[:])
+
+		baseline, ok := p.healthBaseline.embeddings[key]
+		if !ok {
+			p.healthBaseline.embeddings[key] = embeddings[i]
+			continue
+		}
+		if len(baseline) != len(embeddings[i]) {
+			report.DimensionDrift = true
+			continue
+		}
+		totalDrift += 1 - cosineSimilarity(baseline, embeddings[i])
+		report.DriftCompared++
+	}
+	if report.DriftCompared > 0 {
+		report.EmbeddingDrift = totalDrift / float64(report.DriftCompared)
+	}
+}
+
+// ResetHealthBaseline discards the reference embeddings, e.g. after an intentional embedder upgrade
+func (p *AgenticRAGProcessor) ResetHealthBaseline() {
+	p.healthBaseline.mu.Lock()
+	defer p.healthBaseline.mu.Unlock()
+	p.healthBaseline.embeddings = nil
+}
+
+// publishHealthReport records the report in metrics and posts it to the configured webhooks
+func (p *AgenticRAGProcessor) publishHealthReport(ctx context.Context, report *CorpusHealthReport) {
+	metrics := p.config.Metrics
+	metrics.IncCounter("agentic_rag_health_checks_total", 1)
+	metrics.SetGauge("agentic_rag_corpus_chunks_sampled", float64(report.ChunksSampled))
+	metrics.SetGauge("agentic_rag_corpus_hit_rate", report.HitRate)
+	metrics.SetGauge("agentic_rag_corpus_mrr", report.MRR)
+	metrics.SetGauge("agentic_rag_corpus_embedding_drift", report.EmbeddingDrift)
+	metrics.SetGauge("agentic_rag_corpus_problems", float64(len(report.Problems)))
+
+	cfg := p.config.HealthCheck
+	if len(cfg.WebhookURLs) == 0 || (cfg.NotifyOnlyOnProblems && len(report.Problems) == 0) {
+		return
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+	for _, url := range cfg.WebhookURLs {
+		if err := postWebhook(ctx, url, body); err != nil {
+			metrics.IncCounter("agentic_rag_health_webhook_failures_total", 1)
+		}
+	}
+}
+
+// postWebhook sends a JSON payload to a webhook endpoint
+func postWebhook(ctx context.Context, url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Retrieval modes
+const (
+	RetrievalStandard   = "standard"     // Score chunks, then recursively refine the relevant ones (default)
+	RetrievalSmallToBig = "small_to_big" // Score small child chunks, then give their parent chunks to the generator
+	RetrievalEmbedding  = "embedding"    // Shortlist chunks by embedding similarity, then score only the top-k candidates
+	RetrievalHybrid     = "hybrid"       // Shortlist chunks by BM25 and embedding rankings fused by reciprocal rank
+	RetrievalHyDE       = "hyde"         // Shortlist chunks by similarity to a generated hypothetical answer
+)
+
+// retrievalModes lists the supported retrieval modes
+var retrievalModes = map[string]bool{
+	RetrievalStandard:   true,
+	RetrievalSmallToBig: true,
+	RetrievalEmbedding:  true,
+	RetrievalHybrid:     true,
+	RetrievalHyDE:       true,
+}
+
+// parentMetadataKey is the child chunk metadata key holding the ID of its parent chunk
+const parentMetadataKey = "parent_id"
+
+// HierarchicalChunkingConfig contains configuration for parent-child ("small-to-big") retrieval
+type HierarchicalChunkingConfig struct {
+	ParentChunkSize int `json:"parent_chunk_size"` // Size of the parent chunks given to the generator; children use the request chunk size
+}
+
+// ChunkHierarchy holds large parent chunks and the small child chunks split from them
+type ChunkHierarchy struct {
+	Parents  []DocumentChunk `json:"parents"`
+	Children []DocumentChunk `json:"children"`
+
+	parents map[string]int // Parent ID -> index in Parents
+}
+
+// NewChunkHierarchy indexes parents so children can be resolved by their parent_id metadata
+func NewChunkHierarchy(parents, children []DocumentChunk) *ChunkHierarchy {
+	index := make(map[string]int, len(parents))
+	for i, parent := range parents {
+		index[parent.ID] = i
+	}
+	return &ChunkHierarchy{Parents: parents, Children: children, parents: index}
+}
+
+// Parent returns the parent of a child chunk
+func (h *ChunkHierarchy) Parent(child DocumentChunk) (DocumentChunk, bool) {
+	i, ok := h.parents[metadataString(child.Metadata, parentMetadataKey)]
+	if !ok {
+		return DocumentChunk{}, false
+	}
+	return h.Parents[i], true
+}
+
+// Expand replaces scored children with their parents, ordered by their best child's score. Each
+// parent appears once and records the children that matched.
+func (h *ChunkHierarchy) Expand(children []DocumentChunk) []DocumentChunk {
+	index := make(map[string]int)
+	parents := make([]DocumentChunk, 0)
+	for _, child := range children {
+		parent, ok := h.Parent(child)
+		if !ok {
+			// Children without a parent (e.g. pinned content) pass through unchanged
+			parents = append(parents, child)
+			continue
+		}
+		if i, seen := index[parent.ID]; seen {
+			parents[i].RelevanceScore = math.Max(parents[i].RelevanceScore, child.RelevanceScore)
+			parents[i].Metadata["matched_children"] = append(parents[i].Metadata["matched_children"].([]string), child.ID)
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(parent.Metadata)+1)
+		for key, value := range parent.Metadata {
+			metadata[key] = value
+		}
+		metadata["matched_children"] = []string{child.ID}
+		parent.Metadata = metadata
+		parent.RelevanceScore = child.RelevanceScore
+
+		index[parent.ID] = len(parents)
+		parents = append(parents, parent)
+	}
+
+	sort.SliceStable(parents, func(i, j int) bool {
+		return parents[i].RelevanceScore > parents[j].RelevanceScore
+	})
+	return parents
+}
+
+// buildChunkHierarchy chunks each document into parents of the configured parent size and splits
+// every parent into children of childSize, which keep exact offsets into the document
+func (p *AgenticRAGProcessor) buildChunkHierarchy(ctx context.Context, chunker string, documents []Document, childSize, maxChunks int) (*ChunkHierarchy, error) {
+	parentSize := p.config.Hierarchical.ParentChunkSize
+	if parentSize <= childSize {
+		parentSize = childSize * 4
+	}
+
+	allParents := make([]DocumentChunk, 0)
+	allChildren := make([]DocumentChunk, 0)
+	for _, doc := range documents {
+		parents, err := p.chunkDocumentWith(ctx, chunker, doc, parentSize, maxChunks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to chunk document %s: %w", doc.ID, err)
+		}
+
+		for _, parent := range parents {
+			// Split the parent's exact source span so child offsets map back to the document
+			parentDoc := doc
+			parentDoc.ID = parent.ID
+			parentDoc.Content = doc.Content[parent.StartIndex:parent.EndIndex]
+			if parentDoc.Content == "" {
+				parentDoc.Content = parent.Content
+			}
+			parentDoc.Metadata = parent.Metadata
+
+			children, err := p.chunkDocumentWith(ctx, chunker, parentDoc, childSize, math.MaxInt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to chunk parent %s: %w", parent.ID, err)
+			}
+			for i, child := range children {
+				child.ID = fmt.Sprintf("%s_child_%d", parent.ID, i)
+				child.DocumentID = doc.ID
+				child.StartIndex += parent.StartIndex
+				child.EndIndex += parent.StartIndex
+				if child.Metadata == nil {
+					child.Metadata = make(map[string]interface{})
+				}
+				child.Metadata[parentMetadataKey] = parent.ID
+				allChildren = append(allChildren, child)
+			}
+		}
+		allParents = append(allParents, parents...)
+	}
+	return NewChunkHierarchy(allParents, allChildren), nil
+}
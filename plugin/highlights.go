@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Highlight is the sentence of a cited chunk that best supports the answer, located in its original document
+type Highlight struct {
+	Number     int     `json:"number"` // Citation number in the answer
+	ChunkID    string  `json:"chunk_id"`
+	DocumentID string  `json:"document_id"`
+	Text       string  `json:"text"`
+	Start      int     `json:"start"` // Byte offset of the span in the original document content
+	End        int     `json:"end"`
+	Score      float64 `json:"score"` // Lexical support for the citing sentences
+}
+
+// sentenceTerminators are the punctuation marks included at the end of a highlighted span
+const sentenceTerminators = ".!?。！？"
+
+// extractHighlights finds, for each citation, the chunk sentence that best supports the sentences citing it
+func (p *AgenticRAGProcessor) extractHighlights(answer string, citations []Citation, chunks []DocumentChunk, documents []Document) []Highlight {
+	if len(citations) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]Document, len(documents))
+	for _, doc := range documents {
+		byID[doc.ID] = doc
+	}
+
+	// Group the answer sentences by the citation numbers they contain
+	language := p.detectLanguage(answer)
+	citing := make(map[int][]string)
+	for _, sentence := range p.splitIntoSentences(answer, language) {
+		for _, match := range citationPattern.FindAllStringSubmatch(sentence, -1) {
+			if number, err := strconv.Atoi(match[1] + match[2]); err == nil {
+				citing[number] = append(citing[number], strings.TrimSpace(citationPattern.ReplaceAllString(sentence, "")))
+			}
+		}
+	}
+
+	highlights := make([]Highlight, 0, len(citations))
+	for _, citation := range citations {
+		// Chunks from earlier requests have no document in this request to highlight in
+		if isStoredDocument(citation.DocumentID) {
+			continue
+		}
+		doc, ok := byID[citation.DocumentID]
+		if !ok || citation.Number < 1 || citation.Number > len(chunks) {
+			continue
+		}
+		chunk := chunks[citation.Number-1]
+		claim := strings.Join(citing[citation.Number], " ")
+		chunkText := strings.Join(strings.Fields(chunk.Content), " ")
+
+		// Candidates are the document's sentences that made it into the chunk
+		best, bestScore := [2]int{-1, -1}, -1.0
+		for _, span := range p.sentenceSpans(doc.Content, documentLanguage(doc)) {
+			sentence := strings.Join(strings.Fields(doc.Content[span[0]:span[1]]), " ")
+			if sentence == "" || !strings.Contains(chunkText, strings.TrimRight(sentence, sentenceTerminators)) {
+				continue
+			}
+			score := p.calculateRelevanceScoreForLanguage(claim, sentence, language)
+			if score > bestScore {
+				best, bestScore = span, score
+			}
+		}
+		if best[0] < 0 {
+			continue
+		}
+
+		highlights = append(highlights, Highlight{
+			Number:     citation.Number,
+			ChunkID:    chunk.ID,
+			DocumentID: chunk.DocumentID,
+			Text:       doc.Content[best[0]:best[1]],
+			Start:      best[0],
+			End:        best[1],
+			Score:      bestScore,
+		})
+	}
+	return highlights
+}
+
+// sentenceSpans returns the byte offsets of each sentence in text, including its terminating punctuation
+func (p *AgenticRAGProcessor) sentenceSpans(text, language string) [][2]int {
+	spans := make([][2]int, 0)
+	start := 0
+	for _, loc := range p.analyzerFor(language).sentenceExpr.FindAllStringIndex(text, -1) {
+		end := loc[0]
+		for end < loc[1] {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !strings.ContainsRune(sentenceTerminators, r) {
+				break
+			}
+			end += size
+		}
+		if span, ok := trimSpan(text, start, end); ok {
+			spans = append(spans, span)
+		}
+		start = loc[1]
+	}
+	if span, ok := trimSpan(text, start, len(text)); ok {
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// trimSpan narrows a span to exclude surrounding whitespace, reporting false if nothing remains
+func trimSpan(text string, start, end int) ([2]int, bool) {
+	segment := text[start:end]
+	trimmed := strings.TrimLeft(segment, " \t\r\n")
+	start += len(segment) - len(trimmed)
+	end = start + len(strings.TrimRight(trimmed, " \t\r\n"))
+	return [2]int{start, end}, end > start
+}
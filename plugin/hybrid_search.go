@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// HybridSearchConfig contains configuration for hybrid keyword and vector retrieval
+type HybridSearchConfig struct {
+	RRFK        float64           `json:"rrf_k"`   // Reciprocal rank fusion constant; larger values flatten the rank bonus
+	K1          float64           `json:"bm25_k1"` // BM25 term frequency saturation
+	B           float64           `json:"bm25_b"`  // BM25 document length normalization
+	LexicalGate LexicalGateConfig `json:"lexical_gate"`
+}
+
+// hybridCandidates fuses a BM25 keyword ranking with the embedding ranking by reciprocal rank
+// fusion and returns the top-k candidates, so exact terms such as IDs and error codes that embed
+// poorly still reach model scoring. A default vector store with its own hybrid search ranks the
+// candidates itself.
+func (p *AgenticRAGProcessor) hybridCandidates(ctx context.Context, query string, chunks []DocumentChunk, filters domain.Filters) ([]DocumentChunk, error) {
+	topK := p.vectorTopK()
+	chunks = p.lexicalGate(ctx, query, chunks)
+	if store, err := p.defaultVectorStore(ctx); err == nil {
+		if _, ok := store.(domain.HybridSearcher); ok {
+			return p.vectorSearch(ctx, query, chunks, topK, filters, query)
+		}
+	}
+	vectorRanking, err := p.vectorSearch(ctx, query, chunks, topK, filters, "")
+	if err != nil {
+		return nil, err
+	}
+	keywordRanking := p.keywordSearch(query, chunks)
+	if len(keywordRanking) > topK {
+		keywordRanking = keywordRanking[:topK]
+	}
+
+	fused := reciprocalRankFusion(p.config.HybridSearch.RRFK, keywordRanking, vectorRanking)
+	if len(fused) > topK {
+		fused = fused[:topK]
+	}
+	return fused, nil
+}
+
+// keywordSearch ranks the chunks matching any query term by BM25 over stemmed, non-stopword terms
+func (p *AgenticRAGProcessor) keywordSearch(query string, chunks []DocumentChunk) []DocumentChunk {
+	cfg := p.config.HybridSearch
+	k1, b := cfg.K1, cfg.B
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if b < 0 || b > 1 {
+		b = 0.75
+	}
+
+	queryTerms := p.analyzerFor(p.detectLanguage(query)).terms(query)
+	if len(queryTerms) == 0 || len(chunks) == 0 {
+		return nil
+	}
+
+	frequencies := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	documentFrequency := make(map[string]int)
+	totalLength := 0
+	for i, chunk := range chunks {
+		terms := p.analyzerFor(chunkLanguage(chunk)).terms(chunk.Content)
+		frequencies[i] = make(map[string]int, len(terms))
+		for _, term := range terms {
+			frequencies[i][term]++
+		}
+		for term := range frequencies[i] {
+			documentFrequency[term]++
+		}
+		lengths[i] = len(terms)
+		totalLength += len(terms)
+	}
+	averageLength := math.Max(float64(totalLength)/float64(len(chunks)), 1)
+
+	uniqueTerms := make(map[string]struct{}, len(queryTerms))
+	for _, term := range queryTerms {
+		uniqueTerms[term] = struct{}{}
+	}
+
+	ranked := make([]DocumentChunk, 0)
+	for i, chunk := range chunks {
+		score := 0.0
+		for term := range uniqueTerms {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (float64(len(chunks))-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(lengths[i])/averageLength))
+		}
+		if score > 0 {
+			chunk.RelevanceScore = score
+			ranked = append(ranked, chunk)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].RelevanceScore > ranked[j].RelevanceScore
+	})
+	return ranked
+}
+
+// reciprocalRankFusion merges rankings by summing 1/(k+rank) per chunk ID, best first
+func reciprocalRankFusion(k float64, rankings ...[]DocumentChunk) []DocumentChunk {
+	if k <= 0 {
+		k = 60
+	}
+
+	index := make(map[string]int)
+	fused := make([]DocumentChunk, 0)
+	for _, ranking := range rankings {
+		for rank, chunk := range ranking {
+			score := 1 / (k + float64(rank+1))
+			if i, ok := index[chunk.ID]; ok {
+				fused[i].RelevanceScore += score
+				continue
+			}
+			chunk.RelevanceScore = score
+			index[chunk.ID] = len(fused)
+			fused = append(fused, chunk)
+		}
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].RelevanceScore > fused[j].RelevanceScore
+	})
+	return fused
+}
@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// hydeCandidates implements HyDE (hypothetical document embeddings): it drafts an answer to the
+// query without any context and retrieves the top-k chunks closest to that draft, which tends to
+// sit nearer to answering passages in embedding space than a short question does
+func (p *AgenticRAGProcessor) hydeCandidates(ctx context.Context, query string, chunks []DocumentChunk, filters domain.Filters) ([]DocumentChunk, error) {
+	hypothetical, err := p.hypotheticalAnswer(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate hypothetical answer: %w", err)
+	}
+	return p.vectorSearch(ctx, hypothetical, chunks, p.vectorTopK(), filters, "")
+}
+
+// hypotheticalAnswer drafts a short passage answering the query from the model's own knowledge
+func (p *AgenticRAGProcessor) hypotheticalAnswer(ctx context.Context, query string) (string, error) {
+	prompt := fmt.Sprintf(`Write a short passage, as it might appear in a reference document, that answers the question below. State specific facts and terminology even if you are unsure of them; the passage is only used to search for real documents and is never shown to the user.
+
+Question: %s
+
+Passage:`, query)
+
+	text, err := p.generateText(ctx, prompt, 0.7, 300)
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("model returned an empty passage")
+	}
+	return text, nil
+}
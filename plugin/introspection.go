@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/google/dotprompt/go/dotprompt"
+	"github.com/invopop/jsonschema"
+)
+
+// ActionDescriptor describes a flow, tool, or prompt registered with Genkit
+type ActionDescriptor struct {
+	Name         string         `json:"name"`
+	Type         string         `json:"type"` // "flow", "tool", or "prompt"
+	Description  string         `json:"description,omitempty"`
+	InputSchema  map[string]any `json:"input_schema,omitempty"`
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
+	Model        string         `json:"model,omitempty"` // Model configured by a prompt
+}
+
+// ListRegisteredFlows describes every flow registered with Genkit, sorted by name
+func (p *AgenticRAGProcessor) ListRegisteredFlows() ([]ActionDescriptor, error) {
+	if p.config.Genkit == nil {
+		return nil, fmt.Errorf("genkit is not initialized")
+	}
+	descriptors := make([]ActionDescriptor, 0)
+	for _, flow := range genkit.ListFlows(p.config.Genkit) {
+		desc := flow.Desc()
+		descriptors = append(descriptors, ActionDescriptor{
+			Name:         desc.Name,
+			Type:         "flow",
+			Description:  desc.Description,
+			InputSchema:  schemaMap(desc.InputSchema),
+			OutputSchema: schemaMap(desc.OutputSchema),
+		})
+	}
+	sortDescriptors(descriptors)
+	return descriptors, nil
+}
+
+// ListRegisteredTools describes every tool registered with Genkit, including tools from other plugins
+func (p *AgenticRAGProcessor) ListRegisteredTools() ([]ActionDescriptor, error) {
+	if p.config.Genkit == nil {
+		return nil, fmt.Errorf("genkit is not initialized")
+	}
+	descriptors := make([]ActionDescriptor, 0)
+	for _, tool := range genkit.ListTools(p.config.Genkit) {
+		definition := tool.Definition()
+		descriptors = append(descriptors, ActionDescriptor{
+			Name:         definition.Name,
+			Type:         "tool",
+			Description:  definition.Description,
+			InputSchema:  completeSchema(definition.InputSchema, ""),
+			OutputSchema: completeSchema(definition.OutputSchema, ""),
+		})
+	}
+	sortDescriptors(descriptors)
+	return descriptors, nil
+}
+
+// ListRegisteredPrompts describes the prompts the pipeline uses and the prompts in the
+// configured prompt directory that are registered with Genkit
+func (p *AgenticRAGProcessor) ListRegisteredPrompts() ([]ActionDescriptor, error) {
+	if p.config.Genkit == nil {
+		return nil, fmt.Errorf("genkit is not initialized")
+	}
+
+	// Looked-up prompts do not expose their definition, so details come from the prompt files
+	names, files := p.promptNames()
+	descriptors := make([]ActionDescriptor, 0)
+	for _, name := range names {
+		prompt := genkit.LookupPrompt(p.config.Genkit, name)
+		if prompt == nil {
+			continue
+		}
+		descriptor := ActionDescriptor{Name: prompt.Name(), Type: "prompt"}
+		if path, ok := files[name]; ok {
+			if source, err := os.ReadFile(path); err == nil {
+				if parsed, err := dotprompt.ParseDocument(string(source)); err == nil {
+					descriptor.Description = parsed.Description
+					descriptor.Model = parsed.Model
+				}
+			}
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	sortDescriptors(descriptors)
+	return descriptors, nil
+}
+
+// promptNames returns the configured prompt names, their variants, and the prompt files in the
+// prompt directory, named the way Genkit registers them ("name" or "name.variant"), along with
+// the path of each prompt file
+func (p *AgenticRAGProcessor) promptNames() ([]string, map[string]string) {
+	cfg := p.config.Prompts
+	files := make(map[string]string)
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	configured := map[string]string{
+		"relevance_scoring":    cfg.RelevanceScoringPrompt,
+		"response_generation":  cfg.ResponseGenerationPrompt,
+		"knowledge_extraction": cfg.KnowledgeExtractionPrompt,
+		"fact_verification":    cfg.FactVerificationPrompt,
+	}
+	for stage, name := range configured {
+		add(name)
+		if variant, ok := cfg.Variants[stage]; ok && name != "" {
+			add(name + "." + variant)
+		}
+	}
+
+	if cfg.Directory != "" {
+		filepath.WalkDir(cfg.Directory, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			if filename := entry.Name(); strings.HasSuffix(filename, ".prompt") && !strings.HasPrefix(filename, "_") {
+				name := strings.TrimSuffix(filename, ".prompt")
+				files[name] = path
+				add(name)
+			}
+			return nil
+		})
+	}
+	return names, files
+}
+
+// schemaMap converts a JSON Schema into a standalone map
+func schemaMap(schema *jsonschema.Schema) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var converted map[string]any
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil
+	}
+	return completeSchema(converted, "")
+}
+
+// sortDescriptors orders descriptors by name
+func sortDescriptors(descriptors []ActionDescriptor) {
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Name < descriptors[j].Name
+	})
+}
@@ -0,0 +1,215 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JSONMappingConfig maps the records of JSON documents, such as product catalogs and API
+// payloads, onto documents. Fields are dot-separated paths, e.g. "specs.weight".
+type JSONMappingConfig struct {
+	Records        string   `json:"records,omitempty"`         // Path of the array holding the records; empty uses the root, an array or a single record
+	ContentFields  []string `json:"content_fields,omitempty"`  // Fields joined into the content
+	MetadataFields []string `json:"metadata_fields,omitempty"` // Fields stored as metadata under their path; empty stores every scalar field not used as content
+	Flatten        []string `json:"flatten,omitempty"`         // Array fields whose elements each become a document, in nesting order
+	IDField        string   `json:"id_field,omitempty"`        // Field stored as the record ID
+	TitleField     string   `json:"title_field,omitempty"`     // Field stored as the document title
+	MaxDocuments   int      `json:"max_documents"`             // Maximum documents loaded per file
+}
+
+// JSONDocumentLoader loads JSON files by a user-provided mapping. Each record, or each element of
+// its flattened arrays combined with the fields of the enclosing record, becomes a document.
+type JSONDocumentLoader struct {
+	config   JSONMappingConfig
+	maxBytes int64
+}
+
+// NewJSONDocumentLoader creates a loader for JSON files
+func NewJSONDocumentLoader(config JSONMappingConfig, maxBytes int64) *JSONDocumentLoader {
+	return &JSONDocumentLoader{config: config, maxBytes: maxBytes}
+}
+
+// CanLoad reports whether the source is a local JSON file and a mapping with content fields is configured
+func (l *JSONDocumentLoader) CanLoad(source string) bool {
+	if len(l.config.ContentFields) == 0 || strings.ToLower(filepath.Ext(source)) != ".json" {
+		return false
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load reads the file's records and converts them into documents by the mapping
+func (l *JSONDocumentLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := readLimited(file, l.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	records := root
+	if l.config.Records != "" {
+		var ok bool
+		if records, ok = jsonField(root, l.config.Records); !ok {
+			return nil, fmt.Errorf("JSON has no records at %q", l.config.Records)
+		}
+	}
+	items, ok := records.([]interface{})
+	if !ok {
+		items = []interface{}{records}
+	}
+
+	docs := make([]Document, 0, len(items))
+	for i, item := range items {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("JSON record %d is not an object", i)
+		}
+		for _, flattened := range l.flatten(record, l.config.Flatten) {
+			docs = append(docs, l.recordDocument(source, i, flattened))
+			if l.config.MaxDocuments > 0 && len(docs) >= l.config.MaxDocuments {
+				return docs, nil
+			}
+		}
+	}
+	return docs, nil
+}
+
+// flatten expands a record into one record per element of each flattened array, the element
+// replacing the array. A missing or empty array leaves the record as it is.
+func (l *JSONDocumentLoader) flatten(record map[string]interface{}, paths []string) []map[string]interface{} {
+	if len(paths) == 0 {
+		return []map[string]interface{}{record}
+	}
+	value, _ := jsonField(record, paths[0])
+	elements, ok := value.([]interface{})
+	if !ok || len(elements) == 0 {
+		return l.flatten(record, paths[1:])
+	}
+
+	records := make([]map[string]interface{}, 0, len(elements))
+	for _, element := range elements {
+		records = append(records, l.flatten(withJSONField(record, paths[0], element), paths[1:])...)
+	}
+	return records
+}
+
+// recordDocument builds a document from a record, splitting content fields from metadata fields
+func (l *JSONDocumentLoader) recordDocument(source string, index int, record map[string]interface{}) Document {
+	lines := make([]string, 0, len(l.config.ContentFields))
+	for _, path := range l.config.ContentFields {
+		value, ok := jsonField(record, path)
+		if !ok || value == nil {
+			continue
+		}
+		text := structuredValueText(value)
+		if len(l.config.ContentFields) > 1 {
+			text = path + ": " + text
+		}
+		lines = append(lines, text)
+	}
+
+	metadata := map[string]interface{}{"record": index}
+	if len(l.config.MetadataFields) > 0 {
+		for _, path := range l.config.MetadataFields {
+			if value, ok := jsonField(record, path); ok && value != nil {
+				metadata[path] = value
+			}
+		}
+	} else {
+		isContent := make(map[string]bool, len(l.config.ContentFields))
+		for _, path := range l.config.ContentFields {
+			isContent[path] = true
+		}
+		for path, value := range jsonScalars(record, "") {
+			if !isContent[path] {
+				metadata[path] = value
+			}
+		}
+	}
+	if value, ok := jsonField(record, l.config.TitleField); ok && l.config.TitleField != "" {
+		metadata["title"] = structuredValueText(value)
+	}
+	if value, ok := jsonField(record, l.config.IDField); ok && l.config.IDField != "" {
+		metadata["record_id"] = structuredValueText(value)
+	}
+
+	return Document{Content: strings.Join(lines, "\n"), Source: source, Metadata: metadata}
+}
+
+// jsonField returns the value at a dot-separated path of nested objects
+func jsonField(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// withJSONField returns a copy of the record with the value at the path replaced, copying only
+// the objects along the path
+func withJSONField(record map[string]interface{}, path string, value interface{}) map[string]interface{} {
+	key, rest, nested := strings.Cut(path, ".")
+	copied := make(map[string]interface{}, len(record))
+	for k, v := range record {
+		copied[k] = v
+	}
+	if !nested {
+		copied[key] = value
+		return copied
+	}
+	child, _ := record[key].(map[string]interface{})
+	copied[key] = withJSONField(child, rest, value)
+	return copied
+}
+
+// jsonScalars returns the scalar fields of nested objects by dot-separated path, and arrays of
+// scalars, which filters match element-wise
+func jsonScalars(record map[string]interface{}, prefix string) map[string]interface{} {
+	scalars := make(map[string]interface{})
+	for key, value := range record {
+		path := prefix + key
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for nestedPath, nested := range jsonScalars(value, path+".") {
+				scalars[nestedPath] = nested
+			}
+		case []interface{}:
+			if jsonScalarArray(value) {
+				scalars[path] = value
+			}
+		case nil:
+		default:
+			scalars[path] = value
+		}
+	}
+	return scalars
+}
+
+// jsonScalarArray reports whether every element of an array is a scalar
+func jsonScalarArray(values []interface{}) bool {
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}, nil:
+			return false
+		}
+	}
+	return true
+}
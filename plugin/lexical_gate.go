@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"context"
+	"time"
+)
+
+// LexicalGateConfig contains configuration for the lexical pre-filter ahead of hybrid retrieval
+type LexicalGateConfig struct {
+	Enabled       bool                `json:"enabled"`
+	MinCandidates int                 `json:"min_candidates"`     // Candidate sets smaller than this skip the gate (default: 200)
+	Synonyms      map[string][]string `json:"synonyms,omitempty"` // Terms also admitting a chunk, by lowercase query word, e.g. "car" -> ["automobile", "vehicle"]
+}
+
+// lexicalGate drops the chunks sharing no analyzed term with the query, its synonyms, or its
+// glossary expansions, so large candidate sets are cut before embedding and model scoring. When
+// no chunk passes, as for purely semantic queries, all chunks are kept.
+func (p *AgenticRAGProcessor) lexicalGate(ctx context.Context, query string, chunks []DocumentChunk) []DocumentChunk {
+	cfg := p.config.HybridSearch.LexicalGate
+	minCandidates := cfg.MinCandidates
+	if minCandidates <= 0 {
+		minCandidates = 200
+	}
+	if !cfg.Enabled || len(chunks) < minCandidates {
+		return chunks
+	}
+	startTime := time.Now()
+
+	analyzer := p.analyzerFor(p.detectLanguage(query))
+	keywords := make(map[string]struct{})
+	for _, word := range tokenize(query) {
+		texts := append([]string{word}, cfg.Synonyms[word]...)
+		if expansion, ok := lookupGlossary(p.config.QueryNormalization.Glossary, word); ok {
+			texts = append(texts, expansion)
+		}
+		for _, text := range texts {
+			for _, term := range analyzer.terms(text) {
+				keywords[term] = struct{}{}
+			}
+		}
+	}
+	if len(keywords) == 0 {
+		return chunks
+	}
+
+	passed := make([]DocumentChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		for _, term := range p.analyzerFor(chunkLanguage(chunk)).terms(chunk.Content) {
+			if _, ok := keywords[term]; ok {
+				passed = append(passed, chunk)
+				break
+			}
+		}
+	}
+
+	labels := metricLabels(ctx)
+	p.config.Metrics.IncCounterWith("agentic_rag_lexical_gate_candidates_total", labels, float64(len(chunks)))
+	p.config.Metrics.Observe("agentic_rag_lexical_gate_duration_seconds", labels, time.Since(startTime).Seconds())
+	if len(passed) == 0 {
+		return chunks
+	}
+	p.config.Metrics.IncCounterWith("agentic_rag_lexical_gate_dropped_total", labels, float64(len(chunks)-len(passed)))
+	return passed
+}
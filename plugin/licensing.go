@@ -0,0 +1,61 @@
+package plugin
+
+import "strings"
+
+// Metadata keys holding license and copyright information for a source
+const (
+	licenseMetadataKey   = "license"
+	copyrightMetadataKey = "copyright"
+)
+
+// LicensingConfig contains configuration for per-source license tracking
+type LicensingConfig struct {
+	Enabled               bool              `json:"enabled"`
+	DefaultLicense        string            `json:"default_license,omitempty"`         // License for sources that declare none
+	SourceLicenses        map[string]string `json:"source_licenses,omitempty"`         // Glob patterns ("*" matches anything) on document source
+	NonCommercialLicenses []string          `json:"non_commercial_licenses,omitempty"` // Glob patterns on license identifiers excluded from commercial use
+}
+
+// sourceLicense returns the license declared for a document, preferring explicit metadata
+func (p *AgenticRAGProcessor) sourceLicense(doc Document) string {
+	if license := metadataString(doc.Metadata, licenseMetadataKey); license != "" {
+		return license
+	}
+
+	// The most specific (longest) matching pattern wins
+	best := ""
+	license := p.config.Licensing.DefaultLicense
+	for pattern, candidate := range p.config.Licensing.SourceLicenses {
+		if globMatch(pattern, doc.Source) && (len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best)) {
+			best = pattern
+			license = candidate
+		}
+	}
+	return license
+}
+
+// isNonCommercial reports whether a license forbids commercial use
+func (p *AgenticRAGProcessor) isNonCommercial(license string) bool {
+	for _, pattern := range p.config.Licensing.NonCommercialLicenses {
+		if globMatch(strings.ToUpper(pattern), strings.ToUpper(license)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLicensedDocuments removes documents whose license does not permit commercial use
+func (p *AgenticRAGProcessor) filterLicensedDocuments(documents []Document, commercialUse bool) ([]Document, int) {
+	if !p.config.Licensing.Enabled || !commercialUse {
+		return documents, 0
+	}
+
+	filtered := make([]Document, 0, len(documents))
+	for _, doc := range documents {
+		if p.isNonCommercial(metadataString(doc.Metadata, licenseMetadataKey)) {
+			continue
+		}
+		filtered = append(filtered, doc)
+	}
+	return filtered, len(documents) - len(filtered)
+}
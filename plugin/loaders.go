@@ -0,0 +1,212 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// LoaderConfig contains configuration for loading documents from their sources
+type LoaderConfig struct {
+	HTTPTimeout    time.Duration        `json:"http_timeout"` // Timeout for fetching a URL
+	MaxBytes       int64                `json:"max_bytes"`    // Maximum size of a fetched or read document
+	UserAgent      string               `json:"user_agent,omitempty"`
+	IgnorePatterns []string             `json:"ignore_patterns,omitempty"` // Paths skipped when walking directories and globs
+	MaxFiles       int                  `json:"max_files"`                 // Maximum files matched by a directory or glob source
+	Crawl          CrawlConfig          `json:"crawl"`
+	Structured     StructuredDataConfig `json:"structured"`
+	JSON           JSONMappingConfig    `json:"json"` // Mapping of JSON files onto documents; JSON files load as text without content fields
+	Transcription  TranscriptionConfig  `json:"transcription"`
+	Email          EmailConfig          `json:"email"`
+	Atlassian      AtlassianConfig      `json:"atlassian"`
+	Tickets        TicketConfig         `json:"tickets"`
+	SyncDir        string               `json:"sync_dir,omitempty"` // Where connectors keep synced documents and cursors for incremental sync; full sync when empty
+}
+
+// DocumentLoader loads documents from the sources it recognizes
+type DocumentLoader = domain.DocumentLoader
+
+// defaultLoaders returns the built-in loaders, tried in order before treating a source as raw text.
+// Extra file formats are tried after the built-in ones when loading local files.
+func defaultLoaders(cfg LoaderConfig, formats ...DocumentLoader) []DocumentLoader {
+	pdf := NewPDFLoader(cfg.MaxBytes)
+	email := NewEmailLoader(cfg)
+	fileFormats := append([]DocumentLoader{
+		pdf,
+		NewMarkupLoader(cfg.MaxBytes),
+		NewOfficeLoader(cfg.MaxBytes),
+		NewStructuredDataLoader(cfg.Structured, cfg.MaxBytes),
+		NewJSONDocumentLoader(cfg.JSON, cfg.MaxBytes),
+		email,
+	}, formats...)
+	return []DocumentLoader{
+		NewCrawlLoader(cfg, pdf),
+		NewURLLoader(cfg, pdf),
+		NewAtlassianLoader(cfg),
+		NewTicketLoader(cfg),
+		email,
+		NewFileLoader(cfg, fileFormats...),
+	}
+}
+
+// loadSource resolves a source string into one or more documents using the registered loaders, then the
+// built-in ones, treating unrecognized sources as raw text
+func (p *AgenticRAGProcessor) loadSource(ctx context.Context, source string) ([]Document, error) {
+	if loader, ok := p.config.Loaders.Find(source); ok {
+		return loader.Load(ctx, source)
+	}
+	for _, loader := range p.loaders {
+		if loader.CanLoad(source) {
+			return loader.Load(ctx, source)
+		}
+	}
+	return []Document{{Content: source, Source: source}}, nil
+}
+
+// isHTTPURL reports whether a source is an http(s) URL
+func isHTTPURL(source string) bool {
+	if strings.ContainsAny(strings.TrimSpace(source), " \n\t") {
+		return false
+	}
+	parsed, err := url.Parse(source)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// URLLoader fetches http(s) URLs and extracts their readable text
+type URLLoader struct {
+	config LoaderConfig
+	pdf    *PDFLoader
+	client *http.Client
+}
+
+// NewURLLoader creates a URL loader; PDF responses are handed to the PDF loader when one is given
+func NewURLLoader(config LoaderConfig, pdf *PDFLoader) *URLLoader {
+	return &URLLoader{config: config, pdf: pdf, client: http.DefaultClient}
+}
+
+// CanLoad reports whether the source is an http(s) URL
+func (l *URLLoader) CanLoad(source string) bool {
+	return isHTTPURL(source)
+}
+
+// Load fetches a web page and extracts its readable text
+func (l *URLLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	page, err := l.fetch(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return l.documents(ctx, page)
+}
+
+// fetchedPage is the body and headers of a fetched URL
+type fetchedPage struct {
+	url       string
+	mediaType string
+	body      []byte
+	header    http.Header
+}
+
+// fetch downloads a URL, detecting the media type of untyped responses
+func (l *URLLoader) fetch(ctx context.Context, source string) (*fetchedPage, error) {
+	timeout := l.config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if l.config.UserAgent != "" {
+		req.Header.Set("User-Agent", l.config.UserAgent)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("URL returned status %d", resp.StatusCode)
+	}
+
+	body, err := readLimited(resp.Body, l.config.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(sniffContentType(body))
+	}
+	return &fetchedPage{url: source, mediaType: mediaType, body: body, header: resp.Header}, nil
+}
+
+// documents converts a fetched page into documents according to its media type
+func (l *URLLoader) documents(ctx context.Context, page *fetchedPage) ([]Document, error) {
+	var docs []Document
+	var err error
+	switch {
+	case page.mediaType == "application/pdf" && l.pdf != nil:
+		docs, err = l.pdf.LoadBytes(ctx, page.url, page.body)
+		if err != nil {
+			return nil, err
+		}
+	case page.mediaType == "text/html" || page.mediaType == "application/xhtml+xml":
+		doc, err := htmlDocument(page.url, string(page.body))
+		if err != nil {
+			return nil, err
+		}
+		docs = []Document{doc}
+	case page.mediaType == docxMediaType || page.mediaType == pptxMediaType:
+		docs, err = NewOfficeLoader(l.config.MaxBytes).LoadBytes(ctx, page.url, page.body)
+		if err != nil {
+			return nil, err
+		}
+	case page.mediaType == "text/markdown" || page.mediaType == "text/x-markdown":
+		docs = []Document{markdownDocument(page.url, string(page.body))}
+	default:
+		docs = []Document{{Content: string(page.body), Source: page.url, Metadata: map[string]interface{}{}}}
+	}
+
+	for i := range docs {
+		docs[i].Metadata["url"] = page.url
+		docs[i].Metadata["content_type"] = page.mediaType
+		if modified := page.header.Get("Last-Modified"); modified != "" {
+			if parsed, err := http.ParseTime(modified); err == nil {
+				docs[i].Metadata["updated_at"] = parsed
+			}
+		}
+	}
+	return docs, nil
+}
+
+// readLimited reads at most maxBytes from r, failing if the content is larger
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		maxBytes = 10 << 20
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("content exceeds size limit of %d bytes", maxBytes)
+	}
+	return data, nil
+}
+
+// sniffContentType detects the media type of untyped content
+func sniffContentType(data []byte) string {
+	return http.DetectContentType(data)
+}
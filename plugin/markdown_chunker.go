@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"strings"
+)
+
+// chunkUnit is a piece of content the chunker never splits further: a sentence, a code block, or a table
+type chunkUnit struct {
+	text  string
+	code  bool
+	table bool // Tables become chunks of their own
+	start int  // Byte span of the unit in the chunked content
+	end   int
+}
+
+// chunkUnits splits content into sentences, keeping fenced Markdown code blocks and tables whole.
+// Units longer than the chunk size are broken up: sentences on word boundaries, and code
+// blocks on line boundaries with every piece re-fenced, so no chunk ends inside an open fence.
+func (p *AgenticRAGProcessor) chunkUnits(content, language string, chunkSize int, measure func(string) int) []chunkUnit {
+	units := make([]chunkUnit, 0)
+	addSentences := func(start, end int) {
+		units = append(units, p.sentenceUnitsIn(content[start:end], start, language, chunkSize, measure)...)
+	}
+	addProse := func(start, end int) {
+		if !p.config.TableChunking.Enabled {
+			addSentences(start, end)
+			return
+		}
+		offset := start
+		for _, table := range tableSpans(content[start:end]) {
+			addSentences(offset, start+table[0])
+			tableStart, tableEnd := trimNewlines(content, start+table[0], start+table[1])
+			units = append(units, chunkUnit{text: content[tableStart:tableEnd], table: true, start: tableStart, end: tableEnd})
+			offset = start + table[1]
+		}
+		addSentences(offset, end)
+	}
+
+	offset := 0
+	for _, block := range codeBlockSpans(content) {
+		addProse(offset, block[0])
+		codeStart, codeEnd := trimNewlines(content, block[0], block[1])
+		code := content[codeStart:codeEnd]
+		if chunkSize > 0 && measure(code) > chunkSize {
+			units = append(units, splitCodeBlock(code, codeStart, chunkSize, measure)...)
+		} else {
+			units = append(units, chunkUnit{text: code, code: true, start: codeStart, end: codeEnd})
+		}
+		offset = block[1]
+	}
+	addProse(offset, len(content))
+	return units
+}
+
+// trimNewlines narrows the span content[start:end] to exclude leading and trailing newlines
+func trimNewlines(content string, start, end int) (int, int) {
+	for start < end && content[start] == '\n' {
+		start++
+	}
+	for end > start && content[end-1] == '\n' {
+		end--
+	}
+	return start, end
+}
+
+// codeBlockSpans returns the byte spans of fenced code blocks, including their fence lines.
+// An unterminated fence runs to the end of the content.
+func codeBlockSpans(content string) [][2]int {
+	spans := make([][2]int, 0)
+	start := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if markdownFence.MatchString(line) {
+			if start < 0 {
+				start = offset
+			} else {
+				spans = append(spans, [2]int{start, offset + len(line)})
+				start = -1
+			}
+		}
+		offset += len(line)
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(content)})
+	}
+	return spans
+}
+
+// splitCodeBlock breaks an oversized code block, found at offset base of the chunked content, into
+// runs of whole lines, each wrapped in the block's opening and closing fences. The first and last
+// pieces' spans include the block's own fence lines.
+func splitCodeBlock(code string, base, chunkSize int, measure func(string) int) []chunkUnit {
+	lines := strings.Split(code, "\n")
+	opening, closing := lines[0], strings.TrimLeft(lines[0], " \t")[:3]
+	body := lines[1:]
+	if len(body) > 0 && markdownFence.MatchString(body[len(body)-1]) {
+		body = body[:len(body)-1]
+	}
+
+	pieces := make([]chunkUnit, 0)
+	current := make([]string, 0)
+	currentStart, offset := base, base+len(opening)+1
+	flush := func() {
+		if len(current) > 0 {
+			pieces = append(pieces, chunkUnit{
+				text:  opening + "\n" + strings.Join(current, "\n") + "\n" + closing,
+				code:  true,
+				start: currentStart,
+				end:   offset - 1,
+			})
+			current = current[:0]
+			currentStart = offset
+		}
+	}
+	budget := chunkSize - measure(opening+"\n\n"+closing)
+	for _, line := range body {
+		if len(current) > 0 && measure(strings.Join(append(current, line), "\n")) > budget {
+			flush()
+		}
+		current = append(current, line)
+		offset += len(line) + 1
+	}
+	flush()
+	if len(pieces) > 0 {
+		pieces[len(pieces)-1].end = base + len(code)
+	}
+	return pieces
+}
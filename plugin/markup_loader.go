@@ -0,0 +1,361 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// sectionsMetadataKey is the document metadata key holding the heading structure
+const sectionsMetadataKey = "sections"
+
+// DocumentSection is a heading and the span of document content it covers
+type DocumentSection struct {
+	Heading string   `json:"heading"`
+	Level   int      `json:"level"` // 1-6; 0 for content before the first heading
+	Path    []string `json:"path"`  // Headings from the top level down to this section
+	Start   int      `json:"start"`
+	End     int      `json:"end"`
+}
+
+// MarkupLoader loads local Markdown and HTML files, keeping their heading hierarchy
+type MarkupLoader struct {
+	maxBytes int64
+}
+
+// NewMarkupLoader creates a loader for Markdown and HTML files
+func NewMarkupLoader(maxBytes int64) *MarkupLoader {
+	return &MarkupLoader{maxBytes: maxBytes}
+}
+
+// markupExtensions maps file extensions to the markup they contain
+var markupExtensions = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".mdx":      "markdown",
+	".html":     "html",
+	".htm":      "html",
+	".xhtml":    "html",
+}
+
+// CanLoad reports whether the source is a local Markdown or HTML file
+func (l *MarkupLoader) CanLoad(source string) bool {
+	if _, ok := markupExtensions[strings.ToLower(filepath.Ext(source))]; !ok {
+		return false
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load reads the file and extracts its text and sections
+func (l *MarkupLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := readLimited(file, l.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if markupExtensions[strings.ToLower(filepath.Ext(source))] == "html" {
+		doc, err := htmlDocument(source, string(data))
+		if err != nil {
+			return nil, err
+		}
+		return []Document{doc}, nil
+	}
+	return []Document{markdownDocument(source, string(data))}, nil
+}
+
+// documentSections returns the heading structure stored in document metadata
+func documentSections(doc Document) []DocumentSection {
+	sections, _ := doc.Metadata[sectionsMetadataKey].([]DocumentSection)
+	return sections
+}
+
+// textLine is a line of extracted text; headings carry their level
+type textLine struct {
+	text     string
+	level    int
+	verbatim bool // Code lines keep their blank lines
+}
+
+// assembleStructuredText joins extracted lines and computes the section spans
+func assembleStructuredText(lines []textLine) (string, []DocumentSection) {
+	var builder strings.Builder
+	sections := make([]DocumentSection, 0)
+	path := make([]string, 0, 6)
+	levels := make([]int, 0, 6)
+	blank := true
+
+	for _, line := range lines {
+		text := strings.TrimRight(line.text, " \t")
+		if strings.TrimSpace(text) == "" {
+			if (!blank || line.verbatim) && builder.Len() > 0 {
+				builder.WriteString("\n")
+				blank = true
+			}
+			continue
+		}
+
+		if line.level > 0 {
+			// Pop headings at the same or a deeper level before descending
+			for len(levels) > 0 && levels[len(levels)-1] >= line.level {
+				levels = levels[:len(levels)-1]
+				path = path[:len(path)-1]
+			}
+			levels = append(levels, line.level)
+			path = append(path, strings.TrimSpace(text))
+
+			if builder.Len() > 0 && !blank {
+				builder.WriteString("\n")
+			}
+			if len(sections) == 0 && builder.Len() > 0 {
+				sections = append(sections, DocumentSection{Start: 0})
+			}
+			sections = append(sections, DocumentSection{
+				Heading: strings.TrimSpace(text),
+				Level:   line.level,
+				Path:    append([]string(nil), path...),
+				Start:   builder.Len(),
+			})
+		}
+
+		builder.WriteString(text)
+		builder.WriteString("\n")
+		blank = false
+	}
+
+	content := strings.TrimRight(builder.String(), "\n")
+	for i := range sections {
+		if i+1 < len(sections) {
+			sections[i].End = sections[i+1].Start
+		} else {
+			sections[i].End = len(content)
+		}
+		if sections[i].End > len(content) {
+			sections[i].End = len(content)
+		}
+	}
+	return content, sections
+}
+
+// Markdown syntax handled by the Markdown extractor
+var (
+	markdownATXHeading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownSetextH1     = regexp.MustCompile(`^=+\s*$`)
+	markdownSetextH2     = regexp.MustCompile(`^-+\s*$`)
+	markdownFence        = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownComment      = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markdownImage        = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink         = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownRefLink      = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	markdownRefDef       = regexp.MustCompile(`^\s*\[[^\]]+\]:\s+\S+`)
+	markdownHTMLTag      = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	markdownBadgeLine    = regexp.MustCompile(`^\s*(\[?!\[[^\]]*\]\([^)]*\)\]?(\([^)]*\))?\s*)+$`)
+	markdownEmphasis     = regexp.MustCompile(`(\*\*|__|\*|_|~~)([^\s*_~](?:.*?[^\s*_~])?)(\*\*|__|\*|_|~~)`)
+	markdownFrontMatterK = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.*)$`)
+)
+
+// markdownDocument converts Markdown into a document with heading sections and front matter metadata
+func markdownDocument(source, markdown string) Document {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	metadata := map[string]interface{}{"content_type": "text/markdown"}
+
+	// Front matter becomes metadata (e.g. title, date, license)
+	if strings.HasPrefix(markdown, "---\n") {
+		if end := strings.Index(markdown[4:], "\n---"); end >= 0 {
+			for _, line := range strings.Split(markdown[4:4+end], "\n") {
+				if match := markdownFrontMatterK.FindStringSubmatch(line); match != nil {
+					value := strings.Trim(strings.TrimSpace(match[2]), `"'`)
+					if value != "" {
+						metadata[strings.ToLower(match[1])] = value
+					}
+				}
+			}
+			markdown = markdown[4+end+4:]
+			markdown = strings.TrimPrefix(markdown, "\n")
+		}
+	}
+	markdown = markdownComment.ReplaceAllString(markdown, "")
+
+	rawLines := strings.Split(markdown, "\n")
+	lines := make([]textLine, 0, len(rawLines))
+	inFence := false
+	for i := 0; i < len(rawLines); i++ {
+		raw := rawLines[i]
+		// Fences are kept so the chunker can keep each code block together
+		if markdownFence.MatchString(raw) {
+			inFence = !inFence
+			lines = append(lines, textLine{text: strings.TrimSpace(raw), verbatim: true})
+			continue
+		}
+		if inFence {
+			lines = append(lines, textLine{text: raw, verbatim: true})
+			continue
+		}
+		if markdownBadgeLine.MatchString(raw) || markdownRefDef.MatchString(raw) {
+			continue
+		}
+
+		if match := markdownATXHeading.FindStringSubmatch(raw); match != nil {
+			lines = append(lines, textLine{text: cleanMarkdownInline(match[2]), level: len(match[1])})
+			continue
+		}
+		if strings.TrimSpace(raw) != "" && i+1 < len(rawLines) {
+			if markdownSetextH1.MatchString(rawLines[i+1]) {
+				lines = append(lines, textLine{text: cleanMarkdownInline(raw), level: 1})
+				i++
+				continue
+			}
+			if markdownSetextH2.MatchString(rawLines[i+1]) && !strings.HasPrefix(strings.TrimSpace(raw), "-") {
+				lines = append(lines, textLine{text: cleanMarkdownInline(raw), level: 2})
+				i++
+				continue
+			}
+		}
+		lines = append(lines, textLine{text: cleanMarkdownInline(raw)})
+	}
+
+	content, sections := assembleStructuredText(lines)
+	if _, ok := metadata["title"]; !ok {
+		for _, section := range sections {
+			if section.Level == 1 {
+				metadata["title"] = section.Heading
+				break
+			}
+		}
+	}
+	if len(sections) > 0 {
+		metadata[sectionsMetadataKey] = sections
+	}
+	return Document{Content: content, Source: source, Metadata: metadata}
+}
+
+// cleanMarkdownInline strips inline Markdown syntax, keeping the visible text
+func cleanMarkdownInline(text string) string {
+	text = markdownImage.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownRefLink.ReplaceAllString(text, "$1")
+	text = markdownHTMLTag.ReplaceAllString(text, "")
+	text = markdownEmphasis.ReplaceAllString(text, "$2")
+	return strings.ReplaceAll(text, "`", "")
+}
+
+// htmlSkippedElements hold navigation, scripts, and other non-content markup
+var htmlSkippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "iframe": true,
+}
+
+// htmlBlockElements end a line of extracted text
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "br": true, "li": true,
+	"tr": true, "pre": true, "blockquote": true, "table": true, "ul": true, "ol": true, "dd": true, "dt": true,
+}
+
+// htmlHeadingLevels maps heading elements to their level
+var htmlHeadingLevels = map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6}
+
+// htmlDocument converts an HTML page into a document with heading sections
+func htmlDocument(source, page string) (Document, error) {
+	text, title, sections, err := extractHTMLText(page)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	metadata := map[string]interface{}{"content_type": "text/html"}
+	if title != "" {
+		metadata["title"] = title
+	}
+	if len(sections) > 0 {
+		metadata[sectionsMetadataKey] = sections
+	}
+	return Document{Content: text, Source: source, Metadata: metadata}, nil
+}
+
+// extractHTMLText returns the readable text, title, and heading sections of an HTML page
+func extractHTMLText(page string) (string, string, []DocumentSection, error) {
+	root, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	lines := make([]textLine, 0)
+	var current strings.Builder
+	flush := func() {
+		lines = append(lines, textLine{text: strings.TrimSpace(current.String())})
+		current.Reset()
+	}
+
+	title := ""
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if node.Data == "title" && title == "" {
+				title = strings.TrimSpace(nodeText(node))
+				return
+			}
+			if htmlSkippedElements[node.Data] {
+				return
+			}
+			if level, ok := htmlHeadingLevels[node.Data]; ok {
+				flush()
+				lines = append(lines, textLine{text: nodeText(node), level: level})
+				return
+			}
+			if node.Data == "table" {
+				// Tables keep their rows and columns as a Markdown table
+				flush()
+				for _, row := range htmlTableLines(node) {
+					lines = append(lines, textLine{text: row})
+				}
+				return
+			}
+		}
+		if node.Type == html.TextNode {
+			if text := strings.Join(strings.Fields(node.Data), " "); text != "" {
+				if current.Len() > 0 {
+					current.WriteString(" ")
+				}
+				current.WriteString(text)
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if node.Type == html.ElementNode && htmlBlockElements[node.Data] {
+			flush()
+		}
+	}
+	walk(root)
+	flush()
+
+	content, sections := assembleStructuredText(lines)
+	return content, title, sections, nil
+}
+
+// nodeText returns the whitespace-normalized text inside a node
+func nodeText(node *html.Node) string {
+	var builder strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+			builder.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
@@ -0,0 +1,475 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/invopop/jsonschema"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision requested during initialization
+const mcpProtocolVersion = "2025-03-26"
+
+// MCPServerConfig describes an external MCP server whose tools are imported at startup.
+// Set Command for a stdio server or URL for a streamable HTTP server.
+type MCPServerConfig struct {
+	Name     string            `json:"name"`              // Namespace prefixed to imported tool names ("<name>_<tool>")
+	Command  string            `json:"command,omitempty"` // Executable started as a stdio server
+	Args     []string          `json:"args,omitempty"`
+	Env      []string          `json:"env,omitempty"` // Extra "KEY=value" entries for the server process
+	URL      string            `json:"url,omitempty"` // Streamable HTTP endpoint
+	Headers  map[string]string `json:"headers,omitempty"`
+	Tools    []string          `json:"tools,omitempty"` // Tools to import; empty imports every tool
+	Timeout  time.Duration     `json:"timeout"`         // Per-request timeout (default 30s)
+	Required bool              `json:"required"`        // Fail plugin initialization when the server is unavailable
+}
+
+// MCPTool is a tool advertised by an MCP server
+type MCPTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema,omitempty"`
+}
+
+// mcpToolResult is the result of an MCP tools/call request
+type mcpToolResult struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text,omitempty"`
+	} `json:"content"`
+	StructuredContent any  `json:"structuredContent,omitempty"`
+	IsError           bool `json:"isError,omitempty"`
+}
+
+// mcpTransport exchanges JSON-RPC messages with an MCP server
+type mcpTransport interface {
+	// roundTrip sends a request and returns the raw response with the same ID
+	roundTrip(ctx context.Context, id int64, message []byte) ([]byte, error)
+	// notify sends a message that has no response
+	notify(ctx context.Context, message []byte) error
+	close() error
+}
+
+// MCPClient is a minimal Model Context Protocol client for listing and calling tools
+type MCPClient struct {
+	config    MCPServerConfig
+	transport mcpTransport
+
+	mu     sync.Mutex
+	nextID int64
+}
+
+// ConnectMCPServer starts or connects to an MCP server and completes the initialization handshake
+func ConnectMCPServer(ctx context.Context, config MCPServerConfig) (*MCPClient, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("MCP server requires a name")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	var transport mcpTransport
+	var err error
+	switch {
+	case config.Command != "":
+		transport, err = newStdioTransport(config)
+	case config.URL != "":
+		transport = &httpTransport{url: config.URL, headers: config.Headers, client: &http.Client{Timeout: config.Timeout}}
+	default:
+		return nil, fmt.Errorf("MCP server %s requires a command or URL", config.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client := &MCPClient{config: config, transport: transport}
+	params := map[string]any{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": PluginID, "version": "1.0.0"},
+	}
+	if err := client.call(ctx, "initialize", params, nil); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", config.Name, err)
+	}
+	notification, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	if err := transport.notify(ctx, notification); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", config.Name, err)
+	}
+	return client, nil
+}
+
+// ListTools returns every tool the server advertises
+func (c *MCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
+	tools := make([]MCPTool, 0)
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor,omitempty"`
+		}
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("failed to list MCP tools: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a server tool, returning its structured content or its text content
+func (c *MCPClient) CallTool(ctx context.Context, name string, arguments any) (any, error) {
+	var result mcpToolResult
+	if err := c.call(ctx, "tools/call", map[string]any{"name": name, "arguments": arguments}, &result); err != nil {
+		return nil, fmt.Errorf("failed to call MCP tool %s: %w", name, err)
+	}
+
+	texts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if content.Type == "text" {
+			texts = append(texts, content.Text)
+		}
+	}
+	if result.IsError {
+		return nil, fmt.Errorf("MCP tool %s failed: %s", name, strings.Join(texts, "\n"))
+	}
+	if result.StructuredContent != nil {
+		return result.StructuredContent, nil
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// Close shuts down the connection, stopping a stdio server process
+func (c *MCPClient) Close() error {
+	return c.transport.close()
+}
+
+// call sends a JSON-RPC request and decodes its result
+func (c *MCPClient) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.mu.Unlock()
+
+	request, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	data, err := c.transport.roundTrip(ctx, id, request)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, response.Error.Message, response.Error.Code)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// responseID returns the ID of a JSON-RPC response, or false for notifications and server requests
+func responseID(message []byte) (int64, bool) {
+	var envelope struct {
+		ID     *int64 `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil || envelope.ID == nil || envelope.Method != "" {
+		return 0, false
+	}
+	return *envelope.ID, true
+}
+
+// stdioTransport talks to a server process over newline-delimited JSON on stdin/stdout
+type stdioTransport struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	messages chan []byte
+
+	mu sync.Mutex // Serializes requests so responses arrive in order
+}
+
+// newStdioTransport starts the server process and reads its output in the background
+func newStdioTransport(config MCPServerConfig) (*stdioTransport, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Env = append(os.Environ(), config.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MCP server stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MCP server stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server %s: %w", config.Name, err)
+	}
+
+	transport := &stdioTransport{cmd: cmd, stdin: stdin, messages: make(chan []byte, 16)}
+	go func() {
+		defer close(transport.messages)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 16<<20)
+		for scanner.Scan() {
+			transport.messages <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+	return transport, nil
+}
+
+// roundTrip writes the request and waits for the response with its ID
+func (t *stdioTransport) roundTrip(ctx context.Context, id int64, message []byte) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.stdin.Write(append(message, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write to MCP server: %w", err)
+	}
+	for {
+		select {
+		case response, ok := <-t.messages:
+			if !ok {
+				return nil, fmt.Errorf("MCP server exited")
+			}
+			if responseID, ok := responseID(response); ok && responseID == id {
+				return response, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// notify writes a notification
+func (t *stdioTransport) notify(ctx context.Context, message []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.stdin.Write(append(message, '\n')); err != nil {
+		return fmt.Errorf("failed to write to MCP server: %w", err)
+	}
+	return nil
+}
+
+// close closes stdin, which asks the server to exit, and waits for the process
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- t.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		return t.cmd.Process.Kill()
+	}
+}
+
+// httpTransport talks to a streamable HTTP server, accepting JSON or event-stream responses
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// roundTrip posts the request and returns the response with its ID
+func (t *httpTransport) roundTrip(ctx context.Context, id int64, message []byte) ([]byte, error) {
+	resp, err := t.post(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return io.ReadAll(resp.Body)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		if responseID, ok := responseID([]byte(data)); ok && responseID == id {
+			return []byte(strings.TrimSpace(data)), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MCP event stream: %w", err)
+	}
+	return nil, fmt.Errorf("MCP event stream ended without a response")
+}
+
+// notify posts a notification
+func (t *httpTransport) notify(ctx context.Context, message []byte) error {
+	resp, err := t.post(ctx, message)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// post sends a message, carrying the session ID assigned by the server
+func (t *httpTransport) post(ctx context.Context, message []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(message))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach MCP server: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("MCP server returned status %d", resp.StatusCode)
+	}
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// close ends the HTTP session
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// importMCPTools connects to the configured MCP servers and registers their tools under
+// "<server>_<tool>" names, making them available to tool chains and the generation stage
+func (p *AgenticRAGPlugin) importMCPTools(ctx context.Context, g *genkit.Genkit) error {
+	for _, server := range p.config.MCPServers {
+		client, err := ConnectMCPServer(ctx, server)
+		if err == nil {
+			err = p.registerMCPTools(ctx, g, client)
+			if err != nil {
+				client.Close()
+			}
+		}
+		if err != nil {
+			if server.Required {
+				return err
+			}
+			p.config.Metrics.IncCounter("agentic_rag_mcp_server_errors_total", 1)
+			continue
+		}
+		p.mcpClients = append(p.mcpClients, client)
+	}
+	return nil
+}
+
+// registerMCPTools defines a guarded Genkit tool for each selected server tool
+func (p *AgenticRAGPlugin) registerMCPTools(ctx context.Context, g *genkit.Genkit, client *MCPClient) error {
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(client.config.Tools))
+	for _, name := range client.config.Tools {
+		allowed[name] = true
+	}
+	for _, tool := range tools {
+		if len(allowed) > 0 && !allowed[tool.Name] {
+			continue
+		}
+		name := fmt.Sprintf("%s_%s", client.config.Name, tool.Name)
+		if genkit.LookupTool(g, name) != nil {
+			return fmt.Errorf("tool %q is already registered", name)
+		}
+
+		schema := &jsonschema.Schema{}
+		if tool.InputSchema != nil {
+			data, err := json.Marshal(tool.InputSchema)
+			if err != nil {
+				return fmt.Errorf("failed to encode input schema of MCP tool %s: %w", tool.Name, err)
+			}
+			if err := json.Unmarshal(data, schema); err != nil {
+				schema = &jsonschema.Schema{}
+			}
+		}
+
+		remoteName := tool.Name
+		genkit.DefineToolWithInputSchema(g, name, tool.Description, schema,
+			guardedTool(p.config, name, func(ctx *ai.ToolContext, input any) (any, error) {
+				return client.CallTool(ctx, remoteName, input)
+			}))
+		p.processor.addTool(name)
+		p.config.Metrics.IncCounter("agentic_rag_mcp_tools_imported_total", 1)
+	}
+	return nil
+}
+
+// Close disconnects from the MCP servers imported at initialization
+func (p *AgenticRAGPlugin) Close() error {
+	var firstErr error
+	for _, client := range p.mcpClients {
+		if err := client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.mcpClients = nil
+	return firstErr
+}
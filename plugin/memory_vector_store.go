@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// MemoryVectorStore keeps records in memory and searches them by brute-force cosine similarity.
+// It needs no database, which suits tests, examples, and small corpora.
+type MemoryVectorStore struct {
+	mu          *sync.RWMutex
+	collections map[string]map[string]domain.VectorRecord // Collection -> record ID -> record, shared by views
+	bindings    map[string]domain.EmbeddingBinding        // Collection -> embedding binding, shared by views
+	collection  string
+	guard       *embeddingGuard
+}
+
+// NewMemoryVectorStore creates an empty in-memory vector store
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{
+		mu:          &sync.RWMutex{},
+		collections: make(map[string]map[string]domain.VectorRecord),
+		bindings:    make(map[string]domain.EmbeddingBinding),
+		guard:       &embeddingGuard{},
+	}
+}
+
+// WithCollection returns a view of the store holding the collection's records
+func (s *MemoryVectorStore) WithCollection(collection string) domain.VectorStore {
+	return &MemoryVectorStore{mu: s.mu, collections: s.collections, bindings: s.bindings, collection: collection, guard: &embeddingGuard{}}
+}
+
+// BindEmbedding binds the collection to the embedding binding unless it is already bound
+func (s *MemoryVectorStore) BindEmbedding(ctx context.Context, binding domain.EmbeddingBinding) (domain.EmbeddingBinding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bound, ok := s.bindings[s.collection]; ok {
+		return bound, nil
+	}
+	s.bindings[s.collection] = binding
+	return binding, nil
+}
+
+// embeddingBinding returns the collection's binding, if it is bound
+func (s *MemoryVectorStore) embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	bound, ok := s.bindings[s.collection]
+	return bound, ok, nil
+}
+
+// Store inserts records, replacing existing records with the same ID
+func (s *MemoryVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if err := s.guard.checkRecords(ctx, s, records); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.collections[s.collection]
+	if !ok {
+		stored = make(map[string]domain.VectorRecord, len(records))
+		s.collections[s.collection] = stored
+	}
+	for _, record := range records {
+		// Copy what callers may reuse so later changes do not alter stored records
+		record.Embedding = append([]float32(nil), record.Embedding...)
+		metadata := make(map[string]interface{}, len(record.Metadata))
+		for key, value := range record.Metadata {
+			metadata[key] = value
+		}
+		record.Metadata = metadata
+		stored[record.ID] = record
+	}
+	return nil
+}
+
+// Search returns the k records most similar to the embedding whose metadata matches the filters
+func (s *MemoryVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.collections[s.collection]
+	results := make([]domain.SearchResult, 0, len(stored))
+	for _, record := range stored {
+		if len(filters) > 0 && !filters.Matches(record.Metadata) {
+			continue
+		}
+		results = append(results, domain.SearchResult{Record: record, Score: cosineSimilarity(embedding, record.Embedding)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Record.ID < results[j].Record.ID
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs
+func (s *MemoryVectorStore) Delete(ctx context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.collections[s.collection], id)
+	}
+	return nil
+}
+
+// Prune removes the collection's expired records
+func (s *MemoryVectorStore) Prune(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	pruned := 0
+	for id, record := range s.collections[s.collection] {
+		if !record.ExpiresAt.IsZero() && !record.ExpiresAt.After(now) {
+			delete(s.collections[s.collection], id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// Len returns the number of records stored in the collection
+func (s *MemoryVectorStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.collections[s.collection])
+}
@@ -0,0 +1,188 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metrics is a concurrency-safe registry of named counters, gauges, and histograms. Each metric
+// may have several series distinguished by their labels.
+type Metrics struct {
+	mu         sync.RWMutex
+	counters   map[string]*metricSeries
+	gauges     map[string]*metricSeries
+	histograms map[string]*histogramSeries
+}
+
+// Labels are the label names and values of a metric series
+type Labels map[string]string
+
+// MetricSample is a point-in-time value of a single metric series
+type MetricSample struct {
+	Name   string  `json:"name"`
+	Labels Labels  `json:"labels,omitempty"`
+	Type   string  `json:"type"`  // "counter", "gauge", or "histogram"
+	Value  float64 `json:"value"` // Sum of the observations of a histogram
+
+	Count   uint64    `json:"count,omitempty"`   // Observations of a histogram
+	Buckets []float64 `json:"buckets,omitempty"` // Upper bounds of a histogram's buckets
+	Counts  []uint64  `json:"counts,omitempty"`  // Cumulative observations per bucket
+}
+
+// metricSeries is the value of a counter or gauge series
+type metricSeries struct {
+	name   string
+	labels Labels
+	value  float64
+}
+
+// histogramSeries is the bucketed observations of a histogram series
+type histogramSeries struct {
+	name   string
+	labels Labels
+	counts []uint64 // Per bucket of histogramBuckets, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// histogramBuckets are the upper bounds, in seconds, of the histogram buckets
+var histogramBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// NewMetrics creates an empty metrics registry
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counters:   make(map[string]*metricSeries),
+		gauges:     make(map[string]*metricSeries),
+		histograms: make(map[string]*histogramSeries),
+	}
+}
+
+// IncCounter adds delta to a counter
+func (m *Metrics) IncCounter(name string, delta float64) {
+	m.IncCounterWith(name, nil, delta)
+}
+
+// IncCounterWith adds delta to the counter series with the labels
+func (m *Metrics) IncCounterWith(name string, labels Labels, delta float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := seriesKey(name, labels)
+	series, ok := m.counters[key]
+	if !ok {
+		series = &metricSeries{name: name, labels: labels}
+		m.counters[key] = series
+	}
+	series.value += delta
+}
+
+// SetGauge sets a gauge to value
+func (m *Metrics) SetGauge(name string, value float64) {
+	m.SetGaugeWith(name, nil, value)
+}
+
+// SetGaugeWith sets the gauge series with the labels to value
+func (m *Metrics) SetGaugeWith(name string, labels Labels, value float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[seriesKey(name, labels)] = &metricSeries{name: name, labels: labels, value: value}
+}
+
+// Observe records a duration in seconds, or another value on the same scale, in a histogram series
+func (m *Metrics) Observe(name string, labels Labels, value float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := seriesKey(name, labels)
+	series, ok := m.histograms[key]
+	if !ok {
+		series = &histogramSeries{name: name, labels: labels, counts: make([]uint64, len(histogramBuckets))}
+		m.histograms[key] = series
+	}
+	if i := sort.SearchFloat64s(histogramBuckets, value); i < len(histogramBuckets) {
+		series.counts[i]++
+	}
+	series.sum += value
+	series.count++
+}
+
+// Snapshot returns all metric series sorted by name and labels
+func (m *Metrics) Snapshot() []MetricSample {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	samples := make(map[string]MetricSample, len(m.counters)+len(m.gauges)+len(m.histograms))
+	for key, series := range m.counters {
+		samples[key] = MetricSample{Name: series.name, Labels: series.labels, Type: "counter", Value: series.value}
+	}
+	for key, series := range m.gauges {
+		samples[key] = MetricSample{Name: series.name, Labels: series.labels, Type: "gauge", Value: series.value}
+	}
+	for key, series := range m.histograms {
+		counts := make([]uint64, len(series.counts))
+		var cumulative uint64
+		for i, count := range series.counts {
+			cumulative += count
+			counts[i] = cumulative
+		}
+		samples[key] = MetricSample{
+			Name:    series.name,
+			Labels:  series.labels,
+			Type:    "histogram",
+			Value:   series.sum,
+			Count:   series.count,
+			Buckets: histogramBuckets,
+			Counts:  counts,
+		}
+	}
+
+	keys := make([]string, 0, len(samples))
+	for key := range samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sorted := make([]MetricSample, len(keys))
+	for i, key := range keys {
+		sorted[i] = samples[key]
+	}
+	return sorted
+}
+
+// seriesKey identifies a series by its name and sorted labels, e.g. `name{model="a",tenant="b"}`
+func seriesKey(name string, labels Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(name + "{")
+	for i, label := range names {
+		if i > 0 {
+			key.WriteString(",")
+		}
+		key.WriteString(label + "=" + quoteLabelValue(labels[label]))
+	}
+	key.WriteString("}")
+	return key.String()
+}
+
+// quoteLabelValue quotes a label value with the escapes of the Prometheus text format
+func quoteLabelValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,522 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// Vector index types supported by MilvusVectorStore
+const (
+	MilvusHNSW    = "HNSW"     // Graph index, tuned by HNSWM, HNSWEfConstruction, and HNSWEf (default)
+	MilvusIVFFlat = "IVF_FLAT" // Inverted file index, tuned by IVFNList and IVFNProbe
+	MilvusIVFSQ8  = "IVF_SQ8"  // Inverted file index with scalar-quantized vectors, tuned like IVF_FLAT
+)
+
+// MilvusConfig contains configuration for a Milvus or Zilliz Cloud vector store
+type MilvusConfig struct {
+	URL        string        `json:"url"`                 // Base URL of the Milvus RESTful API, e.g. http://localhost:19530
+	Collection string        `json:"collection"`          // Milvus collection holding the records (default: vector_records)
+	TokenEnv   string        `json:"token_env,omitempty"` // Environment variable holding the API key or "user:password" token
+	Dimensions int           `json:"dimensions"`          // Embedding dimensions; required to create the collection
+	IndexType  string        `json:"index_type"`          // MilvusHNSW, MilvusIVFFlat, or MilvusIVFSQ8
+	Timeout    time.Duration `json:"timeout"`             // Timeout of each request (default: 30s)
+
+	HNSWM              int `json:"hnsw_m"`               // Graph degree of HNSW indexes (default: 16)
+	HNSWEfConstruction int `json:"hnsw_ef_construction"` // Build-time candidate list of HNSW indexes (default: 200)
+	HNSWEf             int `json:"hnsw_ef"`              // Search-time candidate list of HNSW indexes; raised to k when smaller (default: 64)
+	IVFNList           int `json:"ivf_nlist"`            // Clusters of IVF indexes (default: 1024)
+	IVFNProbe          int `json:"ivf_nprobe"`           // Clusters searched by IVF indexes (default: 16)
+}
+
+// MilvusVectorStore stores records in a Milvus collection through its RESTful API, with metadata
+// in a JSON field that filters are translated into boolean expressions on. Collections of the
+// domain map to partitions of the one Milvus collection, so they share its schema and index.
+type MilvusVectorStore struct {
+	client    *http.Client
+	config    MilvusConfig
+	partition string // Partition of the store's collection; the default partition when empty
+	schema    *schemaInit
+	guard     *embeddingGuard
+}
+
+// NewMilvusVectorStore creates the store's collection and index unless they exist
+func NewMilvusVectorStore(ctx context.Context, config MilvusConfig) (*MilvusVectorStore, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("milvus URL is required")
+	}
+	if config.Collection == "" {
+		config.Collection = "vector_records"
+	}
+	if config.IndexType == "" {
+		config.IndexType = MilvusHNSW
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.HNSWM <= 0 {
+		config.HNSWM = 16
+	}
+	if config.HNSWEfConstruction <= 0 {
+		config.HNSWEfConstruction = 200
+	}
+	if config.HNSWEf <= 0 {
+		config.HNSWEf = 64
+	}
+	if config.IVFNList <= 0 {
+		config.IVFNList = 1024
+	}
+	if config.IVFNProbe <= 0 {
+		config.IVFNProbe = 16
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	if !pgIdentifier.MatchString(config.Collection) {
+		return nil, fmt.Errorf("invalid milvus collection name %q", config.Collection)
+	}
+	switch config.IndexType {
+	case MilvusHNSW, MilvusIVFFlat, MilvusIVFSQ8:
+	default:
+		return nil, fmt.Errorf("unsupported milvus index type %q", config.IndexType)
+	}
+
+	store := &MilvusVectorStore{client: &http.Client{Timeout: config.Timeout}, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+	if err := store.schema.ensure(ctx, store.bootstrap); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// WithCollection returns a view of the store keeping the collection's records in a partition of
+// the same name, created on first use
+func (s *MilvusVectorStore) WithCollection(collection string) domain.VectorStore {
+	if collection != "" && !pgIdentifier.MatchString(collection) {
+		return invalidVectorStore{err: fmt.Errorf("invalid vector store collection %q", collection)}
+	}
+	return &MilvusVectorStore{client: s.client, config: s.config, partition: collection, schema: &schemaInit{}, guard: &embeddingGuard{}}
+}
+
+// bootstrap creates the collection with its index and the store's partition unless they exist
+func (s *MilvusVectorStore) bootstrap(ctx context.Context) error {
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := s.call(ctx, "/v2/vectordb/collections/has", map[string]interface{}{"collectionName": s.config.Collection}, &has); err != nil {
+		return fmt.Errorf("failed to check milvus collection: %w", err)
+	}
+	if !has.Has {
+		if s.config.Dimensions <= 0 {
+			return fmt.Errorf("milvus dimensions are required to create collection %s", s.config.Collection)
+		}
+		if err := s.call(ctx, "/v2/vectordb/collections/create", s.collectionDefinition(), nil); err != nil {
+			return fmt.Errorf("failed to create milvus collection %s: %w", s.config.Collection, err)
+		}
+	}
+	if s.partition == "" {
+		return nil
+	}
+
+	partition := map[string]interface{}{"collectionName": s.config.Collection, "partitionName": s.partition}
+	if err := s.call(ctx, "/v2/vectordb/partitions/has", partition, &has); err != nil {
+		return fmt.Errorf("failed to check milvus partition: %w", err)
+	}
+	if has.Has {
+		return nil
+	}
+	if err := s.call(ctx, "/v2/vectordb/partitions/create", partition, nil); err != nil {
+		return fmt.Errorf("failed to create milvus partition %s: %w", s.partition, err)
+	}
+	if err := s.call(ctx, "/v2/vectordb/partitions/load", map[string]interface{}{"collectionName": s.config.Collection, "partitionNames": []string{s.partition}}, nil); err != nil {
+		return fmt.Errorf("failed to load milvus partition %s: %w", s.partition, err)
+	}
+	return nil
+}
+
+// collectionDefinition returns the create request of the collection, which is loaded on creation
+func (s *MilvusVectorStore) collectionDefinition() map[string]interface{} {
+	field := func(name, dataType string, params map[string]interface{}) map[string]interface{} {
+		definition := map[string]interface{}{"fieldName": name, "dataType": dataType}
+		if params != nil {
+			definition["elementTypeParams"] = params
+		}
+		return definition
+	}
+	index := map[string]interface{}{
+		"fieldName":  "embedding",
+		"indexName":  "embedding",
+		"metricType": "COSINE",
+		"indexType":  s.config.IndexType,
+	}
+	if s.config.IndexType == MilvusHNSW {
+		index["params"] = map[string]interface{}{"M": s.config.HNSWM, "efConstruction": s.config.HNSWEfConstruction}
+	} else {
+		index["params"] = map[string]interface{}{"nlist": s.config.IVFNList}
+	}
+
+	return map[string]interface{}{
+		"collectionName": s.config.Collection,
+		"schema": map[string]interface{}{
+			"autoId": false,
+			"fields": []map[string]interface{}{
+				{"fieldName": "id", "dataType": "VarChar", "isPrimary": true, "elementTypeParams": map[string]interface{}{"max_length": 512}},
+				field("embedding", "FloatVector", map[string]interface{}{"dim": s.config.Dimensions}),
+				field("content", "VarChar", map[string]interface{}{"max_length": 65535}),
+				field("metadata", "JSON", nil),
+				field("updated_at", "Int64", nil),
+				field("expires_at", "Int64", nil),
+			},
+		},
+		"indexParams": []map[string]interface{}{index},
+	}
+}
+
+// bindingProperty returns the collection property keeping the partition's embedding binding
+func (s *MilvusVectorStore) bindingProperty() string {
+	if s.partition == "" {
+		return "embedding_binding"
+	}
+	return "embedding_binding." + s.partition
+}
+
+// BindEmbedding binds the store's partition to the embedding binding unless it is already bound,
+// keeping the binding as JSON in a collection property
+func (s *MilvusVectorStore) BindEmbedding(ctx context.Context, binding domain.EmbeddingBinding) (domain.EmbeddingBinding, error) {
+	bound, ok, err := s.embeddingBinding(ctx)
+	if err != nil || ok {
+		return bound, err
+	}
+
+	value, err := json.Marshal(binding)
+	if err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to encode embedding binding: %w", err)
+	}
+	body := map[string]interface{}{
+		"collectionName": s.config.Collection,
+		"properties":     map[string]interface{}{s.bindingProperty(): string(value)},
+	}
+	if err := s.call(ctx, "/v2/vectordb/collections/alter_properties", body, nil); err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: %w", err)
+	}
+	// Another process may have bound the partition concurrently; the last update wins
+	bound, _, err = s.embeddingBinding(ctx)
+	return bound, err
+}
+
+// embeddingBinding returns the partition's binding, if it is bound
+func (s *MilvusVectorStore) embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return domain.EmbeddingBinding{}, false, err
+	}
+	var described struct {
+		Properties []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"properties"`
+	}
+	if err := s.call(ctx, "/v2/vectordb/collections/describe", map[string]interface{}{"collectionName": s.config.Collection}, &described); err != nil {
+		return domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: %w", err)
+	}
+	for _, property := range described.Properties {
+		if property.Key != s.bindingProperty() {
+			continue
+		}
+		var bound domain.EmbeddingBinding
+		if err := json.Unmarshal([]byte(property.Value), &bound); err != nil {
+			return domain.EmbeddingBinding{}, false, fmt.Errorf("failed to decode embedding binding: %w", err)
+		}
+		return bound, true, nil
+	}
+	return domain.EmbeddingBinding{}, false, nil
+}
+
+// Store upserts records into the store's partition. Content is limited to Milvus's 65535-byte
+// VarChar maximum.
+func (s *MilvusVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return err
+	}
+	if err := s.guard.checkRecords(ctx, s, records); err != nil {
+		return err
+	}
+	data := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		metadata := make(map[string]interface{}, len(record.Metadata))
+		for key, value := range record.Metadata {
+			metadata[key] = pgFilterValue(value)
+		}
+		var updatedAt int64
+		if !record.UpdatedAt.IsZero() {
+			updatedAt = record.UpdatedAt.UnixMilli()
+		}
+		var expiresAt int64
+		if !record.ExpiresAt.IsZero() {
+			expiresAt = record.ExpiresAt.UnixMilli()
+		}
+		data[i] = map[string]interface{}{
+			"id":         record.ID,
+			"embedding":  record.Embedding,
+			"content":    record.Content,
+			"metadata":   metadata,
+			"updated_at": updatedAt,
+			"expires_at": expiresAt,
+		}
+	}
+	body := s.request(map[string]interface{}{"data": data})
+	if err := s.call(ctx, "/v2/vectordb/entities/upsert", body, nil); err != nil {
+		return fmt.Errorf("failed to store records: %w", err)
+	}
+	return nil
+}
+
+// Search returns the k nearest records in the store's partition matching the filters, scored by
+// cosine similarity
+func (s *MilvusVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return nil, err
+	}
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	filter, err := milvusFilterExpression(filters)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]interface{}{"nprobe": s.config.IVFNProbe}
+	if s.config.IndexType == MilvusHNSW {
+		params = map[string]interface{}{"ef": max(s.config.HNSWEf, k)}
+	}
+	body := map[string]interface{}{
+		"collectionName": s.config.Collection,
+		"data":           [][]float32{embedding},
+		"annsField":      "embedding",
+		"limit":          k,
+		"outputFields":   []string{"content", "metadata", "updated_at"},
+		"searchParams":   map[string]interface{}{"metricType": "COSINE", "params": params},
+	}
+	if filter != "" {
+		body["filter"] = filter
+	}
+	if s.partition != "" {
+		body["partitionNames"] = []string{s.partition}
+	}
+
+	var hits []struct {
+		ID        string                 `json:"id"`
+		Distance  float64                `json:"distance"`
+		Content   string                 `json:"content"`
+		Metadata  map[string]interface{} `json:"metadata"`
+		UpdatedAt int64                  `json:"updated_at"`
+	}
+	if err := s.call(ctx, "/v2/vectordb/entities/search", body, &hits); err != nil {
+		return nil, fmt.Errorf("failed to search records: %w", err)
+	}
+	results := make([]domain.SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = domain.SearchResult{
+			Record: domain.VectorRecord{ID: hit.ID, Content: hit.Content, Metadata: hit.Metadata},
+			Score:  hit.Distance, // The COSINE metric reports similarity
+		}
+		if hit.UpdatedAt > 0 {
+			results[i].Record.UpdatedAt = time.UnixMilli(hit.UpdatedAt)
+		}
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs from the store's partition
+func (s *MilvusVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("failed to encode record IDs: %w", err)
+	}
+	body := s.request(map[string]interface{}{"filter": "id in " + string(encoded)})
+	if err := s.call(ctx, "/v2/vectordb/entities/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+	return nil
+}
+
+// milvusQueryLimit is the most entities a Milvus query returns
+const milvusQueryLimit = 16384
+
+// Prune removes the records in the store's partition whose expiry has passed. Milvus does not
+// report deletions, so the expired IDs are queried first, a page at a time.
+func (s *MilvusVectorStore) Prune(ctx context.Context) (int, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return 0, err
+	}
+	filter := fmt.Sprintf("expires_at > 0 and expires_at <= %d", time.Now().UnixMilli())
+	pruned := 0
+	for {
+		body := map[string]interface{}{
+			"collectionName": s.config.Collection,
+			"filter":         filter,
+			"outputFields":   []string{"id"},
+			"limit":          milvusQueryLimit,
+		}
+		if s.partition != "" {
+			body["partitionNames"] = []string{s.partition}
+		}
+		var entities []struct {
+			ID string `json:"id"`
+		}
+		if err := s.call(ctx, "/v2/vectordb/entities/query", body, &entities); err != nil {
+			return pruned, fmt.Errorf("failed to find expired records: %w", err)
+		}
+		ids := make([]string, len(entities))
+		for i, entity := range entities {
+			ids[i] = entity.ID
+		}
+		if err := s.Delete(ctx, ids); err != nil {
+			return pruned, fmt.Errorf("failed to prune records: %w", err)
+		}
+		pruned += len(ids)
+		if len(ids) < milvusQueryLimit {
+			return pruned, nil
+		}
+	}
+}
+
+// request adds the collection and the store's partition to an entity request body
+func (s *MilvusVectorStore) request(body map[string]interface{}) map[string]interface{} {
+	body["collectionName"] = s.config.Collection
+	if s.partition != "" {
+		body["partitionName"] = s.partition
+	}
+	return body
+}
+
+// call posts a request to the Milvus RESTful API and decodes the data of a successful response
+// into out. Milvus reports failures in the response code rather than the HTTP status.
+func (s *MilvusVectorStore) call(ctx context.Context, path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.TokenEnv != "" {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv(s.config.TokenEnv))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	var response struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if response.Code != 0 {
+		return fmt.Errorf("milvus error %d: %s", response.Code, response.Message)
+	}
+	if out == nil || len(response.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return nil
+}
+
+// milvusFilterExpression translates filters into a Milvus boolean expression on the metadata
+// JSON field. Like domain.Filters, a scalar matches an equal value or a list containing it.
+func milvusFilterExpression(filters domain.Filters) (string, error) {
+	if err := filters.Validate(); err != nil {
+		return "", fmt.Errorf("invalid filters: %w", err)
+	}
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]string, 0, len(filters))
+	for _, key := range keys {
+		quotedKey, err := json.Marshal(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode filter key %q: %w", key, err)
+		}
+		field := fmt.Sprintf("metadata[%s]", quotedKey)
+
+		switch want := filters[key].(type) {
+		case map[string]interface{}:
+			operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+			bounds := make([]string, 0, len(want))
+			for operator := range want {
+				bounds = append(bounds, operator)
+			}
+			sort.Strings(bounds)
+			for _, operator := range bounds {
+				value, err := milvusLiteral(want[operator])
+				if err != nil {
+					return "", err
+				}
+				conditions = append(conditions, fmt.Sprintf("%s %s %s", field, operators[operator], value))
+			}
+		default:
+			options, ok := filterOptions(want)
+			if !ok {
+				options = []interface{}{want}
+			}
+			if len(options) == 0 {
+				// An empty any-of list matches nothing
+				conditions = append(conditions, "false")
+				continue
+			}
+			list, err := milvusLiteral(options)
+			if err != nil {
+				return "", err
+			}
+			conditions = append(conditions, fmt.Sprintf("(%[1]s in %[2]s or json_contains_any(%[1]s, %[2]s))", field, list))
+		}
+	}
+	return strings.Join(conditions, " and "), nil
+}
+
+// milvusLiteral writes a filter value as an expression literal, with times as the RFC 3339
+// strings they are stored as
+func milvusLiteral(value interface{}) (string, error) {
+	if options, ok := value.([]interface{}); ok {
+		converted := make([]interface{}, len(options))
+		for i, option := range options {
+			converted[i] = pgFilterValue(option)
+		}
+		value = converted
+	}
+	encoded, err := json.Marshal(pgFilterValue(value))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode filter value: %w", err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,102 @@
+package plugin
+
+import "context"
+
+// diversifyChunks selects up to k chunks by maximal marginal relevance, trading each candidate's
+// relevance against its similarity to the chunks already selected. Lambda 1 keeps the relevance
+// order and lambda 0 selects for diversity alone. Similarity uses chunk embeddings when an
+// embedder is configured and falls back to term overlap otherwise.
+func (p *AgenticRAGProcessor) diversifyChunks(ctx context.Context, chunks []DocumentChunk, lambda float64, k int) []DocumentChunk {
+	if k <= 0 || k > len(chunks) {
+		k = len(chunks)
+	}
+	if len(chunks) <= 1 {
+		return chunks
+	}
+
+	similarity := p.chunkSimilarity(ctx, chunks)
+	selected := maximalMarginalRelevance(normalizedRelevance(chunks), similarity, lambda, k)
+
+	diversified := make([]DocumentChunk, len(selected))
+	for i, index := range selected {
+		diversified[i] = chunks[index]
+	}
+	return diversified
+}
+
+// chunkSimilarity returns a pairwise similarity function over the chunks, by embedding cosine
+// similarity or, when the chunks cannot be embedded, by Jaccard similarity of their terms
+func (p *AgenticRAGProcessor) chunkSimilarity(ctx context.Context, chunks []DocumentChunk) func(i, j int) float64 {
+	if embedderName := p.embedderNameFor(chunkLanguage(chunks[0])); embedderName != "" {
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Content
+		}
+		if embeddings, err := p.cachedEmbeddings(ctx, embedderName, texts); err == nil {
+			return func(i, j int) float64 {
+				return cosineSimilarity(embeddings[i], embeddings[j])
+			}
+		}
+	}
+
+	terms := make([][]string, len(chunks))
+	for i, chunk := range chunks {
+		terms[i] = p.analyzerFor(chunkLanguage(chunk)).terms(chunk.Content)
+	}
+	return func(i, j int) float64 {
+		return jaccard(terms[i], terms[j])
+	}
+}
+
+// normalizedRelevance rescales relevance scores to [0, 1], since retrieval modes score on
+// different scales (model scores, BM25, reciprocal rank fusion)
+func normalizedRelevance(chunks []DocumentChunk) []float64 {
+	low, high := chunks[0].RelevanceScore, chunks[0].RelevanceScore
+	for _, chunk := range chunks[1:] {
+		low = min(low, chunk.RelevanceScore)
+		high = max(high, chunk.RelevanceScore)
+	}
+
+	relevance := make([]float64, len(chunks))
+	for i, chunk := range chunks {
+		if high > low {
+			relevance[i] = (chunk.RelevanceScore - low) / (high - low)
+		} else {
+			relevance[i] = 1
+		}
+	}
+	return relevance
+}
+
+// maximalMarginalRelevance greedily selects k indexes maximizing
+// lambda*relevance - (1-lambda)*max similarity to the already selected indexes
+func maximalMarginalRelevance(relevance []float64, similarity func(i, j int) float64, lambda float64, k int) []int {
+	selected := make([]int, 0, k)
+	maxSimilarity := make([]float64, len(relevance)) // Highest similarity of each candidate to the selection
+	chosen := make([]bool, len(relevance))
+
+	for len(selected) < k {
+		best, bestScore := -1, 0.0
+		for i := range relevance {
+			if chosen[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*maxSimilarity[i]
+			if best == -1 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best == -1 {
+			break
+		}
+
+		chosen[best] = true
+		selected = append(selected, best)
+		for i := range relevance {
+			if !chosen[i] {
+				maxSimilarity[i] = max(maxSimilarity[i], similarity(i, best))
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,304 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonitoringOptions configures the generated Grafana dashboard and Prometheus alert rules
+type MonitoringOptions struct {
+	Namespace  string        // Metric name prefix, as in MetricsConfig.Namespace (default: agentic_rag)
+	SLOTarget  float64       // Availability objective whose error budget burn is alerted on (default: 0.995)
+	ErrorRate  float64       // Ratio of failed requests that fires the error rate alert (default: 0.05)
+	LatencyP95 time.Duration // 95th percentile request latency that fires the latency alert (default: 10s)
+}
+
+// withDefaults fills in unset options
+func (o MonitoringOptions) withDefaults() MonitoringOptions {
+	if o.Namespace == "" {
+		o.Namespace = strings.TrimSuffix(metricsPrefix, "_")
+	}
+	if o.SLOTarget <= 0 || o.SLOTarget >= 1 {
+		o.SLOTarget = 0.995
+	}
+	if o.ErrorRate <= 0 {
+		o.ErrorRate = 0.05
+	}
+	if o.LatencyP95 <= 0 {
+		o.LatencyP95 = 10 * time.Second
+	}
+	return o
+}
+
+// metric returns the exported name of a built-in metric
+func (o MonitoringOptions) metric(name string) string {
+	return exportedMetricName(o.Namespace, name)
+}
+
+// errorRatio returns the PromQL ratio of failed requests over a window, with an optional selector
+func (o MonitoringOptions) errorRatio(window, selector string) string {
+	requests := o.metric("agentic_rag_requests_total")
+	errorSelector := `status="error"`
+	if selector != "" {
+		errorSelector = selector + "," + errorSelector
+	}
+	return fmt.Sprintf("sum(rate(%s{%s}[%s])) / sum(rate(%s[%s]))", requests, errorSelector, window, withSelector(requests, selector), window)
+}
+
+// latencyQuantile returns the PromQL quantile of a duration histogram grouped by labels
+func (o MonitoringOptions) latencyQuantile(quantile float64, histogram, selector, by string) string {
+	grouping := "le"
+	if by != "" {
+		grouping = by + ", le"
+	}
+	return fmt.Sprintf("histogram_quantile(%g, sum by (%s) (rate(%s[5m])))", quantile, grouping, withSelector(o.metric(histogram)+"_bucket", selector))
+}
+
+// withSelector appends a label selector to a metric name unless it is empty
+func withSelector(metric, selector string) string {
+	if selector == "" {
+		return metric
+	}
+	return metric + "{" + selector + "}"
+}
+
+// degradedSubsystems returns the subsystems with a degradation counter, sorted
+func degradedSubsystems() []string {
+	subsystems := make([]string, 0, len(degradationActions))
+	for subsystem := range degradationActions {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+	return subsystems
+}
+
+// grafanaPanel is a time series panel of the generated dashboard
+type grafanaPanel struct {
+	title   string
+	unit    string
+	targets [][2]string // PromQL expression and legend
+}
+
+// GrafanaDashboard returns a Grafana dashboard over the metrics served by MetricsHandler, with
+// datasource and tenant variables
+func GrafanaDashboard(options MonitoringOptions) ([]byte, error) {
+	o := options.withDefaults()
+	tenant := `tenant=~"$tenant"`
+	budget := 1 - o.SLOTarget
+
+	degraded := make([][2]string, 0)
+	for _, subsystem := range degradedSubsystems() {
+		degraded = append(degraded, [2]string{
+			fmt.Sprintf("sum(rate(%s[5m]))", o.metric("agentic_rag_degraded_"+subsystem+"_total")), subsystem,
+		})
+	}
+	degraded = append(degraded,
+		[2]string{fmt.Sprintf("sum(rate(%s[5m]))", o.metric("agentic_rag_requests_degraded_total")), "admission (degraded)"},
+		[2]string{fmt.Sprintf("sum(rate(%s[5m]))", o.metric("agentic_rag_requests_shed_total")), "admission (shed)"},
+	)
+
+	panels := []grafanaPanel{
+		{title: "Requests", unit: "reqps", targets: [][2]string{
+			{fmt.Sprintf("sum by (status) (rate(%s{%s}[5m]))", o.metric("agentic_rag_requests_total"), tenant), "{{status}}"},
+		}},
+		{title: "Error rate", unit: "percentunit", targets: [][2]string{
+			{o.errorRatio("5m", tenant), "errors"},
+		}},
+		{title: "Request latency", unit: "s", targets: [][2]string{
+			{o.latencyQuantile(0.5, "agentic_rag_request_duration_seconds", tenant, ""), "p50"},
+			{o.latencyQuantile(0.95, "agentic_rag_request_duration_seconds", tenant, ""), "p95"},
+		}},
+		{title: "Error budget burn rate", unit: "none", targets: [][2]string{
+			{fmt.Sprintf("(%s) / %g", o.errorRatio("1h", tenant), roundThreshold(budget)), "1h"},
+			{fmt.Sprintf("(%s) / %g", o.errorRatio("6h", tenant), roundThreshold(budget)), "6h"},
+		}},
+		{title: "Model calls", unit: "reqps", targets: [][2]string{
+			{fmt.Sprintf("sum by (model, status) (rate(%s{%s}[5m]))", o.metric("agentic_rag_model_requests_total"), tenant), "{{model}} {{status}}"},
+		}},
+		{title: "Model latency p95", unit: "s", targets: [][2]string{
+			{o.latencyQuantile(0.95, "agentic_rag_model_request_duration_seconds", tenant, "model"), "{{model}}"},
+		}},
+		{title: "Model tokens", unit: "short", targets: [][2]string{
+			{fmt.Sprintf("sum by (model, type) (rate(%s{%s}[5m]))", o.metric("agentic_rag_model_tokens_total"), tenant), "{{model}} {{type}}"},
+		}},
+		{title: "Vector store latency p95", unit: "s", targets: [][2]string{
+			{o.latencyQuantile(0.95, "agentic_rag_vector_store_duration_seconds", tenant, "collection, operation"), "{{collection}} {{operation}}"},
+		}},
+		{title: "Answer cache hit ratio", unit: "percentunit", targets: [][2]string{
+			{fmt.Sprintf("sum(rate(%[1]s{%[3]s}[5m])) / (sum(rate(%[1]s{%[3]s}[5m])) + sum(rate(%[2]s{%[3]s}[5m])))",
+				o.metric("agentic_rag_answer_cache_hits_total"), o.metric("agentic_rag_answer_cache_misses_total"), tenant), "hit ratio"},
+		}},
+		{title: "Queue depth", unit: "short", targets: [][2]string{
+			{o.metric("agentic_rag_queue_depth"), "queued"},
+		}},
+		{title: "Degraded stages", unit: "ops", targets: degraded},
+	}
+
+	encoded := make([]map[string]interface{}, len(panels))
+	for i, panel := range panels {
+		targets := make([]map[string]interface{}, len(panel.targets))
+		for j, target := range panel.targets {
+			targets[j] = map[string]interface{}{
+				"expr":         target[0],
+				"legendFormat": target[1],
+				"refId":        string(rune('A' + j)),
+			}
+		}
+		encoded[i] = map[string]interface{}{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      panel.title,
+			"datasource": map[string]string{"type": "prometheus", "uid": "${datasource}"},
+			"gridPos":    map[string]int{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]string{"unit": panel.unit},
+				"overrides": []interface{}{},
+			},
+			"targets": targets,
+		}
+	}
+
+	dashboard := map[string]interface{}{
+		"uid":           o.Namespace,
+		"title":         "Agentic RAG (" + o.Namespace + ")",
+		"tags":          []string{"agentic-rag"},
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{"name": "datasource", "type": "datasource", "query": "prometheus"},
+				{
+					"name":       "tenant",
+					"type":       "query",
+					"datasource": map[string]string{"type": "prometheus", "uid": "${datasource}"},
+					"query":      fmt.Sprintf("label_values(%s, tenant)", o.metric("agentic_rag_requests_total")),
+					"includeAll": true,
+					"multi":      true,
+					"allValue":   ".*",
+					"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+				},
+			},
+		},
+		"panels": encoded,
+	}
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(dashboard); err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	return data.Bytes(), nil
+}
+
+// alertRule is a Prometheus alerting rule
+type alertRule struct {
+	name     string
+	expr     string
+	duration string
+	severity string
+	summary  string
+}
+
+// PrometheusAlertRules returns a Prometheus rule file alerting on the error rate, p95 latency,
+// error budget burn over the SLO, and degraded or shed requests
+func PrometheusAlertRules(options MonitoringOptions) []byte {
+	o := options.withDefaults()
+	budget := 1 - o.SLOTarget
+
+	rules := []alertRule{
+		{
+			name:     "AgenticRAGHighErrorRate",
+			expr:     fmt.Sprintf("(%s) > %g", o.errorRatio("5m", ""), o.ErrorRate),
+			duration: "10m",
+			severity: "page",
+			summary:  fmt.Sprintf("More than %g%% of agentic RAG requests are failing", o.ErrorRate*100),
+		},
+		{
+			name:     "AgenticRAGHighLatency",
+			expr:     fmt.Sprintf("%s > %g", o.latencyQuantile(0.95, "agentic_rag_request_duration_seconds", "", ""), o.LatencyP95.Seconds()),
+			duration: "10m",
+			severity: "warning",
+			summary:  fmt.Sprintf("Agentic RAG p95 latency is above %s", o.LatencyP95),
+		},
+		{
+			// Multiwindow burn rates: 2% of a 30-day budget within an hour, or 5% within six hours
+			name:     "AgenticRAGErrorBudgetFastBurn",
+			expr:     fmt.Sprintf("(%s) > %g and (%s) > %g", o.errorRatio("1h", ""), roundThreshold(14.4*budget), o.errorRatio("5m", ""), roundThreshold(14.4*budget)),
+			duration: "2m",
+			severity: "page",
+			summary:  fmt.Sprintf("Agentic RAG is burning its %g%% availability error budget 14x too fast", o.SLOTarget*100),
+		},
+		{
+			name:     "AgenticRAGErrorBudgetSlowBurn",
+			expr:     fmt.Sprintf("(%s) > %g and (%s) > %g", o.errorRatio("6h", ""), roundThreshold(6*budget), o.errorRatio("30m", ""), roundThreshold(6*budget)),
+			duration: "15m",
+			severity: "warning",
+			summary:  fmt.Sprintf("Agentic RAG is burning its %g%% availability error budget 6x too fast", o.SLOTarget*100),
+		},
+	}
+	for _, subsystem := range degradedSubsystems() {
+		rules = append(rules, alertRule{
+			name:     "AgenticRAGDegraded" + camelCase(subsystem),
+			expr:     fmt.Sprintf("sum(increase(%s[15m])) > 0", o.metric("agentic_rag_degraded_"+subsystem+"_total")),
+			severity: "warning",
+			summary:  fmt.Sprintf("Requests continued past %s failures", strings.ReplaceAll(subsystem, "_", " ")),
+		})
+	}
+	rules = append(rules, alertRule{
+		name:     "AgenticRAGRequestsShed",
+		expr:     fmt.Sprintf("sum(increase(%s[15m])) > 0", o.metric("agentic_rag_requests_shed_total")),
+		severity: "warning",
+		summary:  "Admission control is shedding agentic RAG requests under load",
+	})
+
+	var file strings.Builder
+	file.WriteString("# Generated by agenticrag monitoring generate\n")
+	file.WriteString("groups:\n")
+	file.WriteString("  - name: " + yamlString(o.Namespace) + "\n")
+	file.WriteString("    rules:\n")
+	for _, rule := range rules {
+		file.WriteString("      - alert: " + rule.name + "\n")
+		file.WriteString("        expr: " + yamlString(rule.expr) + "\n")
+		if rule.duration != "" {
+			file.WriteString("        for: " + rule.duration + "\n")
+		}
+		file.WriteString("        labels:\n")
+		file.WriteString("          severity: " + rule.severity + "\n")
+		file.WriteString("        annotations:\n")
+		file.WriteString("          summary: " + yamlString(rule.summary) + "\n")
+	}
+	return []byte(file.String())
+}
+
+// yamlString quotes a string as a YAML scalar; JSON strings are valid YAML
+func yamlString(value string) string {
+	var quoted bytes.Buffer
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
+
+// roundThreshold drops floating-point noise from a computed threshold
+func roundThreshold(value float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'g', 6, 64), 64)
+	return rounded
+}
+
+// camelCase converts a snake_case name to CamelCase
+func camelCase(name string) string {
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
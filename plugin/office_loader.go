@@ -0,0 +1,344 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Media types of the supported Office Open XML formats
+const (
+	docxMediaType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	pptxMediaType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+)
+
+// OfficeLoader extracts text, headings, slide titles, and tables from DOCX and PPTX files.
+// A DOCX file becomes one document with heading sections; a PPTX file becomes one document per slide.
+type OfficeLoader struct {
+	maxBytes int64
+}
+
+// NewOfficeLoader creates an Office loader that refuses files or archive parts larger than maxBytes
+func NewOfficeLoader(maxBytes int64) *OfficeLoader {
+	return &OfficeLoader{maxBytes: maxBytes}
+}
+
+// CanLoad reports whether the source is a local DOCX or PPTX file
+func (l *OfficeLoader) CanLoad(source string) bool {
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".docx", ".pptx":
+	default:
+		return false
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load reads an Office file from disk
+func (l *OfficeLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Office file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := readLimited(file, l.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return l.LoadBytes(ctx, source, data)
+}
+
+// LoadBytes extracts an in-memory DOCX or PPTX file, telling them apart by their content
+func (l *OfficeLoader) LoadBytes(ctx context.Context, source string, data []byte) ([]Document, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Office archive: %w", err)
+	}
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+
+	title := ""
+	if core, ok := parts["docProps/core.xml"]; ok {
+		if data, err := l.readPart(core); err == nil {
+			title = coreTitle(data)
+		}
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+
+	if document, ok := parts["word/document.xml"]; ok {
+		return l.loadDocx(source, title, document)
+	}
+	if _, ok := parts["ppt/presentation.xml"]; ok {
+		return l.loadPptx(ctx, source, title, parts)
+	}
+	return nil, fmt.Errorf("unsupported Office document: expected DOCX or PPTX")
+}
+
+// readPart decompresses an archive part within the size limit
+func (l *OfficeLoader) readPart(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+	return readLimited(reader, l.maxBytes)
+}
+
+// docxHeadingStyle matches Word heading paragraph styles such as "Heading2"
+var docxHeadingStyle = regexp.MustCompile(`^(?i)heading\s?([1-6])$`)
+
+// loadDocx extracts paragraphs, headings, and tables from a Word document
+func (l *OfficeLoader) loadDocx(source, title string, part *zip.File) ([]Document, error) {
+	data, err := l.readPart(part)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]textLine, 0)
+	var paragraph strings.Builder
+	style := ""
+	var cells []string
+	var cell []string
+	tableDepth := 0
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DOCX: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "p":
+				paragraph.Reset()
+				style = ""
+			case "pStyle":
+				style = xmlAttr(element, "val")
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				paragraph.WriteString("\n")
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &element); err == nil {
+					paragraph.WriteString(text)
+				}
+			case "tbl":
+				tableDepth++
+			case "tr":
+				cells = nil
+			case "tc":
+				cell = nil
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				if tableDepth > 0 {
+					if text != "" {
+						cell = append(cell, text)
+					}
+					continue
+				}
+				lines = append(lines, textLine{text: text, level: docxHeadingLevel(style)})
+			case "tc":
+				cells = append(cells, strings.Join(cell, " "))
+			case "tr":
+				if tableDepth == 1 {
+					lines = append(lines, textLine{text: strings.Join(cells, " | ")})
+				}
+			case "tbl":
+				tableDepth--
+				lines = append(lines, textLine{})
+			}
+		}
+	}
+
+	content, sections := assembleStructuredText(lines)
+	metadata := map[string]interface{}{"title": title, "content_type": docxMediaType}
+	if len(sections) > 0 {
+		metadata[sectionsMetadataKey] = sections
+	}
+	return []Document{{Content: content, Source: source, Metadata: metadata}}, nil
+}
+
+// docxHeadingLevel returns the heading level of a paragraph style, or 0 for body text
+func docxHeadingLevel(style string) int {
+	if strings.EqualFold(style, "Title") {
+		return 1
+	}
+	if match := docxHeadingStyle.FindStringSubmatch(style); match != nil {
+		level, _ := strconv.Atoi(match[1])
+		return level
+	}
+	return 0
+}
+
+// pptxSlidePart matches slide parts and captures their number
+var pptxSlidePart = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+
+// loadPptx extracts each slide's title, text, and tables as a separate document
+func (l *OfficeLoader) loadPptx(ctx context.Context, source, title string, parts map[string]*zip.File) ([]Document, error) {
+	type slidePart struct {
+		number int
+		file   *zip.File
+	}
+	slides := make([]slidePart, 0)
+	for name, file := range parts {
+		if match := pptxSlidePart.FindStringSubmatch(name); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			slides = append(slides, slidePart{number: number, file: file})
+		}
+	}
+	sort.Slice(slides, func(i, j int) bool {
+		return slides[i].number < slides[j].number
+	})
+
+	docs := make([]Document, 0, len(slides))
+	for _, slide := range slides {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := l.readPart(slide.file)
+		if err != nil {
+			return nil, err
+		}
+		slideTitle, content, err := extractSlide(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse slide %d: %w", slide.number, err)
+		}
+		if content == "" {
+			continue
+		}
+
+		metadata := map[string]interface{}{
+			"title":        title,
+			"slide":        slide.number,
+			"slide_count":  len(slides),
+			"content_type": pptxMediaType,
+		}
+		if slideTitle != "" {
+			metadata["section"] = slideTitle
+		}
+		docs = append(docs, Document{Content: content, Source: source, Metadata: metadata})
+	}
+	return docs, nil
+}
+
+// extractSlide returns a slide's title and its text, with table rows as " | "-separated cells
+func extractSlide(data []byte) (string, string, error) {
+	lines := make([]string, 0)
+	title := ""
+	var paragraph strings.Builder
+	var shapeLines []string
+	isTitle := false
+	var cells []string
+	var cell []string
+	inTable := false
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "sp":
+				shapeLines = nil
+				isTitle = false
+			case "ph":
+				placeholder := xmlAttr(element, "type")
+				isTitle = placeholder == "title" || placeholder == "ctrTitle"
+			case "p":
+				paragraph.Reset()
+			case "br":
+				paragraph.WriteString("\n")
+			case "t":
+				var text string
+				if err := decoder.DecodeElement(&text, &element); err == nil {
+					paragraph.WriteString(text)
+				}
+			case "tbl":
+				inTable = true
+			case "tr":
+				cells = nil
+			case "tc":
+				cell = nil
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				if text == "" {
+					continue
+				}
+				if inTable {
+					cell = append(cell, text)
+				} else {
+					shapeLines = append(shapeLines, text)
+				}
+			case "sp":
+				if isTitle && title == "" {
+					title = strings.Join(shapeLines, " ")
+					lines = append([]string{title}, lines...)
+				} else {
+					lines = append(lines, shapeLines...)
+				}
+			case "tc":
+				cells = append(cells, strings.Join(cell, " "))
+			case "tr":
+				lines = append(lines, strings.Join(cells, " | "))
+			case "tbl":
+				inTable = false
+			}
+		}
+	}
+	return title, strings.Join(lines, "\n"), nil
+}
+
+// coreTitle reads the dc:title from an Office core properties part
+func coreTitle(data []byte) string {
+	var core struct {
+		Title string `xml:"title"`
+	}
+	if err := xml.Unmarshal(data, &core); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(core.Title)
+}
+
+// xmlAttr returns the value of an attribute by local name
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
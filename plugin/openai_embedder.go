@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// OpenAI embedding models
+const (
+	OpenAITextEmbedding3Small = "text-embedding-3-small" // 1536 dimensions by default
+	OpenAITextEmbedding3Large = "text-embedding-3-large" // 3072 dimensions by default
+)
+
+// openAIMaxInputs is the most texts the embeddings endpoint accepts per request
+const openAIMaxInputs = 2048
+
+// OpenAIEmbedderConfig contains configuration for an OpenAI embedder
+type OpenAIEmbedderConfig struct {
+	Name       string        `json:"name,omitempty"`        // Embedder name registered as "openai/<name>" (default: the model)
+	Model      string        `json:"model"`                 // Embedding model (default: text-embedding-3-small)
+	Dimensions int           `json:"dimensions,omitempty"`  // Shortened embedding dimensions of text-embedding-3 models; 0 keeps the model's
+	APIKeyEnv  string        `json:"api_key_env,omitempty"` // Environment variable holding the API key (default: OPENAI_API_KEY)
+	BaseURL    string        `json:"base_url,omitempty"`    // API base URL, for proxies and compatible servers (default: https://api.openai.com/v1)
+	BatchSize  int           `json:"batch_size"`            // Texts per request, at most 2048 (default: 512)
+	Timeout    time.Duration `json:"timeout"`               // Timeout of each request (default: 60s)
+}
+
+// OpenAIEmbedder is a domain.Embedder calling the OpenAI embeddings API
+type OpenAIEmbedder struct {
+	client *http.Client
+	config OpenAIEmbedderConfig
+}
+
+// NewOpenAIEmbedder creates an OpenAI embedder
+func NewOpenAIEmbedder(config OpenAIEmbedderConfig) (*OpenAIEmbedder, error) {
+	if config.Model == "" {
+		config.Model = OpenAITextEmbedding3Small
+	}
+	if config.Name == "" {
+		config.Name = config.Model
+	}
+	if config.Dimensions < 0 {
+		return nil, fmt.Errorf("invalid embedding dimensions %d", config.Dimensions)
+	}
+	if config.Dimensions > 0 && !strings.HasPrefix(config.Model, "text-embedding-3") {
+		return nil, fmt.Errorf("model %s does not support shortened dimensions", config.Model)
+	}
+	if config.APIKeyEnv == "" {
+		config.APIKeyEnv = "OPENAI_API_KEY"
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.openai.com/v1"
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.BatchSize > openAIMaxInputs {
+		config.BatchSize = openAIMaxInputs
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 60 * time.Second
+	}
+	return &OpenAIEmbedder{client: &http.Client{Timeout: config.Timeout}, config: config}, nil
+}
+
+// Name returns the name the embedder is registered under after "openai/"
+func (e *OpenAIEmbedder) Name() string {
+	return e.config.Name
+}
+
+// Embed embeds the texts in requests of the configured batch size
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	apiKey := os.Getenv(e.config.APIKeyEnv)
+	if apiKey == "" {
+		return nil, fmt.Errorf("OpenAI API key not set in %s", e.config.APIKeyEnv)
+	}
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.config.BatchSize {
+		end := min(start+e.config.BatchSize, len(texts))
+		batch, err := e.embedBatch(ctx, apiKey, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+// embedBatch embeds texts with one request, ordering the embeddings by their input index
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, apiKey string, texts []string) ([][]float32, error) {
+	body := map[string]interface{}{
+		"model":           e.config.Model,
+		"input":           texts,
+		"encoding_format": "float",
+	}
+	if e.config.Dimensions > 0 {
+		body["dimensions"] = e.config.Dimensions
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embeddings request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.BaseURL+"/embeddings", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenAI embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAI embeddings response: status %d: %w", resp.StatusCode, err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("failed to embed texts with %s: %s", e.config.Model, response.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to embed texts with %s: status %d", e.config.Model, resp.StatusCode)
+	}
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("OpenAI returned %d embeddings for %d texts", len(response.Data), len(texts))
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("OpenAI returned an embedding for unknown input %d", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	return embeddings, nil
+}
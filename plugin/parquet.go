@@ -0,0 +1,374 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Parquet format constants used by the writer
+const (
+	parquetTypeInt64     = 2
+	parquetTypeFloat     = 4
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetRequired = 0
+	parquetRepeated = 2
+
+	parquetConvertedUTF8 = 0
+	parquetConvertedList = 3
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+)
+
+// parquetColumn is one column of a parquet file. Exactly one of the value slices is set; floatLists
+// columns are written as three-level LIST<float> columns and the others as required scalars.
+type parquetColumn struct {
+	name       string
+	strings    []string
+	int64s     []int64
+	doubles    []float64
+	floatLists [][]float32
+}
+
+// writeParquet writes the columns as an uncompressed, single row group parquet file with PLAIN
+// encoded values, readable by pyarrow, pandas, polars, DuckDB, and Spark
+func writeParquet(w io.Writer, columns []parquetColumn, numRows int) error {
+	var file bytes.Buffer
+	file.WriteString("PAR1")
+
+	chunks := make([]parquetColumnChunk, len(columns))
+	for i, column := range columns {
+		page, numValues, err := column.page(numRows)
+		if err != nil {
+			return err
+		}
+
+		header := newThriftWriter()
+		header.fieldI32(1, 0) // DATA_PAGE
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.fieldStruct(5, func(t *thriftWriter) {
+			t.fieldI32(1, int32(numValues))
+			t.fieldI32(2, parquetEncodingPlain)
+			t.fieldI32(3, parquetEncodingRLE)
+			t.fieldI32(4, parquetEncodingRLE)
+		})
+		header.stop()
+
+		offset := int64(file.Len())
+		file.Write(header.bytes())
+		file.Write(page)
+		chunks[i] = parquetColumnChunk{
+			column:    column,
+			offset:    offset,
+			size:      int64(file.Len()) - offset,
+			numValues: int64(numValues),
+		}
+	}
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.size
+	}
+
+	footer := newThriftWriter()
+	footer.fieldI32(1, 1)
+	footer.fieldList(2, thriftStruct, parquetSchemaLength(columns), func(t *thriftWriter) {
+		t.structElement(func(t *thriftWriter) {
+			t.fieldString(4, "schema")
+			t.fieldI32(5, int32(len(columns)))
+		})
+		for _, column := range columns {
+			column.writeSchema(t)
+		}
+	})
+	footer.fieldI64(3, int64(numRows))
+	footer.fieldList(4, thriftStruct, 1, func(t *thriftWriter) {
+		t.structElement(func(t *thriftWriter) {
+			t.fieldList(1, thriftStruct, len(chunks), func(t *thriftWriter) {
+				for _, chunk := range chunks {
+					chunk.write(t)
+				}
+			})
+			t.fieldI64(2, totalSize)
+			t.fieldI64(3, int64(numRows))
+		})
+	})
+	footer.fieldString(6, "genkit-agentic-rag")
+	footer.stop()
+
+	file.Write(footer.bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(len(footer.bytes())))
+	file.WriteString("PAR1")
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetColumnChunk locates a written column in the file
+type parquetColumnChunk struct {
+	column    parquetColumn
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// write encodes the column chunk and its metadata
+func (c parquetColumnChunk) write(t *thriftWriter) {
+	t.structElement(func(t *thriftWriter) {
+		t.fieldI64(2, c.offset)
+		t.fieldStruct(3, func(t *thriftWriter) {
+			t.fieldI32(1, c.column.physicalType())
+			t.fieldList(2, thriftI32, 2, func(t *thriftWriter) {
+				t.i32(parquetEncodingPlain)
+				t.i32(parquetEncodingRLE)
+			})
+			path := c.column.path()
+			t.fieldList(3, thriftBinary, len(path), func(t *thriftWriter) {
+				for _, name := range path {
+					t.string(name)
+				}
+			})
+			t.fieldI32(4, 0) // UNCOMPRESSED
+			t.fieldI64(5, c.numValues)
+			t.fieldI64(6, c.size)
+			t.fieldI64(7, c.size)
+			t.fieldI64(9, c.offset)
+		})
+	})
+}
+
+// physicalType returns the parquet type of the column's values
+func (c parquetColumn) physicalType() int32 {
+	switch {
+	case c.floatLists != nil:
+		return parquetTypeFloat
+	case c.int64s != nil:
+		return parquetTypeInt64
+	case c.doubles != nil:
+		return parquetTypeDouble
+	default:
+		return parquetTypeByteArray
+	}
+}
+
+// path returns the schema path of the column's leaf
+func (c parquetColumn) path() []string {
+	if c.floatLists != nil {
+		return []string{c.name, "list", "element"}
+	}
+	return []string{c.name}
+}
+
+// parquetSchemaLength counts the flattened schema elements, including the root
+func parquetSchemaLength(columns []parquetColumn) int {
+	length := 1
+	for _, column := range columns {
+		length += len(column.path())
+	}
+	return length
+}
+
+// writeSchema writes the column's schema elements
+func (c parquetColumn) writeSchema(t *thriftWriter) {
+	if c.floatLists != nil {
+		t.structElement(func(t *thriftWriter) {
+			t.fieldI32(3, parquetRequired)
+			t.fieldString(4, c.name)
+			t.fieldI32(5, 1)
+			t.fieldI32(6, parquetConvertedList)
+		})
+		t.structElement(func(t *thriftWriter) {
+			t.fieldI32(3, parquetRepeated)
+			t.fieldString(4, "list")
+			t.fieldI32(5, 1)
+		})
+		t.structElement(func(t *thriftWriter) {
+			t.fieldI32(1, parquetTypeFloat)
+			t.fieldI32(3, parquetRequired)
+			t.fieldString(4, "element")
+		})
+		return
+	}
+	t.structElement(func(t *thriftWriter) {
+		t.fieldI32(1, c.physicalType())
+		t.fieldI32(3, parquetRequired)
+		t.fieldString(4, c.name)
+		if c.strings != nil {
+			t.fieldI32(6, parquetConvertedUTF8)
+		}
+	})
+}
+
+// page encodes the column's data page body and returns it with its number of level entries
+func (c parquetColumn) page(numRows int) ([]byte, int, error) {
+	var page bytes.Buffer
+	switch {
+	case c.floatLists != nil:
+		if len(c.floatLists) != numRows {
+			return nil, 0, fmt.Errorf("column %s has %d rows, want %d", c.name, len(c.floatLists), numRows)
+		}
+		// Empty lists are one entry at definition level 0; each element is an entry at level 1,
+		// with repetition level 0 for the first element of a row and 1 for the rest
+		repetition := make([]byte, 0)
+		definition := make([]byte, 0)
+		var values bytes.Buffer
+		for _, list := range c.floatLists {
+			if len(list) == 0 {
+				repetition = append(repetition, 0)
+				definition = append(definition, 0)
+				continue
+			}
+			for j, value := range list {
+				repetition = append(repetition, byte(min(j, 1)))
+				definition = append(definition, 1)
+				binary.Write(&values, binary.LittleEndian, math.Float32bits(value))
+			}
+		}
+		writeParquetLevels(&page, repetition)
+		writeParquetLevels(&page, definition)
+		page.Write(values.Bytes())
+		return page.Bytes(), len(definition), nil
+	case c.int64s != nil:
+		if len(c.int64s) != numRows {
+			return nil, 0, fmt.Errorf("column %s has %d rows, want %d", c.name, len(c.int64s), numRows)
+		}
+		for _, value := range c.int64s {
+			binary.Write(&page, binary.LittleEndian, value)
+		}
+	case c.doubles != nil:
+		if len(c.doubles) != numRows {
+			return nil, 0, fmt.Errorf("column %s has %d rows, want %d", c.name, len(c.doubles), numRows)
+		}
+		for _, value := range c.doubles {
+			binary.Write(&page, binary.LittleEndian, math.Float64bits(value))
+		}
+	default:
+		if len(c.strings) != numRows {
+			return nil, 0, fmt.Errorf("column %s has %d rows, want %d", c.name, len(c.strings), numRows)
+		}
+		for _, value := range c.strings {
+			binary.Write(&page, binary.LittleEndian, uint32(len(value)))
+			page.WriteString(value)
+		}
+	}
+	return page.Bytes(), numRows, nil
+}
+
+// writeParquetLevels writes 1-bit levels as length-prefixed RLE runs
+func writeParquetLevels(page *bytes.Buffer, levels []byte) {
+	var encoded bytes.Buffer
+	for start := 0; start < len(levels); {
+		end := start
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		encoded.Write(binary.AppendUvarint(nil, uint64(end-start)<<1))
+		encoded.WriteByte(levels[start])
+		start = end
+	}
+	binary.Write(page, binary.LittleEndian, uint32(encoded.Len()))
+	page.Write(encoded.Bytes())
+}
+
+// Thrift compact protocol type IDs
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes parquet metadata structs in the Thrift compact protocol
+type thriftWriter struct {
+	buf       bytes.Buffer
+	lastField []int16 // Last field ID of each open struct
+}
+
+// newThriftWriter starts encoding a top-level struct
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{lastField: []int16{0}}
+}
+
+// bytes returns the encoded data
+func (t *thriftWriter) bytes() []byte {
+	return t.buf.Bytes()
+}
+
+// fieldHeader writes a field header; field IDs must increase within a struct
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := &t.lastField[len(t.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.buf.Write(binary.AppendVarint(nil, int64(id)))
+	}
+	*last = id
+}
+
+// i32 writes a zigzag varint
+func (t *thriftWriter) i32(value int32) {
+	t.buf.Write(binary.AppendVarint(nil, int64(value)))
+}
+
+// string writes a length-prefixed string
+func (t *thriftWriter) string(value string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(value))))
+	t.buf.WriteString(value)
+}
+
+// fieldI32 writes an i32 field
+func (t *thriftWriter) fieldI32(id int16, value int32) {
+	t.fieldHeader(id, thriftI32)
+	t.i32(value)
+}
+
+// fieldI64 writes an i64 field
+func (t *thriftWriter) fieldI64(id int16, value int64) {
+	t.fieldHeader(id, thriftI64)
+	t.buf.Write(binary.AppendVarint(nil, value))
+}
+
+// fieldString writes a binary field
+func (t *thriftWriter) fieldString(id int16, value string) {
+	t.fieldHeader(id, thriftBinary)
+	t.string(value)
+}
+
+// fieldStruct writes a struct field whose fields are written by body
+func (t *thriftWriter) fieldStruct(id int16, body func(*thriftWriter)) {
+	t.fieldHeader(id, thriftStruct)
+	t.structElement(body)
+}
+
+// fieldList writes a list field of size elements of elementType written by body
+func (t *thriftWriter) fieldList(id int16, elementType byte, size int, body func(*thriftWriter)) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elementType)
+	} else {
+		t.buf.WriteByte(0xF0 | elementType)
+		t.buf.Write(binary.AppendUvarint(nil, uint64(size)))
+	}
+	body(t)
+}
+
+// structElement writes a struct, as a list element or field value, whose fields are written by body
+func (t *thriftWriter) structElement(body func(*thriftWriter)) {
+	t.lastField = append(t.lastField, 0)
+	body(t)
+	t.stop()
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+// stop ends the current struct
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
@@ -0,0 +1,31 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// retrievalDeadline returns a context for loading sources that ends the configured reserve before
+// the request's deadline, so sources still loading then are abandoned and the answer is generated
+// from the rest. The reserve is capped at half the remaining time. Requests without a deadline are
+// not limited.
+func (p *AgenticRAGProcessor) retrievalDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	reserve := p.config.Processing.GenerationReserve
+	if reserve <= 0 {
+		reserve = 10 * time.Second
+	}
+	reserve = min(reserve, time.Until(deadline)/2)
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// timedOut reports whether err is the retrieval deadline passing while the request itself can
+// still be answered
+func timedOut(ctx, retrievalCtx context.Context, err error) bool {
+	return errors.Is(retrievalCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil &&
+		(errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled))
+}
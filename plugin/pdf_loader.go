@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfMagic is the header every PDF file starts with
+var pdfMagic = []byte("%PDF-")
+
+// PDFLoader extracts text from PDF files, emitting one document per page
+type PDFLoader struct {
+	maxBytes int64
+}
+
+// NewPDFLoader creates a PDF loader that refuses files larger than maxBytes
+func NewPDFLoader(maxBytes int64) *PDFLoader {
+	return &PDFLoader{maxBytes: maxBytes}
+}
+
+// CanLoad reports whether the source is a local file whose content is a PDF
+func (l *PDFLoader) CanLoad(source string) bool {
+	if strings.ContainsAny(source, "\n\x00") {
+		return false
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(pdfMagic))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, pdfMagic)
+}
+
+// Load reads a PDF file from disk
+func (l *PDFLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer file.Close()
+	return l.LoadReader(ctx, source, file)
+}
+
+// LoadReader reads a PDF from a byte stream
+func (l *PDFLoader) LoadReader(ctx context.Context, source string, r io.Reader) ([]Document, error) {
+	data, err := readLimited(r, l.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return l.LoadBytes(ctx, source, data)
+}
+
+// LoadBytes extracts the text of each page of an in-memory PDF
+func (l *PDFLoader) LoadBytes(ctx context.Context, source string, data []byte) ([]Document, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	title := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	pageCount := reader.NumPage()
+	docs := make([]Document, 0, pageCount)
+	for number := 1; number <= pageCount; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		page := reader.Page(number)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from page %d: %w", number, err)
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+
+		docs = append(docs, Document{
+			Content: text,
+			Source:  source,
+			Metadata: map[string]interface{}{
+				"title":        title,
+				"page":         number,
+				"page_count":   pageCount,
+				"content_type": "application/pdf",
+			},
+		})
+	}
+	return docs, nil
+}
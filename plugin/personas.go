@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Citation styles supported by personas
+const (
+	CitationStyleInline   = "inline"   // "According to Source N..." in the answer text
+	CitationStyleFootnote = "footnote" // Numbered [N] markers resolved at the end of the answer
+	CitationStyleNone     = "none"     // No citations in the answer text
+)
+
+// PersonaConfig bundles the prompt fragments and generation settings for an answer style
+type PersonaConfig struct {
+	Description     string   `json:"description"`
+	SystemPrompt    string   `json:"system_prompt"`              // Appended to the system instructions of the generation prompt
+	Temperature     float32  `json:"temperature,omitempty"`      // Used when the request does not set a temperature
+	FormattingRules []string `json:"formatting_rules,omitempty"` // Additional formatting instructions
+	CitationStyle   string   `json:"citation_style,omitempty"`   // One of the CitationStyle* constants (default: inline)
+}
+
+// DefaultPersonas returns the built-in answer style profiles
+func DefaultPersonas() map[string]PersonaConfig {
+	return map[string]PersonaConfig{
+		"technical_writer": {
+			Description:  "Precise, structured documentation-style answers",
+			SystemPrompt: "Write like an experienced technical writer: precise terminology, no marketing language, and explicit prerequisites or caveats.",
+			Temperature:  0.3,
+			FormattingRules: []string{
+				"Use Markdown headings and numbered steps for procedures",
+				"Put code, commands, and identifiers in code formatting",
+			},
+			CitationStyle: CitationStyleFootnote,
+		},
+		"support_agent": {
+			Description:  "Friendly, solution-oriented answers for end users",
+			SystemPrompt: "Respond like a helpful support agent: acknowledge the problem, give the most likely fix first, and keep jargon to a minimum.",
+			Temperature:  0.5,
+			FormattingRules: []string{
+				"Start with a one-sentence direct answer",
+				"Use short paragraphs or bullet points",
+				"When a source is a resolved ticket, base the fix on its resolution and name the ticket ID",
+			},
+			CitationStyle: CitationStyleInline,
+		},
+		"executive_summary": {
+			Description:  "Brief, decision-focused summaries",
+			SystemPrompt: "Write for a busy executive: lead with the conclusion, quantify impact where the sources allow, and omit implementation detail.",
+			Temperature:  0.2,
+			FormattingRules: []string{
+				"Keep the answer under 150 words",
+				"Use at most five bullet points",
+			},
+			CitationStyle: CitationStyleNone,
+		},
+	}
+}
+
+// resolvePersona looks up the persona selected for a request
+func (p *AgenticRAGProcessor) resolvePersona(name string) (*PersonaConfig, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	persona, ok := p.config.Personas[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown persona %q", name)
+	}
+	if persona.CitationStyle == "" {
+		persona.CitationStyle = CitationStyleInline
+	}
+	return &persona, nil
+}
+
+// promptInput converts the persona into template input for the generation prompt
+func (persona *PersonaConfig) promptInput() map[string]any {
+	return map[string]any{
+		"system_prompt":    persona.SystemPrompt,
+		"formatting_rules": persona.FormattingRules,
+		"citation_style":   persona.CitationStyle,
+	}
+}
+
+// citationInstruction returns the citation instruction for the persona's citation style
+func (persona *PersonaConfig) citationInstruction() string {
+	switch persona.CitationStyle {
+	case CitationStyleFootnote:
+		return `Cite sources with numbered markers like [1] after each supported statement, and list "[N] Source N" at the end`
+	case CitationStyleNone:
+		return "Do not include citations in the answer text"
+	default:
+		return `Cite which sources support your statements (e.g., "According to Source 1...")`
+	}
+}
+
+// instructions renders the persona as plain-text prompt instructions
+func (persona *PersonaConfig) instructions() string {
+	var builder strings.Builder
+	if persona.SystemPrompt != "" {
+		builder.WriteString(persona.SystemPrompt)
+		builder.WriteString("\n\n")
+	}
+	if len(persona.FormattingRules) > 0 {
+		builder.WriteString("Formatting rules:\n")
+		for _, rule := range persona.FormattingRules {
+			builder.WriteString("- ")
+			builder.WriteString(rule)
+			builder.WriteString("\n")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
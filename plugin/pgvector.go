@@ -0,0 +1,433 @@
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// Vector distances supported by PgVectorStore
+const (
+	PgVectorCosine = "cosine" // Cosine distance, the <=> operator (default)
+	PgVectorL2     = "l2"     // Euclidean distance, the <-> operator
+)
+
+// PgVectorConfig contains configuration for a PostgreSQL + pgvector vector store
+type PgVectorConfig struct {
+	Table      string `json:"table"`      // Records table (default: vector_records)
+	Dimensions int    `json:"dimensions"` // Embedding dimensions; required for the HNSW index, which is skipped when 0
+	Distance   string `json:"distance"`   // PgVectorCosine or PgVectorL2
+}
+
+// PgVectorStore stores records in a PostgreSQL table with a pgvector embedding column, opened
+// with any database/sql PostgreSQL driver (e.g. pgx's stdlib). Metadata is kept as JSONB and
+// filters are evaluated in the database as SQL/JSON path expressions.
+type PgVectorStore struct {
+	db     *sql.DB
+	config PgVectorConfig
+	schema *schemaInit
+	guard  *embeddingGuard
+}
+
+// pgIdentifier matches the table names PgVectorStore accepts, since they are interpolated into SQL
+var pgIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewPgVectorStore applies the store's pending schema migrations
+func NewPgVectorStore(ctx context.Context, db *sql.DB, config PgVectorConfig) (*PgVectorStore, error) {
+	if config.Table == "" {
+		config.Table = "vector_records"
+	}
+	if config.Distance == "" {
+		config.Distance = PgVectorCosine
+	}
+	if !pgIdentifier.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid pgvector table name %q", config.Table)
+	}
+	if config.Distance != PgVectorCosine && config.Distance != PgVectorL2 {
+		return nil, fmt.Errorf("unsupported pgvector distance %q", config.Distance)
+	}
+
+	store := &PgVectorStore{db: db, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+	if err := store.schema.ensure(ctx, store.migrate); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Stats returns the statistics of the database connection pool
+func (s *PgVectorStore) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// WithCollection returns a view of the store keeping the collection's records in their own
+// table, named after the base table and the collection and migrated on first use
+func (s *PgVectorStore) WithCollection(collection string) domain.VectorStore {
+	table, err := collectionTable(s.config.Table, collection)
+	if err != nil {
+		return invalidVectorStore{err: err}
+	}
+	config := s.config
+	config.Table = table
+	return &PgVectorStore{db: s.db, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+}
+
+// migrations returns the schema migrations in version order. Released migrations must never
+// change; schema changes are added as new migrations.
+func (s *PgVectorStore) migrations() []string {
+	column, operatorClass := "vector", "vector_cosine_ops"
+	if s.config.Dimensions > 0 {
+		column = fmt.Sprintf("vector(%d)", s.config.Dimensions)
+	}
+	if s.config.Distance == PgVectorL2 {
+		operatorClass = "vector_l2_ops"
+	}
+	index := "SELECT 1" // HNSW indexes need a fixed number of dimensions
+	if s.config.Dimensions > 0 {
+		index = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[1]s_embedding_idx ON %[1]s USING hnsw (embedding %[2]s)", s.config.Table, operatorClass)
+	}
+
+	return []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			embedding %s NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMPTZ
+		)`, s.config.Table, column),
+		index,
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[1]s_metadata_idx ON %[1]s USING gin (metadata jsonb_path_ops)", s.config.Table),
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ", s.config.Table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[1]s_expires_at_idx ON %[1]s (expires_at) WHERE expires_at IS NOT NULL", s.config.Table),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_binding (
+			singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+			embedder TEXT NOT NULL,
+			dimensions INTEGER NOT NULL
+		)`, s.config.Table),
+	}
+}
+
+// migrate applies each migration not yet recorded in the migrations table, one transaction per
+// migration, holding an advisory lock so concurrent processes migrate once
+func (s *PgVectorStore) migrate(ctx context.Context) error {
+	versions := s.config.Table + "_migrations"
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, versions)
+	if _, err := s.db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	for i, migration := range s.migrations() {
+		version := i + 1
+		if err := s.applyMigration(ctx, versions, version, migration); err != nil {
+			return fmt.Errorf("failed to apply pgvector migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a migration and records its version unless it was already applied
+func (s *PgVectorStore) applyMigration(ctx context.Context, versions string, version int, migration string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", versions); err != nil {
+		return err
+	}
+	var applied bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE version = $1)", versions)
+	if err := tx.QueryRowContext(ctx, query, version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version) VALUES ($1)", versions), version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BindEmbedding binds the table to the embedding binding unless it is already bound, keeping the
+// binding in a single-row table beside the records table
+func (s *PgVectorStore) BindEmbedding(ctx context.Context, binding domain.EmbeddingBinding) (domain.EmbeddingBinding, error) {
+	if err := s.schema.ensure(ctx, s.migrate); err != nil {
+		return domain.EmbeddingBinding{}, err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s_binding (embedder, dimensions) VALUES ($1, $2) ON CONFLICT DO NOTHING", s.config.Table)
+	if _, err := s.db.ExecContext(ctx, insert, binding.Embedder, binding.Dimensions); err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: %w", err)
+	}
+	bound, _, err := s.embeddingBinding(ctx)
+	return bound, err
+}
+
+// embeddingBinding returns the table's binding, if it is bound
+func (s *PgVectorStore) embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error) {
+	if err := s.schema.ensure(ctx, s.migrate); err != nil {
+		return domain.EmbeddingBinding{}, false, err
+	}
+	var bound domain.EmbeddingBinding
+	query := fmt.Sprintf("SELECT embedder, dimensions FROM %s_binding", s.config.Table)
+	err := s.db.QueryRowContext(ctx, query).Scan(&bound.Embedder, &bound.Dimensions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.EmbeddingBinding{}, false, nil
+	}
+	if err != nil {
+		return domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: %w", err)
+	}
+	return bound, true, nil
+}
+
+// Store inserts records, replacing existing records with the same ID
+func (s *PgVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if err := s.schema.ensure(ctx, s.migrate); err != nil {
+		return err
+	}
+	if err := s.guard.checkRecords(ctx, s, records); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, content, embedding, metadata, updated_at, expires_at) VALUES ($1, $2, $3::vector, $4::jsonb, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET content = excluded.content, embedding = excluded.embedding, metadata = excluded.metadata,
+		updated_at = excluded.updated_at, expires_at = excluded.expires_at`, s.config.Table)
+	statement, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer statement.Close()
+
+	for _, record := range records {
+		metadata := record.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of record %s: %w", record.ID, err)
+		}
+		var updatedAt sql.NullTime
+		if !record.UpdatedAt.IsZero() {
+			updatedAt = sql.NullTime{Time: record.UpdatedAt, Valid: true}
+		}
+		var expiresAt sql.NullTime
+		if !record.ExpiresAt.IsZero() {
+			expiresAt = sql.NullTime{Time: record.ExpiresAt, Valid: true}
+		}
+		if _, err := statement.ExecContext(ctx, record.ID, record.Content, vectorLiteral(record.Embedding), string(encoded), updatedAt, expiresAt); err != nil {
+			return fmt.Errorf("failed to store record %s: %w", record.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit records: %w", err)
+	}
+	return nil
+}
+
+// Search returns the k nearest records matching the filters. With the L2 distance the score is
+// the cosine similarity of normalized embeddings at that distance, 1 - d²/2.
+func (s *PgVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.schema.ensure(ctx, s.migrate); err != nil {
+		return nil, err
+	}
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	operator, score := "<=>", "1 - (embedding <=> $1::vector)"
+	if s.config.Distance == PgVectorL2 {
+		operator, score = "<->", "1 - power(embedding <-> $1::vector, 2) / 2"
+	}
+
+	args := []interface{}{vectorLiteral(embedding)}
+	conditions, err := pgFilterConditions(filters, &args)
+	if err != nil {
+		return nil, err
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, k)
+	query := fmt.Sprintf("SELECT id, content, metadata, updated_at, %s FROM %s %s ORDER BY embedding %s $1::vector LIMIT $%d",
+		score, s.config.Table, where, operator, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search records: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]domain.SearchResult, 0, k)
+	for rows.Next() {
+		var (
+			result    domain.SearchResult
+			metadata  []byte
+			updatedAt sql.NullTime
+		)
+		if err := rows.Scan(&result.Record.ID, &result.Record.Content, &metadata, &updatedAt, &result.Score); err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+		if err := json.Unmarshal(metadata, &result.Record.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of record %s: %w", result.Record.ID, err)
+		}
+		if updatedAt.Valid {
+			result.Record.UpdatedAt = updatedAt.Time
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs
+func (s *PgVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.migrate); err != nil {
+		return err
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", s.config.Table, strings.Join(placeholders, ", "))
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+	return nil
+}
+
+// Prune removes the records whose expiry has passed
+func (s *PgVectorStore) Prune(ctx context.Context) (int, error) {
+	if err := s.schema.ensure(ctx, s.migrate); err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE expires_at <= now()", s.config.Table))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune records: %w", err)
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pruned records: %w", err)
+	}
+	return int(pruned), nil
+}
+
+// vectorLiteral formats an embedding as the "[1,2,3]" text literal of pgvector and libSQL
+func vectorLiteral(embedding []float32) string {
+	var builder strings.Builder
+	builder.WriteByte('[')
+	for i, value := range embedding {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
+
+// pgFilterConditions translates filters into jsonb_path_exists conditions, appending their
+// arguments. Paths run in lax mode, so "[*]" matches list values element-wise and scalars as
+// themselves, matching domain.Filters semantics.
+func pgFilterConditions(filters domain.Filters, args *[]interface{}) ([]string, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	conditions := make([]string, 0, len(filters))
+	for key, want := range filters {
+		variables := make(map[string]interface{})
+		predicates := make([]string, 0)
+		bind := func(value interface{}) string {
+			name := "v" + strconv.Itoa(len(variables))
+			variables[name] = pgFilterValue(value)
+			return "$" + name
+		}
+
+		switch want := want.(type) {
+		case map[string]interface{}:
+			operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+			bounds := make([]string, 0, len(want))
+			for operator, bound := range want {
+				bounds = append(bounds, "@ "+operators[operator]+" "+bind(bound))
+			}
+			predicates = append(predicates, strings.Join(bounds, " && "))
+		default:
+			if options, ok := filterOptions(want); ok {
+				for _, option := range options {
+					predicates = append(predicates, "@ == "+bind(option))
+				}
+			} else {
+				predicates = append(predicates, "@ == "+bind(want))
+			}
+		}
+		if len(predicates) == 0 {
+			// An empty any-of list matches nothing
+			conditions = append(conditions, "FALSE")
+			continue
+		}
+
+		quotedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filter key %q: %w", key, err)
+		}
+		encoded, err := json.Marshal(variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filter %q: %w", key, err)
+		}
+		path := fmt.Sprintf("lax $.%s[*] ? (%s)", quotedKey, strings.Join(predicates, " || "))
+		*args = append(*args, path, string(encoded))
+		conditions = append(conditions, fmt.Sprintf("jsonb_path_exists(metadata, $%d::jsonpath, $%d::jsonb)", len(*args)-1, len(*args)))
+	}
+	return conditions, nil
+}
+
+// filterOptions returns the values of an any-of filter list
+func filterOptions(value interface{}) ([]interface{}, bool) {
+	switch value := value.(type) {
+	case []interface{}:
+		return value, true
+	case []string:
+		options := make([]interface{}, len(value))
+		for i, option := range value {
+			options[i] = option
+		}
+		return options, true
+	}
+	return nil, false
+}
+
+// pgFilterValue encodes times as the RFC 3339 strings domain.Filters compares them as
+func pgFilterValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return value
+}
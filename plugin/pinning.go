@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PinRule forces content into the generation context for matching queries
+type PinRule struct {
+	ID           string   `json:"id"`
+	DocumentID   string   `json:"document_id,omitempty"`   // Pin every chunk of this document when it is in the corpus
+	ChunkID      string   `json:"chunk_id,omitempty"`      // Pin a single chunk when it is in the corpus
+	Content      string   `json:"content,omitempty"`       // Static content pinned regardless of the corpus (e.g. a policy disclaimer)
+	QueryPattern string   `json:"query_pattern,omitempty"` // Regex the query must match; empty matches every query
+	Keywords     []string `json:"keywords,omitempty"`      // The query must contain at least one keyword; empty matches every query
+}
+
+// DocumentBoost statically scales the relevance of a document's chunks
+type DocumentBoost struct {
+	DocumentID    string  `json:"document_id,omitempty"`
+	SourcePattern string  `json:"source_pattern,omitempty"` // Glob on document source ("*" matches anything)
+	Factor        float64 `json:"factor"`                   // >1 boosts, <1 demotes
+}
+
+// boostMetadataKey is the document/chunk metadata key holding the static boost factor
+const boostMetadataKey = "boost"
+
+// RetrievalOverrides holds pins and static boosts applied on top of relevance scoring
+type RetrievalOverrides struct {
+	mu     sync.RWMutex
+	pins   map[string]PinRule
+	boosts map[string]DocumentBoost
+	nextID int
+}
+
+// NewRetrievalOverrides creates an empty set of retrieval overrides
+func NewRetrievalOverrides() *RetrievalOverrides {
+	return &RetrievalOverrides{
+		pins:   make(map[string]PinRule),
+		boosts: make(map[string]DocumentBoost),
+	}
+}
+
+// Pin registers a pin rule, returning its ID
+func (o *RetrievalOverrides) Pin(rule PinRule) (string, error) {
+	if rule.DocumentID == "" && rule.ChunkID == "" && rule.Content == "" {
+		return "", fmt.Errorf("pin rule requires a document ID, chunk ID, or content")
+	}
+	if rule.QueryPattern != "" {
+		if _, err := regexp.Compile(rule.QueryPattern); err != nil {
+			return "", fmt.Errorf("invalid query pattern: %w", err)
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if rule.ID == "" {
+		o.nextID++
+		rule.ID = fmt.Sprintf("pin_%d", o.nextID)
+	}
+	o.pins[rule.ID] = rule
+	return rule.ID, nil
+}
+
+// Unpin removes a pin rule
+func (o *RetrievalOverrides) Unpin(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.pins, id)
+}
+
+// Pins returns all registered pin rules
+func (o *RetrievalOverrides) Pins() []PinRule {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	pins := make([]PinRule, 0, len(o.pins))
+	for _, pin := range o.pins {
+		pins = append(pins, pin)
+	}
+	return pins
+}
+
+// SetBoost registers a static boost or demotion for a document ID or source pattern
+func (o *RetrievalOverrides) SetBoost(boost DocumentBoost) error {
+	if boost.DocumentID == "" && boost.SourcePattern == "" {
+		return fmt.Errorf("document boost requires a document ID or source pattern")
+	}
+	if boost.Factor < 0 {
+		return fmt.Errorf("boost factor must not be negative")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.boosts[boostKey(boost)] = boost
+	return nil
+}
+
+// ClearBoost removes the boost registered for a document ID or source pattern
+func (o *RetrievalOverrides) ClearBoost(documentID, sourcePattern string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.boosts, boostKey(DocumentBoost{DocumentID: documentID, SourcePattern: sourcePattern}))
+}
+
+// boostFor returns the combined boost factor of every rule matching the document
+func (o *RetrievalOverrides) boostFor(doc Document) float64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	factor := 1.0
+	for _, boost := range o.boosts {
+		if (boost.DocumentID != "" && boost.DocumentID == doc.ID) ||
+			(boost.SourcePattern != "" && globMatch(boost.SourcePattern, doc.Source)) {
+			factor *= boost.Factor
+		}
+	}
+	return factor
+}
+
+// matchingPins returns the pin rules that apply to the query
+func (o *RetrievalOverrides) matchingPins(query string) []PinRule {
+	queryLower := strings.ToLower(query)
+	matching := make([]PinRule, 0)
+	for _, pin := range o.Pins() {
+		if pin.QueryPattern != "" {
+			if matched, err := regexp.MatchString(pin.QueryPattern, query); err != nil || !matched {
+				continue
+			}
+		}
+		if len(pin.Keywords) > 0 {
+			found := false
+			for _, keyword := range pin.Keywords {
+				if strings.Contains(queryLower, strings.ToLower(keyword)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		matching = append(matching, pin)
+	}
+	return matching
+}
+
+// boostKey identifies a boost by its selector
+func boostKey(boost DocumentBoost) string {
+	return boost.DocumentID + "|" + boost.SourcePattern
+}
+
+// applyPins appends pinned chunks for the query after the selected chunks, skipping duplicates.
+// Appending keeps the source numbering referenced by earlier generation notes stable.
+func (p *AgenticRAGProcessor) applyPins(query string, selected, corpus []DocumentChunk) ([]DocumentChunk, int) {
+	if p.config.Overrides == nil {
+		return selected, 0
+	}
+	pins := p.config.Overrides.matchingPins(query)
+	if len(pins) == 0 {
+		return selected, 0
+	}
+
+	present := make(map[string]bool, len(selected))
+	for _, chunk := range selected {
+		present[chunk.ID] = true
+	}
+
+	pinned := make([]DocumentChunk, 0)
+	addPinned := func(chunk DocumentChunk) {
+		if present[chunk.ID] {
+			return
+		}
+		present[chunk.ID] = true
+		metadata := make(map[string]interface{}, len(chunk.Metadata)+1)
+		for key, value := range chunk.Metadata {
+			metadata[key] = value
+		}
+		metadata["pinned"] = true
+		chunk.Metadata = metadata
+		pinned = append(pinned, chunk)
+	}
+
+	for _, pin := range pins {
+		if pin.Content != "" {
+			addPinned(DocumentChunk{
+				ID:             pin.ID,
+				Content:        pin.Content,
+				DocumentID:     pin.ID,
+				EndIndex:       len(pin.Content),
+				RelevanceScore: 1.0,
+			})
+		}
+		for _, chunk := range corpus {
+			if (pin.DocumentID != "" && chunk.DocumentID == pin.DocumentID) || (pin.ChunkID != "" && chunk.ID == pin.ChunkID) {
+				addPinned(chunk)
+			}
+		}
+	}
+
+	return append(selected, pinned...), len(pinned)
+}
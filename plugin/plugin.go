@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	v1 "github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/api/v1"
+
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 )
@@ -12,8 +14,9 @@ const PluginID = "agentic-rag"
 
 // AgenticRAGPlugin represents the GenKit plugin for agentic RAG
 type AgenticRAGPlugin struct {
-	processor *AgenticRAGProcessor
-	config    *AgenticRAGConfig
+	processor  *AgenticRAGProcessor
+	config     *AgenticRAGConfig
+	mcpClients []*MCPClient
 }
 
 // NewPlugin creates a new agentic RAG plugin
@@ -43,6 +46,11 @@ func (p *AgenticRAGPlugin) Init(ctx context.Context, g *genkit.Genkit) error {
 		return fmt.Errorf("failed to initialize prompts: %w", err)
 	}
 
+	// Register configured embedders so they can be selected by name like GenKit's own
+	if err := p.registerEmbedders(ctx, g); err != nil {
+		return fmt.Errorf("failed to register embedders: %w", err)
+	}
+
 	// Register the main agentic RAG flow
 	if err := p.registerFlows(ctx, g); err != nil {
 		return fmt.Errorf("failed to register flows: %w", err)
@@ -53,30 +61,23 @@ func (p *AgenticRAGPlugin) Init(ctx context.Context, g *genkit.Genkit) error {
 		return fmt.Errorf("failed to register tools: %w", err)
 	}
 
+	// Import tools from external MCP servers
+	if err := p.importMCPTools(ctx, g); err != nil {
+		return fmt.Errorf("failed to import MCP tools: %w", err)
+	}
+
 	return nil
 }
 
 // registerFlows registers the agentic RAG flows
 func (p *AgenticRAGPlugin) registerFlows(ctx context.Context, g *genkit.Genkit) error {
-	// Main agentic RAG streaming flow using correct GenKit Go API
+	// Main agentic RAG streaming flow; answer tokens carry sequence numbers and a resume token
+	// so a client that disconnects can continue the answer instead of restarting the pipeline
 	genkit.DefineStreamingFlow(
 		g,
 		"agenticRAG",
-		func(ctx context.Context, input AgenticRAGRequest, cb func(context.Context, *AgenticRAGResponse) error) (*AgenticRAGResponse, error) {
-			// Use the processor to handle the full agentic RAG pipeline
-			response, err := p.processor.Process(ctx, input)
-			if err != nil {
-				return nil, err
-			}
-
-			// If streaming callback is provided, stream the response
-			if cb != nil {
-				if err := cb(ctx, response); err != nil {
-					return nil, err
-				}
-			}
-
-			return response, nil
+		func(ctx context.Context, input AgenticRAGRequest, cb func(context.Context, AnswerStreamChunk) error) (*AgenticRAGResponse, error) {
+			return p.processor.ProcessStream(ctx, input, cb)
 		},
 	)
 
@@ -85,14 +86,64 @@ func (p *AgenticRAGPlugin) registerFlows(ctx context.Context, g *genkit.Genkit)
 		return p.processor.Process(ctx, input)
 	})
 
+	// Continue a checkpointed request after a restart
+	genkit.DefineFlow(g, "resumeAgenticRAG", func(ctx context.Context, checkpointID string) (*AgenticRAGResponse, error) {
+		return p.processor.Resume(ctx, checkpointID)
+	})
+
+	// User ratings of canary-served answers, used to decide on rollback
+	genkit.DefineFlow(g, "canaryFeedback", func(ctx context.Context, input CanaryFeedback) (CanaryStatus, error) {
+		if err := p.processor.RecordCanaryFeedback(input); err != nil {
+			return CanaryStatus{}, err
+		}
+		return p.processor.CanaryStatus()
+	})
+
+	// Versioned flow whose payloads follow the v1 compatibility guarantees
+	genkit.DefineFlow(g, "agenticRAGV1", func(ctx context.Context, input v1.Request) (*v1.Response, error) {
+		return p.processor.ProcessV1(ctx, input)
+	})
+
+	// Dependency-ordered execution of the registered tools
+	genkit.DefineFlow(g, "toolChain", func(ctx context.Context, input ToolChainRequest) (*ToolChainResponse, error) {
+		return p.processor.ExecuteToolChain(ctx, input)
+	})
+
+	// JSON Schemas of the registered tools for clients and models
+	genkit.DefineFlow(g, "toolSchemas", func(ctx context.Context, names []string) ([]ToolSchema, error) {
+		return p.processor.ToolSchemas(names...)
+	})
+
+	// Related documents for showing "more like this" next to answers
+	genkit.DefineFlow(g, "findSimilar", func(ctx context.Context, input SimilarDocumentsRequest) ([]SimilarDocument, error) {
+		if input.DocumentID != "" {
+			return p.processor.FindSimilar(ctx, input.DocumentID, input.K)
+		}
+		return p.processor.MoreLikeText(ctx, input.Text, input.K)
+	})
+
+	// Chronological, cited events about an entity or topic, e.g. for incident reviews
+	genkit.DefineFlow(g, "buildTimeline", func(ctx context.Context, input TimelineRequest) (*Timeline, error) {
+		return p.processor.BuildTimeline(ctx, input.Subject)
+	})
+
+	// Long-form cited report following an outline, streamed section by section
+	genkit.DefineStreamingFlow(
+		g,
+		"generateReport",
+		func(ctx context.Context, input ReportRequest, cb func(context.Context, ReportProgress) error) (*Report, error) {
+			return p.processor.GenerateReportStream(ctx, input.Outline, input.StoreName, cb)
+		},
+	)
+
 	return nil
 }
 
 // registerTools registers helper tools
 func (p *AgenticRAGPlugin) registerTools(ctx context.Context, g *genkit.Genkit) error {
 	// Document chunking tool
-	genkit.DefineTool(
-		g,
+	defineTool(
+		p, g,
 		"chunkDocument",
 		"Chunks a document into smaller pieces respecting sentence boundaries",
 		func(ctx *ai.ToolContext, input ChunkDocumentRequest) (ChunkDocumentResponse, error) {
@@ -116,8 +167,8 @@ func (p *AgenticRAGPlugin) registerTools(ctx context.Context, g *genkit.Genkit)
 	)
 
 	// Relevance scoring tool
-	genkit.DefineTool(
-		g,
+	defineTool(
+		p, g,
 		"scoreRelevance",
 		"Scores the relevance of text chunks against a query",
 		func(ctx *ai.ToolContext, input RelevanceScoreRequest) (RelevanceScoreResponse, error) {
@@ -140,8 +191,8 @@ func (p *AgenticRAGPlugin) registerTools(ctx context.Context, g *genkit.Genkit)
 
 	// Knowledge graph extraction tool
 	if p.config.KnowledgeGraph.Enabled {
-		genkit.DefineTool(
-			g,
+		defineTool(
+			p, g,
 			"extractKnowledgeGraph",
 			"Extracts entities and relations to build a knowledge graph",
 			func(ctx *ai.ToolContext, input KnowledgeGraphRequest) (KnowledgeGraphResponse, error) {
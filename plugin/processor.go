@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/concurrency"
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 )
@@ -17,6 +19,32 @@ import (
 // AgenticRAGProcessor implements the core agentic RAG flow
 type AgenticRAGProcessor struct {
 	config *AgenticRAGConfig
+
+	analyzersMu sync.Mutex
+	analyzers   map[string]*languageAnalyzer
+
+	admission *admissionController
+	loaders   []DocumentLoader
+	canary    *canaryController
+	streams   *streamRegistry
+
+	healthBaseline healthBaseline
+	embeddings     embeddingCache
+
+	answersOnce sync.Once
+	answers     AnswerCache
+
+	faqOnce sync.Once
+	faq     map[string]*FAQEntry
+
+	vectorStoresMu sync.Mutex
+	vectorStores   map[string]domain.VectorStore // Named stores opened from configuration
+
+	toolsMu sync.Mutex
+	tools   []string
+
+	auditMu        sync.Mutex
+	lastAuditPrune time.Time
 }
 
 // NewAgenticRAGProcessor creates a new processor with the given configuration
@@ -24,9 +52,18 @@ func NewAgenticRAGProcessor(config *AgenticRAGConfig) *AgenticRAGProcessor {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	return &AgenticRAGProcessor{
-		config: config,
+	p := &AgenticRAGProcessor{
+		config:       config,
+		analyzers:    make(map[string]*languageAnalyzer),
+		vectorStores: make(map[string]domain.VectorStore),
+		admission:    newAdmissionController(config.Admission, config.Metrics),
+		streams:      newStreamRegistry(config.Streaming),
+	}
+	p.loaders = defaultLoaders(config.Loading, NewTranscriptionLoader(p))
+	if config.Canary.Enabled && config.Canary.Candidate != nil {
+		p.canary = newCanaryController(config.Canary, p)
 	}
+	return p
 }
 
 // DefaultConfig returns a default configuration
@@ -34,10 +71,58 @@ func DefaultConfig() *AgenticRAGConfig {
 	return &AgenticRAGConfig{
 		ModelName: "googleai/gemini-2.5-flash", // Default model name - DO NOT CHANGE
 		Processing: ProcessingConfig{
-			DefaultChunkSize:      1000,
+			DefaultChunkSize:      256,
 			DefaultMaxChunks:      20,
 			DefaultRecursiveDepth: 3,
 			RespectSentences:      true,
+			ChunkUnit:             ChunkUnitTokens,
+			Chunker:               ChunkerMarkdown,
+			Separators:            defaultRecursiveSeparators,
+			Retrieval:             RetrievalStandard,
+			ContextWindow:         32768,
+			Concurrency:           4,
+			EmbeddingBatchSize:    100,
+			EmbeddingCacheSize:    10000,
+			GenerationReserve:     10 * time.Second,
+			Reranking: RerankingConfig{
+				TopN: 20,
+			},
+			Scoring: ScoringConfig{
+				Weights:         defaultScoreWeights,
+				RecencyHalfLife: 365 * 24 * time.Hour,
+			},
+		},
+		VectorRetrieval: VectorRetrievalConfig{
+			TopK: 20,
+		},
+		Degradation: DegradationConfig{
+			Policies: map[string]string{
+				SubsystemVectorStore:      DegradeFallback,
+				SubsystemKnowledgeGraph:   DegradeSkip,
+				SubsystemFactVerification: DegradeSkip,
+				SubsystemReranker:         DegradeSkip,
+			},
+		},
+		HybridSearch: HybridSearchConfig{
+			RRFK: 60,
+			K1:   1.2,
+			B:    0.75,
+			LexicalGate: LexicalGateConfig{
+				MinCandidates: 200,
+			},
+		},
+		Analysis: AnalysisConfig{
+			DetectLanguage:  true,
+			DefaultLanguage: "en",
+			Languages:       make(map[string]LanguageAnalyzerConfig),
+		},
+		QueryNormalization: QueryNormalizationConfig{
+			Enabled:              true,
+			UnicodeNormalization: true,
+			SpellCorrection:      true,
+			MaxEditDistance:      2,
+			MinWordLength:        4,
+			Glossary:             make(map[string]string),
 		},
 		KnowledgeGraph: KnowledgeGraphConfig{
 			Enabled:                true,
@@ -50,12 +135,166 @@ func DefaultConfig() *AgenticRAGConfig {
 			RequireEvidence:    true,
 			MinConfidenceScore: 0.7,
 		},
+		Freshness: FreshnessConfig{
+			Enabled:             true,
+			PreferNewer:         true,
+			DateMetadataKeys:    []string{"updated_at", "published_at", "date"},
+			SimilarityThreshold: 0.3,
+			SupersededPenalty:   0.5,
+			UseLLM:              true,
+		},
+		Routing: RoutingConfig{
+			Enabled:       true,
+			UseLLM:        false,
+			MinConfidence: 0.8,
+		},
+		CollectionRouting: CollectionRoutingConfig{
+			Enabled:         true,
+			UseLLM:          true,
+			FanOutThreshold: 0.3,
+			MaxCollections:  3,
+		},
+		Credibility: CredibilityConfig{
+			Enabled:       true,
+			DefaultWeight: 1.0,
+			SourceWeights: make(map[string]float64),
+		},
+		CitationVerification: CitationVerificationConfig{
+			Enabled:         true,
+			UseLLM:          true,
+			MinSupportScore: 0.5,
+			Action:          CitationActionFlag,
+		},
+		FewShot: FewShotConfig{
+			Enabled:       true,
+			MaxExamples:   3,
+			MaxTokens:     1000,
+			MinSimilarity: 0.3,
+		},
+		Admission: AdmissionConfig{
+			Enabled:            false,
+			MaxConcurrent:      16,
+			MaxQueueDepth:      64,
+			BatchShedThreshold: 0.5,
+			DegradeThreshold:   0.75,
+			QueueTimeout:       30 * time.Second,
+			DegradeProfile:     ProfileFast,
+		},
+		Loading: LoaderConfig{
+			HTTPTimeout:    30 * time.Second,
+			MaxBytes:       10 << 20,
+			UserAgent:      "genkit-agentic-rag",
+			IgnorePatterns: []string{".git", "node_modules", ".DS_Store"},
+			MaxFiles:       1000,
+			Crawl: CrawlConfig{
+				MaxDepth:      2,
+				MaxPages:      50,
+				SameHost:      true,
+				RespectRobots: true,
+				Delay:         500 * time.Millisecond,
+			},
+			Structured: StructuredDataConfig{
+				MaxRows: 10000,
+			},
+			JSON: JSONMappingConfig{
+				MaxDocuments: 10000,
+			},
+			Transcription: TranscriptionConfig{
+				Enabled:  true,
+				MaxBytes: 20 << 20,
+			},
+			Email: EmailConfig{
+				MaxMessages: 1000,
+			},
+			Atlassian: AtlassianConfig{
+				TokenEnv: "ATLASSIAN_API_TOKEN",
+				MaxItems: 5000,
+			},
+			Tickets: TicketConfig{
+				ZendeskTokenEnv:  "ZENDESK_API_TOKEN",
+				IntercomTokenEnv: "INTERCOM_ACCESS_TOKEN",
+				MaxTickets:       5000,
+			},
+		},
+		ToolSandbox: ToolSandboxConfig{
+			Enabled: true,
+			Default: ToolLimits{
+				Timeout:        30 * time.Second,
+				MaxOutputBytes: 1 << 20,
+			},
+			Tools: make(map[string]ToolLimits),
+		},
+		Canary: CanaryConfig{
+			Percent:              5,
+			MinSamples:           50,
+			MaxConfidenceDrop:    0.1,
+			MaxGroundednessDrop:  0.1,
+			MaxFeedbackDrop:      0.15,
+			MaxErrorRateIncrease: 0.05,
+		},
+		Streaming: StreamingConfig{
+			ResumeWindow: 5 * time.Minute,
+			MaxStreams:   1000,
+			MaxDuration:  10 * time.Minute,
+		},
+		TableChunking: TableChunkingConfig{
+			Enabled: true,
+		},
+		Hierarchical: HierarchicalChunkingConfig{
+			ParentChunkSize: 1024,
+		},
+		SemanticChunking: SemanticChunkingConfig{
+			Enabled:    false,
+			WindowSize: 2,
+			Threshold:  0.75,
+			BatchSize:  64,
+		},
+		GenerationTools: GenerationToolsConfig{
+			Enabled:  false,
+			MaxTurns: 5,
+		},
+		Licensing: LicensingConfig{
+			Enabled:               true,
+			SourceLicenses:        make(map[string]string),
+			NonCommercialLicenses: []string{"*-NC*", "*NONCOMMERCIAL*", "*NON-COMMERCIAL*"},
+		},
+		SecretScanning: SecretScanningConfig{
+			Enabled:          true,
+			Action:           SecretActionRedact,
+			EntropyThreshold: 4.5,
+			MinEntropyLength: 32,
+		},
+		HealthCheck: HealthCheckConfig{
+			Enabled:              false,
+			Interval:             24 * time.Hour,
+			SampleSize:           50,
+			TopK:                 3,
+			UseLLM:               true,
+			MinHitRate:           0.7,
+			DriftThreshold:       0.05,
+			NotifyOnlyOnProblems: true,
+		},
+		Audit: AuditConfig{
+			Enabled:     false,
+			Retention:   90 * 24 * time.Hour,
+			FailOnError: true,
+		},
+		ExampleBank: NewExampleBank(),
+		Metrics:     NewMetrics(),
+		MetricsExport: MetricsConfig{
+			Namespace: "agentic_rag",
+		},
+		Sessions: NewSessionStore(),
+		Loaders:  domain.NewLoaderRegistry(),
+		Personas: DefaultPersonas(),
+		Profiles: DefaultProfiles(),
 		Prompts: PromptsConfig{
 			Directory:                 "./prompts",
 			RelevanceScoringPrompt:    "relevance_scoring",
 			ResponseGenerationPrompt:  "response_generation",
 			KnowledgeExtractionPrompt: "knowledge_extraction",
 			FactVerificationPrompt:    "fact_verification",
+			RerankingPrompt:           "reranking",
 			Variants:                  make(map[string]string),
 			CustomHelpers:             true,
 		},
@@ -110,51 +349,316 @@ func (p *AgenticRAGProcessor) initializePrompts(ctx context.Context) error {
 	return nil
 }
 
-// Process executes the agentic RAG flow according to the specification
+// Process executes the agentic RAG flow according to the specification and records it in the audit log
 func (p *AgenticRAGProcessor) Process(ctx context.Context, request AgenticRAGRequest) (*AgenticRAGResponse, error) {
 	startTime := time.Now()
+	ctx = p.withMetricLabels(ctx, request)
+
+	// Route a share of traffic to the canary candidate configuration
+	var response *AgenticRAGResponse
+	var err error
+	if p.canary != nil {
+		response, err = p.canary.process(ctx, request)
+	} else {
+		response, err = p.serve(ctx, request)
+	}
+	p.observeRequest(ctx, request, response, err, startTime)
+	return response, err
+}
+
+// serve admits, runs, and audits a request with this processor's configuration
+func (p *AgenticRAGProcessor) serve(ctx context.Context, request AgenticRAGRequest) (*AgenticRAGResponse, error) {
+	startTime := time.Now()
+
+	// Answer questions covered by the reviewed FAQ without running the pipeline
+	if response := p.faqAnswer(ctx, request, startTime); response != nil {
+		p.audit(ctx, request, response, nil, startTime)
+		return response, nil
+	}
+
+	// Serve repeated requests from the answer cache without running the pipeline
+	cacheKey, cacheable := p.answerCacheKey(ctx, request)
+	if cacheable {
+		if response := p.cachedAnswer(ctx, cacheKey); response != nil {
+			p.audit(ctx, request, response, nil, startTime)
+			return response, nil
+		}
+	}
+
+	// Admit the request, shedding or degrading it under load
+	degraded := false
+	if p.config.Admission.Enabled {
+		release, degrade, err := p.admission.acquire(ctx, request.Options.Priority)
+		if err != nil {
+			p.audit(ctx, request, nil, err, startTime)
+			return nil, err
+		}
+		defer release()
+		if degrade {
+			degraded = true
+			request.Options = p.degradeOptions(request.Options)
+			p.config.Metrics.IncCounter("agentic_rag_requests_degraded_total", 1)
+		}
+	}
+
+	// Route model calls to a provider that satisfies the tenant's data residency rules
+	var response *AgenticRAGResponse
+	ctx, err := p.withTenantEndpoint(ctx, request.TenantID)
+	if err == nil {
+		response, err = p.process(ctx, request, startTime)
+	}
+	if response != nil {
+		response.ProcessingMetadata.Degraded = degraded
+		p.recordSession(request, response)
+		if cacheable && !degraded && len(response.ProcessingMetadata.Degradations) == 0 && !response.ProcessingMetadata.Partial {
+			p.cacheAnswer(ctx, cacheKey, response)
+		}
+	}
+	if auditErr := p.audit(ctx, request, response, err, startTime); auditErr != nil && err == nil && p.config.Audit.FailOnError {
+		return nil, auditErr
+	}
+	return response, err
+}
+
+// process runs the pipeline stages for a request
+func (p *AgenticRAGProcessor) process(ctx context.Context, request AgenticRAGRequest, startTime time.Time) (*AgenticRAGResponse, error) {
+	ctx, degradations := withDegradationRecorder(ctx)
+
+	// Resume from the request's checkpoint, if it was saved for the same tenant and query
+	state, err := p.loadCheckpoint(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the pipeline profile; residency routing takes precedence over its model tier
+	profile, err := p.resolveProfile(request.Options.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		request.Options = profile.apply(request.Options, false)
+		if endpointFromContext(ctx) == nil {
+			ctx = withEndpoint(ctx, profile.endpoint(request.Options.Profile))
+		}
+	}
 
 	// Set default options
+	if request.Options.ChunkSize == 0 {
+		request.Options.ChunkSize = p.config.Processing.DefaultChunkSize
+	}
 	if request.Options.MaxChunks == 0 {
 		request.Options.MaxChunks = p.config.Processing.DefaultMaxChunks
 	}
 	if request.Options.RecursiveDepth == 0 {
 		request.Options.RecursiveDepth = p.config.Processing.DefaultRecursiveDepth
 	}
+
+	// Resolve the answer persona, which may supply the default temperature
+	persona, err := p.resolvePersona(request.Options.Persona)
+	if err != nil {
+		return nil, err
+	}
+	if request.Options.Temperature == 0 && persona != nil {
+		request.Options.Temperature = persona.Temperature
+	}
 	if request.Options.Temperature == 0 {
 		request.Options.Temperature = 0.7 // Default temperature
 	}
+	if request.Options.Retrieval == "" {
+		request.Options.Retrieval = p.config.Processing.Retrieval
+	}
+	if request.Options.Retrieval != "" && !retrievalModes[request.Options.Retrieval] {
+		return nil, fmt.Errorf("unsupported retrieval mode %q", request.Options.Retrieval)
+	}
+	if err := request.Filters.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+	if lambda := request.Options.MMRLambda; lambda != nil && (*lambda < 0 || *lambda > 1) {
+		return nil, fmt.Errorf("mmr_lambda must be between 0 and 1, got %g", *lambda)
+	}
+	if request.Options.OutputFormat != "" {
+		if _, ok := answerRenderers[request.Options.OutputFormat]; !ok {
+			return nil, fmt.Errorf("unsupported output format %q", request.Options.OutputFormat)
+		}
+	}
 
-	// Step 1: Load documents into context window
-	documents, err := p.loadDocuments(ctx, request.Documents)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load documents: %w", err)
+	state.Request = request
+
+	// Skip the pipeline for queries that do not need retrieval
+	if p.config.Routing.Enabled && !state.reached(StageLoaded) {
+		state.Routing = p.routeQuery(ctx, request.Query)
+		if state.Routing.Route == RouteDirect && state.Routing.Confidence >= p.config.Routing.MinConfidence {
+			return p.answerDirectly(ctx, request, persona, state.Routing, startTime)
+		}
 	}
 
+	if !state.reached(StageLoaded) {
+		// Route to named collections when the request selects them or supplies no documents
+		sources := request.Documents
+		if len(request.Options.Collections) > 0 || (len(sources) == 0 && p.config.CollectionRouting.Enabled && len(p.config.Collections) > 0) {
+			collectionSources, decision, err := p.collectionSources(ctx, request.Query, request.Options.Collections)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(append([]string{}, sources...), collectionSources...)
+			state.CollectionRouting = decision
+		}
+
+		// Step 1: Load documents into context window
+		documents, timedOutSources, err := p.loadDocuments(ctx, sources)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load documents: %w", err)
+		}
+		state.TimedOutSources = timedOutSources
+
+		// Keep leaked secrets out of chunks and prompts
+		documents, state.SecretFindings, err = p.scanDocumentsForSecrets(documents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan documents for secrets: %w", err)
+		}
+
+		// Exclude "never cite" content from retrieval and citation
+		documents, excludedDocuments := p.filterBlockedDocuments(documents, request.Options.Blocklist)
+
+		// Exclude content whose license does not permit the requested use
+		documents, unlicensedDocuments := p.filterLicensedDocuments(documents, request.Options.CommercialUse)
+		excludedDocuments += unlicensedDocuments
+
+		// Restrict retrieval to documents whose metadata matches the request filter
+		documents, unmatchedDocuments := filterDocumentsByMetadata(documents, request.Options.MetadataFilter)
+		state.ExcludedDocuments = excludedDocuments + unmatchedDocuments
+		state.Documents = documents
+
+		// Normalize the query against the corpus vocabulary before retrieval
+		state.QueryNormalization = p.normalizeQuery(request.Query, documents)
+		if err := p.saveCheckpoint(ctx, state, StageLoaded); err != nil {
+			return nil, err
+		}
+	}
+	documents := state.Documents
+	query := state.QueryNormalization.NormalizedQuery
+
 	// Step 2: Chunk documents into initial chunks (respecting sentence boundaries)
-	allChunks := make([]DocumentChunk, 0)
-	for _, doc := range documents {
-		chunks, err := p.chunkDocument(ctx, doc, request.Options.MaxChunks)
+	if !state.reached(StageChunked) && request.Options.Retrieval == RetrievalSmallToBig {
+		// Small-to-big retrieval scores small children and keeps their large parents for generation
+		hierarchy, err := p.buildChunkHierarchy(ctx, request.Options.Chunker, documents, request.Options.ChunkSize, request.Options.MaxChunks)
 		if err != nil {
-			return nil, fmt.Errorf("failed to chunk document %s: %w", doc.ID, err)
+			return nil, err
+		}
+		state.Chunks, state.ParentChunks = hierarchy.Children, hierarchy.Parents
+		if err := p.saveCheckpoint(ctx, state, StageChunked); err != nil {
+			return nil, err
 		}
-		allChunks = append(allChunks, chunks...)
 	}
+	if !state.reached(StageChunked) {
+		state.Chunks = make([]DocumentChunk, 0)
+		for _, doc := range documents {
+			chunks, err := p.chunkDocumentWith(ctx, request.Options.Chunker, doc, request.Options.ChunkSize, request.Options.MaxChunks)
+			if err != nil {
+				return nil, fmt.Errorf("failed to chunk document %s: %w", doc.ID, err)
+			}
+			state.Chunks = append(state.Chunks, chunks...)
+		}
+		if err := p.saveCheckpoint(ctx, state, StageChunked); err != nil {
+			return nil, err
+		}
+	}
+	allChunks := state.Chunks
 
-	// Step 3: Prompt model to identify relevant chunks
-	relevantChunks, err := p.identifyRelevantChunks(ctx, request.Query, allChunks)
-	if err != nil {
-		return nil, fmt.Errorf("failed to identify relevant chunks: %w", err)
+	// Answer queries about a known knowledge graph entity from its subgraph and the chunks
+	// mentioning it instead of generic retrieval
+	var card *EntityCard
+	if request.Options.EntityMode && !state.reached(StageScored) {
+		var sources []DocumentChunk
+		card, sources, err = p.resolveEntityCard(ctx, query, filterChunks(allChunks, request.Filters), request.Options.MaxChunks)
+		if err != nil {
+			if err := p.degrade(ctx, SubsystemKnowledgeGraph, err); err != nil {
+				return nil, fmt.Errorf("failed to resolve query entity: %w", err)
+			}
+		}
+		if card != nil {
+			state.RelevantChunks, state.FinalChunks, state.RecursiveLevels = sources, sources, 0
+			if err := p.saveCheckpoint(ctx, state, StageRefined); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Step 3: Prompt model to identify relevant chunks matching the request filters, shortlisted first
+	// in embedding, hybrid, and HyDE modes
+	if !state.reached(StageScored) {
+		candidates := filterChunks(allChunks, request.Filters)
+		retrievalCtx := withRetrievalScope(ctx, request, documents)
+		switch request.Options.Retrieval {
+		case RetrievalEmbedding:
+			candidates, err = p.retrieveCandidates(retrievalCtx, query, candidates, request.Filters)
+		case RetrievalHybrid:
+			candidates, err = p.hybridCandidates(retrievalCtx, query, candidates, request.Filters)
+		case RetrievalHyDE:
+			candidates, err = p.hydeCandidates(retrievalCtx, query, candidates, request.Filters)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve candidate chunks: %w", err)
+		}
+		state.RelevantChunks, err = p.identifyRelevantChunks(ctx, query, candidates, request.Options.MaxChunks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to identify relevant chunks: %w", err)
+		}
+		if err := p.saveCheckpoint(ctx, state, StageScored); err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 4 & 5: Recursively drill down into selected chunks, or hand small-to-big retrieval's
+	// relevant children to the generator as their parents
+	corpus := allChunks
+	if request.Options.Retrieval == RetrievalSmallToBig {
+		hierarchy := NewChunkHierarchy(state.ParentChunks, allChunks)
+		corpus = hierarchy.Parents
+		if !state.reached(StageRefined) {
+			state.FinalChunks, state.RecursiveLevels = hierarchy.Expand(state.RelevantChunks), 0
+			if err := p.saveCheckpoint(ctx, state, StageRefined); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !state.reached(StageRefined) {
+		state.FinalChunks, state.RecursiveLevels, err = p.recursivelyRefineChunks(ctx, query, state.RelevantChunks, request.Options.RecursiveDepth, documentContents(documents))
+		if err != nil {
+			return nil, fmt.Errorf("failed to recursively refine chunks: %w", err)
+		}
+		if err := p.saveCheckpoint(ctx, state, StageRefined); err != nil {
+			return nil, err
+		}
 	}
+	finalChunks, recursiveLevels := state.FinalChunks, state.RecursiveLevels
 
-	// Step 4 & 5: Recursively drill down into selected chunks
-	finalChunks, recursiveLevels, err := p.recursivelyRefineChunks(ctx, request.Query, relevantChunks, request.Options.RecursiveDepth)
+	// Rescore the top candidates with the reranker before generation
+	finalChunks, err = p.rerankChunks(ctx, query, finalChunks)
 	if err != nil {
-		return nil, fmt.Errorf("failed to recursively refine chunks: %w", err)
+		return nil, err
 	}
 
-	// Step 6: Generate response based on retrieved information
-	answer, tokenCount, err := p.generateResponse(ctx, request.Query, finalChunks, request.Options)
+	// Balance relevance against redundancy in the final chunk set if requested
+	if request.Options.MMRLambda != nil {
+		finalChunks = p.diversifyChunks(ctx, finalChunks, *request.Options.MMRLambda, request.Options.MaxChunks)
+	}
+
+	// Resolve disagreements between dated sources before generation
+	finalChunks, conflicts, sourceNotes := p.resolveFreshnessConflicts(ctx, finalChunks)
+
+	// Always include pinned content for matching queries
+	finalChunks, pinnedChunks := p.applyPins(query, finalChunks, corpus)
+
+	// Step 6: Generate response based on retrieved information, with few-shot demonstrations if configured
+	examples := p.selectExamples(ctx, request.Query)
+	var answer string
+	var tokenCount int
+	if card != nil {
+		answer, tokenCount, err = p.generateEntityCard(ctx, card, finalChunks, request.Options)
+	} else {
+		answer, tokenCount, err = p.generateResponse(ctx, request.Query, finalChunks, request.Options, examples, sourceNotes)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate response: %w", err)
 	}
@@ -164,7 +668,10 @@ func (p *AgenticRAGProcessor) Process(ctx context.Context, request AgenticRAGReq
 	if request.Options.EnableKnowledgeGraph && p.config.KnowledgeGraph.Enabled {
 		knowledgeGraph, err = p.buildKnowledgeGraph(ctx, finalChunks)
 		if err != nil {
-			return nil, fmt.Errorf("failed to build knowledge graph: %w", err)
+			if err := p.degrade(ctx, SubsystemKnowledgeGraph, err); err != nil {
+				return nil, fmt.Errorf("failed to build knowledge graph: %w", err)
+			}
+			knowledgeGraph = nil
 		}
 	}
 
@@ -173,7 +680,37 @@ func (p *AgenticRAGProcessor) Process(ctx context.Context, request AgenticRAGReq
 	if request.Options.EnableFactVerification {
 		factVerification, err = p.verifyFacts(ctx, answer, finalChunks)
 		if err != nil {
-			return nil, fmt.Errorf("failed to verify facts: %w", err)
+			if err := p.degrade(ctx, SubsystemFactVerification, err); err != nil {
+				return nil, fmt.Errorf("failed to verify facts: %w", err)
+			}
+			factVerification = &FactVerification{Overall: FactVerificationSkipped}
+		}
+	}
+
+	// Verify that each cited chunk supports the sentence citing it
+	var citationChecks []CitationCheck
+	if request.Options.EnableCitationVerification && p.config.CitationVerification.Enabled {
+		answer, citationChecks = p.verifyCitations(ctx, answer, finalChunks)
+	}
+
+	// Resolve citations and render the answer in the requested output format
+	citations := buildCitations(answer, finalChunks)
+	flagCitations(citations, citationChecks)
+	highlights := p.extractHighlights(answer, citations, finalChunks, documents)
+	formattedAnswer := ""
+	if request.Options.OutputFormat != "" {
+		formattedAnswer, err = renderAnswer(request.Options.OutputFormat, answer, citations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sign the answer and its provenance if requested
+	var bundle *AnswerBundle
+	if request.Options.SignAnswer {
+		bundle, err = p.signAnswer(ctx, answer, citations, finalChunks, p.pipelinePrompts())
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign answer: %w", err)
 		}
 	}
 
@@ -186,120 +723,236 @@ func (p *AgenticRAGProcessor) Process(ctx context.Context, request AgenticRAGReq
 		}
 	}
 
+	// Return chunk embeddings for client-side clustering and visualization if requested
+	if request.Options.IncludeEmbeddings {
+		if err := p.attachEmbeddings(ctx, processedChunks); err != nil {
+			return nil, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+	}
+
+	// The request finished, so its checkpoint is no longer needed
+	if err := p.clearCheckpoint(ctx, state); err != nil {
+		return nil, err
+	}
+
 	return &AgenticRAGResponse{
 		Answer:           answer,
+		FormattedAnswer:  formattedAnswer,
+		Citations:        citations,
+		Bundle:           bundle,
+		CitationChecks:   citationChecks,
+		Highlights:       highlights,
 		RelevantChunks:   processedChunks,
 		KnowledgeGraph:   knowledgeGraph,
+		EntityCard:       card,
 		FactVerification: factVerification,
 		ProcessingMetadata: ProcessingMetadata{
-			ProcessingTime:  time.Since(startTime),
-			ChunksProcessed: len(allChunks),
-			RecursiveLevels: recursiveLevels,
-			ModelCalls:      1 + recursiveLevels + 1, // identification + recursive calls + generation
-			TokensUsed:      tokenCount,
+			ProcessingTime:     time.Since(startTime),
+			ChunksProcessed:    len(allChunks),
+			RecursiveLevels:    recursiveLevels,
+			ModelCalls:         1 + recursiveLevels + 1, // identification + recursive calls + generation
+			TokensUsed:         tokenCount,
+			QueryNormalization: state.QueryNormalization,
+			ExamplesUsed:       len(examples),
+			SourceConflicts:    conflicts,
+			PinnedChunks:       pinnedChunks,
+			ExcludedDocuments:  state.ExcludedDocuments,
+			Routing:            state.Routing,
+			CollectionRouting:  state.CollectionRouting,
+			SecretFindings:     state.SecretFindings,
+			Degradations:       degradations.list(),
+			Partial:            len(state.TimedOutSources) > 0,
+			TimedOutSources:    state.TimedOutSources,
 		},
 	}, nil
 }
 
-// loadDocuments loads documents from various sources
-func (p *AgenticRAGProcessor) loadDocuments(ctx context.Context, sources []string) ([]Document, error) {
-	documents := make([]Document, 0, len(sources))
+// concurrencyLimit returns how many scoring batches, embedding batches, or sources a request
+// processes at once
+func (p *AgenticRAGProcessor) concurrencyLimit() int {
+	if p.config.Processing.Concurrency <= 0 {
+		return 4
+	}
+	return p.config.Processing.Concurrency
+}
 
+// loadDocuments loads documents from various sources concurrently, keeping their order. Sources
+// still loading at the retrieval deadline are skipped and returned as timed out.
+func (p *AgenticRAGProcessor) loadDocuments(ctx context.Context, sources []string) ([]Document, []string, error) {
+	retrievalCtx, cancel := p.retrievalDeadline(ctx)
+	defer cancel()
+
+	loadedSources := make([][]Document, len(sources))
+	timedOutSources := make([]bool, len(sources))
+	group, loadCtx := concurrency.WithContext(retrievalCtx, p.concurrencyLimit())
 	for i, source := range sources {
-		doc := Document{
-			ID:      fmt.Sprintf("doc_%d", i),
-			Content: source, // For MVP, treat as raw text
-			Source:  source,
-			Metadata: map[string]interface{}{
-				"loaded_at": time.Now(),
-			},
+		group.Go(func() error {
+			err := loadCtx.Err()
+			if err == nil {
+				loadedSources[i], err = p.loadSource(loadCtx, source)
+			}
+			if err != nil && timedOut(ctx, retrievalCtx, err) {
+				timedOutSources[i] = true
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", truncateText(source, 100), err)
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	documents := make([]Document, 0, len(sources))
+	timedOut := make([]string, 0)
+	for i, source := range sources {
+		if timedOutSources[i] {
+			timedOut = append(timedOut, source)
+			continue
+		}
+		for _, doc := range loadedSources[i] {
+			documents = append(documents, p.prepareDocument(doc, len(documents)))
 		}
-		documents = append(documents, doc)
 	}
+	return documents, timedOut, nil
+}
 
-	return documents, nil
+// prepareDocument assigns an ID and the metadata every loaded document carries
+func (p *AgenticRAGProcessor) prepareDocument(doc Document, index int) Document {
+	doc.ID = fmt.Sprintf("doc_%d", index)
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	doc.Metadata["loaded_at"] = time.Now()
+	doc.Metadata["language"] = p.detectLanguage(doc.Content)
+	if p.config.Credibility.Enabled {
+		doc.Metadata[credibilityMetadataKey] = p.sourceCredibility(doc)
+	}
+	if p.config.Licensing.Enabled {
+		if license := p.sourceLicense(doc); license != "" {
+			doc.Metadata[licenseMetadataKey] = license
+		}
+	}
+	if p.config.Overrides != nil {
+		if boost := p.config.Overrides.boostFor(doc); boost != 1.0 {
+			doc.Metadata[boostMetadataKey] = boost
+		}
+	}
+	return doc
 }
 
 // chunkDocument breaks a document into chunks respecting sentence boundaries
 func (p *AgenticRAGProcessor) chunkDocument(ctx context.Context, doc Document, maxChunks int) ([]DocumentChunk, error) {
-	chunkSize := p.config.Processing.DefaultChunkSize
-	content := doc.Content
-
-	// Simple sentence-aware chunking
-	sentences := p.splitIntoSentences(content)
-	chunks := make([]DocumentChunk, 0)
-
-	currentChunk := ""
-	currentStart := 0
-	chunkIndex := 0
-
-	for _, sentence := range sentences {
-		// If adding this sentence would exceed chunk size, finalize current chunk
-		if len(currentChunk)+len(sentence) > chunkSize && currentChunk != "" {
-			chunk := DocumentChunk{
-				ID:         fmt.Sprintf("%s_chunk_%d", doc.ID, chunkIndex),
-				Content:    strings.TrimSpace(currentChunk),
-				DocumentID: doc.ID,
-				ChunkIndex: chunkIndex,
-				StartIndex: currentStart,
-				EndIndex:   currentStart + len(currentChunk),
-			}
-			chunks = append(chunks, chunk)
+	return p.chunkDocumentWithSize(ctx, doc, p.config.Processing.DefaultChunkSize, maxChunks)
+}
 
-			// Start new chunk
-			chunkIndex++
-			currentStart = currentStart + len(currentChunk)
-			currentChunk = sentence + " "
+// chunkDocumentWithSize breaks a document into chunks of at most chunkSize with the configured chunker
+func (p *AgenticRAGProcessor) chunkDocumentWithSize(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	return p.chunkDocumentWith(ctx, "", doc, chunkSize, maxChunks)
+}
 
-			// Stop if we've reached max chunks
-			if len(chunks) >= maxChunks {
-				break
-			}
-		} else {
-			currentChunk += sentence + " "
-		}
-	}
+// splitIntoSentences splits text into sentences using the language's sentence pattern
+func (p *AgenticRAGProcessor) splitIntoSentences(text, language string) []string {
+	return p.analyzerFor(language).splitSentences(text)
+}
 
-	// Add final chunk if it has content
-	if currentChunk != "" && len(chunks) < maxChunks {
-		chunk := DocumentChunk{
-			ID:         fmt.Sprintf("%s_chunk_%d", doc.ID, chunkIndex),
-			Content:    strings.TrimSpace(currentChunk),
-			DocumentID: doc.ID,
-			ChunkIndex: chunkIndex,
-			StartIndex: currentStart,
-			EndIndex:   currentStart + len(currentChunk),
-		}
-		chunks = append(chunks, chunk)
-	}
+// documentLanguage returns the language recorded in document metadata, if any
+func documentLanguage(doc Document) string {
+	language, _ := doc.Metadata["language"].(string)
+	return language
+}
 
-	return chunks, nil
+// chunkLanguage returns the language recorded in chunk metadata, if any
+func chunkLanguage(chunk DocumentChunk) string {
+	language, _ := chunk.Metadata["language"].(string)
+	return language
 }
 
-// splitIntoSentences splits text into sentences using simple regex
-func (p *AgenticRAGProcessor) splitIntoSentences(text string) []string {
-	// Simple sentence splitting regex
-	sentenceRegex := regexp.MustCompile(`[.!?]+\s+`)
-	sentences := sentenceRegex.Split(text, -1)
-
-	// Filter out empty sentences
-	result := make([]string, 0, len(sentences))
-	for _, sentence := range sentences {
-		sentence = strings.TrimSpace(sentence)
-		if sentence != "" {
-			result = append(result, sentence)
+// newChunkMetadata creates chunk metadata inherited from the parent document
+func newChunkMetadata(doc Document) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(doc.Metadata))
+	for key, value := range doc.Metadata {
+		if key == sectionsMetadataKey || key == transcriptSegmentsMetadataKey {
+			continue
 		}
+		metadata[key] = value
 	}
+	return metadata
+}
 
-	return result
+// identifyRelevantChunks identifies up to maxChunks chunks most relevant to the query, ranked by the model score combined with the other ranking signals
+func (p *AgenticRAGProcessor) identifyRelevantChunks(ctx context.Context, query string, chunks []DocumentChunk, maxChunks int) ([]DocumentChunk, error) {
+	relevantChunks, err := p.scoreRelevantChunks(ctx, query, chunks, maxChunks)
+	if err != nil {
+		return nil, err
+	}
+	return p.combineScores(ctx, query, relevantChunks), nil
 }
 
-// identifyRelevantChunks uses LLM to identify which chunks are most relevant to the query
-func (p *AgenticRAGProcessor) identifyRelevantChunks(ctx context.Context, query string, chunks []DocumentChunk) ([]DocumentChunk, error) {
+// scoreRelevantChunks uses LLM to identify which chunks are most relevant to the query, scoring
+// them in batches that fit the model's context window and merging the results. Each batch may
+// select up to maxChunks chunks, so the merged results are cut back to the maxChunks best.
+func (p *AgenticRAGProcessor) scoreRelevantChunks(ctx context.Context, query string, chunks []DocumentChunk, maxChunks int) ([]DocumentChunk, error) {
 	if len(chunks) == 0 {
 		return chunks, nil
 	}
 
+	batches := p.scoringBatches(query, chunks)
+	if len(batches) == 1 {
+		return p.scoreRelevantBatch(ctx, query, chunks, maxChunks)
+	}
+	scoredBatches, err := concurrency.Map(ctx, batches, p.concurrencyLimit(), func(ctx context.Context, _ int, batch []DocumentChunk) ([]DocumentChunk, error) {
+		return p.scoreRelevantBatch(ctx, query, batch, maxChunks)
+	})
+	if err != nil {
+		return nil, err
+	}
+	relevantChunks := make([]DocumentChunk, 0)
+	for _, scored := range scoredBatches {
+		relevantChunks = append(relevantChunks, scored...)
+	}
+	sort.SliceStable(relevantChunks, func(i, j int) bool {
+		return relevantChunks[i].RelevanceScore > relevantChunks[j].RelevanceScore
+	})
+	if maxChunks > 0 && len(relevantChunks) > maxChunks {
+		relevantChunks = relevantChunks[:maxChunks]
+	}
+	return relevantChunks, nil
+}
+
+// scoringBatches splits the chunks into consecutive batches whose text, with the query and a reserve
+// for the instructions and the scores, fits the context window; oversized chunks get their own batch
+func (p *AgenticRAGProcessor) scoringBatches(query string, chunks []DocumentChunk) [][]DocumentChunk {
+	window := p.config.Processing.ContextWindow
+	if window <= 0 {
+		window = 32768
+	}
+	tokenizer := p.tokenizer()
+	budget := window - scoringReserveTokens - tokenizer.CountTokens(query)
+
+	batches := make([][]DocumentChunk, 0, 1)
+	start, used := 0, 0
+	for i, chunk := range chunks {
+		cost := tokenizer.CountTokens(chunk.Content) + scoringChunkOverheadTokens
+		if i > start && used+cost > budget {
+			batches = append(batches, chunks[start:i])
+			start, used = i, 0
+		}
+		used += cost
+	}
+	return append(batches, chunks[start:])
+}
+
+// Token budget of a relevance scoring call outside the chunk texts
+const (
+	scoringReserveTokens       = 2048 // Instructions and the returned scores
+	scoringChunkOverheadTokens = 8    // Index marker and separators around each chunk
+)
+
+// scoreRelevantBatch scores one batch of chunks with a single model call, asking for up to maxChunks
+func (p *AgenticRAGProcessor) scoreRelevantBatch(ctx context.Context, query string, chunks []DocumentChunk, maxChunks int) ([]DocumentChunk, error) {
 	// Initialize prompts if not done already
 	if err := p.initializePrompts(ctx); err != nil {
 		return nil, fmt.Errorf("failed to initialize prompts: %w", err)
@@ -325,11 +978,11 @@ func (p *AgenticRAGProcessor) identifyRelevantChunks(ctx context.Context, query
 	}
 
 	// Execute the prompt with proper input
-	response, err := relevancePrompt.Execute(ctx,
+	response, err := p.executePrompt(ctx, relevancePrompt,
 		ai.WithInput(map[string]any{
 			"query":      query,
 			"chunks":     chunkTexts,
-			"max_chunks": p.config.Processing.DefaultMaxChunks,
+			"max_chunks": maxChunks,
 		}),
 	)
 	if err != nil {
@@ -371,31 +1024,15 @@ Only include chunks with score > 0.3. Order by relevance score (highest first).
 Example: [{"index": 2, "score": 0.9}, {"index": 0, "score": 0.7}]`
 
 	// Use genkit.Generate to get LLM response
-	model := p.config.Model
-	var response *ai.ModelResponse
-	var err error
-
-	if model == nil {
-		// Use model by name if no model instance available
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModelName(p.config.ModelName),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     0.1, // Low temperature for consistent scoring
-				MaxOutputTokens: 1000,
-			}),
-		)
-	} else {
-		// Use model instance
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModel(model),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     0.1, // Low temperature for consistent scoring
-				MaxOutputTokens: 1000,
-			}),
-		)
-	}
+	response, err := genkit.Generate(ctx, p.config.Genkit,
+		p.modelOption(ctx),
+		p.modelMetricsOption(ctx),
+		ai.WithPrompt(prompt),
+		ai.WithConfig(&ai.GenerationCommonConfig{
+			Temperature:     0.1, // Low temperature for consistent scoring
+			MaxOutputTokens: 1000,
+		}),
+	)
 
 	if err != nil {
 		// Final fallback to simple keyword matching
@@ -496,7 +1133,7 @@ func (p *AgenticRAGProcessor) fallbackRelevanceScoring(query string, chunks []Do
 	relevantChunks := make([]DocumentChunk, 0)
 
 	for _, chunk := range chunks {
-		score := p.calculateRelevanceScore(query, chunk.Content)
+		score := p.calculateRelevanceScoreForLanguage(query, chunk.Content, chunkLanguage(chunk))
 		if score > 0.3 { // Simple threshold
 			chunk.RelevanceScore = score
 			relevantChunks = append(relevantChunks, chunk)
@@ -517,23 +1154,31 @@ func (p *AgenticRAGProcessor) fallbackRelevanceScoring(query string, chunks []Do
 	return relevantChunks[:maxRelevant]
 }
 
-// calculateRelevanceScore calculates a simple relevance score
+// calculateRelevanceScore calculates a simple relevance score, detecting the language from the content
 func (p *AgenticRAGProcessor) calculateRelevanceScore(query, content string) float64 {
-	queryWords := strings.Fields(strings.ToLower(query))
+	return p.calculateRelevanceScoreForLanguage(query, content, p.detectLanguage(content))
+}
+
+// calculateRelevanceScoreForLanguage calculates the fraction of stemmed, non-stopword query terms found in the content
+func (p *AgenticRAGProcessor) calculateRelevanceScoreForLanguage(query, content, language string) float64 {
+	queryTerms := p.analyzerFor(language).terms(query)
+	if len(queryTerms) == 0 {
+		return 0
+	}
 	contentLower := strings.ToLower(content)
 
 	matches := 0
-	for _, word := range queryWords {
-		if strings.Contains(contentLower, word) {
+	for _, term := range queryTerms {
+		if strings.Contains(contentLower, term) {
 			matches++
 		}
 	}
 
-	return float64(matches) / float64(len(queryWords))
+	return float64(matches) / float64(len(queryTerms))
 }
 
 // recursivelyRefineChunks recursively drills down into chunks for more granular information
-func (p *AgenticRAGProcessor) recursivelyRefineChunks(ctx context.Context, query string, chunks []DocumentChunk, maxDepth int) ([]DocumentChunk, int, error) {
+func (p *AgenticRAGProcessor) recursivelyRefineChunks(ctx context.Context, query string, chunks []DocumentChunk, maxDepth int, sources map[string]string) ([]DocumentChunk, int, error) {
 	if maxDepth <= 0 || len(chunks) == 0 {
 		return chunks, 0, nil
 	}
@@ -545,13 +1190,18 @@ func (p *AgenticRAGProcessor) recursivelyRefineChunks(ctx context.Context, query
 	for _, chunk := range chunks {
 		// If chunk is large enough, break it down further
 		if len(chunk.Content) > 200 { // Paragraph-level threshold
-			subChunks := p.breakdownChunk(chunk)
+			// Chunks from earlier requests are not located in this request's documents
+			source := ""
+			if !isStoredDocument(chunk.DocumentID) {
+				source = sources[chunk.DocumentID]
+			}
+			subChunks := p.breakdownChunk(chunk, source)
 
 			// Recursively process sub-chunks
 			if len(subChunks) > 1 {
-				relevantSubChunks, _ := p.identifyRelevantChunks(ctx, query, subChunks)
+				relevantSubChunks, _ := p.identifyRelevantChunks(ctx, query, subChunks, p.config.Processing.DefaultMaxChunks)
 				if len(relevantSubChunks) > 0 {
-					furtherRefined, depth, _ := p.recursivelyRefineChunks(ctx, query, relevantSubChunks, maxDepth-1)
+					furtherRefined, depth, _ := p.recursivelyRefineChunks(ctx, query, relevantSubChunks, maxDepth-1, sources)
 					refinedChunks = append(refinedChunks, furtherRefined...)
 					if depth+1 > currentDepth {
 						currentDepth = depth + 1
@@ -568,24 +1218,35 @@ func (p *AgenticRAGProcessor) recursivelyRefineChunks(ctx context.Context, query
 	return refinedChunks, currentDepth, nil
 }
 
-// breakdownChunk breaks a chunk into smaller sub-chunks
-func (p *AgenticRAGProcessor) breakdownChunk(chunk DocumentChunk) []DocumentChunk {
+// breakdownChunk breaks a chunk into smaller sub-chunks, locating each in the source document
+// text so sub-chunk offsets point at the exact span they came from
+func (p *AgenticRAGProcessor) breakdownChunk(chunk DocumentChunk, source string) []DocumentChunk {
 	// Break into sentences for paragraph-level content
-	sentences := p.splitIntoSentences(chunk.Content)
+	sentences := p.splitIntoSentences(chunk.Content, chunkLanguage(chunk))
 
 	if len(sentences) <= 1 {
 		return []DocumentChunk{chunk}
 	}
 
+	located := chunk.EndIndex <= len(source) && chunk.StartIndex < chunk.EndIndex
+	from := chunk.StartIndex
 	subChunks := make([]DocumentChunk, 0, len(sentences))
 	for idx, sentence := range sentences {
+		// Sub-chunks that cannot be located keep the parent's span
+		start, end := chunk.StartIndex, chunk.EndIndex
+		if located {
+			if spanStart, spanEnd, ok := locateText(source, sentence, from, chunk.EndIndex); ok {
+				start, end, from = spanStart, spanEnd, spanEnd
+			}
+		}
 		subChunk := DocumentChunk{
 			ID:         fmt.Sprintf("%s_sub_%d", chunk.ID, idx),
 			Content:    sentence,
 			DocumentID: chunk.DocumentID,
 			ChunkIndex: chunk.ChunkIndex*100 + idx, // Hierarchical indexing
-			StartIndex: chunk.StartIndex,           // Simplified for MVP
-			EndIndex:   chunk.EndIndex,             // Simplified for MVP
+			StartIndex: start,
+			EndIndex:   end,
+			Metadata:   chunk.Metadata,
 		}
 		subChunks = append(subChunks, subChunk)
 	}
@@ -593,8 +1254,17 @@ func (p *AgenticRAGProcessor) breakdownChunk(chunk DocumentChunk) []DocumentChun
 	return subChunks
 }
 
+// documentContents maps document IDs to their content
+func documentContents(documents []Document) map[string]string {
+	contents := make(map[string]string, len(documents))
+	for _, doc := range documents {
+		contents[doc.ID] = doc.Content
+	}
+	return contents
+}
+
 // generateResponse generates the final response using LLM based on retrieved chunks
-func (p *AgenticRAGProcessor) generateResponse(ctx context.Context, query string, chunks []DocumentChunk, options AgenticRAGOptions) (string, int, error) {
+func (p *AgenticRAGProcessor) generateResponse(ctx context.Context, query string, chunks []DocumentChunk, options AgenticRAGOptions, examples []ScoredExample, sourceNotes []string) (string, int, error) {
 	if len(chunks) == 0 {
 		return "I don't have enough information to answer your question.", 0, nil
 	}
@@ -614,6 +1284,15 @@ func (p *AgenticRAGProcessor) generateResponse(ctx context.Context, query string
 		}
 	}
 
+	// Prepare few-shot demonstrations for prompt
+	exampleData := make([]map[string]any, len(examples))
+	for i, example := range examples {
+		exampleData[i] = map[string]any{
+			"question": example.Example.Question,
+			"answer":   example.Example.Answer,
+		}
+	}
+
 	// Get the prompt variant to use
 	promptName := p.config.Prompts.ResponseGenerationPrompt
 	if variant, exists := p.config.Prompts.Variants["response_generation"]; exists {
@@ -624,20 +1303,40 @@ func (p *AgenticRAGProcessor) generateResponse(ctx context.Context, query string
 	responsePrompt := genkit.LookupPrompt(p.config.Genkit, promptName)
 	if responsePrompt == nil {
 		// Fallback to hardcoded prompt if dotprompt not found
-		return p.generateResponseFallback(ctx, query, chunks, options)
+		return p.generateResponseFallback(ctx, query, chunks, options, examples, sourceNotes)
+	}
+
+	input := map[string]any{
+		"query":            query,
+		"context_chunks":   contextChunks,
+		"enable_citations": true,
+		"examples":         exampleData,
+		"source_notes":     sourceNotes,
+	}
+	executeOptions := []ai.PromptExecuteOption{ai.WithInput(input)}
+	for _, option := range p.generationToolOptions() {
+		executeOptions = append(executeOptions, option)
+	}
+
+	// Apply the persona's prompt fragments and generation settings
+	persona, _ := p.resolvePersona(options.Persona)
+	if persona != nil {
+		input["persona"] = persona.promptInput()
+		input["enable_citations"] = persona.CitationStyle != CitationStyleNone
+		input["citation_instruction"] = persona.citationInstruction()
+	}
+	if persona != nil || options.Generation != nil {
+		executeOptions = append(executeOptions, ai.WithConfig(p.generationConfig(ctx, float64(options.Temperature), 2000, options.Generation)))
+	}
+	if option, ok := streamingOption(ctx); ok {
+		executeOptions = append(executeOptions, option)
 	}
 
 	// Execute the prompt with proper input
-	response, err := responsePrompt.Execute(ctx,
-		ai.WithInput(map[string]any{
-			"query":            query,
-			"context_chunks":   contextChunks,
-			"enable_citations": true,
-		}),
-	)
+	response, err := p.executePrompt(ctx, responsePrompt, executeOptions...)
 	if err != nil {
 		// Fallback if LLM fails
-		return p.generateResponseFallback(ctx, query, chunks, options)
+		return p.generateResponseFallback(ctx, query, chunks, options, examples, sourceNotes)
 	}
 
 	// Parse the structured response
@@ -657,7 +1356,7 @@ func (p *AgenticRAGProcessor) generateResponse(ctx context.Context, query string
 }
 
 // generateResponseFallback provides a fallback when dotprompt is not available
-func (p *AgenticRAGProcessor) generateResponseFallback(ctx context.Context, query string, chunks []DocumentChunk, options AgenticRAGOptions) (string, int, error) {
+func (p *AgenticRAGProcessor) generateResponseFallback(ctx context.Context, query string, chunks []DocumentChunk, options AgenticRAGOptions, examples []ScoredExample, sourceNotes []string) (string, int, error) {
 	// Build context from relevant chunks
 	contextBuilder := strings.Builder{}
 	contextBuilder.WriteString("Based on the following relevant information:\n\n")
@@ -665,11 +1364,35 @@ func (p *AgenticRAGProcessor) generateResponseFallback(ctx context.Context, quer
 	for i, chunk := range chunks {
 		contextBuilder.WriteString(fmt.Sprintf("Source %d:\n%s\n\n", i+1, chunk.Content))
 	}
+	if len(sourceNotes) > 0 {
+		contextBuilder.WriteString("Notes on sources:\n")
+		for _, note := range sourceNotes {
+			contextBuilder.WriteString(fmt.Sprintf("- %s\n", note))
+		}
+		contextBuilder.WriteString("\n")
+	}
+
+	// Build few-shot demonstrations of ideal answers
+	examplesBuilder := strings.Builder{}
+	if len(examples) > 0 {
+		examplesBuilder.WriteString("Examples of ideal answers:\n\n")
+		for i, example := range examples {
+			examplesBuilder.WriteString(fmt.Sprintf("Example %d\nQuestion: %s\nAnswer: %s\n\n", i+1, example.Example.Question, example.Example.Answer))
+		}
+	}
+
+	// Apply the persona's instructions and citation style
+	personaInstructions := ""
+	citationInstruction := `Cite which sources support your statements (e.g., "According to Source 1...")`
+	if persona, _ := p.resolvePersona(options.Persona); persona != nil {
+		personaInstructions = persona.instructions()
+		citationInstruction = persona.citationInstruction()
+	}
 
 	// Create a sophisticated prompt for response generation
 	prompt := fmt.Sprintf(`You are an expert AI assistant that provides accurate, comprehensive answers based on provided context.
 
-Context Information:
+%s%sContext Information:
 %s
 
 User Question: %s
@@ -678,34 +1401,28 @@ Instructions:
 1. Answer the question using ONLY the information provided in the context
 2. Be comprehensive but concise
 3. If the context doesn't contain enough information to answer fully, state what you can answer and what information is missing
-4. Cite which sources support your statements (e.g., "According to Source 1...")
+4. %s
 5. If the question cannot be answered with the given context, clearly state this
 
-Answer:`, contextBuilder.String(), query)
+Answer:`, personaInstructions, examplesBuilder.String(), contextBuilder.String(), query, citationInstruction)
 
 	// Generate response using LLM
 	var response *ai.ModelResponse
 	var err error
 
-	if p.config.Model != nil {
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModel(p.config.Model),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     float64(options.Temperature),
-				MaxOutputTokens: 2000,
-			}),
-		)
-	} else {
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModelName(p.config.ModelName),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     float64(options.Temperature),
-				MaxOutputTokens: 2000,
-			}),
-		)
+	generateOptions := []ai.GenerateOption{
+		p.modelOption(ctx),
+		p.modelMetricsOption(ctx),
+		ai.WithPrompt(prompt),
+		ai.WithConfig(p.generationConfig(ctx, float64(options.Temperature), 2000, options.Generation)),
+	}
+	for _, option := range p.generationToolOptions() {
+		generateOptions = append(generateOptions, option)
 	}
+	if option, ok := streamingOption(ctx); ok {
+		generateOptions = append(generateOptions, option)
+	}
+	response, err = genkit.Generate(ctx, p.config.Genkit, generateOptions...)
 
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to generate response: %w", err)
@@ -746,7 +1463,7 @@ func (p *AgenticRAGProcessor) buildKnowledgeGraph(ctx context.Context, chunks []
 	}
 
 	// Execute the prompt with proper input
-	response, err := kgPrompt.Execute(ctx,
+	response, err := p.executePrompt(ctx, kgPrompt,
 		ai.WithInput(map[string]any{
 			"text_chunks":    textChunks,
 			"entity_types":   p.config.KnowledgeGraph.EntityTypes,
@@ -816,25 +1533,15 @@ Respond with JSON in this exact format:
 	var response *ai.ModelResponse
 	var err error
 
-	if p.config.Model != nil {
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModel(p.config.Model),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     0.2, // Low temperature for structured output
-				MaxOutputTokens: 2500,
-			}),
-		)
-	} else {
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModelName(p.config.ModelName),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     0.2, // Low temperature for structured output
-				MaxOutputTokens: 2500,
-			}),
-		)
-	}
+	response, err = genkit.Generate(ctx, p.config.Genkit,
+		p.modelOption(ctx),
+		p.modelMetricsOption(ctx),
+		ai.WithPrompt(prompt),
+		ai.WithConfig(&ai.GenerationCommonConfig{
+			Temperature:     0.2, // Low temperature for structured output
+			MaxOutputTokens: 2500,
+		}),
+	)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract knowledge graph: %w", err)
@@ -1012,10 +1719,12 @@ func (p *AgenticRAGProcessor) verifyFacts(ctx context.Context, answer string, ch
 		return nil, fmt.Errorf("failed to initialize prompts: %w", err)
 	}
 
-	// Prepare source documents for prompt
+	// Prepare source documents and their credibility weights for prompt
 	sourceDocuments := make([]string, len(chunks))
+	sourceCredibility := make([]float64, len(chunks))
 	for i, chunk := range chunks {
 		sourceDocuments[i] = chunk.Content
+		sourceCredibility[i] = p.chunkCredibility(chunk)
 	}
 
 	// Get the prompt variant to use
@@ -1032,11 +1741,13 @@ func (p *AgenticRAGProcessor) verifyFacts(ctx context.Context, answer string, ch
 	}
 
 	// Execute the prompt with proper input
-	response, err := factPrompt.Execute(ctx,
+	response, err := p.executePrompt(ctx, factPrompt,
 		ai.WithInput(map[string]any{
-			"answer_text":      answer,
-			"source_documents": sourceDocuments,
-			"require_evidence": p.config.FactVerification.RequireEvidence,
+			"answer_text":        answer,
+			"source_documents":   sourceDocuments,
+			"require_evidence":   p.config.FactVerification.RequireEvidence,
+			"source_credibility": sourceCredibility,
+			"weight_credibility": p.config.Credibility.Enabled,
 		}),
 	)
 	if err != nil {
@@ -1103,7 +1814,11 @@ func (p *AgenticRAGProcessor) verifyFactsFallback(ctx context.Context, answer st
 	var contextBuilder strings.Builder
 	contextBuilder.WriteString("Source documents:\n\n")
 	for i, chunk := range chunks {
-		contextBuilder.WriteString(fmt.Sprintf("Source %d:\n%s\n\n", i+1, chunk.Content))
+		if p.config.Credibility.Enabled {
+			contextBuilder.WriteString(fmt.Sprintf("Source %d (credibility %.2f):\n%s\n\n", i+1, p.chunkCredibility(chunk), chunk.Content))
+		} else {
+			contextBuilder.WriteString(fmt.Sprintf("Source %d:\n%s\n\n", i+1, chunk.Content))
+		}
 	}
 
 	// Create prompt for fact verification
@@ -1121,6 +1836,7 @@ Task:
 3. Assign status: "verified" (supported by sources), "refuted" (contradicted by sources), or "inconclusive" (not addressed in sources)
 4. Provide confidence score (0.0-1.0)
 5. List evidence from sources that support or refute each claim
+6. When sources conflict, trust the source with the higher credibility score
 
 Respond with JSON in this exact format:
 {
@@ -1139,25 +1855,15 @@ Respond with JSON in this exact format:
 	var response *ai.ModelResponse
 	var err error
 
-	if p.config.Model != nil {
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModel(p.config.Model),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     0.1, // Low temperature for consistent verification
-				MaxOutputTokens: 2048,
-			}),
-		)
-	} else {
-		response, err = genkit.Generate(ctx, p.config.Genkit,
-			ai.WithModelName(p.config.ModelName),
-			ai.WithPrompt(prompt),
-			ai.WithConfig(&ai.GenerationCommonConfig{
-				Temperature:     0.1, // Low temperature for consistent verification
-				MaxOutputTokens: 2048,
-			}),
-		)
-	}
+	response, err = genkit.Generate(ctx, p.config.Genkit,
+		p.modelOption(ctx),
+		p.modelMetricsOption(ctx),
+		ai.WithPrompt(prompt),
+		ai.WithConfig(&ai.GenerationCommonConfig{
+			Temperature:     0.1, // Low temperature for consistent verification
+			MaxOutputTokens: 2048,
+		}),
+	)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify facts: %w", err)
@@ -0,0 +1,96 @@
+package plugin
+
+import "fmt"
+
+// Built-in pipeline profile names
+const (
+	ProfileFast     = "fast"
+	ProfileBalanced = "balanced"
+	ProfileThorough = "thorough"
+	profileEndpoint = "profile:"
+)
+
+// PipelineProfile bundles processing settings into a named cost/quality trade-off
+type PipelineProfile struct {
+	Description                string `json:"description"`
+	ChunkSize                  int    `json:"chunk_size"`
+	MaxChunks                  int    `json:"max_chunks"`
+	RecursiveDepth             int    `json:"recursive_depth"`
+	EnableKnowledgeGraph       bool   `json:"enable_knowledge_graph"`
+	EnableFactVerification     bool   `json:"enable_fact_verification"`
+	EnableCitationVerification bool   `json:"enable_citation_verification"`
+	ModelName                  string `json:"model_name,omitempty"` // Model tier; empty uses the configured model
+}
+
+// DefaultProfiles returns the built-in pipeline profiles
+func DefaultProfiles() map[string]PipelineProfile {
+	return map[string]PipelineProfile{
+		ProfileFast: {
+			Description:    "Lowest latency and cost: large chunks, a single refinement pass, no verification",
+			ChunkSize:      384,
+			MaxChunks:      10,
+			RecursiveDepth: 1,
+			ModelName:      "googleai/gemini-2.5-flash-lite",
+		},
+		ProfileBalanced: {
+			Description:                "Default trade-off with citation checks",
+			ChunkSize:                  256,
+			MaxChunks:                  20,
+			RecursiveDepth:             3,
+			EnableCitationVerification: true,
+		},
+		ProfileThorough: {
+			Description:                "Highest quality: small chunks, deep refinement, and full verification",
+			ChunkSize:                  200,
+			MaxChunks:                  40,
+			RecursiveDepth:             4,
+			EnableKnowledgeGraph:       true,
+			EnableFactVerification:     true,
+			EnableCitationVerification: true,
+			ModelName:                  "googleai/gemini-2.5-pro",
+		},
+	}
+}
+
+// resolveProfile looks up the profile selected for a request
+func (p *AgenticRAGProcessor) resolveProfile(name string) (*PipelineProfile, error) {
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := p.config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	return &profile, nil
+}
+
+// apply fills the request options from the profile; explicit request values win unless force is set
+func (profile *PipelineProfile) apply(options AgenticRAGOptions, force bool) AgenticRAGOptions {
+	if force || options.ChunkSize == 0 {
+		options.ChunkSize = profile.ChunkSize
+	}
+	if force || options.MaxChunks == 0 {
+		options.MaxChunks = profile.MaxChunks
+	}
+	if force || options.RecursiveDepth == 0 {
+		options.RecursiveDepth = profile.RecursiveDepth
+	}
+	if force {
+		options.EnableKnowledgeGraph = profile.EnableKnowledgeGraph
+		options.EnableFactVerification = profile.EnableFactVerification
+		options.EnableCitationVerification = profile.EnableCitationVerification
+	} else {
+		options.EnableKnowledgeGraph = options.EnableKnowledgeGraph || profile.EnableKnowledgeGraph
+		options.EnableFactVerification = options.EnableFactVerification || profile.EnableFactVerification
+		options.EnableCitationVerification = options.EnableCitationVerification || profile.EnableCitationVerification
+	}
+	return options
+}
+
+// endpoint returns the model tier of the profile as an endpoint, if it sets one
+func (profile *PipelineProfile) endpoint(name string) *ProviderEndpoint {
+	if profile == nil || profile.ModelName == "" {
+		return nil
+	}
+	return &ProviderEndpoint{Name: profileEndpoint + name, ModelName: profile.ModelName}
+}
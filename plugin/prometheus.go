@@ -0,0 +1,179 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// metricsPrefix is the prefix of the built-in metric names, replaced by the configured namespace
+const metricsPrefix = "agentic_rag_"
+
+// MetricsConfig contains configuration for exporting metrics to Prometheus
+type MetricsConfig struct {
+	Enabled   bool   `json:"enabled"`   // Serve MetricsHandler and record per-tenant, model, and collection metrics
+	Namespace string `json:"namespace"` // Prefix of exported metric names (default: agentic_rag)
+}
+
+// MetricsHandler returns an HTTP handler serving the metrics in the Prometheus text format, e.g.
+// mux.Handle("/metrics", processor.MetricsHandler()). It responds 404 Not Found unless
+// metrics.enabled is set.
+func (p *AgenticRAGProcessor) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.config.MetricsExport.Enabled {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writePrometheus(w, p.config.Metrics.Snapshot(), p.config.MetricsExport.Namespace)
+	})
+}
+
+// writePrometheus writes metric samples in the Prometheus text exposition format, renaming them
+// into the namespace
+func writePrometheus(w io.Writer, samples []MetricSample, namespace string) {
+	byName := make(map[string][]MetricSample)
+	for _, sample := range samples {
+		name := exportedMetricName(namespace, sample.Name)
+		byName[name] = append(byName[name], sample)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		series := byName[name]
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, series[0].Type)
+		for _, sample := range series {
+			if sample.Type != "histogram" {
+				fmt.Fprintf(w, "%s %g\n", seriesKey(name, sample.Labels), sample.Value)
+				continue
+			}
+			for i, bound := range sample.Buckets {
+				fmt.Fprintf(w, "%s %d\n", seriesKey(name+"_bucket", withLabel(sample.Labels, "le", fmt.Sprintf("%g", bound))), sample.Counts[i])
+			}
+			fmt.Fprintf(w, "%s %d\n", seriesKey(name+"_bucket", withLabel(sample.Labels, "le", "+Inf")), sample.Count)
+			fmt.Fprintf(w, "%s %g\n", seriesKey(name+"_sum", sample.Labels), sample.Value)
+			fmt.Fprintf(w, "%s %d\n", seriesKey(name+"_count", sample.Labels), sample.Count)
+		}
+	}
+}
+
+// exportedMetricName renames a built-in metric into the namespace
+func exportedMetricName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "_" + strings.TrimPrefix(name, metricsPrefix)
+}
+
+// withLabel returns a copy of the labels with one more label
+func withLabel(labels Labels, name, value string) Labels {
+	extended := make(Labels, len(labels)+1)
+	for key, existing := range labels {
+		extended[key] = existing
+	}
+	extended[name] = value
+	return extended
+}
+
+// metricLabelsContextKey is the context key of the request's metric labels
+type metricLabelsContextKey struct{}
+
+// withMetricLabels attaches the request's tenant label to the context for metrics recorded while
+// serving it, when metrics are enabled
+func (p *AgenticRAGProcessor) withMetricLabels(ctx context.Context, request AgenticRAGRequest) context.Context {
+	if !p.config.MetricsExport.Enabled {
+		return ctx
+	}
+	return context.WithValue(ctx, metricLabelsContextKey{}, Labels{"tenant": request.TenantID})
+}
+
+// metricLabels returns a copy of the context's request labels with extra label pairs
+func metricLabels(ctx context.Context, pairs ...string) Labels {
+	base, _ := ctx.Value(metricLabelsContextKey{}).(Labels)
+	labels := make(Labels, len(base)+len(pairs)/2)
+	for key, value := range base {
+		labels[key] = value
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels[pairs[i]] = pairs[i+1]
+	}
+	return labels
+}
+
+// observeRequest records a served request's outcome and latency by tenant, model, and collection
+func (p *AgenticRAGProcessor) observeRequest(ctx context.Context, request AgenticRAGRequest, response *AgenticRAGResponse, err error, startTime time.Time) {
+	if !p.config.MetricsExport.Enabled {
+		return
+	}
+	model := p.modelName(ctx)
+	if endpointCtx, endpointErr := p.withTenantEndpoint(ctx, request.TenantID); endpointErr == nil {
+		model = p.modelName(endpointCtx)
+	}
+	collection := strings.Join(request.Options.Collections, "+")
+	if response != nil && response.ProcessingMetadata.CollectionRouting != nil {
+		collection = strings.Join(response.ProcessingMetadata.CollectionRouting.Collections, "+")
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	labels := metricLabels(ctx, "model", model, "collection", collection)
+	p.config.Metrics.IncCounterWith("agentic_rag_requests_total", withLabel(labels, "status", status), 1)
+	p.config.Metrics.Observe("agentic_rag_request_duration_seconds", labels, time.Since(startTime).Seconds())
+}
+
+// modelMetricsOption returns generation middleware recording model calls, latency, and token usage
+// by tenant and model. It applies no middleware unless metrics are enabled.
+func (p *AgenticRAGProcessor) modelMetricsOption(ctx context.Context) ai.CommonGenOption {
+	if !p.config.MetricsExport.Enabled {
+		return ai.WithMiddleware()
+	}
+	return ai.WithMiddleware(func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, request *ai.ModelRequest, callback ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			startTime := time.Now()
+			response, err := next(ctx, request, callback)
+
+			labels := metricLabels(ctx, "model", p.modelName(ctx))
+			status := "ok"
+			if err != nil {
+				status = "error"
+			}
+			p.config.Metrics.IncCounterWith("agentic_rag_model_requests_total", withLabel(labels, "status", status), 1)
+			p.config.Metrics.Observe("agentic_rag_model_request_duration_seconds", labels, time.Since(startTime).Seconds())
+			if response != nil && response.Usage != nil {
+				p.config.Metrics.IncCounterWith("agentic_rag_model_tokens_total", withLabel(labels, "type", "input"), float64(response.Usage.InputTokens))
+				p.config.Metrics.IncCounterWith("agentic_rag_model_tokens_total", withLabel(labels, "type", "output"), float64(response.Usage.OutputTokens))
+			}
+			return response, err
+		}
+	})
+}
+
+// observeVectorStore records a vector store operation's outcome and latency by collection
+func (p *AgenticRAGProcessor) observeVectorStore(ctx context.Context, operation string, err error, startTime time.Time) {
+	if !p.config.MetricsExport.Enabled {
+		return
+	}
+	collection := p.config.VectorStores.Default
+	if p.config.VectorStore != nil {
+		collection = "default"
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	labels := metricLabels(ctx, "collection", collection, "operation", operation)
+	p.config.Metrics.IncCounterWith("agentic_rag_vector_store_operations_total", withLabel(labels, "status", status), 1)
+	p.config.Metrics.Observe("agentic_rag_vector_store_duration_seconds", labels, time.Since(startTime).Seconds())
+}
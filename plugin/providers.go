@@ -0,0 +1,181 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ErrNoCompliantProvider is returned when no registered provider satisfies a tenant's residency rules
+var ErrNoCompliantProvider = errors.New("no compliant provider available")
+
+// ProviderEndpoint is a model served by a provider in a specific region
+type ProviderEndpoint struct {
+	Name      string   `json:"name"`       // Unique endpoint name, e.g. "vertex-eu"
+	Provider  string   `json:"provider"`   // e.g. "vertexai", "googleai", "openai"
+	Region    string   `json:"region"`     // e.g. "europe-west4", "us-central1"
+	ModelName string   `json:"model_name"` // Registered model name ("provider/name")
+	Model     ai.Model `json:"-"`          // Model instance (not serialized)
+}
+
+// ResidencyRule restricts the providers and regions a tenant's requests may use
+type ResidencyRule struct {
+	Tenant    string   `json:"tenant"`              // Glob pattern ("*" matches anything) on tenant ID
+	Regions   []string `json:"regions,omitempty"`   // Glob patterns on allowed regions; empty allows any region
+	Providers []string `json:"providers,omitempty"` // Allowed providers; empty allows any provider
+}
+
+// ProviderManager routes each tenant's model calls to an endpoint that satisfies its residency rules
+type ProviderManager struct {
+	mu        sync.RWMutex
+	endpoints []ProviderEndpoint
+	rules     []ResidencyRule
+}
+
+// NewProviderManager creates an empty provider manager
+func NewProviderManager() *ProviderManager {
+	return &ProviderManager{}
+}
+
+// Register adds an endpoint; endpoints are preferred in registration order
+func (m *ProviderManager) Register(endpoint ProviderEndpoint) error {
+	if endpoint.Name == "" {
+		return fmt.Errorf("provider endpoint requires a name")
+	}
+	if endpoint.Model == nil && endpoint.ModelName == "" {
+		return fmt.Errorf("provider endpoint %q requires a model or model name", endpoint.Name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.endpoints {
+		if existing.Name == endpoint.Name {
+			return fmt.Errorf("provider endpoint %q already registered", endpoint.Name)
+		}
+	}
+	m.endpoints = append(m.endpoints, endpoint)
+	return nil
+}
+
+// Endpoints returns the registered endpoints in preference order
+func (m *ProviderManager) Endpoints() []ProviderEndpoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]ProviderEndpoint(nil), m.endpoints...)
+}
+
+// AddRule adds a residency rule; every rule matching a tenant must be satisfied
+func (m *ProviderManager) AddRule(rule ResidencyRule) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, rule)
+}
+
+// Select returns the first endpoint satisfying every rule for the tenant, or nil when no rule applies
+func (m *ProviderManager) Select(tenantID string) (*ProviderEndpoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rules := make([]ResidencyRule, 0)
+	for _, rule := range m.rules {
+		if globMatch(rule.Tenant, tenantID) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	for _, endpoint := range m.endpoints {
+		if endpointSatisfies(endpoint, rules) {
+			selected := endpoint
+			return &selected, nil
+		}
+	}
+
+	requirements := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		requirements = append(requirements, fmt.Sprintf("regions %v providers %v", rule.Regions, rule.Providers))
+	}
+	return nil, fmt.Errorf("%w for tenant %q (requires %s; %d endpoints registered)",
+		ErrNoCompliantProvider, tenantID, strings.Join(requirements, " and "), len(m.endpoints))
+}
+
+// endpointSatisfies reports whether the endpoint meets every rule
+func endpointSatisfies(endpoint ProviderEndpoint, rules []ResidencyRule) bool {
+	for _, rule := range rules {
+		if len(rule.Regions) > 0 && !matchesAny(rule.Regions, endpoint.Region) {
+			return false
+		}
+		if len(rule.Providers) > 0 && !matchesAny(rule.Providers, endpoint.Provider) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether value matches any glob pattern
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if globMatch(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointContextKey carries the endpoint selected for a request
+type endpointContextKey struct{}
+
+// withEndpoint returns a context whose model calls use the endpoint
+func withEndpoint(ctx context.Context, endpoint *ProviderEndpoint) context.Context {
+	if endpoint == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, endpointContextKey{}, endpoint)
+}
+
+// withTenantEndpoint selects the tenant's endpoint and attaches it to the context
+func (p *AgenticRAGProcessor) withTenantEndpoint(ctx context.Context, tenantID string) (context.Context, error) {
+	if p.config.Providers == nil {
+		return ctx, nil
+	}
+	endpoint, err := p.config.Providers.Select(tenantID)
+	if err != nil {
+		return ctx, err
+	}
+	return withEndpoint(ctx, endpoint), nil
+}
+
+// endpointFromContext returns the endpoint selected for the request, if any
+func endpointFromContext(ctx context.Context) *ProviderEndpoint {
+	endpoint, _ := ctx.Value(endpointContextKey{}).(*ProviderEndpoint)
+	return endpoint
+}
+
+// modelOption returns the model option for the request, honoring the selected endpoint
+func (p *AgenticRAGProcessor) modelOption(ctx context.Context) ai.CommonGenOption {
+	model, modelName := p.config.Model, p.config.ModelName
+	if endpoint := endpointFromContext(ctx); endpoint != nil {
+		model, modelName = endpoint.Model, endpoint.ModelName
+	}
+	if model != nil {
+		return ai.WithModel(model)
+	}
+	return ai.WithModelName(modelName)
+}
+
+// executePrompt runs a dotprompt, overriding its model when residency rules selected an endpoint
+func (p *AgenticRAGProcessor) executePrompt(ctx context.Context, prompt *ai.Prompt, opts ...ai.PromptExecuteOption) (*ai.ModelResponse, error) {
+	if endpointFromContext(ctx) != nil {
+		opts = append(opts, p.modelOption(ctx))
+	}
+	if p.config.MetricsExport.Enabled {
+		opts = append(opts, p.modelMetricsOption(ctx))
+	}
+	return prompt.Execute(ctx, opts...)
+}
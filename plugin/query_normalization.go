@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// QueryNormalizationConfig contains configuration for the pre-retrieval query rewrite step
+type QueryNormalizationConfig struct {
+	Enabled              bool              `json:"enabled"`
+	UnicodeNormalization bool              `json:"unicode_normalization"` // Apply NFKC normalization and collapse whitespace
+	SpellCorrection      bool              `json:"spell_correction"`      // Correct query terms against the corpus vocabulary
+	MaxEditDistance      int               `json:"max_edit_distance"`     // Maximum edit distance for a spelling correction
+	MinWordLength        int               `json:"min_word_length"`       // Words shorter than this are never corrected
+	Glossary             map[string]string `json:"glossary,omitempty"`    // Acronym expansions, e.g. "RAG" -> "retrieval-augmented generation"
+}
+
+// QueryNormalization describes how the query was rewritten before retrieval
+type QueryNormalization struct {
+	OriginalQuery    string            `json:"original_query"`
+	NormalizedQuery  string            `json:"normalized_query"`
+	Corrections      map[string]string `json:"corrections,omitempty"`
+	ExpandedAcronyms map[string]string `json:"expanded_acronyms,omitempty"`
+}
+
+// vocabulary holds corpus term frequencies used for spelling correction
+type vocabulary map[string]int
+
+// buildVocabulary collects lowercase term frequencies from the loaded documents
+func buildVocabulary(documents []Document) vocabulary {
+	vocab := make(vocabulary)
+	for _, doc := range documents {
+		for _, token := range tokenize(doc.Content) {
+			vocab[token]++
+		}
+	}
+	return vocab
+}
+
+// normalizeQuery rewrites the query according to the normalization config
+func (p *AgenticRAGProcessor) normalizeQuery(query string, documents []Document) *QueryNormalization {
+	cfg := p.config.QueryNormalization
+	result := &QueryNormalization{
+		OriginalQuery:   query,
+		NormalizedQuery: query,
+	}
+	if !cfg.Enabled {
+		return result
+	}
+
+	normalized := query
+	if cfg.UnicodeNormalization {
+		normalized = strings.Join(strings.Fields(norm.NFKC.String(normalized)), " ")
+	}
+
+	var vocab vocabulary
+	if cfg.SpellCorrection {
+		vocab = buildVocabulary(documents)
+	}
+
+	words := strings.Fields(normalized)
+	for i, word := range words {
+		prefix, core, suffix := splitPunctuation(word)
+		if core == "" {
+			continue
+		}
+
+		if expansion, ok := lookupGlossary(cfg.Glossary, core); ok {
+			if result.ExpandedAcronyms == nil {
+				result.ExpandedAcronyms = make(map[string]string)
+			}
+			result.ExpandedAcronyms[core] = expansion
+			words[i] = prefix + core + " (" + expansion + ")" + suffix
+			continue
+		}
+
+		if vocab != nil {
+			if corrected, ok := p.correctSpelling(core, vocab); ok {
+				if result.Corrections == nil {
+					result.Corrections = make(map[string]string)
+				}
+				result.Corrections[core] = corrected
+				words[i] = prefix + corrected + suffix
+			}
+		}
+	}
+
+	result.NormalizedQuery = strings.Join(words, " ")
+	return result
+}
+
+// correctSpelling returns the most frequent vocabulary term within the configured edit distance
+func (p *AgenticRAGProcessor) correctSpelling(word string, vocab vocabulary) (string, bool) {
+	cfg := p.config.QueryNormalization
+	lower := strings.ToLower(word)
+
+	minLength := cfg.MinWordLength
+	if minLength <= 0 {
+		minLength = 4
+	}
+	length := utf8.RuneCountInString(lower)
+	if length < minLength || vocab[lower] > 0 || hasDigit(lower) {
+		return "", false
+	}
+
+	maxDistance := cfg.MaxEditDistance
+	if maxDistance <= 0 {
+		maxDistance = 2
+	}
+	// Short words tolerate fewer edits before corrections become guesses
+	if length <= 5 && maxDistance > 1 {
+		maxDistance = 1
+	}
+
+	best, bestDistance, bestFrequency := "", maxDistance+1, 0
+	for term, frequency := range vocab {
+		// Lengths are compared in runes like the edit distance, since one accented or non-Latin
+		// letter takes several bytes
+		if abs(utf8.RuneCountInString(term)-length) > maxDistance {
+			continue
+		}
+		distance := editDistance(lower, term)
+		if distance < bestDistance || (distance == bestDistance && (frequency > bestFrequency || (frequency == bestFrequency && term < best))) {
+			best, bestDistance, bestFrequency = term, distance, frequency
+		}
+	}
+
+	if best == "" || bestDistance > maxDistance {
+		return "", false
+	}
+	return best, true
+}
+
+// lookupGlossary finds an acronym expansion, matching exactly first and then case-insensitively
+func lookupGlossary(glossary map[string]string, word string) (string, bool) {
+	if expansion, ok := glossary[word]; ok {
+		return expansion, true
+	}
+	for acronym, expansion := range glossary {
+		if strings.EqualFold(acronym, word) {
+			return expansion, true
+		}
+	}
+	return "", false
+}
+
+// splitPunctuation separates leading and trailing punctuation from a word
+func splitPunctuation(word string) (string, string, string) {
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r)
+	}
+
+	start := strings.IndexFunc(word, isWordRune)
+	if start < 0 {
+		return word, "", ""
+	}
+	end := strings.LastIndexFunc(word, isWordRune)
+	_, size := utf8.DecodeRuneInString(word[end:])
+	return word[:start], word[start : end+size], word[end+size:]
+}
+
+// hasDigit reports whether s contains a digit; identifiers and codes are never corrected
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+// editDistance computes the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
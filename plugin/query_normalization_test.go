@@ -0,0 +1,30 @@
+package plugin
+
+import "testing"
+
+func TestCorrectSpellingCountsRunes(t *testing.T) {
+	config := DefaultConfig()
+	config.QueryNormalization.MaxEditDistance = 1
+	p := NewAgenticRAGProcessor(config)
+
+	tests := []struct {
+		name  string
+		word  string
+		vocab vocabulary
+		want  string
+	}{
+		{"ascii insertion", "retrieal", vocabulary{"retrieval": 3}, "retrieval"},
+		{"cyrillic insertion", "поисковй", vocabulary{"поисковый": 3}, "поисковый"},
+		{"greek insertion", "ανάκτηη", vocabulary{"ανάκτηση": 3}, "ανάκτηση"},
+		{"accented insertion", "reponse", vocabulary{"réponse": 3}, "réponse"},
+		{"too far", "поиск", vocabulary{"поисковый": 3}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.correctSpelling(tt.word, tt.vocab)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("correctSpelling(%q) = %q, %v, want %q", tt.word, got, ok, tt.want)
+			}
+		})
+	}
+}
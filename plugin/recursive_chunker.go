@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultRecursiveSeparators split on paragraphs, then lines, then sentences, then words, then characters
+var defaultRecursiveSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// chunkRecursive splits the document on the first configured separator it contains, recursing into
+// pieces that are still too large with the remaining separators, then merges adjacent pieces up to
+// the chunk size. It suits documents that lack clean sentence punctuation.
+func (p *AgenticRAGProcessor) chunkRecursive(ctx context.Context, doc Document, chunkSize, maxChunks int) ([]DocumentChunk, error) {
+	separators := p.config.Processing.Separators
+	if len(separators) == 0 {
+		separators = defaultRecursiveSeparators
+	}
+	measure := p.chunkMeasure()
+	units := recursiveSplit(doc.Content, 0, separators, chunkSize, measure)
+
+	chunks := make([]DocumentChunk, 0)
+	start, end := -1, -1
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		chunks = append(chunks, DocumentChunk{
+			ID:         fmt.Sprintf("%s_chunk_%d", doc.ID, len(chunks)),
+			Content:    doc.Content[start:end],
+			DocumentID: doc.ID,
+			ChunkIndex: len(chunks),
+			StartIndex: start,
+			EndIndex:   end,
+			Metadata:   newChunkMetadata(doc),
+		})
+		start, end = -1, -1
+	}
+
+	for _, unit := range units {
+		if start >= 0 && measure(doc.Content[start:unit.end]) > chunkSize {
+			flush()
+			if len(chunks) >= maxChunks {
+				break
+			}
+		}
+		if start < 0 {
+			start = unit.start
+		}
+		end = unit.end
+	}
+	if len(chunks) < maxChunks {
+		flush()
+	}
+
+	// Transcript chunks carry the time span of the recording they cover
+	if segments, ok := doc.Metadata[transcriptSegmentsMetadataKey].([]TranscriptSegment); ok {
+		annotateChunkTimestamps(chunks, segments)
+	}
+
+	return chunks, nil
+}
+
+// recursiveSplit splits text, found at offset base of the chunked content, into trimmed pieces that
+// each fit in chunkSize. The separator stays attached to the end of the piece it terminates.
+func recursiveSplit(text string, base int, separators []string, chunkSize int, measure func(string) int) []chunkUnit {
+	units := make([]chunkUnit, 0)
+
+	// Use the first separator present in the text; the empty separator splits into characters
+	index := len(separators)
+	for i, separator := range separators {
+		if separator == "" || strings.Contains(text, separator) {
+			index = i
+			break
+		}
+	}
+
+	pieces := make([][2]int, 0)
+	switch {
+	case index == len(separators):
+		pieces = append(pieces, [2]int{0, len(text)})
+	case separators[index] == "":
+		for offset := 0; offset < len(text); {
+			_, size := utf8.DecodeRuneInString(text[offset:])
+			pieces = append(pieces, [2]int{offset, offset + size})
+			offset += size
+		}
+	default:
+		separator := separators[index]
+		offset := 0
+		for offset < len(text) {
+			next := strings.Index(text[offset:], separator)
+			if next < 0 {
+				pieces = append(pieces, [2]int{offset, len(text)})
+				break
+			}
+			pieces = append(pieces, [2]int{offset, offset + next + len(separator)})
+			offset += next + len(separator)
+		}
+	}
+
+	for _, piece := range pieces {
+		span, ok := trimSpan(text, piece[0], piece[1])
+		if !ok {
+			continue
+		}
+		start, end := span[0], span[1]
+		if measure(text[start:end]) <= chunkSize || index >= len(separators)-1 {
+			units = append(units, chunkUnit{text: text[start:end], start: base + start, end: base + end})
+			continue
+		}
+		units = append(units, recursiveSplit(text[start:end], base+start, separators[index+1:], chunkSize, measure)...)
+	}
+	return units
+}
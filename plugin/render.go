@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Output formats supported by the answer renderers
+const (
+	OutputFormatMarkdown = "markdown"
+	OutputFormatHTML     = "html"
+	OutputFormatText     = "text"
+)
+
+// Citation links a numbered source reference in the answer to the chunk it came from
+type Citation struct {
+	Number      int    `json:"number"`
+	ChunkID     string `json:"chunk_id"`
+	DocumentID  string `json:"document_id"`
+	Title       string `json:"title,omitempty"`
+	URL         string `json:"url,omitempty"`
+	License     string `json:"license,omitempty"`
+	Copyright   string `json:"copyright,omitempty"`
+	Page        int    `json:"page,omitempty"` // Page number for paginated sources such as PDFs
+	Snippet     string `json:"snippet,omitempty"`
+	Unsupported bool   `json:"unsupported,omitempty"` // Set when citation verification found no supporting sentence
+}
+
+// AnswerRenderer converts a generated answer and its citations into a presentation format
+type AnswerRenderer func(answer string, citations []Citation) string
+
+// answerRenderers maps output formats to their renderers
+var answerRenderers = map[string]AnswerRenderer{
+	OutputFormatMarkdown: renderMarkdown,
+	OutputFormatHTML:     renderHTML,
+	OutputFormatText:     renderText,
+}
+
+// citationPattern matches "Source 3", "Sources 1", and "[3]" citation markers
+var citationPattern = regexp.MustCompile(`(?i)\bsources?\s+(\d+)\b|\[(\d+)\]`)
+
+// buildCitations resolves the citation markers in the answer against the chunks given to the generator
+func buildCitations(answer string, chunks []DocumentChunk) []Citation {
+	seen := make(map[int]bool)
+	citations := make([]Citation, 0)
+
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		number, err := strconv.Atoi(match[1] + match[2])
+		if err != nil || number < 1 || number > len(chunks) || seen[number] {
+			continue
+		}
+		seen[number] = true
+
+		chunk := chunks[number-1]
+		citations = append(citations, Citation{
+			Number:     number,
+			ChunkID:    chunk.ID,
+			DocumentID: chunk.DocumentID,
+			Title:      metadataString(chunk.Metadata, "title"),
+			URL:        metadataString(chunk.Metadata, "url"),
+			License:    metadataString(chunk.Metadata, licenseMetadataKey),
+			Copyright:  metadataString(chunk.Metadata, copyrightMetadataKey),
+			Page:       metadataInt(chunk.Metadata, "page"),
+			Snippet:    truncateText(chunk.Content, 200),
+		})
+	}
+
+	sort.Slice(citations, func(i, j int) bool {
+		return citations[i].Number < citations[j].Number
+	})
+	return citations
+}
+
+// renderAnswer renders the answer in the requested output format
+func renderAnswer(format, answer string, citations []Citation) (string, error) {
+	renderer, ok := answerRenderers[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported output format %q", format)
+	}
+	return renderer(answer, citations), nil
+}
+
+// replaceCitations rewrites each resolvable citation marker with the result of replace
+func replaceCitations(answer string, citations []Citation, replace func(number int) string) string {
+	known := make(map[int]bool, len(citations))
+	for _, citation := range citations {
+		known[citation.Number] = true
+	}
+
+	return citationPattern.ReplaceAllStringFunc(answer, func(marker string) string {
+		match := citationPattern.FindStringSubmatch(marker)
+		number, err := strconv.Atoi(match[1] + match[2])
+		if err != nil || !known[number] {
+			return marker
+		}
+		return replace(number)
+	})
+}
+
+// renderMarkdown renders citations as Markdown footnotes
+func renderMarkdown(answer string, citations []Citation) string {
+	var builder strings.Builder
+	builder.WriteString(replaceCitations(answer, citations, func(number int) string {
+		return fmt.Sprintf("[^%d]", number)
+	}))
+
+	if len(citations) > 0 {
+		builder.WriteString("\n\n")
+		for _, citation := range citations {
+			label := citationLabel(citation)
+			if link, ok := citationLink(citation); ok {
+				label = fmt.Sprintf("[%s](%s)", label, markdownLinkTarget(link))
+			}
+			builder.WriteString(fmt.Sprintf("[^%d]: %s\n", citation.Number, label))
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// renderHTML renders the answer as HTML paragraphs with anchor-linked citations
+func renderHTML(answer string, citations []Citation) string {
+	var builder strings.Builder
+
+	for _, paragraph := range strings.Split(strings.TrimSpace(answer), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		escaped := html.EscapeString(paragraph)
+		escaped = replaceCitations(escaped, citations, func(number int) string {
+			return fmt.Sprintf(`<sup><a href="#cite-%d">[%d]</a></sup>`, number, number)
+		})
+		builder.WriteString("<p>")
+		builder.WriteString(strings.ReplaceAll(escaped, "\n", "<br>"))
+		builder.WriteString("</p>\n")
+	}
+
+	if len(citations) > 0 {
+		builder.WriteString(`<ol class="citations">` + "\n")
+		for _, citation := range citations {
+			label := html.EscapeString(citationLabel(citation))
+			if link, ok := citationLink(citation); ok {
+				label = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link), label)
+			}
+			builder.WriteString(fmt.Sprintf(`<li id="cite-%d" value="%d">%s</li>`+"\n", citation.Number, citation.Number, label))
+		}
+		builder.WriteString("</ol>\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// citationLink returns the citation's URL if it is safe to link. URLs come from document metadata,
+// so only absolute http and https URLs are linked; javascript:, data:, and other schemes are not.
+func citationLink(citation Citation) (string, bool) {
+	if citation.URL == "" {
+		return "", false
+	}
+	parsed, err := url.Parse(citation.URL)
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return citation.URL, true
+	default:
+		return "", false
+	}
+}
+
+// markdownLinkTarget percent-encodes the characters that end a Markdown link or autolink target
+var markdownLinkTarget = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace
+
+// markdownSyntax matches inline Markdown emphasis, code, and heading markers
+var markdownSyntax = regexp.MustCompile("(?m)^#{1,6}\\s+|\\*\\*|__|`")
+
+// renderText renders the answer as plain text with a trailing source list
+func renderText(answer string, citations []Citation) string {
+	var builder strings.Builder
+	builder.WriteString(markdownSyntax.ReplaceAllString(answer, ""))
+
+	if len(citations) > 0 {
+		builder.WriteString("\n\nSources:\n")
+		for _, citation := range citations {
+			line := fmt.Sprintf("[%d] %s", citation.Number, citationLabel(citation))
+			if citation.URL != "" {
+				line += " - " + citation.URL
+			}
+			builder.WriteString(line + "\n")
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// citationLabel returns the human-readable label for a citation
+func citationLabel(citation Citation) string {
+	label := fmt.Sprintf("Source %d (%s)", citation.Number, citation.DocumentID)
+	if citation.Title != "" {
+		label = citation.Title
+	}
+	if citation.Page > 0 {
+		label = fmt.Sprintf("%s, p. %d", label, citation.Page)
+	}
+	return label
+}
+
+// metadataString reads a string value from metadata
+func metadataString(metadata map[string]interface{}, key string) string {
+	value, _ := metadata[key].(string)
+	return value
+}
+
+// metadataInt reads an integer value from metadata
+func metadataInt(metadata map[string]interface{}, key string) int {
+	value, _ := metadataFloat(metadata, key)
+	return int(value)
+}
+
+// truncateText shortens text to at most length bytes, appending an ellipsis when truncated
+func truncateText(text string, length int) string {
+	if len(text) <= length {
+		return text
+	}
+	cut := length
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
@@ -0,0 +1,32 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderLinksOnlyHTTPCitations(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		wantHTML     string
+		wantMarkdown string
+	}{
+		{"https", "https://example.com/a", `<a href="https://example.com/a">Guide</a>`, "[Guide](https://example.com/a)"},
+		{"parentheses and spaces", "https://example.com/a (b)", `<a href="https://example.com/a (b)">Guide</a>`, "[Guide](https://example.com/a%20%28b%29)"},
+		{"javascript", "javascript:alert(1)", "<li id=\"cite-1\" value=\"1\">Guide</li>", "[^1]: Guide"},
+		{"data", "data:text/html,<script>alert(1)</script>", "<li id=\"cite-1\" value=\"1\">Guide</li>", "[^1]: Guide"},
+		{"relative", "/docs/guide", "<li id=\"cite-1\" value=\"1\">Guide</li>", "[^1]: Guide"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			citations := []Citation{{Number: 1, ChunkID: "doc_0_chunk_0", DocumentID: "doc_0", Title: "Guide", URL: tt.url}}
+			if got := renderHTML("Answer [1].", citations); !strings.Contains(got, tt.wantHTML) {
+				t.Errorf("renderHTML() = %s, want it to contain %s", got, tt.wantHTML)
+			}
+			if got := renderMarkdown("Answer [1].", citations); !strings.Contains(got, tt.wantMarkdown) {
+				t.Errorf("renderMarkdown() = %s, want it to contain %s", got, tt.wantMarkdown)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ReportRequest asks for a long-form report following an outline
+type ReportRequest struct {
+	Outline   ReportOutline `json:"outline"`
+	StoreName string        `json:"store_name,omitempty" jsonschema_description:"Configured vector store to retrieve from; the configured collections when empty"`
+}
+
+// ReportOutline is the title and sections of a report to generate
+type ReportOutline struct {
+	Title    string                 `json:"title"`
+	Sections []ReportOutlineSection `json:"sections"`
+}
+
+// ReportOutlineSection is one section of a report outline
+type ReportOutlineSection struct {
+	Heading  string `json:"heading"`
+	Guidance string `json:"guidance,omitempty"` // What the section should cover, also used for retrieval
+}
+
+// Report is a generated report with citations numbered across all sections
+type Report struct {
+	Title     string          `json:"title"`
+	Sections  []ReportSection `json:"sections"`
+	Citations []Citation      `json:"citations"`
+	Markdown  string          `json:"markdown"`            // Whole report with Markdown footnote citations
+	Revisions []string        `json:"revisions,omitempty"` // Inconsistencies between sections fixed by the consistency pass
+}
+
+// ReportSection is a generated report section
+type ReportSection struct {
+	Heading   string     `json:"heading"`
+	Content   string     `json:"content"` // Markdown with [n] citation markers
+	Citations []Citation `json:"citations"`
+}
+
+// Report progress events
+const (
+	ReportEventSection     = "section"     // A section was drafted
+	ReportEventConsistency = "consistency" // The consistency pass finished
+)
+
+// ReportProgress is streamed as a report is generated
+type ReportProgress struct {
+	Event   string         `json:"event"`             // One of the ReportEvent types
+	Section *ReportSection `json:"section,omitempty"` // Drafted section of a section event
+	Index   int            `json:"index"`             // Position of the section, starting at 1
+	Total   int            `json:"total"`             // Number of sections in the outline
+}
+
+// GenerateReport writes a cited report section by section; see GenerateReportStream
+func (p *AgenticRAGProcessor) GenerateReport(ctx context.Context, outline ReportOutline, storeName string) (*Report, error) {
+	return p.GenerateReportStream(ctx, outline, storeName, nil)
+}
+
+// GenerateReportStream retrieves sources for each outline section from the named vector store, or
+// from the configured collections when storeName is empty, and drafts the section from them. A
+// final consistency pass revises sections that contradict or repeat each other; when it fails the
+// drafts are kept. Progress is passed to cb, which may be nil, as each step finishes.
+func (p *AgenticRAGProcessor) GenerateReportStream(ctx context.Context, outline ReportOutline, storeName string, cb func(context.Context, ReportProgress) error) (*Report, error) {
+	if len(outline.Sections) == 0 {
+		return nil, fmt.Errorf("outline has no sections")
+	}
+	progress := func(event ReportProgress) error {
+		if cb == nil {
+			return nil
+		}
+		event.Total = len(outline.Sections)
+		return cb(ctx, event)
+	}
+
+	retrieve, err := p.reportRetriever(ctx, storeName)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Title: outline.Title, Sections: make([]ReportSection, 0, len(outline.Sections))}
+	sources := make([]DocumentChunk, 0) // Cited chunks in report-wide citation order
+	numbers := make(map[string]int)     // Chunk ID -> report-wide citation number
+	for i, section := range outline.Sections {
+		query := strings.TrimSpace(strings.Join([]string{outline.Title, section.Heading, section.Guidance}, " "))
+		chunks, err := retrieve(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve sources for section %q: %w", section.Heading, err)
+		}
+		content, err := p.draftReportSection(ctx, outline, i, chunks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to draft section %q: %w", section.Heading, err)
+		}
+
+		// Renumber the section's citations into the report-wide numbering
+		renumber := make(map[int]int)
+		for _, citation := range buildCitations(content, chunks) {
+			chunk := chunks[citation.Number-1]
+			if _, ok := numbers[chunk.ID]; !ok {
+				sources = append(sources, chunk)
+				numbers[chunk.ID] = len(sources)
+			}
+			renumber[citation.Number] = numbers[chunk.ID]
+		}
+		content = renumberCitations(content, renumber)
+
+		drafted := ReportSection{Heading: section.Heading, Content: content, Citations: buildCitations(content, sources)}
+		report.Sections = append(report.Sections, drafted)
+		if err := progress(ReportProgress{Event: ReportEventSection, Section: &drafted, Index: i + 1}); err != nil {
+			return nil, err
+		}
+	}
+
+	if revisions, err := p.reviseReportConsistency(ctx, report); err == nil {
+		report.Revisions = revisions
+		for i := range report.Sections {
+			report.Sections[i].Citations = buildCitations(report.Sections[i].Content, sources)
+		}
+	}
+	if err := progress(ReportProgress{Event: ReportEventConsistency, Index: len(outline.Sections)}); err != nil {
+		return nil, err
+	}
+
+	var body strings.Builder
+	if report.Title != "" {
+		body.WriteString("# " + report.Title + "\n\n")
+	}
+	for _, section := range report.Sections {
+		body.WriteString("## " + section.Heading + "\n\n" + section.Content + "\n\n")
+	}
+	report.Citations = buildCitations(body.String(), sources)
+	report.Markdown = renderMarkdown(strings.TrimSpace(body.String()), report.Citations)
+	return report, nil
+}
+
+// reportRetriever returns the retrieval used for report sections: a similarity search of the named
+// vector store, or a BM25 search of the chunked corpus
+func (p *AgenticRAGProcessor) reportRetriever(ctx context.Context, storeName string) (func(context.Context, string) ([]DocumentChunk, error), error) {
+	if storeName != "" {
+		store, err := p.namedVectorStore(ctx, storeName)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, query string) ([]DocumentChunk, error) {
+			embedderName, err := p.retrievalEmbedder(query)
+			if err != nil {
+				return nil, err
+			}
+			embeddings, err := p.cachedEmbeddings(ctx, embedderName, []string{query})
+			if err != nil {
+				return nil, err
+			}
+			ctx = withEmbedder(ctx, embedderName, len(embeddings[0]))
+			results, err := store.Search(ctx, embeddings[0], p.vectorTopK(), nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search vector store: %w", err)
+			}
+			chunks := make([]DocumentChunk, len(results))
+			for i, result := range results {
+				chunks[i] = chunkFromRecord(result.Record)
+				chunks[i].RelevanceScore = result.Score
+			}
+			return p.aboveSimilarityThreshold(chunks), nil
+		}, nil
+	}
+
+	documents, err := p.corpusDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	corpus := make([]DocumentChunk, 0)
+	for _, doc := range documents {
+		chunks, err := p.chunkDocument(ctx, doc, math.MaxInt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to chunk document %s: %w", doc.ID, err)
+		}
+		corpus = append(corpus, chunks...)
+	}
+	return func(ctx context.Context, query string) ([]DocumentChunk, error) {
+		chunks := p.keywordSearch(query, corpus)
+		if len(chunks) > p.vectorTopK() {
+			chunks = chunks[:p.vectorTopK()]
+		}
+		return chunks, nil
+	}, nil
+}
+
+// draftReportSection writes one section from its numbered sources, knowing the rest of the outline
+func (p *AgenticRAGProcessor) draftReportSection(ctx context.Context, outline ReportOutline, index int, sources []DocumentChunk) (string, error) {
+	section := outline.Sections[index]
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("You are writing the section %q of the report %q.\n", section.Heading, outline.Title))
+	if section.Guidance != "" {
+		prompt.WriteString(fmt.Sprintf("The section should cover: %s\n", section.Guidance))
+	}
+	prompt.WriteString("\nReport outline:\n")
+	for i, other := range outline.Sections {
+		marker := ""
+		if i == index {
+			marker = " (this section)"
+		}
+		prompt.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, other.Heading, marker))
+	}
+	prompt.WriteString("\nSources:\n")
+	for i, chunk := range sources {
+		prompt.WriteString(fmt.Sprintf("[%d] %s\n\n", i+1, chunk.Content))
+	}
+	prompt.WriteString(`Write only the body of this section in Markdown, without its heading. Stay within its scope, leaving topics of other sections to them.
+Support every claim with the numbered sources, citing them as [1], [2]. If the sources do not cover the section, say so briefly.`)
+
+	text, err := p.generateText(ctx, prompt.String(), 0.3, 2048)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// reviseReportConsistency asks the model to fix contradictions and repetition between sections,
+// replacing the revised sections' content, and returns the issues it fixed
+func (p *AgenticRAGProcessor) reviseReportConsistency(ctx context.Context, report *Report) ([]string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Review this report draft for contradictions, inconsistent terminology or figures, and content repeated across sections.\n\n")
+	for i, section := range report.Sections {
+		prompt.WriteString(fmt.Sprintf("Section %d: %s\n%s\n\n", i+1, section.Heading, section.Content))
+	}
+	prompt.WriteString(`Respond with only JSON: {"issues": ["what was inconsistent"], "sections": [{"section": 1, "content": "revised section body"}]}
+Include only the sections you changed, keep their [n] citation markers on the claims they support, and do not add claims. Respond with empty lists when the draft is consistent.`)
+
+	text, err := p.generateText(ctx, prompt.String(), 0.1, 8192)
+	if err != nil {
+		return nil, err
+	}
+	var review struct {
+		Issues   []string `json:"issues"`
+		Sections []struct {
+			Section int    `json:"section"`
+			Content string `json:"content"`
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(text)), &review); err != nil {
+		return nil, fmt.Errorf("failed to parse consistency review: %w", err)
+	}
+	for _, revision := range review.Sections {
+		content := strings.TrimSpace(revision.Content)
+		if revision.Section < 1 || revision.Section > len(report.Sections) || content == "" {
+			continue
+		}
+		report.Sections[revision.Section-1].Content = content
+	}
+	return review.Issues, nil
+}
+
+// renumberCitations rewrites citation markers to new numbers as [n], dropping markers that have none
+func renumberCitations(text string, numbers map[int]int) string {
+	return citationPattern.ReplaceAllStringFunc(text, func(marker string) string {
+		match := citationPattern.FindStringSubmatch(marker)
+		number, err := strconv.Atoi(match[1] + match[2])
+		if err != nil {
+			return marker
+		}
+		if renumbered, ok := numbers[number]; ok {
+			return fmt.Sprintf("[%d]", renumbered)
+		}
+		return ""
+	})
+}
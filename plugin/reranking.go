@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// RerankingConfig contains configuration for the reranking stage between retrieval and generation
+type RerankingConfig struct {
+	Enabled   bool     `json:"enabled"`
+	TopN      int      `json:"top_n"`                // Candidates rescored by the reranker; the rest keep their order after them
+	MinScore  float64  `json:"min_score"`            // Reranked candidates scoring below this are dropped
+	ModelName string   `json:"model_name,omitempty"` // Model for the LLM reranker; defaults to the pipeline model
+	Reranker  Reranker `json:"-"`                    // Dedicated reranker such as a cross-encoder; the LLM reranker is used when unset (not serialized)
+}
+
+// Reranker scores how relevant each document is to a query, returning one score in [0, 1] per document
+type Reranker interface {
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+// rerankChunks rescores the top-N chunks with the configured reranker and orders them by the new
+// scores. A reranker failure is handled by the degradation policy.
+func (p *AgenticRAGProcessor) rerankChunks(ctx context.Context, query string, chunks []DocumentChunk) ([]DocumentChunk, error) {
+	cfg := p.config.Processing.Reranking
+	if !cfg.Enabled || len(chunks) == 0 {
+		return chunks, nil
+	}
+	topN := cfg.TopN
+	if topN <= 0 || topN > len(chunks) {
+		topN = len(chunks)
+	}
+
+	documents := make([]string, topN)
+	for i, chunk := range chunks[:topN] {
+		documents[i] = chunk.Content
+	}
+
+	var scores []float64
+	var err error
+	if cfg.Reranker != nil {
+		scores, err = cfg.Reranker.Rerank(ctx, query, documents)
+	} else {
+		scores, err = p.rerankWithLLM(ctx, query, documents)
+	}
+	if err == nil && len(scores) != topN {
+		err = fmt.Errorf("reranker returned %d scores for %d documents", len(scores), topN)
+	}
+	if err != nil {
+		if err := p.degrade(ctx, SubsystemReranker, fmt.Errorf("failed to rerank chunks: %w", err)); err != nil {
+			return nil, err
+		}
+		return chunks, nil
+	}
+
+	reranked := make([]DocumentChunk, 0, len(chunks))
+	for i, chunk := range chunks[:topN] {
+		if scores[i] < cfg.MinScore {
+			continue
+		}
+		chunk.RelevanceScore = scores[i]
+		reranked = append(reranked, chunk)
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].RelevanceScore > reranked[j].RelevanceScore
+	})
+	p.config.Metrics.IncCounter("agentic_rag_reranked_chunks_total", float64(topN))
+	return append(reranked, chunks[topN:]...), nil
+}
+
+// rerankWithLLM scores documents with the reranking dotprompt, or an inline prompt when it is missing
+func (p *AgenticRAGProcessor) rerankWithLLM(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if err := p.initializePrompts(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize prompts: %w", err)
+	}
+	// A dedicated reranker model applies unless residency routing already selected an endpoint
+	if modelName := p.config.Processing.Reranking.ModelName; modelName != "" && endpointFromContext(ctx) == nil {
+		ctx = withEndpoint(ctx, &ProviderEndpoint{Name: "reranker", ModelName: modelName})
+	}
+
+	var responseText string
+	if prompt := genkit.LookupPrompt(p.config.Genkit, p.config.Prompts.RerankingPrompt); prompt != nil {
+		response, err := p.executePrompt(ctx, prompt, ai.WithInput(map[string]any{
+			"query":     query,
+			"documents": documents,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		responseText = response.Text()
+	} else {
+		var builder strings.Builder
+		for i, document := range documents {
+			builder.WriteString(fmt.Sprintf("\n[%d] %s\n", i, document))
+		}
+		prompt := fmt.Sprintf(`You are a search result reranker. Score how well each document answers the query, from 0.0 (irrelevant) to 1.0 (directly answers it). Judge each document on its own content.
+
+Query: %q
+
+Documents:
+%s
+Respond with a JSON array containing one element per document, with "index" (0-based document index) and "score".
+
+Example: [{"index": 0, "score": 0.9}, {"index": 1, "score": 0.2}]`, query, builder.String())
+
+		text, err := p.generateText(ctx, prompt, 0.0, 1000)
+		if err != nil {
+			return nil, err
+		}
+		responseText = text
+	}
+	return parseRerankScores(responseText, len(documents))
+}
+
+// parseRerankScores reads a JSON array of index/score pairs, or an object holding it under "scores"
+func parseRerankScores(text string, count int) ([]float64, error) {
+	type indexScore struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+	var pairs []indexScore
+	text = extractJSON(text)
+	if err := json.Unmarshal([]byte(text), &pairs); err != nil {
+		var wrapped struct {
+			Scores []indexScore `json:"scores"`
+		}
+		if err := json.Unmarshal([]byte(text), &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to parse reranker response: %w", err)
+		}
+		pairs = wrapped.Scores
+	}
+
+	scores := make([]float64, count)
+	seen := make([]bool, count)
+	for _, pair := range pairs {
+		if pair.Index < 0 || pair.Index >= count {
+			return nil, fmt.Errorf("reranker returned invalid index %d", pair.Index)
+		}
+		scores[pair.Index], seen[pair.Index] = pair.Score, true
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("reranker returned no score for document %d", i)
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Routes chosen by the query router
+const (
+	RouteRetrieval = "retrieval" // Run the full agentic RAG pipeline
+	RouteDirect    = "direct"    // Answer from the model without retrieval
+)
+
+// RoutingConfig contains configuration for routing queries between retrieval and direct generation
+type RoutingConfig struct {
+	Enabled       bool    `json:"enabled"`
+	UseLLM        bool    `json:"use_llm"`        // Classify with the model when the heuristics are inconclusive
+	MinConfidence float64 `json:"min_confidence"` // Minimum confidence required to skip retrieval
+}
+
+// RoutingDecision records how the router handled a query
+type RoutingDecision struct {
+	Route      string  `json:"route"`              // RouteRetrieval or RouteDirect
+	Category   string  `json:"category,omitempty"` // e.g. "chit_chat", "math", "general_knowledge", "domain"
+	Confidence float64 `json:"confidence"`
+	Method     string  `json:"method"` // "heuristic" or "llm"
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// chitChatPhrases are short conversational messages that never need retrieval
+var chitChatPhrases = map[string]bool{
+	"hi": true, "hello": true, "hey": true, "thanks": true, "thank you": true, "thx": true,
+	"good morning": true, "good afternoon": true, "good evening": true, "how are you": true,
+	"bye": true, "goodbye": true, "ok": true, "okay": true, "cool": true, "great": true,
+}
+
+// arithmeticPattern matches queries that are plain arithmetic expressions
+var arithmeticPattern = regexp.MustCompile(`^(?i:what\s+is\s+|calculate\s+|compute\s+)?[\d\s.,()]*\d\s*[-+*/^%x×÷]\s*[\d\s.,()+\-*/^%x×÷]*\d[\s)]*[?=]?$`)
+
+// routeQuery decides whether the query needs retrieval
+func (p *AgenticRAGProcessor) routeQuery(ctx context.Context, query string) *RoutingDecision {
+	normalized := strings.ToLower(strings.TrimSpace(strings.TrimRight(strings.TrimSpace(query), "!?.")))
+
+	if chitChatPhrases[normalized] {
+		return &RoutingDecision{Route: RouteDirect, Category: "chit_chat", Confidence: 0.95, Method: "heuristic"}
+	}
+	if arithmeticPattern.MatchString(strings.TrimSpace(query)) {
+		return &RoutingDecision{Route: RouteDirect, Category: "math", Confidence: 0.9, Method: "heuristic"}
+	}
+
+	if p.config.Routing.UseLLM {
+		if decision, err := p.routeQueryWithLLM(ctx, query); err == nil {
+			return decision
+		}
+	}
+
+	// Default to retrieval when unsure; skipping it wrongly is costlier than running it needlessly
+	return &RoutingDecision{Route: RouteRetrieval, Category: "domain", Confidence: 0.5, Method: "heuristic"}
+}
+
+// routeQueryWithLLM asks the model to classify the query
+func (p *AgenticRAGProcessor) routeQueryWithLLM(ctx context.Context, query string) (*RoutingDecision, error) {
+	prompt := fmt.Sprintf(`You are a query router for a document question-answering system. Decide whether the question below needs to be answered from the user's documents or can be answered directly.
+
+Question: %s
+
+Answer directly only for chit-chat, arithmetic, or widely known general knowledge. Anything about specific products, organizations, policies, or documents needs retrieval.
+
+Respond with a JSON object with "route" ("retrieval" or "direct"), "category" ("chit_chat", "math", "general_knowledge", or "domain"), "confidence" (0.0-1.0), and "reason" (one short sentence).`, query)
+
+	responseText, err := p.generateText(ctx, prompt, 0.0, 200)
+	if err != nil {
+		return nil, err
+	}
+
+	var decision RoutingDecision
+	if err := json.Unmarshal([]byte(extractJSON(responseText)), &decision); err != nil {
+		return nil, fmt.Errorf("failed to parse routing response: %w", err)
+	}
+	if decision.Route != RouteRetrieval && decision.Route != RouteDirect {
+		return nil, fmt.Errorf("routing returned unknown route %q", decision.Route)
+	}
+	decision.Method = "llm"
+	return &decision, nil
+}
+
+// answerDirectly answers a query without retrieval
+func (p *AgenticRAGProcessor) answerDirectly(ctx context.Context, request AgenticRAGRequest, persona *PersonaConfig, decision *RoutingDecision, startTime time.Time) (*AgenticRAGResponse, error) {
+	var builder strings.Builder
+	if persona != nil {
+		builder.WriteString(persona.instructions())
+	}
+	builder.WriteString("Answer the following question concisely.\n\nQuestion: ")
+	builder.WriteString(request.Query)
+
+	answer, err := p.generateText(ctx, builder.String(), float64(request.Options.Temperature), 1000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate direct response: %w", err)
+	}
+
+	formattedAnswer := ""
+	if request.Options.OutputFormat != "" {
+		formattedAnswer, err = renderAnswer(request.Options.OutputFormat, answer, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bundle *AnswerBundle
+	if request.Options.SignAnswer {
+		bundle, err = p.signAnswer(ctx, answer, nil, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign answer: %w", err)
+		}
+	}
+
+	modelCalls := 1
+	if decision.Method == "llm" {
+		modelCalls++
+	}
+
+	return &AgenticRAGResponse{
+		Answer:          answer,
+		FormattedAnswer: formattedAnswer,
+		RelevantChunks:  []ProcessedChunk{},
+		Bundle:          bundle,
+		ProcessingMetadata: ProcessingMetadata{
+			ProcessingTime: time.Since(startTime),
+			ModelCalls:     modelCalls,
+			TokensUsed:     estimateTokens(answer),
+			Routing:        decision,
+		},
+	}, nil
+}
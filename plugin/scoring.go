@@ -0,0 +1,197 @@
+package plugin
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// ScoringConfig contains configuration for combining ranking signals into chunk relevance scores
+type ScoringConfig struct {
+	Weights         ScoreWeights  `json:"weights"`
+	RecencyHalfLife time.Duration `json:"recency_half_life"` // Source age at which the recency signal halves (default: 365 days)
+	Combiner        ScoreCombiner `json:"-"`                 // Custom combiner; the weighted combiner when unset (not serialized)
+}
+
+// ScoreWeights weighs the ranking signals of the default combiner. Relevance signals are averaged
+// by weight; credibility and boost multiply the average, raised to their weight (0 ignores them).
+// All-zero weights use the defaults: model score only, scaled by credibility and boost.
+type ScoreWeights struct {
+	LLM         float64 `json:"llm"`
+	Vector      float64 `json:"vector"`
+	BM25        float64 `json:"bm25"`
+	Recency     float64 `json:"recency"`
+	Credibility float64 `json:"credibility"`
+	Boost       float64 `json:"boost"`
+}
+
+// defaultScoreWeights rank by model score scaled by credibility and static boosts
+var defaultScoreWeights = ScoreWeights{LLM: 1, Credibility: 1, Boost: 1}
+
+// ChunkSignals are the ranking signals of a scored chunk. Optional signals are nil when they
+// could not be computed, e.g. vector similarity without an embedder or recency without a date.
+type ChunkSignals struct {
+	LLM         float64  `json:"llm"`               // Model relevance score, 0-1
+	Vector      *float64 `json:"vector,omitempty"`  // Cosine similarity of the chunk and query embeddings
+	BM25        *float64 `json:"bm25,omitempty"`    // BM25 keyword score divided by the best score among the chunks
+	Recency     *float64 `json:"recency,omitempty"` // Time since the source was updated, decayed to 0-1 by the recency half-life
+	Credibility float64  `json:"credibility"`       // Source credibility multiplier, 1 when disabled
+	Boost       float64  `json:"boost"`             // Static document boost, 1 when unset
+}
+
+// ScoreCombiner turns a chunk's ranking signals into its final relevance score; chunks are
+// ranked by the combined score, highest first
+type ScoreCombiner interface {
+	Combine(ctx context.Context, query string, chunk DocumentChunk, signals ChunkSignals) float64
+}
+
+// WeightedScoreCombiner is the default combiner, weighing signals by configured weights
+type WeightedScoreCombiner struct {
+	Weights ScoreWeights
+}
+
+// Combine returns the weighted average of the available relevance signals scaled by credibility
+// and boost
+func (c WeightedScoreCombiner) Combine(ctx context.Context, query string, chunk DocumentChunk, signals ChunkSignals) float64 {
+	weights := c.Weights
+	if weights == (ScoreWeights{}) {
+		weights = defaultScoreWeights
+	}
+
+	sum, total := weights.LLM*signals.LLM, weights.LLM
+	for _, signal := range []struct {
+		weight float64
+		value  *float64
+	}{
+		{weights.Vector, signals.Vector},
+		{weights.BM25, signals.BM25},
+		{weights.Recency, signals.Recency},
+	} {
+		if signal.weight > 0 && signal.value != nil {
+			sum += signal.weight * *signal.value
+			total += signal.weight
+		}
+	}
+	score := signals.LLM
+	if total > 0 {
+		score = sum / total
+	}
+	if weights.Credibility != 0 {
+		score *= math.Pow(signals.Credibility, weights.Credibility)
+	}
+	if weights.Boost != 0 {
+		score *= math.Pow(signals.Boost, weights.Boost)
+	}
+	return score
+}
+
+// combineScores replaces the model scores of the chunks with the configured combiner's scores
+// and ranks the chunks by them
+func (p *AgenticRAGProcessor) combineScores(ctx context.Context, query string, chunks []DocumentChunk) []DocumentChunk {
+	if len(chunks) == 0 {
+		return chunks
+	}
+	cfg := p.config.Processing.Scoring
+	combiner := cfg.Combiner
+	weights := cfg.Weights
+	if weights == (ScoreWeights{}) {
+		weights = defaultScoreWeights
+	}
+	if combiner == nil {
+		combiner = WeightedScoreCombiner{Weights: weights}
+	}
+
+	// Costlier signals are only computed when a custom combiner may use them or they are weighted
+	custom := cfg.Combiner != nil
+	signals := make([]ChunkSignals, len(chunks))
+	for i, chunk := range chunks {
+		signals[i] = ChunkSignals{
+			LLM:         chunk.RelevanceScore,
+			Credibility: 1,
+			Boost:       1,
+		}
+		if p.config.Credibility.Enabled {
+			signals[i].Credibility = p.chunkCredibility(chunk)
+		}
+		if boost, ok := metadataFloat(chunk.Metadata, boostMetadataKey); ok {
+			signals[i].Boost = boost
+		}
+		if custom || weights.Recency > 0 {
+			if date, ok := p.chunkDate(chunk); ok {
+				recency := recencyDecay(time.Since(date), cfg.RecencyHalfLife)
+				signals[i].Recency = &recency
+			}
+		}
+	}
+	if custom || weights.Vector > 0 {
+		p.vectorSignals(ctx, query, chunks, signals)
+	}
+	if custom || weights.BM25 > 0 {
+		p.keywordSignals(query, chunks, signals)
+	}
+
+	for i := range chunks {
+		chunks[i].RelevanceScore = combiner.Combine(ctx, query, chunks[i], signals[i])
+	}
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].RelevanceScore > chunks[j].RelevanceScore
+	})
+	return chunks
+}
+
+// vectorSignals sets the embedding similarity of each chunk to the query; without an embedder, or
+// when embedding fails, the signal is left unset
+func (p *AgenticRAGProcessor) vectorSignals(ctx context.Context, query string, chunks []DocumentChunk, signals []ChunkSignals) {
+	embedderName := p.config.VectorRetrieval.EmbedderName
+	if embedderName == "" {
+		embedderName = p.embedderNameFor(p.detectLanguage(query))
+	}
+	if embedderName == "" {
+		return
+	}
+
+	texts := make([]string, len(chunks)+1)
+	texts[0] = query
+	for i, chunk := range chunks {
+		texts[i+1] = chunk.Content
+	}
+	embeddings, err := p.cachedEmbeddings(ctx, embedderName, texts)
+	if err != nil {
+		return
+	}
+	for i := range chunks {
+		similarity := cosineSimilarity(embeddings[0], embeddings[i+1])
+		signals[i].Vector = &similarity
+	}
+}
+
+// keywordSignals sets each chunk's BM25 score relative to the best scoring chunk; chunks without
+// query terms score 0
+func (p *AgenticRAGProcessor) keywordSignals(query string, chunks []DocumentChunk, signals []ChunkSignals) {
+	ranked := p.keywordSearch(query, chunks)
+	scores := make(map[string]float64, len(ranked))
+	best := 0.0
+	for _, chunk := range ranked {
+		scores[chunk.ID] = chunk.RelevanceScore
+		best = math.Max(best, chunk.RelevanceScore)
+	}
+	for i, chunk := range chunks {
+		score := 0.0
+		if best > 0 {
+			score = scores[chunk.ID] / best
+		}
+		signals[i].BM25 = &score
+	}
+}
+
+// recencyDecay maps a source age to 0-1, halving every half-life; future dates score 1
+func recencyDecay(age, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		halfLife = 365 * 24 * time.Hour
+	}
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(age) / float64(halfLife))
+}
@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Actions taken for documents containing secrets
+const (
+	SecretActionReject = "reject" // Drop the document from ingestion
+	SecretActionRedact = "redact" // Replace each secret with a placeholder
+	SecretActionFlag   = "flag"   // Keep the document and mark it in metadata
+)
+
+// SecretScanningConfig contains configuration for leaked-secret scanning during ingestion
+type SecretScanningConfig struct {
+	Enabled          bool              `json:"enabled"`
+	Action           string            `json:"action"`                   // SecretActionReject, SecretActionRedact, or SecretActionFlag
+	EntropyThreshold float64           `json:"entropy_threshold"`        // Minimum Shannon entropy (bits/char) for an unlabelled token to count as a secret; 0 disables
+	MinEntropyLength int               `json:"min_entropy_length"`       // Minimum token length considered by the entropy heuristic
+	Patterns         map[string]string `json:"patterns,omitempty"`       // Additional named regex patterns
+	AllowList        []string          `json:"allow_list,omitempty"`     // Exact values never reported (e.g. documented example keys)
+	IgnoreSources    []string          `json:"ignore_sources,omitempty"` // Glob patterns on document source that are not scanned
+}
+
+// SecretFinding records a secret detected in a document, without the secret itself
+type SecretFinding struct {
+	DocumentID string `json:"document_id"`
+	Type       string `json:"type"`
+	Offset     int    `json:"offset"`
+	Preview    string `json:"preview"` // Masked value, e.g. "AKIA************MPLE"
+	Action     string `json:"action"`
+}
+
+// secretMetadataKey marks documents that were flagged for containing secrets
+const secretMetadataKey = "contains_secrets"
+
+// builtinSecretPatterns are well-known credential formats
+var builtinSecretPatterns = map[string]*regexp.Regexp{
+	"private_key":        regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----.*?-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----`),
+	"aws_access_key":     regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"github_token":       regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`),
+	"slack_token":        regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),
+	"google_api_key":     regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	"openai_api_key":     regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}\b`),
+	"stripe_secret_key":  regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`),
+	"jwt":                regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	"credential_setting": regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|access[_-]?token|auth[_-]?token|password|passwd)\b\s*[:=]\s*["']?([^\s"'<>]{8,})`),
+}
+
+// entropyCandidate matches tokens the entropy heuristic considers
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+
+// secretMatch is a secret located in document content
+type secretMatch struct {
+	kind       string
+	start, end int
+}
+
+// scanDocumentsForSecrets applies the configured secret action to every document and reports findings
+func (p *AgenticRAGProcessor) scanDocumentsForSecrets(documents []Document) ([]Document, []SecretFinding, error) {
+	cfg := p.config.SecretScanning
+	if !cfg.Enabled {
+		return documents, nil, nil
+	}
+
+	patterns := make(map[string]*regexp.Regexp, len(builtinSecretPatterns)+len(cfg.Patterns))
+	for name, pattern := range builtinSecretPatterns {
+		patterns[name] = pattern
+	}
+	for name, expr := range cfg.Patterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid secret pattern %q: %w", name, err)
+		}
+		patterns[name] = pattern
+	}
+
+	kept := make([]Document, 0, len(documents))
+	findings := make([]SecretFinding, 0)
+	for _, doc := range documents {
+		ignored := false
+		for _, pattern := range cfg.IgnoreSources {
+			if globMatch(pattern, doc.Source) {
+				ignored = true
+				break
+			}
+		}
+
+		var matches []secretMatch
+		if !ignored {
+			matches = p.findSecrets(doc.Content, patterns)
+		}
+		if len(matches) == 0 {
+			kept = append(kept, doc)
+			continue
+		}
+
+		for _, match := range matches {
+			findings = append(findings, SecretFinding{
+				DocumentID: doc.ID,
+				Type:       match.kind,
+				Offset:     match.start,
+				Preview:    maskSecret(doc.Content[match.start:match.end]),
+				Action:     cfg.Action,
+			})
+		}
+
+		switch cfg.Action {
+		case SecretActionReject:
+			continue
+		case SecretActionRedact:
+			doc.Content = redactSecrets(doc.Content, matches)
+		default:
+			doc.Metadata[secretMetadataKey] = true
+		}
+		kept = append(kept, doc)
+	}
+
+	return kept, findings, nil
+}
+
+// findSecrets returns non-overlapping secret matches ordered by position
+func (p *AgenticRAGProcessor) findSecrets(content string, patterns map[string]*regexp.Regexp) []secretMatch {
+	cfg := p.config.SecretScanning
+	allowed := make(map[string]bool, len(cfg.AllowList))
+	for _, value := range cfg.AllowList {
+		allowed[value] = true
+	}
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matches := make([]secretMatch, 0)
+	for _, name := range names {
+		for _, loc := range patterns[name].FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			// Patterns with a capture group only redact the captured value
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if !allowed[content[start:end]] {
+				matches = append(matches, secretMatch{kind: name, start: start, end: end})
+			}
+		}
+	}
+
+	if cfg.EntropyThreshold > 0 {
+		minLength := cfg.MinEntropyLength
+		if minLength <= 0 {
+			minLength = 20
+		}
+		for _, loc := range entropyCandidate.FindAllStringIndex(content, -1) {
+			token := content[loc[0]:loc[1]]
+			if len(token) < minLength || allowed[token] || !hasLetterAndDigit(token) {
+				continue
+			}
+			if shannonEntropy(token) >= cfg.EntropyThreshold {
+				matches = append(matches, secretMatch{kind: "high_entropy_string", start: loc[0], end: loc[1]})
+			}
+		}
+	}
+
+	// Keep the earliest, longest match where matches overlap
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+	merged := make([]secretMatch, 0, len(matches))
+	for _, match := range matches {
+		if len(merged) > 0 && match.start < merged[len(merged)-1].end {
+			continue
+		}
+		merged = append(merged, match)
+	}
+	return merged
+}
+
+// redactSecrets replaces each match with a typed placeholder
+func redactSecrets(content string, matches []secretMatch) string {
+	var builder strings.Builder
+	last := 0
+	for _, match := range matches {
+		builder.WriteString(content[last:match.start])
+		builder.WriteString(fmt.Sprintf("[REDACTED:%s]", match.kind))
+		last = match.end
+	}
+	builder.WriteString(content[last:])
+	return builder.String()
+}
+
+// maskSecret keeps at most four characters at each end of a secret
+func maskSecret(secret string) string {
+	if len(secret) <= 12 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", len(secret)-8) + secret[len(secret)-4:]
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		probability := float64(count) / float64(total)
+		entropy -= probability * math.Log2(probability)
+	}
+	return entropy
+}
+
+// hasLetterAndDigit reports whether s mixes letters and digits, as generated secrets do
+func hasLetterAndDigit(s string) bool {
+	hasLetter, hasDigit := false, false
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		} else if unicode.IsDigit(r) {
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit
+}
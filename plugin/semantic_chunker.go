@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"context"
+)
+
+// SemanticChunkingConfig contains configuration for splitting documents where the topic shifts
+type SemanticChunkingConfig struct {
+	Enabled      bool    `json:"enabled"`
+	EmbedderName string  `json:"embedder_name,omitempty"` // Embedder override; defaults to the per-language embedder
+	WindowSize   int     `json:"window_size"`             // Sentences averaged on each side of a candidate boundary
+	Threshold    float64 `json:"threshold"`               // Split where adjacent windows are less similar than this
+	BatchSize    int     `json:"batch_size"`              // Sentences embedded per embedder call
+}
+
+// semanticBreaks reports, for each sentence, whether a chunk should start before it because the
+// cosine similarity of the sentence windows around that boundary drops below the threshold.
+// It returns nil when semantic chunking is disabled or embeddings are unavailable, leaving
+// the chunk size as the only boundary.
+func (p *AgenticRAGProcessor) semanticBreaks(ctx context.Context, sentences []string, language string) []bool {
+	if !p.config.SemanticChunking.Enabled {
+		return nil
+	}
+	return p.topicBreaks(ctx, sentences, language)
+}
+
+// topicBreaks computes the semantic chunk boundaries regardless of whether semantic chunking is
+// enabled for the default chunker; it returns nil when embeddings are unavailable
+func (p *AgenticRAGProcessor) topicBreaks(ctx context.Context, sentences []string, language string) []bool {
+	cfg := p.config.SemanticChunking
+	if len(sentences) < 2 {
+		return nil
+	}
+	embedderName := cfg.EmbedderName
+	if embedderName == "" {
+		embedderName = p.embedderNameFor(language)
+	}
+	if embedderName == "" {
+		return nil
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 64
+	}
+	embeddings := make([][]float32, 0, len(sentences))
+	for start := 0; start < len(sentences); start += batchSize {
+		batch, err := p.embedTexts(ctx, embedderName, sentences[start:min(start+batchSize, len(sentences))])
+		if err != nil {
+			p.config.Metrics.IncCounter("agentic_rag_semantic_chunking_errors_total", 1)
+			return nil
+		}
+		embeddings = append(embeddings, batch...)
+	}
+
+	window := max(cfg.WindowSize, 1)
+	similarities := make([]float64, len(sentences))
+	for i := 1; i < len(sentences); i++ {
+		left := meanVector(embeddings[max(0, i-window):i])
+		right := meanVector(embeddings[i:min(len(sentences), i+window)])
+		similarities[i] = cosineSimilarity(left, right)
+	}
+
+	// Overlapping windows dip on both sides of a topic shift, so only the deepest point splits
+	breaks := make([]bool, len(sentences))
+	for i := 1; i < len(sentences); i++ {
+		breaks[i] = similarities[i] < cfg.Threshold &&
+			(i == 1 || similarities[i] <= similarities[i-1]) &&
+			(i == len(sentences)-1 || similarities[i] <= similarities[i+1])
+	}
+	return breaks
+}
+
+// meanVector returns the element-wise mean of equal-length vectors
+func meanVector(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	mean := make([]float32, len(vectors[0]))
+	for _, vector := range vectors {
+		if len(vector) != len(mean) {
+			return nil
+		}
+		for i, value := range vector {
+			mean[i] += value
+		}
+	}
+	for i := range mean {
+		mean[i] /= float32(len(vectors))
+	}
+	return mean
+}
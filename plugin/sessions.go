@@ -0,0 +1,287 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transcript export formats
+const (
+	TranscriptFormatJSON     = "json"
+	TranscriptFormatMarkdown = "markdown"
+)
+
+// Session is a conversation recorded turn by turn
+type Session struct {
+	ID        string        `json:"id"`
+	UserID    string        `json:"user_id,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Turns     []SessionTurn `json:"turns"`
+}
+
+// SessionTurn is one question, its answer, and the context it was answered from
+type SessionTurn struct {
+	Query     string           `json:"query"`
+	Answer    string           `json:"answer"`
+	Citations []Citation       `json:"citations,omitempty"`
+	Contexts  []SessionContext `json:"contexts,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// SessionContext is a chunk retrieved for a turn
+type SessionContext struct {
+	ChunkID    string  `json:"chunk_id"`
+	DocumentID string  `json:"document_id"`
+	Content    string  `json:"content"`
+	Score      float64 `json:"score"`
+}
+
+// SessionStore keeps conversation sessions in memory
+type SessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewSessionStore creates an empty session store
+func NewSessionStore() *SessionStore {
+	return &SessionStore{sessions: make(map[string]*Session)}
+}
+
+// Get returns a copy of the session
+func (s *SessionStore) Get(id string) (*Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *session
+	copied.Turns = append([]SessionTurn(nil), session.Turns...)
+	return &copied, true
+}
+
+// Delete removes a session
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// IDs returns the IDs of all stored sessions
+func (s *SessionStore) IDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// record appends a turn to the session, creating it on first use
+func (s *SessionStore) record(id, userID string, turn SessionTurn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		session = &Session{ID: id, UserID: userID, CreatedAt: turn.Timestamp}
+		s.sessions[id] = session
+	}
+	session.Turns = append(session.Turns, turn)
+	session.UpdatedAt = turn.Timestamp
+}
+
+// recordSession stores the request and response as a turn of the request's session
+func (p *AgenticRAGProcessor) recordSession(request AgenticRAGRequest, response *AgenticRAGResponse) {
+	if p.config.Sessions == nil || request.SessionID == "" || response == nil {
+		return
+	}
+
+	contexts := make([]SessionContext, len(response.RelevantChunks))
+	for i, processed := range response.RelevantChunks {
+		contexts[i] = SessionContext{
+			ChunkID:    processed.Chunk.ID,
+			DocumentID: processed.Chunk.DocumentID,
+			Content:    processed.Chunk.Content,
+			Score:      processed.Chunk.RelevanceScore,
+		}
+	}
+	p.config.Sessions.record(request.SessionID, request.UserID, SessionTurn{
+		Query:     request.Query,
+		Answer:    response.Answer,
+		Citations: response.Citations,
+		Contexts:  contexts,
+		Timestamp: time.Now(),
+	})
+}
+
+// Export renders a session as a JSON or Markdown transcript
+func (s *SessionStore) Export(id, format string) ([]byte, error) {
+	session, ok := s.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("session %q not found", id)
+	}
+
+	switch format {
+	case TranscriptFormatJSON, "":
+		data, err := json.MarshalIndent(session, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal session: %w", err)
+		}
+		return data, nil
+	case TranscriptFormatMarkdown:
+		return []byte(sessionMarkdown(session)), nil
+	default:
+		return nil, fmt.Errorf("unsupported transcript format %q", format)
+	}
+}
+
+// Import loads a JSON or Markdown transcript, replacing any session with the same ID.
+// Markdown transcripts restore messages and citations but only truncated retrieved contexts.
+func (s *SessionStore) Import(data []byte) (*Session, error) {
+	var session *Session
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		session = &Session{}
+		if err := json.Unmarshal([]byte(trimmed), session); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON transcript: %w", err)
+		}
+	} else {
+		var err error
+		if session, err = parseSessionMarkdown(trimmed); err != nil {
+			return nil, err
+		}
+	}
+	if session.ID == "" {
+		return nil, fmt.Errorf("transcript has no session ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return session, nil
+}
+
+// sessionMarkdown renders a session as a readable transcript that parseSessionMarkdown can read back
+func sessionMarkdown(session *Session) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# Session %s\n\n", session.ID))
+	if session.UserID != "" {
+		builder.WriteString(fmt.Sprintf("- User: %s\n", session.UserID))
+	}
+	builder.WriteString(fmt.Sprintf("- Created: %s\n", session.CreatedAt.Format(time.RFC3339)))
+
+	for i, turn := range session.Turns {
+		builder.WriteString(fmt.Sprintf("\n## Turn %d (%s)\n\n", i+1, turn.Timestamp.Format(time.RFC3339)))
+		builder.WriteString("### User\n\n" + strings.TrimSpace(turn.Query) + "\n\n")
+		builder.WriteString("### Assistant\n\n" + strings.TrimSpace(turn.Answer) + "\n")
+
+		if len(turn.Citations) > 0 {
+			builder.WriteString("\n### Sources\n\n")
+			for _, citation := range turn.Citations {
+				line := fmt.Sprintf("%d. %s (%s, %s)", citation.Number, citationLabel(citation), citation.DocumentID, citation.ChunkID)
+				if link, ok := citationLink(citation); ok {
+					line += " <" + markdownLinkTarget(link) + ">"
+				}
+				builder.WriteString(line + "\n")
+			}
+		}
+		if len(turn.Contexts) > 0 {
+			builder.WriteString("\n### Retrieved context\n\n")
+			for _, context := range turn.Contexts {
+				builder.WriteString(fmt.Sprintf("- %s (score %.2f): %s\n", context.ChunkID, context.Score, truncateText(strings.Join(strings.Fields(context.Content), " "), 300)))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// Patterns for reading Markdown transcripts
+var (
+	transcriptTurnHeading = regexp.MustCompile(`^## Turn \d+ \((.+)\)$`)
+	transcriptSource      = regexp.MustCompile(`^(\d+)\. (.*) \(([^,()]+), ([^,()]+)\)(?: <(.+)>)?$`)
+	transcriptContext     = regexp.MustCompile(`^- (\S+) \(score ([0-9.]+)\): (.*)$`)
+)
+
+// parseSessionMarkdown reads a transcript written by sessionMarkdown
+func parseSessionMarkdown(markdown string) (*Session, error) {
+	session := &Session{}
+	var turn *SessionTurn
+	section := ""
+	var body []string
+
+	flush := func() {
+		if turn == nil {
+			return
+		}
+		text := strings.TrimSpace(strings.Join(body, "\n"))
+		switch section {
+		case "User":
+			turn.Query = text
+		case "Assistant":
+			turn.Answer = text
+		}
+		body = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(markdown))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(markdown)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# Session "):
+			session.ID = strings.TrimSpace(strings.TrimPrefix(line, "# Session "))
+		case turn == nil && strings.HasPrefix(line, "- User: "):
+			session.UserID = strings.TrimPrefix(line, "- User: ")
+		case turn == nil && strings.HasPrefix(line, "- Created: "):
+			session.CreatedAt, _ = time.Parse(time.RFC3339, strings.TrimPrefix(line, "- Created: "))
+		case transcriptTurnHeading.MatchString(line):
+			flush()
+			session.Turns = append(session.Turns, SessionTurn{})
+			turn = &session.Turns[len(session.Turns)-1]
+			turn.Timestamp, _ = time.Parse(time.RFC3339, transcriptTurnHeading.FindStringSubmatch(line)[1])
+			section = ""
+		case strings.HasPrefix(line, "### ") && turn != nil:
+			flush()
+			section = strings.TrimPrefix(line, "### ")
+		case turn != nil && section == "Sources":
+			if match := transcriptSource.FindStringSubmatch(line); match != nil {
+				number, _ := strconv.Atoi(match[1])
+				turn.Citations = append(turn.Citations, Citation{
+					Number:     number,
+					Title:      match[2],
+					DocumentID: match[3],
+					ChunkID:    match[4],
+					URL:        match[5],
+				})
+			}
+		case turn != nil && section == "Retrieved context":
+			if match := transcriptContext.FindStringSubmatch(line); match != nil {
+				score, _ := strconv.ParseFloat(match[2], 64)
+				turn.Contexts = append(turn.Contexts, SessionContext{ChunkID: match[1], Score: score, Content: match[3]})
+			}
+		default:
+			body = append(body, line)
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Markdown transcript: %w", err)
+	}
+
+	if len(session.Turns) > 0 {
+		session.UpdatedAt = session.Turns[len(session.Turns)-1].Timestamp
+	}
+	return session, nil
+}
@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// answerBundleAlgorithm identifies the signature scheme used for answer bundles
+const answerBundleAlgorithm = "Ed25519"
+
+// SigningConfig contains the key used to sign answer bundles
+type SigningConfig struct {
+	KeyID      string             `json:"key_id,omitempty"` // Identifies the public key verifiers should use
+	PrivateKey ed25519.PrivateKey `json:"-"`                // Signing key (not serialized)
+}
+
+// PromptVersion identifies a prompt by name and content hash
+type PromptVersion struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256,omitempty"` // Empty when the prompt file could not be read
+}
+
+// AnswerBundle is a signed record of an answer and its provenance
+type AnswerBundle struct {
+	Answer      string            `json:"answer"`
+	Citations   []Citation        `json:"citations,omitempty"`
+	ChunkHashes map[string]string `json:"chunk_hashes"` // Chunk ID to SHA-256 of the chunk content
+	Model       string            `json:"model"`
+	Prompts     []PromptVersion   `json:"prompts,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+	KeyID       string            `json:"key_id,omitempty"`
+	Algorithm   string            `json:"algorithm"`
+	Signature   string            `json:"signature,omitempty"` // Base64 signature over the bundle with this field empty
+}
+
+// signAnswer builds and signs the provenance bundle for an answer
+func (p *AgenticRAGProcessor) signAnswer(ctx context.Context, answer string, citations []Citation, chunks []DocumentChunk, prompts []string) (*AnswerBundle, error) {
+	cfg := p.config.Signing
+	if len(cfg.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("answer signing requires an Ed25519 private key")
+	}
+
+	bundle := &AnswerBundle{
+		Answer:      answer,
+		Citations:   citations,
+		ChunkHashes: make(map[string]string, len(chunks)),
+		Model:       p.modelName(ctx),
+		Timestamp:   time.Now().UTC(),
+		KeyID:       cfg.KeyID,
+		Algorithm:   answerBundleAlgorithm,
+	}
+	for _, chunk := range chunks {
+		sum := sha256.Sum256([]byte(chunk.Content))
+		bundle.ChunkHashes[chunk.ID] = hex.EncodeToString(sum[:])
+	}
+	for _, name := range prompts {
+		bundle.Prompts = append(bundle.Prompts, p.promptVersion(name))
+	}
+
+	payload, err := bundle.signingPayload()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(cfg.PrivateKey, payload))
+	return bundle, nil
+}
+
+// promptVersion hashes the prompt file backing a prompt name
+func (p *AgenticRAGProcessor) promptVersion(name string) PromptVersion {
+	version := PromptVersion{Name: name}
+	content, err := os.ReadFile(filepath.Join(p.config.Prompts.Directory, name+".prompt"))
+	if err == nil {
+		sum := sha256.Sum256(content)
+		version.SHA256 = hex.EncodeToString(sum[:])
+	}
+	return version
+}
+
+// signingPayload returns the canonical bytes covered by the signature
+func (b *AnswerBundle) signingPayload() ([]byte, error) {
+	unsigned := *b
+	unsigned.Signature = ""
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal answer bundle: %w", err)
+	}
+	return payload, nil
+}
+
+// VerifyAnswerBundle checks that a bundle was signed by the holder of the private key for publicKey
+func VerifyAnswerBundle(bundle *AnswerBundle, publicKey ed25519.PublicKey) error {
+	if bundle == nil {
+		return fmt.Errorf("answer bundle is nil")
+	}
+	if bundle.Algorithm != answerBundleAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm %q", bundle.Algorithm)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid Ed25519 public key")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(bundle.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	payload, err := bundle.signingPayload()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return fmt.Errorf("answer bundle signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/concurrency"
+)
+
+// SimilarDocument is a corpus document ranked by similarity to a document or text
+type SimilarDocument struct {
+	ID      string  `json:"id"`
+	Source  string  `json:"source"`
+	Title   string  `json:"title,omitempty"`
+	Score   float64 `json:"score"`
+	Method  string  `json:"method"` // "embedding" or "lexical"
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+// SimilarDocumentsRequest asks for documents similar to a corpus document or to free text
+type SimilarDocumentsRequest struct {
+	DocumentID string `json:"document_id,omitempty" jsonschema_description:"Corpus document ID or source to find neighbours of"`
+	Text       string `json:"text,omitempty" jsonschema_description:"Text to find similar documents for when no document ID is given"`
+	K          int    `json:"k,omitempty" jsonschema_description:"Number of documents to return (default: 5)"`
+}
+
+// similarityEmbeddingChars bounds how much of each document is embedded for similarity
+const similarityEmbeddingChars = 8000
+
+// embeddingCache memoizes embeddings by embedder and content hash, evicting the least recently
+// used beyond Processing.EmbeddingCacheSize
+type embeddingCache struct {
+	mu      sync.Mutex
+	vectors *lruCache[string, []float32]
+}
+
+// FindSimilar returns the k corpus documents most similar to the document with the given ID or source
+func (p *AgenticRAGProcessor) FindSimilar(ctx context.Context, documentID string, k int) ([]SimilarDocument, error) {
+	documents, err := p.corpusDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, doc := range documents {
+		if doc.ID == documentID || doc.Source == documentID {
+			others := append(append([]Document(nil), documents[:i]...), documents[i+1:]...)
+			return p.rankSimilar(ctx, doc.Content, others, k), nil
+		}
+	}
+	return nil, fmt.Errorf("document %q not found in corpus", documentID)
+}
+
+// MoreLikeText returns the k corpus documents most similar to the text
+func (p *AgenticRAGProcessor) MoreLikeText(ctx context.Context, text string, k int) ([]SimilarDocument, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	documents, err := p.corpusDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.rankSimilar(ctx, text, documents, k), nil
+}
+
+// rankSimilar scores documents against the text by embedding similarity, falling back to term overlap
+func (p *AgenticRAGProcessor) rankSimilar(ctx context.Context, text string, documents []Document, k int) []SimilarDocument {
+	if k <= 0 {
+		k = 5
+	}
+
+	results := make([]SimilarDocument, len(documents))
+	for i, doc := range documents {
+		results[i] = SimilarDocument{
+			ID:      doc.ID,
+			Source:  doc.Source,
+			Title:   metadataString(doc.Metadata, "title"),
+			Snippet: truncateText(doc.Content, 200),
+		}
+	}
+
+	scored := false
+	if embedderName := p.embedderNameFor(p.detectLanguage(text)); embedderName != "" {
+		if target, err := p.cachedEmbedding(ctx, embedderName, text); err == nil {
+			scored = true
+			for i, doc := range documents {
+				vector, err := p.cachedEmbedding(ctx, embedderName, doc.Content)
+				if err != nil {
+					scored = false
+					break
+				}
+				results[i].Score = cosineSimilarity(target, vector)
+				results[i].Method = "embedding"
+			}
+		}
+	}
+	if !scored {
+		language := p.detectLanguage(text)
+		terms := p.analyzerFor(language).terms(text)
+		for i, doc := range documents {
+			results[i].Score = jaccard(terms, p.analyzerFor(documentLanguage(doc)).terms(doc.Content))
+			results[i].Method = "lexical"
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// cachedEmbedding embeds the leading part of a text, reusing earlier embeddings of the same content
+func (p *AgenticRAGProcessor) cachedEmbedding(ctx context.Context, embedderName, text string) ([]float32, error) {
+	embeddings, err := p.cachedEmbeddings(ctx, embedderName, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// cachedEmbeddings embeds the leading part of each text, batching the texts not embedded before
+// and embedding the batches concurrently
+func (p *AgenticRAGProcessor) cachedEmbeddings(ctx context.Context, embedderName string, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	missing := make([]int, 0)
+	missingTexts := make([]string, 0)
+
+	p.embeddings.mu.Lock()
+	if p.embeddings.vectors == nil {
+		size := p.config.Processing.EmbeddingCacheSize
+		if size <= 0 {
+			size = 10000
+		}
+		p.embeddings.vectors = newLRUCache[string, []float32](size, 0)
+	}
+	for i, text := range texts {
+		if len(text) > similarityEmbeddingChars {
+			text = truncateText(text, similarityEmbeddingChars)
+		}
+		sum := sha256.Sum256([]byte(text))
+		keys[i] = embedderName + "|" + hex.EncodeToString(sum[:])
+		if vector, ok := p.embeddings.vectors.get(keys[i]); ok {
+			embeddings[i] = vector
+			continue
+		}
+		missing = append(missing, i)
+		missingTexts = append(missingTexts, text)
+	}
+	p.embeddings.mu.Unlock()
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	batchSize := p.config.Processing.EmbeddingBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	batches := make([][]string, 0, (len(missingTexts)+batchSize-1)/batchSize)
+	for start := 0; start < len(missingTexts); start += batchSize {
+		batches = append(batches, missingTexts[start:min(start+batchSize, len(missingTexts))])
+	}
+	embeddedBatches, err := concurrency.Map(ctx, batches, p.concurrencyLimit(), func(ctx context.Context, _ int, batch []string) ([][]float32, error) {
+		return p.embedTexts(ctx, embedderName, batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	embedded := make([][]float32, 0, len(missingTexts))
+	for _, batch := range embeddedBatches {
+		embedded = append(embedded, batch...)
+	}
+
+	p.embeddings.mu.Lock()
+	for j, i := range missing {
+		embeddings[i] = embedded[j]
+		p.embeddings.vectors.set(keys[i], embedded[j])
+	}
+	p.embeddings.mu.Unlock()
+	return embeddings, nil
+}
@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	v1 "github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/api/v1"
+)
+
+// StreamHandler returns an HTTP handler serving answers as server-sent events in the protocol
+// described in docs/streaming-protocol.md. POST a v1.Request as JSON, or GET with the JSON-encoded
+// request in the "request" parameter (or just a "query") for EventSource clients. Event IDs are
+// "<resume token>:<sequence>", so a reconnecting EventSource continues the answer through its
+// Last-Event-ID header instead of restarting the pipeline.
+func (p *AgenticRAGProcessor) StreamHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request v1.Request
+		switch r.Method {
+		case http.MethodGet:
+			values := r.URL.Query()
+			if encoded := values.Get("request"); encoded != "" {
+				if err := json.Unmarshal([]byte(encoded), &request); err != nil {
+					http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+					return
+				}
+			} else {
+				request.Query = values.Get("query")
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token, sequence, ok := parseStreamEventID(r.Header.Get("Last-Event-ID")); ok {
+			request.ResumeToken, request.ResumeAfter = token, sequence
+		}
+
+		converted, err := RequestFromV1(request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if converted.ResumeToken != "" && p.streams.get(converted.ResumeToken) == nil {
+			http.Error(w, ErrStreamNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Pipeline failures arrive as error events; a returned error means the client went away,
+		// unless the stream was refused before it started
+		_, err = p.ProcessStream(r.Context(), converted, func(ctx context.Context, chunk AnswerStreamChunk) error {
+			return writeStreamEvent(w, flusher, chunk)
+		})
+		if errors.Is(err, ErrTooManyStreams) {
+			writeStreamEvent(w, flusher, AnswerStreamChunk{Event: StreamEventError, Error: err.Error()})
+		}
+	})
+}
+
+// writeStreamEvent writes a chunk as a server-sent event carrying its v1 encoding
+func writeStreamEvent(w http.ResponseWriter, flusher http.Flusher, chunk AnswerStreamChunk) error {
+	var event v1.StreamChunk
+	if err := convertJSON(chunk, &event); err != nil {
+		return fmt.Errorf("failed to convert stream chunk to v1: %w", err)
+	}
+	if event.Response != nil {
+		event.Response.APIVersion = v1.Version
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode stream chunk: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %s:%d\nevent: %s\ndata: %s\n\n", chunk.ResumeToken, chunk.Sequence, chunk.Event, data); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// parseStreamEventID splits a "<resume token>:<sequence>" event ID
+func parseStreamEventID(id string) (string, int, bool) {
+	token, sequence, ok := strings.Cut(id, ":")
+	if !ok || token == "" {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(sequence)
+	if err != nil {
+		return "", 0, false
+	}
+	return token, number, true
+}
@@ -0,0 +1,303 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/concurrency"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ErrStreamNotFound is returned when a resume token is unknown or its stream has expired
+var ErrStreamNotFound = errors.New("answer stream not found or expired")
+
+// ErrTooManyStreams is returned when a new stream would exceed the configured running streams
+var ErrTooManyStreams = errors.New("too many running answer streams")
+
+// StreamingConfig contains configuration for resumable answer streams
+type StreamingConfig struct {
+	ResumeWindow time.Duration `json:"resume_window"` // How long a stream can be resumed after it finished or its last client disconnected; a running stream nobody resumes in time is cancelled
+	MaxStreams   int           `json:"max_streams"`   // Running and finished streams retained; new streams are refused while this many are running
+	MaxDuration  time.Duration `json:"max_duration"`  // Deadline of a stream's pipeline, shortened by the caller's deadline (default: 10m)
+}
+
+// Stream event types, in the order a stream emits them
+const (
+	StreamEventMessage      = "message"      // Answer tokens in Text
+	StreamEventCitation     = "citation"     // A source cited in the answer
+	StreamEventEntity       = "kg-entity"    // A knowledge graph entity, when the graph was requested
+	StreamEventVerification = "verification" // Fact verification results, when requested
+	StreamEventDone         = "done"         // The full response; always the last event of a successful stream
+	StreamEventError        = "error"        // The pipeline failed; always the last event of a failed stream
+)
+
+// AnswerStreamChunk is one event of a streamed answer
+type AnswerStreamChunk struct {
+	Sequence     int                 `json:"sequence"`               // Position in the stream, starting at 1
+	ResumeToken  string              `json:"resume_token"`           // Reconnect with this token and the last sequence to continue
+	Event        string              `json:"event"`                  // One of the StreamEvent types
+	Text         string              `json:"text,omitempty"`         // Answer tokens of a message event
+	Citation     *Citation           `json:"citation,omitempty"`     // Set on citation events
+	Entity       *Entity             `json:"entity,omitempty"`       // Set on kg-entity events
+	Verification *FactVerification   `json:"verification,omitempty"` // Set on verification events
+	Response     *AgenticRAGResponse `json:"response,omitempty"`     // Set on the done event
+	Error        string              `json:"error,omitempty"`        // Set on the error event
+}
+
+// final reports whether the chunk ends the stream
+func (c AnswerStreamChunk) final() bool {
+	return c.Event == StreamEventDone || c.Event == StreamEventError
+}
+
+// answerStream buffers the chunks of one answer so a reconnecting client can continue from any sequence
+type answerStream struct {
+	token  string
+	window time.Duration      // How long the pipeline runs without followers
+	cancel context.CancelFunc // Cancels the pipeline
+
+	mu        sync.Mutex
+	chunks    []AnswerStreamChunk
+	notify    chan struct{} // Closed and replaced whenever a chunk is appended
+	done      bool
+	finished  time.Time
+	response  *AgenticRAGResponse
+	err       error
+	followers int
+	idle      *time.Timer // Cancels the pipeline unless a follower attaches in time
+}
+
+// append adds a chunk and wakes followers
+func (s *answerStream) append(chunk AnswerStreamChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		s.appendLocked(chunk)
+	}
+}
+
+// finish records the pipeline result and appends the events describing it, ending with done or error
+func (s *answerStream) finish(response *AgenticRAGResponse, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	s.finished = time.Now()
+	s.response = response
+	s.err = err
+
+	if err != nil {
+		s.appendLocked(AnswerStreamChunk{Event: StreamEventError, Error: err.Error()})
+		return
+	}
+	for i := range response.Citations {
+		s.appendLocked(AnswerStreamChunk{Event: StreamEventCitation, Citation: &response.Citations[i]})
+	}
+	if response.KnowledgeGraph != nil {
+		for i := range response.KnowledgeGraph.Entities {
+			s.appendLocked(AnswerStreamChunk{Event: StreamEventEntity, Entity: &response.KnowledgeGraph.Entities[i]})
+		}
+	}
+	if response.FactVerification != nil {
+		s.appendLocked(AnswerStreamChunk{Event: StreamEventVerification, Verification: response.FactVerification})
+	}
+	s.appendLocked(AnswerStreamChunk{Event: StreamEventDone, Response: response})
+}
+
+// appendLocked assigns the chunk its sequence number and appends it; s.mu must be held
+func (s *answerStream) appendLocked(chunk AnswerStreamChunk) {
+	chunk.Sequence = len(s.chunks) + 1
+	chunk.ResumeToken = s.token
+	s.chunks = append(s.chunks, chunk)
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// attach counts a follower, keeping the pipeline running
+func (s *answerStream) attach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.followers++
+	if s.idle != nil {
+		s.idle.Stop()
+		s.idle = nil
+	}
+}
+
+// detach uncounts a follower. When the last one leaves a running stream, the pipeline is cancelled
+// unless a follower attaches within the resume window.
+func (s *answerStream) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.followers--
+	if s.followers > 0 || s.done {
+		return
+	}
+	s.idle = time.AfterFunc(s.window, func() {
+		s.mu.Lock()
+		abandoned := s.followers == 0 && !s.done
+		s.mu.Unlock()
+		if abandoned {
+			s.cancel()
+		}
+	})
+}
+
+// follow passes chunks after the given sequence to cb until the done or error event, then returns the result
+func (s *answerStream) follow(ctx context.Context, after int, cb func(context.Context, AnswerStreamChunk) error) (*AgenticRAGResponse, error) {
+	s.attach()
+	defer s.detach()
+	for {
+		s.mu.Lock()
+		pending := make([]AnswerStreamChunk, 0)
+		if after < len(s.chunks) {
+			pending = append(pending, s.chunks[max(after, 0):]...)
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		for _, chunk := range pending {
+			if cb != nil {
+				if err := cb(ctx, chunk); err != nil {
+					return nil, err
+				}
+			}
+			after = chunk.Sequence
+			if chunk.final() {
+				s.mu.Lock()
+				response, err := s.response, s.err
+				s.mu.Unlock()
+				return response, err
+			}
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// streamRegistry tracks running and recently finished answer streams by resume token
+type streamRegistry struct {
+	mu      sync.Mutex
+	cfg     StreamingConfig
+	streams map[string]*answerStream
+}
+
+// newStreamRegistry creates an empty stream registry
+func newStreamRegistry(cfg StreamingConfig) *streamRegistry {
+	return &streamRegistry{cfg: cfg, streams: make(map[string]*answerStream)}
+}
+
+// create registers a new stream whose pipeline is cancelled by cancel, dropping expired streams and
+// the oldest finished ones beyond the limit. It fails while the limit of streams is running.
+func (r *streamRegistry) create(cancel context.CancelFunc) (*answerStream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var oldest *answerStream
+	var oldestAt time.Time
+	running, finished := 0, 0
+	for token, stream := range r.streams {
+		stream.mu.Lock()
+		done, finishedAt := stream.done, stream.finished
+		stream.mu.Unlock()
+		if !done {
+			running++
+			continue
+		}
+		if r.cfg.ResumeWindow > 0 && time.Since(finishedAt) > r.cfg.ResumeWindow {
+			delete(r.streams, token)
+			continue
+		}
+		finished++
+		if oldest == nil || finishedAt.Before(oldestAt) {
+			oldest, oldestAt = stream, finishedAt
+		}
+	}
+	if r.cfg.MaxStreams > 0 && running >= r.cfg.MaxStreams {
+		return nil, fmt.Errorf("failed to start stream: %w (%d running)", ErrTooManyStreams, running)
+	}
+	if r.cfg.MaxStreams > 0 && running+finished >= r.cfg.MaxStreams && oldest != nil {
+		delete(r.streams, oldest.token)
+	}
+
+	stream := &answerStream{token: newAuditID(), window: r.cfg.ResumeWindow, cancel: cancel, notify: make(chan struct{})}
+	r.streams[stream.token] = stream
+	return stream, nil
+}
+
+// get returns the stream for a resume token
+func (r *streamRegistry) get(token string) *answerStream {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.streams[token]
+}
+
+// answerStreamContextKey carries the stream answer tokens are appended to
+type answerStreamContextKey struct{}
+
+// streamingOption returns the model option forwarding generated tokens to the request's stream, if any
+func streamingOption(ctx context.Context) (ai.ExecutionOption, bool) {
+	stream, ok := ctx.Value(answerStreamContextKey{}).(*answerStream)
+	if !ok {
+		return nil, false
+	}
+	return ai.WithStreaming(func(ctx context.Context, chunk *ai.ModelResponseChunk) error {
+		if text := chunk.Text(); text != "" {
+			stream.append(AnswerStreamChunk{Event: StreamEventMessage, Text: text})
+		}
+		return nil
+	}), true
+}
+
+// ProcessStream runs the pipeline and passes answer tokens to cb as they are generated. The pipeline
+// keeps running if the client disconnects; calling again with the request's ResumeToken and the last
+// received sequence in ResumeAfter replays the missed chunks and continues the same answer. A
+// pipeline nobody resumes within the resume window is cancelled, and every pipeline ends by the
+// caller's deadline or the configured maximum duration, whichever is earlier.
+func (p *AgenticRAGProcessor) ProcessStream(ctx context.Context, request AgenticRAGRequest, cb func(context.Context, AnswerStreamChunk) error) (*AgenticRAGResponse, error) {
+	if request.ResumeToken != "" {
+		stream := p.streams.get(request.ResumeToken)
+		if stream == nil {
+			return nil, fmt.Errorf("failed to resume stream %q: %w", request.ResumeToken, ErrStreamNotFound)
+		}
+		return stream.follow(ctx, request.ResumeAfter, cb)
+	}
+
+	pipelineCtx, cancel := p.streamDeadline(ctx)
+	stream, err := p.streams.create(cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	streamCtx := context.WithValue(pipelineCtx, answerStreamContextKey{}, stream)
+	// A panicking pipeline finishes the stream with an error rather than leaving followers waiting
+	var response *AgenticRAGResponse
+	concurrency.Go(func() (err error) {
+		response, err = p.Process(streamCtx, request)
+		return err
+	}, func(err error) {
+		stream.finish(response, err)
+		cancel()
+	})
+	return stream.follow(ctx, 0, cb)
+}
+
+// streamDeadline returns the context a stream's pipeline runs in: detached from the caller's
+// cancellation, so the answer survives a disconnect, but ending by the caller's deadline or the
+// configured maximum duration, whichever is earlier
+func (p *AgenticRAGProcessor) streamDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	maxDuration := p.config.Streaming.MaxDuration
+	if maxDuration <= 0 {
+		maxDuration = 10 * time.Minute
+	}
+	deadline := time.Now().Add(maxDuration)
+	if callerDeadline, ok := ctx.Deadline(); ok && callerDeadline.Before(deadline) {
+		deadline = callerDeadline
+	}
+	return context.WithDeadline(context.WithoutCancel(ctx), deadline)
+}
@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StructuredDataConfig maps the columns of CSV and JSONL records onto documents
+type StructuredDataConfig struct {
+	ContentColumns []string `json:"content_columns,omitempty"` // Columns joined into the content; empty uses every column
+	TitleColumn    string   `json:"title_column,omitempty"`    // Column stored as the document title
+	IDColumn       string   `json:"id_column,omitempty"`       // Column stored as the record ID
+	MaxRows        int      `json:"max_rows"`                  // Maximum records loaded per file
+}
+
+// StructuredDataLoader loads each row of a CSV/TSV file or each line of a JSONL file as a document.
+// Content columns become the document content and the remaining columns its metadata.
+type StructuredDataLoader struct {
+	config   StructuredDataConfig
+	maxBytes int64
+}
+
+// NewStructuredDataLoader creates a loader for CSV, TSV, and JSONL files
+func NewStructuredDataLoader(config StructuredDataConfig, maxBytes int64) *StructuredDataLoader {
+	return &StructuredDataLoader{config: config, maxBytes: maxBytes}
+}
+
+// structuredExtensions are the file extensions of supported tabular formats
+var structuredExtensions = map[string]bool{".csv": true, ".tsv": true, ".jsonl": true, ".ndjson": true}
+
+// CanLoad reports whether the source is a local CSV, TSV, or JSONL file
+func (l *StructuredDataLoader) CanLoad(source string) bool {
+	if !structuredExtensions[strings.ToLower(filepath.Ext(source))] {
+		return false
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load reads the file's records and converts each into a document
+func (l *StructuredDataLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := readLimited(file, l.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	var columns []string
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".jsonl", ".ndjson":
+		records, columns, err = l.parseJSONL(data)
+	case ".tsv":
+		records, columns, err = l.parseCSV(data, '\t')
+	default:
+		records, columns, err = l.parseCSV(data, ',')
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]Document, 0, len(records))
+	for i, record := range records {
+		docs = append(docs, l.recordDocument(source, i+1, record, columns))
+	}
+	return docs, nil
+}
+
+// parseCSV reads delimited records keyed by the header row
+func (l *StructuredDataLoader) parseCSV(data []byte, delimiter rune) ([]map[string]interface{}, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	records := make([]map[string]interface{}, 0)
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed to read CSV row %d: %w", len(records)+2, err)
+		}
+		record := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if i < len(row) && row[i] != "" {
+				record[column] = row[i]
+			}
+		}
+		records = append(records, record)
+		if l.config.MaxRows > 0 && len(records) >= l.config.MaxRows {
+			break
+		}
+	}
+	return records, header, nil
+}
+
+// parseJSONL reads one JSON object per line; columns are ordered by first appearance
+func (l *StructuredDataLoader) parseJSONL(data []byte) ([]map[string]interface{}, []string, error) {
+	records := make([]map[string]interface{}, 0)
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse JSONL line %d: %w", line, err)
+		}
+
+		keys := make([]string, 0, len(record))
+		for key := range record {
+			if !seen[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			seen[key] = true
+			columns = append(columns, key)
+		}
+
+		records = append(records, record)
+		if l.config.MaxRows > 0 && len(records) >= l.config.MaxRows {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read JSONL: %w", err)
+	}
+	return records, columns, nil
+}
+
+// recordDocument builds a document from a record, splitting content columns from metadata columns
+func (l *StructuredDataLoader) recordDocument(source string, row int, record map[string]interface{}, columns []string) Document {
+	contentColumns := l.config.ContentColumns
+	if len(contentColumns) == 0 {
+		contentColumns = columns
+	}
+	isContent := make(map[string]bool, len(contentColumns))
+	for _, column := range contentColumns {
+		isContent[column] = true
+	}
+
+	lines := make([]string, 0, len(contentColumns))
+	for _, column := range contentColumns {
+		value, ok := record[column]
+		if !ok || value == nil {
+			continue
+		}
+		text := structuredValueText(value)
+		if len(contentColumns) > 1 {
+			text = column + ": " + text
+		}
+		lines = append(lines, text)
+	}
+
+	metadata := map[string]interface{}{"row": row}
+	for column, value := range record {
+		// With every column used as content, all columns also stay filterable as metadata
+		if !isContent[column] || len(l.config.ContentColumns) == 0 {
+			metadata[column] = value
+		}
+	}
+	if l.config.TitleColumn != "" {
+		if title, ok := record[l.config.TitleColumn]; ok {
+			metadata["title"] = structuredValueText(title)
+		}
+	}
+	if l.config.IDColumn != "" {
+		if id, ok := record[l.config.IDColumn]; ok {
+			metadata["record_id"] = structuredValueText(id)
+		}
+	}
+
+	return Document{Content: strings.Join(lines, "\n"), Source: source, Metadata: metadata}
+}
+
+// structuredValueText renders a record value as text, encoding nested values as JSON
+func structuredValueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// TableChunkingConfig contains configuration for keeping tables together when chunking
+type TableChunkingConfig struct {
+	Enabled bool `json:"enabled"`
+	UseLLM  bool `json:"use_llm"` // Summarize tables with the model; a structural summary is used otherwise or on failure
+}
+
+// markdownTableSeparator matches the delimiter row under a Markdown table header
+var markdownTableSeparator = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+
+// tableSpans returns the byte spans of Markdown tables: runs of pipe-led lines whose second line is a delimiter row
+func tableSpans(content string) [][2]int {
+	spans := make([][2]int, 0)
+	start, rows := -1, 0
+	offset := 0
+	closeTable := func(end int) {
+		if start >= 0 && rows >= 2 {
+			spans = append(spans, [2]int{start, end})
+		}
+		start, rows = -1, 0
+	}
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "|") && (start < 0 || rows != 1 || markdownTableSeparator.MatchString(trimmed)):
+			if start < 0 {
+				start = offset
+			}
+			rows++
+		default:
+			closeTable(offset)
+			if strings.HasPrefix(trimmed, "|") {
+				start, rows = offset, 1
+			}
+		}
+		offset += len(line)
+	}
+	closeTable(offset)
+	return spans
+}
+
+// parseMarkdownTable returns the header cells and body rows of a Markdown table
+func parseMarkdownTable(table string) ([]string, [][]string) {
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	cells := func(line string) []string {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+		parts := strings.Split(line, "|")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+
+	header := cells(lines[0])
+	rows := make([][]string, 0, len(lines))
+	for _, line := range lines[2:] {
+		rows = append(rows, cells(line))
+	}
+	return header, rows
+}
+
+// summarizeTable describes a table in prose so it can be matched against queries
+func (p *AgenticRAGProcessor) summarizeTable(ctx context.Context, table string) string {
+	if p.config.TableChunking.UseLLM && p.config.Genkit != nil {
+		prompt := fmt.Sprintf(`Summarize what the following table contains in two or three sentences. Mention the columns and the most important values.
+
+%s`, table)
+		if summary, err := p.generateText(ctx, prompt, 0.0, 200); err == nil && strings.TrimSpace(summary) != "" {
+			return strings.TrimSpace(summary)
+		}
+	}
+
+	header, rows := parseMarkdownTable(table)
+	rowLabel := "rows"
+	if len(rows) == 1 {
+		rowLabel = "row"
+	}
+	summary := fmt.Sprintf("Table with %d %s and columns %s.", len(rows), rowLabel, strings.Join(header, ", "))
+	if len(rows) > 0 && len(header) > 0 {
+		keys := make([]string, 0, len(rows))
+		for _, row := range rows {
+			if len(row) > 0 && row[0] != "" {
+				keys = append(keys, row[0])
+			}
+		}
+		if len(keys) > 0 {
+			summary += fmt.Sprintf(" %s: %s.", header[0], truncateText(strings.Join(keys, ", "), 200))
+		}
+	}
+	return summary
+}
+
+// htmlTableLines renders an HTML table as Markdown table rows, using the first row as the header
+func htmlTableLines(table *html.Node) []string {
+	rows := make([][]string, 0)
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "tr" {
+			cells := make([]string, 0)
+			for cell := node.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					cells = append(cells, strings.ReplaceAll(nodeText(cell), "|", "/"))
+				}
+			}
+			if len(cells) > 0 {
+				rows = append(rows, cells)
+			}
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Data == "table" {
+				continue // Nested tables are flattened into their cell's text
+			}
+			walk(child)
+		}
+	}
+	walk(table)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", len(row)))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,468 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source prefixes of the ticket system connectors
+const (
+	zendeskSourcePrefix  = "zendesk:"  // zendesk:<help desk URL>
+	intercomSourcePrefix = "intercom:" // intercom:<API URL>
+)
+
+// TicketConfig contains configuration for the Zendesk and Intercom connectors
+type TicketConfig struct {
+	ZendeskEmail     string `json:"zendesk_email,omitempty"`   // Zendesk agent used with the API token; empty sends the token as an OAuth bearer token
+	ZendeskTokenEnv  string `json:"zendesk_token_env"`         // Environment variable holding the Zendesk API token
+	IntercomTokenEnv string `json:"intercom_token_env"`        // Environment variable holding the Intercom access token
+	ProductField     string `json:"product_field,omitempty"`   // Zendesk custom field ID or Intercom conversation attribute naming the product
+	ComponentField   string `json:"component_field,omitempty"` // Zendesk custom field ID or Intercom conversation attribute naming the component
+	MaxTickets       int    `json:"max_tickets"`               // Maximum resolved tickets fetched per sync
+}
+
+// TicketLoader loads resolved support tickets from Zendesk or Intercom, e.g.
+// "zendesk:https://acme.zendesk.com" or "intercom:https://api.intercom.io". Each solved ticket or
+// closed conversation becomes a document with the customer's problem and the agent's resolution,
+// titled with the ticket ID so answers cite it. The configured product and component fields are
+// copied into the metadata for filtering. With LoaderConfig.SyncDir set, later loads only fetch
+// tickets updated since the previous one; reopened tickets keep their last resolution until solved
+// again.
+type TicketLoader struct {
+	config LoaderConfig
+	client *http.Client
+}
+
+// NewTicketLoader creates a Zendesk and Intercom loader
+func NewTicketLoader(config LoaderConfig) *TicketLoader {
+	return &TicketLoader{config: config, client: http.DefaultClient}
+}
+
+// CanLoad reports whether the source is a Zendesk help desk or Intercom workspace
+func (l *TicketLoader) CanLoad(source string) bool {
+	for _, prefix := range []string{zendeskSourcePrefix, intercomSourcePrefix} {
+		if rest, ok := strings.CutPrefix(source, prefix); ok {
+			return isHTTPURL(rest)
+		}
+	}
+	return false
+}
+
+// Load syncs the help desk's resolved tickets and returns all of them
+func (l *TicketLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	if rest, ok := strings.CutPrefix(source, zendeskSourcePrefix); ok {
+		base := strings.TrimSuffix(rest, "/")
+		return syncConnectorSource(ctx, l.config.SyncDir, source, func(ctx context.Context, since time.Time) ([]syncedItem, error) {
+			return l.fetchZendesk(ctx, base, since)
+		})
+	}
+	base := strings.TrimSuffix(strings.TrimPrefix(source, intercomSourcePrefix), "/")
+	return syncConnectorSource(ctx, l.config.SyncDir, source, func(ctx context.Context, since time.Time) ([]syncedItem, error) {
+		return l.fetchIntercom(ctx, base, since)
+	})
+}
+
+// resolvedTicket is a resolved ticket from either system, before conversion into a document
+type resolvedTicket struct {
+	system     string
+	id         string
+	subject    string
+	link       string
+	status     string
+	problem    string
+	resolution string
+	product    string
+	component  string
+	tags       []string
+	createdAt  time.Time
+	updatedAt  time.Time
+}
+
+// item converts the ticket into a document laid out as problem and resolution
+func (t resolvedTicket) item() syncedItem {
+	title := fmt.Sprintf("Ticket #%s: %s", t.id, t.subject)
+
+	var content strings.Builder
+	content.WriteString(title + "\n")
+	if t.product != "" {
+		content.WriteString("Product: " + t.product + "\n")
+	}
+	if t.component != "" {
+		content.WriteString("Component: " + t.component + "\n")
+	}
+	content.WriteString("\nProblem:\n" + strings.TrimSpace(t.problem) + "\n")
+	if t.resolution != "" {
+		content.WriteString("\nResolution:\n" + strings.TrimSpace(t.resolution) + "\n")
+	}
+
+	metadata := map[string]interface{}{
+		"content_type":  "text/plain",
+		"title":         title,
+		"url":           t.link,
+		"ticket_id":     t.id,
+		"ticket_system": t.system,
+		"status":        t.status,
+	}
+	if t.resolution != "" {
+		metadata["resolution"] = strings.TrimSpace(t.resolution)
+	}
+	if t.product != "" {
+		metadata["product"] = t.product
+	}
+	if t.component != "" {
+		metadata["component"] = t.component
+	}
+	if len(t.tags) > 0 {
+		metadata["tags"] = t.tags
+	}
+	if !t.createdAt.IsZero() {
+		metadata["published_at"] = t.createdAt
+	}
+	if !t.updatedAt.IsZero() {
+		metadata["updated_at"] = t.updatedAt
+	}
+	return syncedItem{
+		id:        t.id,
+		updatedAt: t.updatedAt,
+		document:  Document{Content: strings.TrimSpace(content.String()), Source: t.link, Metadata: metadata},
+	}
+}
+
+// zendeskTicket is a ticket returned by the Zendesk incremental export API
+type zendeskTicket struct {
+	ID           int64    `json:"id"`
+	Subject      string   `json:"subject"`
+	Description  string   `json:"description"`
+	Status       string   `json:"status"`
+	Tags         []string `json:"tags"`
+	RequesterID  int64    `json:"requester_id"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+	CustomFields []struct {
+		ID    int64       `json:"id"`
+		Value interface{} `json:"value"`
+	} `json:"custom_fields"`
+}
+
+// zendeskComment is a ticket comment returned by the Zendesk comments API
+type zendeskComment struct {
+	AuthorID  int64  `json:"author_id"`
+	PlainBody string `json:"plain_body"`
+	Public    bool   `json:"public"`
+}
+
+// fetchZendesk exports the tickets updated since the cursor and fetches the comments of the solved ones
+func (l *TicketLoader) fetchZendesk(ctx context.Context, base string, since time.Time) ([]syncedItem, error) {
+	var startTime int64
+	if !since.IsZero() {
+		startTime = since.Unix()
+	}
+
+	items := make([]syncedItem, 0)
+	next := fmt.Sprintf("%s/api/v2/incremental/tickets.json?start_time=%d", base, startTime)
+	for next != "" && !l.reachedMaxTickets(len(items)) {
+		var page struct {
+			Tickets     []zendeskTicket `json:"tickets"`
+			NextPage    string          `json:"next_page"`
+			EndOfStream bool            `json:"end_of_stream"`
+		}
+		if err := l.doJSON(ctx, http.MethodGet, next, nil, l.authorizeZendesk, &page); err != nil {
+			return nil, fmt.Errorf("failed to export Zendesk tickets: %w", err)
+		}
+		for _, ticket := range page.Tickets {
+			if ticket.Status != "solved" && ticket.Status != "closed" {
+				continue
+			}
+			item, err := l.zendeskItem(ctx, base, ticket)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if l.reachedMaxTickets(len(items)) {
+				break
+			}
+		}
+		next = page.NextPage
+		if page.EndOfStream || len(page.Tickets) == 0 {
+			break
+		}
+	}
+	return items, nil
+}
+
+// zendeskItem fetches a solved ticket's comments and takes the last public agent reply as its resolution
+func (l *TicketLoader) zendeskItem(ctx context.Context, base string, ticket zendeskTicket) (syncedItem, error) {
+	id := strconv.FormatInt(ticket.ID, 10)
+	var comments []zendeskComment
+	next := base + "/api/v2/tickets/" + id + "/comments.json"
+	for next != "" {
+		var page struct {
+			Comments []zendeskComment `json:"comments"`
+			NextPage string           `json:"next_page"`
+		}
+		if err := l.doJSON(ctx, http.MethodGet, next, nil, l.authorizeZendesk, &page); err != nil {
+			return syncedItem{}, fmt.Errorf("failed to fetch comments of Zendesk ticket %s: %w", id, err)
+		}
+		comments = append(comments, page.Comments...)
+		next = page.NextPage
+	}
+
+	resolved := resolvedTicket{
+		system:  "zendesk",
+		id:      id,
+		subject: ticket.Subject,
+		link:    base + "/agent/tickets/" + id,
+		status:  ticket.Status,
+		problem: ticket.Description,
+		tags:    ticket.Tags,
+	}
+	for i := len(comments) - 1; i > 0; i-- {
+		if comments[i].Public && comments[i].AuthorID != ticket.RequesterID {
+			resolved.resolution = comments[i].PlainBody
+			break
+		}
+	}
+	for _, field := range ticket.CustomFields {
+		value, ok := field.Value.(string)
+		if !ok {
+			continue
+		}
+		switch strconv.FormatInt(field.ID, 10) {
+		case l.config.Tickets.ProductField:
+			resolved.product = value
+		case l.config.Tickets.ComponentField:
+			resolved.component = value
+		}
+	}
+	resolved.createdAt, _ = time.Parse(time.RFC3339, ticket.CreatedAt)
+	resolved.updatedAt, _ = time.Parse(time.RFC3339, ticket.UpdatedAt)
+	return resolved.item(), nil
+}
+
+// authorizeZendesk authenticates with the API token as the configured agent, or as an OAuth token
+func (l *TicketLoader) authorizeZendesk(req *http.Request) {
+	token := os.Getenv(l.config.Tickets.ZendeskTokenEnv)
+	if token == "" {
+		return
+	}
+	if l.config.Tickets.ZendeskEmail != "" {
+		req.SetBasicAuth(l.config.Tickets.ZendeskEmail+"/token", token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// intercomPart is a message of an Intercom conversation
+type intercomPart struct {
+	PartType string `json:"part_type"`
+	Body     string `json:"body"`
+	Author   struct {
+		Type string `json:"type"`
+	} `json:"author"`
+}
+
+// intercomConversation is a conversation returned by the Intercom conversations API
+type intercomConversation struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+	Source    struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	} `json:"source"`
+	Tags struct {
+		Tags []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	} `json:"tags"`
+	CustomAttributes  map[string]interface{} `json:"custom_attributes"`
+	ConversationParts struct {
+		ConversationParts []intercomPart `json:"conversation_parts"`
+	} `json:"conversation_parts"`
+}
+
+// fetchIntercom searches the conversations closed and updated since the cursor and fetches their parts
+func (l *TicketLoader) fetchIntercom(ctx context.Context, base string, since time.Time) ([]syncedItem, error) {
+	conditions := []map[string]interface{}{
+		{"field": "state", "operator": "=", "value": "closed"},
+	}
+	if !since.IsZero() {
+		// Conversations updated within the cursor's second are fetched again and merged by ID
+		conditions = append(conditions, map[string]interface{}{"field": "updated_at", "operator": ">", "value": since.Unix() - 1})
+	}
+
+	items := make([]syncedItem, 0)
+	for cursor := ""; ; {
+		pagination := map[string]interface{}{"per_page": 50}
+		if cursor != "" {
+			pagination["starting_after"] = cursor
+		}
+		search := map[string]interface{}{
+			"query":      map[string]interface{}{"operator": "AND", "value": conditions},
+			"pagination": pagination,
+		}
+		var page struct {
+			Conversations []intercomConversation `json:"conversations"`
+			Pages         struct {
+				Next *struct {
+					StartingAfter string `json:"starting_after"`
+				} `json:"next"`
+			} `json:"pages"`
+		}
+		if err := l.doJSON(ctx, http.MethodPost, base+"/conversations/search", search, l.authorizeIntercom, &page); err != nil {
+			return nil, fmt.Errorf("failed to search Intercom conversations: %w", err)
+		}
+		for _, summary := range page.Conversations {
+			var conversation intercomConversation
+			if err := l.doJSON(ctx, http.MethodGet, base+"/conversations/"+url.PathEscape(summary.ID)+"?display_as=plaintext", nil, l.authorizeIntercom, &conversation); err != nil {
+				return nil, fmt.Errorf("failed to fetch Intercom conversation %s: %w", summary.ID, err)
+			}
+			items = append(items, l.intercomItem(base, conversation))
+			if l.reachedMaxTickets(len(items)) {
+				return items, nil
+			}
+		}
+		if page.Pages.Next == nil || page.Pages.Next.StartingAfter == "" || len(page.Conversations) == 0 {
+			break
+		}
+		cursor = page.Pages.Next.StartingAfter
+	}
+	return items, nil
+}
+
+// intercomItem takes a closed conversation's last admin reply as its resolution
+func (l *TicketLoader) intercomItem(base string, conversation intercomConversation) syncedItem {
+	subject := conversation.Title
+	if subject == "" {
+		subject = conversation.Source.Subject
+	}
+	resolved := resolvedTicket{
+		system:    "intercom",
+		id:        conversation.ID,
+		subject:   intercomText(subject),
+		link:      base + "/conversations/" + url.PathEscape(conversation.ID),
+		status:    conversation.State,
+		problem:   intercomText(conversation.Source.Body),
+		product:   attributeString(conversation.CustomAttributes, l.config.Tickets.ProductField),
+		component: attributeString(conversation.CustomAttributes, l.config.Tickets.ComponentField),
+	}
+	if conversation.CreatedAt > 0 {
+		resolved.createdAt = time.Unix(conversation.CreatedAt, 0).UTC()
+	}
+	if conversation.UpdatedAt > 0 {
+		resolved.updatedAt = time.Unix(conversation.UpdatedAt, 0).UTC()
+	}
+	if resolved.subject == "" {
+		resolved.subject = firstLine(resolved.problem)
+	}
+	for _, tag := range conversation.Tags.Tags {
+		resolved.tags = append(resolved.tags, tag.Name)
+	}
+	parts := conversation.ConversationParts.ConversationParts
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i].Author.Type == "admin" && parts[i].Body != "" && (parts[i].PartType == "comment" || parts[i].PartType == "close") {
+			resolved.resolution = intercomText(parts[i].Body)
+			break
+		}
+	}
+	return resolved.item()
+}
+
+// authorizeIntercom authenticates with the access token and pins the API version the loader decodes
+func (l *TicketLoader) authorizeIntercom(req *http.Request) {
+	req.Header.Set("Intercom-Version", "2.11")
+	if token := os.Getenv(l.config.Tickets.IntercomTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// intercomText converts an Intercom message body, which may be HTML, to plain text
+func intercomText(body string) string {
+	if !strings.Contains(body, "<") {
+		return strings.TrimSpace(body)
+	}
+	text, _, _, err := extractHTMLText(body)
+	if err != nil {
+		return strings.TrimSpace(body)
+	}
+	return strings.TrimSpace(text)
+}
+
+// attributeString returns a string-valued custom attribute, or "" when the name is unset
+func attributeString(attributes map[string]interface{}, name string) string {
+	if name == "" {
+		return ""
+	}
+	value, _ := attributes[name].(string)
+	return value
+}
+
+// firstLine returns the first line of a text
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+// reachedMaxTickets reports whether a sync fetched the configured maximum of tickets
+func (l *TicketLoader) reachedMaxTickets(count int) bool {
+	return l.config.Tickets.MaxTickets > 0 && count >= l.config.Tickets.MaxTickets
+}
+
+// doJSON sends an authenticated API request with an optional JSON body and decodes its JSON response
+func (l *TicketLoader) doJSON(ctx context.Context, method, apiURL string, body interface{}, authorize func(*http.Request), out interface{}) error {
+	timeout := l.config.HTTPTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if l.config.UserAgent != "" {
+		req.Header.Set("User-Agent", l.config.UserAgent)
+	}
+	authorize(req)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	data, err := readLimited(resp.Body, l.config.MaxBytes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
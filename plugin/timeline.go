@@ -0,0 +1,198 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimelineRequest asks for the timeline of an entity or topic in the corpus
+type TimelineRequest struct {
+	Subject string `json:"subject" jsonschema_description:"Entity or topic to build the timeline for, e.g. an incident or a product"`
+}
+
+// Timeline is the chronologically ordered events about an entity or topic
+type Timeline struct {
+	Subject string          `json:"subject"`
+	Events  []TimelineEvent `json:"events"`
+}
+
+// TimelineEvent is a dated event with the sources reporting it
+type TimelineEvent struct {
+	Date        string     `json:"date"` // As precise as the sources: 2006-01-02T15:04, 2006-01-02, 2006-01, or 2006
+	Time        time.Time  `json:"time"` // Start of the date, used for ordering
+	Description string     `json:"description"`
+	Entities    []string   `json:"entities,omitempty"`
+	Citations   []Citation `json:"citations"`
+}
+
+// timelineDateLayouts are the event date formats accepted from the model, most precise first
+var timelineDateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02", "2006-01", "2006"}
+
+// BuildTimeline extracts dated events about an entity or topic from the corpus chunks that mention
+// it, using knowledge graph relations as extra context when the graph is enabled, and returns them
+// in chronological order with citations. Events the model cannot date are left out.
+func (p *AgenticRAGProcessor) BuildTimeline(ctx context.Context, subject string) (*Timeline, error) {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	documents, err := p.corpusDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]DocumentChunk, 0)
+	for _, doc := range documents {
+		docChunks, err := p.chunkDocument(ctx, doc, math.MaxInt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to chunk document %s: %w", doc.ID, err)
+		}
+		chunks = append(chunks, docChunks...)
+	}
+	sources := p.keywordSearch(subject, chunks)
+	if len(sources) > p.vectorTopK() {
+		sources = sources[:p.vectorTopK()]
+	}
+	timeline := &Timeline{Subject: subject, Events: make([]TimelineEvent, 0)}
+	if len(sources) == 0 {
+		return timeline, nil
+	}
+
+	events, err := p.extractTimelineEvents(ctx, subject, sources, p.timelineRelations(ctx, subject, sources))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract timeline events: %w", err)
+	}
+	timeline.Events = events
+	return timeline, nil
+}
+
+// timelineRelations returns the knowledge graph relations of the sources between entities named in
+// the subject, or naming it,
+// or none when the graph is disabled or fails; the timeline only uses them as context
+func (p *AgenticRAGProcessor) timelineRelations(ctx context.Context, subject string, sources []DocumentChunk) []Relation {
+	graph, err := p.buildKnowledgeGraph(ctx, sources)
+	if err != nil || graph == nil {
+		return nil
+	}
+	relations := make([]Relation, 0)
+	for _, relation := range graph.Relations {
+		named := make([]string, 0, 2)
+		for _, name := range []string{relation.Subject, relation.Object} {
+			if strings.TrimSpace(name) != "" {
+				named = append(named, name)
+			}
+		}
+		if mentionsAny(subject, named) || mentionsAny(relation.Subject+" "+relation.Object, []string{subject}) {
+			relations = append(relations, relation)
+		}
+	}
+	return relations
+}
+
+// extractTimelineEvents asks the model for the dated events in the numbered sources and orders them
+func (p *AgenticRAGProcessor) extractTimelineEvents(ctx context.Context, subject string, sources []DocumentChunk, relations []Relation) ([]TimelineEvent, error) {
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("List the dated events about %q reported in the numbered sources below.\n\n", subject))
+	if len(relations) > 0 {
+		prompt.WriteString("Known relations:\n")
+		for _, relation := range relations {
+			prompt.WriteString(fmt.Sprintf("- %s %s %s\n", relation.Subject, relation.Predicate, relation.Object))
+		}
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("Sources:\n")
+	for i, chunk := range sources {
+		prompt.WriteString(fmt.Sprintf("[%d]", i+1))
+		if date, ok := p.chunkDate(chunk); ok {
+			// Lets the model resolve relative dates such as "last Tuesday"
+			prompt.WriteString(fmt.Sprintf(" (published %s)", date.Format("2006-01-02")))
+		}
+		prompt.WriteString(fmt.Sprintf(" %s\n\n", chunk.Content))
+	}
+	prompt.WriteString(`Respond with only a JSON array: [{"date": "2021-03-04", "event": "what happened", "entities": ["names involved"], "sources": [1]}]
+Use the most precise date the sources support (YYYY-MM-DDTHH:MM, YYYY-MM-DD, YYYY-MM, or YYYY) and leave out events without a date.`)
+
+	text, err := p.generateText(ctx, prompt.String(), 0.1, 2048)
+	if err != nil {
+		return nil, err
+	}
+	var extracted []struct {
+		Date     string   `json:"date"`
+		Event    string   `json:"event"`
+		Entities []string `json:"entities"`
+		Sources  []int    `json:"sources"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(text)), &extracted); err != nil {
+		return nil, fmt.Errorf("failed to parse timeline events: %w", err)
+	}
+
+	events := make([]TimelineEvent, 0, len(extracted))
+	seen := make(map[string]int)
+	for _, item := range extracted {
+		date, ok := parseTimelineDate(item.Date)
+		description := strings.TrimSpace(item.Event)
+		if !ok || description == "" {
+			continue
+		}
+		var markers strings.Builder
+		for _, source := range item.Sources {
+			markers.WriteString(fmt.Sprintf("[%d]", source))
+		}
+		citations := buildCitations(markers.String(), sources)
+
+		// The same event reported by several sources is merged into one with all their citations
+		key := strings.TrimSpace(item.Date) + "|" + strings.ToLower(description)
+		if i, ok := seen[key]; ok {
+			events[i].Citations = mergeCitations(events[i].Citations, citations)
+			continue
+		}
+		seen[key] = len(events)
+		events = append(events, TimelineEvent{
+			Date:        strings.TrimSpace(item.Date),
+			Time:        date,
+			Description: description,
+			Entities:    item.Entities,
+			Citations:   citations,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// parseTimelineDate parses an event date in any accepted precision
+func parseTimelineDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timelineDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// mergeCitations adds the citations not already present, keeping them ordered by number
+func mergeCitations(citations, more []Citation) []Citation {
+	for _, citation := range more {
+		present := false
+		for _, existing := range citations {
+			if existing.Number == citation.Number {
+				present = true
+				break
+			}
+		}
+		if !present {
+			citations = append(citations, citation)
+		}
+	}
+	sort.Slice(citations, func(i, j int) bool {
+		return citations[i].Number < citations[j].Number
+	})
+	return citations
+}
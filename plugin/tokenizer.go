@@ -0,0 +1,204 @@
+package plugin
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Units chunk sizes are measured in
+const (
+	ChunkUnitTokens     = "tokens"
+	ChunkUnitCharacters = "characters"
+)
+
+// Tokenizer counts the model tokens in text. Plug in an exact BPE tokenizer (e.g. a tiktoken
+// port for the configured model) when chunks must match a model's context budget precisely.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// ApproximateTokenizer estimates BPE token counts without a vocabulary. It splits text the way
+// tiktoken's pre-tokenizer does and prices each piece by script and length, erring on the high side.
+type ApproximateTokenizer struct{}
+
+// CountTokens returns the estimated number of tokens in text
+func (ApproximateTokenizer) CountTokens(text string) int {
+	count := 0
+	for len(text) > 0 {
+		n := nextPretoken(text)
+		count += pieceTokens(text[:n])
+		text = text[n:]
+	}
+	return count
+}
+
+// nextPretoken returns the byte length of the piece text starts with. Pieces are contractions,
+// words with their leading space, digit groups of up to three, punctuation runs, and whitespace,
+// following the cl100k pattern
+//
+//	(?i)'(?:s|t|re|ve|m|ll|d)| ?\p{L}+| ?\p{N}{1,3}| ?[^\s\p{L}\p{N}]+|\s+
+//
+// matched by hand, since a regexp allocates for every piece of every measured chunk.
+func nextPretoken(text string) int {
+	if text[0] == '\'' {
+		if n := contractionLength(text[1:]); n > 0 {
+			return 1 + n
+		}
+	}
+	if isPretokenSpace(text[0]) && (text[0] != ' ' || len(text) == 1 || isPretokenSpace(text[1])) {
+		n := 1
+		for n < len(text) && isPretokenSpace(text[n]) {
+			n++
+		}
+		return n
+	}
+
+	// A single leading space belongs to the following word, number, or punctuation run
+	offset := 0
+	if text[0] == ' ' {
+		offset = 1
+	}
+	r, _ := utf8.DecodeRuneInString(text[offset:])
+	switch {
+	case unicode.IsLetter(r):
+		return offset + runLength(text[offset:], unicode.IsLetter, -1)
+	case unicode.IsNumber(r):
+		return offset + runLength(text[offset:], unicode.IsNumber, 3)
+	default:
+		return offset + runLength(text[offset:], isPretokenPunctuation, -1)
+	}
+}
+
+// contractionLength returns the length of the contraction suffix text starts with after an apostrophe
+func contractionLength(text string) int {
+	for _, suffix := range [...]string{"s", "t", "re", "ve", "m", "ll", "d"} {
+		if len(text) >= len(suffix) && strings.EqualFold(text[:len(suffix)], suffix) {
+			return len(suffix)
+		}
+	}
+	return 0
+}
+
+// runLength returns the byte length of the leading runes of text matching match, up to limit runes (-1 for no limit)
+func runLength(text string, match func(rune) bool, limit int) int {
+	n, runes := 0, 0
+	for n < len(text) && runes != limit {
+		r, size := utf8.DecodeRuneInString(text[n:])
+		if !match(r) {
+			break
+		}
+		n += size
+		runes++
+	}
+	return n
+}
+
+// isPretokenSpace reports whether b is whitespace as matched by the regexp \s class
+func isPretokenSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
+}
+
+// isPretokenPunctuation reports whether r is neither whitespace, a letter, nor a number
+func isPretokenPunctuation(r rune) bool {
+	return !(r < utf8.RuneSelf && isPretokenSpace(byte(r))) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// pieceTokens estimates the tokens of a single pre-tokenized piece
+func pieceTokens(piece string) int {
+	if strings.TrimSpace(piece) == "" {
+		// Single spaces merge into the following word; longer runs and line breaks are tokens
+		if len(piece) > 1 || piece == "\n" {
+			return 1
+		}
+		return 0
+	}
+	piece = strings.TrimPrefix(piece, " ")
+
+	first, _ := utf8.DecodeRuneInString(piece)
+	length := utf8.RuneCountInString(piece)
+	switch {
+	case unicode.IsDigit(first):
+		return 1
+	case unicode.In(first, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return length
+	case unicode.IsLetter(first) && first <= unicode.MaxLatin1:
+		return (length + 5) / 6
+	case unicode.IsLetter(first):
+		return (length + 2) / 3
+	default:
+		return (length + 1) / 2
+	}
+}
+
+// defaultTokenizer is used when no tokenizer is configured
+var defaultTokenizer Tokenizer = ApproximateTokenizer{}
+
+// tokenizer returns the configured tokenizer or the approximate default
+func (p *AgenticRAGProcessor) tokenizer() Tokenizer {
+	if p.config.Tokenizer != nil {
+		return p.config.Tokenizer
+	}
+	return defaultTokenizer
+}
+
+// chunkMeasure returns the function measuring text in the configured chunk size unit
+func (p *AgenticRAGProcessor) chunkMeasure() func(string) int {
+	if p.config.Processing.ChunkUnit == ChunkUnitCharacters {
+		return func(text string) int { return len(text) }
+	}
+	return p.tokenizer().CountTokens
+}
+
+// splitOversized breaks sentences longer than the chunk size on word boundaries, and words
+// longer than the chunk size on rune boundaries, so every piece fits in a chunk
+func splitOversized(sentences []string, chunkSize int, measure func(string) int) []string {
+	pieces := make([]string, 0, len(sentences))
+	for _, sentence := range sentences {
+		if measure(sentence) <= chunkSize {
+			pieces = append(pieces, sentence)
+			continue
+		}
+
+		current := ""
+		for _, word := range strings.Fields(sentence) {
+			if measure(word) > chunkSize {
+				if current != "" {
+					pieces = append(pieces, current)
+					current = ""
+				}
+				pieces = append(pieces, splitWord(word, chunkSize, measure)...)
+				continue
+			}
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if measure(candidate) > chunkSize {
+				pieces = append(pieces, current)
+				candidate = word
+			}
+			current = candidate
+		}
+		if current != "" {
+			pieces = append(pieces, current)
+		}
+	}
+	return pieces
+}
+
+// splitWord cuts a word into rune runs that each fit the chunk size
+func splitWord(word string, chunkSize int, measure func(string) int) []string {
+	runes := []rune(word)
+	step := len(runes) * chunkSize / measure(word)
+	for step > 1 && measure(string(runes[:step])) > chunkSize {
+		step--
+	}
+	step = max(step, 1)
+
+	pieces := make([]string, 0, len(runes)/step+1)
+	for start := 0; start < len(runes); start += step {
+		pieces = append(pieces, string(runes[start:min(start+step, len(runes))]))
+	}
+	return pieces
+}
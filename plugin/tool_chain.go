@@ -0,0 +1,285 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/concurrency"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// ToolChainStep runs one registered tool once the steps it depends on have finished.
+// String inputs of the form "$step" or "$step.field.0" are replaced with earlier outputs; "$$" escapes a literal "$".
+type ToolChainStep struct {
+	ID        string   `json:"id" jsonschema_description:"Unique step ID used in references"`
+	Tool      string   `json:"tool" jsonschema_description:"Name of the registered tool to run"`
+	Input     any      `json:"input,omitempty" jsonschema_description:"Tool input, possibly containing $step references"`
+	DependsOn []string `json:"depends_on,omitempty" jsonschema_description:"Steps that must finish first"`
+}
+
+// ToolChainRequest is a dependency graph of tool steps
+type ToolChainRequest struct {
+	Steps []ToolChainStep `json:"steps"`
+}
+
+// ToolStepResult is the outcome of one chain step
+type ToolStepResult struct {
+	StepID   string        `json:"step_id"`
+	Tool     string        `json:"tool"`
+	Output   any           `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ToolChainResponse holds the results of every step that ran, in completion order
+type ToolChainResponse struct {
+	Results []ToolStepResult `json:"results"`
+}
+
+// toolChainReference matches a whole-string reference to a step output
+var toolChainReference = regexp.MustCompile(`^\$([A-Za-z0-9_-]+)((?:\.[A-Za-z0-9_-]+)*)$`)
+
+// Validate checks step IDs, dependencies, and references, rejecting cycles and references to steps that
+// are not guaranteed to have finished
+func (r ToolChainRequest) Validate() error {
+	steps := make(map[string]ToolChainStep, len(r.Steps))
+	for _, step := range r.Steps {
+		if step.ID == "" || step.Tool == "" {
+			return fmt.Errorf("every step requires an ID and a tool")
+		}
+		if _, exists := steps[step.ID]; exists {
+			return fmt.Errorf("duplicate step ID %q", step.ID)
+		}
+		steps[step.ID] = step
+	}
+	for _, step := range r.Steps {
+		for _, dependency := range step.DependsOn {
+			if _, ok := steps[dependency]; !ok {
+				return fmt.Errorf("step %q depends on unknown step %q", step.ID, dependency)
+			}
+		}
+	}
+
+	// Depth-first search for cycles while collecting each step's ancestors
+	ancestors := make(map[string]map[string]bool, len(steps))
+	visiting := make(map[string]bool)
+	var visit func(id string) error
+	visit = func(id string) error {
+		if ancestors[id] != nil {
+			return nil
+		}
+		if visiting[id] {
+			return fmt.Errorf("dependency cycle through step %q", id)
+		}
+		visiting[id] = true
+		set := make(map[string]bool)
+		for _, dependency := range steps[id].DependsOn {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+			set[dependency] = true
+			for ancestor := range ancestors[dependency] {
+				set[ancestor] = true
+			}
+		}
+		visiting[id] = false
+		ancestors[id] = set
+		return nil
+	}
+	for _, step := range r.Steps {
+		if err := visit(step.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range r.Steps {
+		for _, reference := range chainReferences(step.Input) {
+			if !ancestors[step.ID][reference] {
+				return fmt.Errorf("step %q references %q, which it does not depend on", step.ID, reference)
+			}
+		}
+	}
+	return nil
+}
+
+// chainReferences returns the step IDs referenced anywhere in an input value
+func chainReferences(input any) []string {
+	references := make([]string, 0)
+	walkChainInput(normalizeChainValue(input), func(text string) {
+		if match := toolChainReference.FindStringSubmatch(text); match != nil {
+			references = append(references, match[1])
+		}
+	})
+	return references
+}
+
+// walkChainInput calls visit for every string in a decoded JSON value
+func walkChainInput(value any, visit func(string)) {
+	switch v := value.(type) {
+	case string:
+		visit(v)
+	case map[string]any:
+		for _, item := range v {
+			walkChainInput(item, visit)
+		}
+	case []any:
+		for _, item := range v {
+			walkChainInput(item, visit)
+		}
+	}
+}
+
+// normalizeChainValue converts typed Go values into their decoded JSON form
+func normalizeChainValue(value any) any {
+	switch value.(type) {
+	case nil, string, float64, bool, map[string]any, []any:
+		return value
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// ExecuteToolChain runs the chain's steps, starting each as soon as its dependencies finish.
+// Independent steps run in parallel; the first failure stops the chain and returns the results so far.
+func (p *AgenticRAGProcessor) ExecuteToolChain(ctx context.Context, request ToolChainRequest) (*ToolChainResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tool chain: %w", err)
+	}
+	if p.config.Genkit == nil {
+		return nil, fmt.Errorf("GenKit instance not provided in config")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	outputs := make(map[string]any, len(request.Steps))
+	response := &ToolChainResponse{Results: make([]ToolStepResult, 0, len(request.Steps))}
+	var chainErr error
+
+	done := make(map[string]chan struct{}, len(request.Steps))
+	for _, step := range request.Steps {
+		done[step.ID] = make(chan struct{})
+	}
+
+	// Steps wait on their dependencies inside the group, so it is unbounded to avoid waiting steps
+	// holding every slot
+	group, ctx := concurrency.WithContext(ctx, 0)
+	for _, step := range request.Steps {
+		group.Go(func() error {
+			defer close(done[step.ID])
+
+			for _, dependency := range step.DependsOn {
+				select {
+				case <-done[dependency]:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+
+			mu.Lock()
+			failed := chainErr != nil
+			input := resolveChainInput(normalizeChainValue(step.Input), outputs)
+			mu.Unlock()
+			if failed {
+				return nil
+			}
+
+			result := ToolStepResult{StepID: step.ID, Tool: step.Tool}
+			startedAt := time.Now()
+			var output any
+			err := concurrency.Recover(func() (err error) {
+				output, err = p.runTool(ctx, step.Tool, input)
+				return err
+			})
+			result.Duration = time.Since(startedAt)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Error = err.Error()
+				if chainErr == nil {
+					chainErr = fmt.Errorf("step %q (%s) failed: %w", step.ID, step.Tool, err)
+					cancel()
+				}
+			} else {
+				result.Output = output
+				outputs[step.ID] = normalizeChainValue(output)
+			}
+			response.Results = append(response.Results, result)
+			return nil
+		})
+	}
+	group.Wait()
+
+	return response, chainErr
+}
+
+// runTool looks up a registered tool and runs it with decoded JSON input
+func (p *AgenticRAGProcessor) runTool(ctx context.Context, name string, input any) (any, error) {
+	tool := genkit.LookupTool(p.config.Genkit, name)
+	if tool == nil {
+		return nil, fmt.Errorf("tool %q not found", name)
+	}
+	return tool.RunRaw(ctx, input)
+}
+
+// resolveChainInput replaces step references in an input with the referenced outputs
+func resolveChainInput(value any, outputs map[string]any) any {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "$$") {
+			return v[1:]
+		}
+		match := toolChainReference.FindStringSubmatch(v)
+		if match == nil {
+			return v
+		}
+		resolved := outputs[match[1]]
+		for _, key := range strings.Split(strings.TrimPrefix(match[2], "."), ".") {
+			if key == "" {
+				continue
+			}
+			switch container := resolved.(type) {
+			case map[string]any:
+				resolved = container[key]
+			case []any:
+				index, err := strconv.Atoi(key)
+				if err != nil || index < 0 || index >= len(container) {
+					return nil
+				}
+				resolved = container[index]
+			default:
+				return nil
+			}
+		}
+		return resolved
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved[key] = resolveChainInput(item, outputs)
+		}
+		return resolved
+	case []any:
+		resolved := make([]any, len(v))
+		for i, item := range v {
+			resolved[i] = resolveChainInput(item, outputs)
+		}
+		return resolved
+	default:
+		return v
+	}
+}
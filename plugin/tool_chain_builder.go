@@ -0,0 +1,160 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChainStep is a step passed to ChainBuilder.Parallel
+type ChainStep struct {
+	ID    string
+	Tool  string
+	Input any
+}
+
+// NewStep creates a parallel step; its ID defaults to the tool name
+func NewStep(tool string, input any) ChainStep {
+	return ChainStep{Tool: tool, Input: input}
+}
+
+// As names the step so later steps can reference it
+func (s ChainStep) As(id string) ChainStep {
+	s.ID = id
+	return s
+}
+
+// Ref builds a reference to a step's output, optionally to a field path within it (e.g. Ref("chunk", "chunks", "0"))
+func Ref(stepID string, path ...string) string {
+	if len(path) == 0 {
+		return "$" + stepID
+	}
+	return "$" + stepID + "." + strings.Join(path, ".")
+}
+
+// ChainBuilder builds a ToolChainRequest fluently:
+//
+//	NewChain().Step("chunkDocument", in).Then("scoreRelevance", scoreIn).Parallel(a, b).Build()
+//
+// Each added step depends on the steps added just before it (all of them, after Parallel).
+type ChainBuilder struct {
+	steps    []ToolChainStep
+	frontier []string
+	ids      map[string]int
+	err      error
+}
+
+// NewChain starts an empty tool chain
+func NewChain() *ChainBuilder {
+	return &ChainBuilder{ids: make(map[string]int)}
+}
+
+// Step adds a step with an explicit input after the current steps
+func (b *ChainBuilder) Step(tool string, input any) *ChainBuilder {
+	b.frontier = []string{b.add(ChainStep{Tool: tool, Input: input})}
+	return b
+}
+
+// Then adds a step after the current steps; without an input it receives the previous step's whole output
+func (b *ChainBuilder) Then(tool string, input ...any) *ChainBuilder {
+	step := ChainStep{Tool: tool}
+	switch {
+	case len(input) == 1:
+		step.Input = input[0]
+	case len(input) > 1:
+		b.fail(fmt.Errorf("step %q: Then takes at most one input", tool))
+		return b
+	case len(b.frontier) == 1:
+		step.Input = Ref(b.frontier[0])
+	default:
+		b.fail(fmt.Errorf("step %q: Then without input requires exactly one previous step", tool))
+		return b
+	}
+	b.frontier = []string{b.add(step)}
+	return b
+}
+
+// Parallel adds steps that all run after the current steps and before any step added later
+func (b *ChainBuilder) Parallel(steps ...ChainStep) *ChainBuilder {
+	if len(steps) == 0 {
+		b.fail(fmt.Errorf("Parallel requires at least one step"))
+		return b
+	}
+	frontier := make([]string, 0, len(steps))
+	for _, step := range steps {
+		frontier = append(frontier, b.add(step))
+	}
+	b.frontier = frontier
+	return b
+}
+
+// As renames the most recently added step
+func (b *ChainBuilder) As(id string) *ChainBuilder {
+	if len(b.steps) == 0 {
+		b.fail(fmt.Errorf("As called before any step"))
+		return b
+	}
+	last := &b.steps[len(b.steps)-1]
+	if b.hasID(id) {
+		b.fail(fmt.Errorf("duplicate step ID %q", id))
+		return b
+	}
+	delete(b.ids, last.ID)
+	b.ids[id] = len(b.steps) - 1
+	for i, frontierID := range b.frontier {
+		if frontierID == last.ID {
+			b.frontier[i] = id
+		}
+	}
+	last.ID = id
+	return b
+}
+
+// Build validates the chain and returns the request
+func (b *ChainBuilder) Build() (ToolChainRequest, error) {
+	if b.err != nil {
+		return ToolChainRequest{}, b.err
+	}
+	if len(b.steps) == 0 {
+		return ToolChainRequest{}, fmt.Errorf("tool chain has no steps")
+	}
+	request := ToolChainRequest{Steps: append([]ToolChainStep(nil), b.steps...)}
+	if err := request.Validate(); err != nil {
+		return ToolChainRequest{}, err
+	}
+	return request, nil
+}
+
+// add appends a step depending on the current frontier and returns its ID
+func (b *ChainBuilder) add(step ChainStep) string {
+	id := step.ID
+	if id == "" {
+		id = step.Tool
+		for n := 2; b.hasID(id); n++ {
+			id = fmt.Sprintf("%s_%d", step.Tool, n)
+		}
+	} else if b.hasID(id) {
+		b.fail(fmt.Errorf("duplicate step ID %q", id))
+	}
+
+	b.ids[id] = len(b.steps)
+	b.steps = append(b.steps, ToolChainStep{
+		ID:        id,
+		Tool:      step.Tool,
+		Input:     step.Input,
+		DependsOn: append([]string(nil), b.frontier...),
+	})
+	return id
+}
+
+// hasID reports whether a step already uses the ID
+func (b *ChainBuilder) hasID(id string) bool {
+	_, exists := b.ids[id]
+	return exists
+}
+
+// fail records the first builder error
+func (b *ChainBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
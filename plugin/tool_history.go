@@ -0,0 +1,187 @@
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ToolExecutionResult records one invocation of a registered tool
+type ToolExecutionResult struct {
+	ID        string          `json:"id"`
+	ToolName  string          `json:"tool_name"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Success   bool            `json:"success"`
+	Error     string          `json:"error,omitempty"`
+	StartedAt time.Time       `json:"started_at"`
+	Duration  time.Duration   `json:"duration"`
+}
+
+// ToolHistoryQuery filters recorded tool executions; zero values match everything
+type ToolHistoryQuery struct {
+	ToolName string    `json:"tool_name,omitempty"`
+	Success  *bool     `json:"success,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+	Limit    int       `json:"limit,omitempty"` // Defaults to 100
+}
+
+// ToolHistoryStore persists tool executions for auditing and debugging
+type ToolHistoryStore interface {
+	Record(ctx context.Context, result ToolExecutionResult) error
+	Query(ctx context.Context, query ToolHistoryQuery) ([]ToolExecutionResult, error)
+}
+
+// recordedTool wraps a tool function so each execution is written to the configured tool history.
+// History failures are counted but never fail the tool.
+func recordedTool[In, Out any](config *AgenticRAGConfig, name string, fn func(*ai.ToolContext, In) (Out, error)) func(*ai.ToolContext, In) (Out, error) {
+	return func(ctx *ai.ToolContext, input In) (Out, error) {
+		startedAt := time.Now()
+		output, err := fn(ctx, input)
+
+		config.Metrics.IncCounter("agentic_rag_tool_executions_total", 1)
+		if config.ToolHistory == nil {
+			return output, err
+		}
+
+		result := ToolExecutionResult{
+			ID:        newAuditID(),
+			ToolName:  name,
+			Success:   err == nil,
+			StartedAt: startedAt,
+			Duration:  time.Since(startedAt),
+		}
+		result.Input, _ = json.Marshal(input)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Output, _ = json.Marshal(output)
+		}
+		if recordErr := config.ToolHistory.Record(ctx, result); recordErr != nil {
+			config.Metrics.IncCounter("agentic_rag_tool_history_errors_total", 1)
+		}
+		return output, err
+	}
+}
+
+// SQLToolHistory stores tool executions in a SQL table, e.g. a Turso/libSQL database opened with its database/sql driver
+type SQLToolHistory struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLToolHistory creates the tool history table and its indexes if needed
+func NewSQLToolHistory(ctx context.Context, db *sql.DB, table string) (*SQLToolHistory, error) {
+	if table == "" {
+		table = "tool_executions"
+	}
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			tool_name TEXT NOT NULL,
+			success INTEGER NOT NULL,
+			started_at INTEGER NOT NULL,
+			duration_ns INTEGER NOT NULL,
+			input TEXT,
+			output TEXT,
+			error TEXT
+		)`, table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_tool_time ON %s (tool_name, started_at)", table, table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_time ON %s (started_at)", table, table),
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to create tool history table: %w", err)
+		}
+	}
+	return &SQLToolHistory{db: db, table: table}, nil
+}
+
+// Record inserts a tool execution
+func (h *SQLToolHistory) Record(ctx context.Context, result ToolExecutionResult) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, tool_name, success, started_at, duration_ns, input, output, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", h.table)
+	success := 0
+	if result.Success {
+		success = 1
+	}
+	if _, err := h.db.ExecContext(ctx, query, result.ID, result.ToolName, success, result.StartedAt.UnixNano(),
+		int64(result.Duration), string(result.Input), string(result.Output), result.Error); err != nil {
+		return fmt.Errorf("failed to insert tool execution: %w", err)
+	}
+	return nil
+}
+
+// Query returns matching executions, newest first
+func (h *SQLToolHistory) Query(ctx context.Context, filter ToolHistoryQuery) ([]ToolExecutionResult, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if filter.ToolName != "" {
+		conditions = append(conditions, "tool_name = ?")
+		args = append(args, filter.ToolName)
+	}
+	if filter.Success != nil {
+		conditions = append(conditions, "success = ?")
+		if *filter.Success {
+			args = append(args, 1)
+		} else {
+			args = append(args, 0)
+		}
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "started_at >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "started_at < ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := fmt.Sprintf("SELECT id, tool_name, success, started_at, duration_ns, input, output, error FROM %s", h.table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY started_at DESC LIMIT %d", limit)
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tool history: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]ToolExecutionResult, 0)
+	for rows.Next() {
+		var result ToolExecutionResult
+		var success int
+		var startedAt, duration int64
+		var input, output, errText sql.NullString
+		if err := rows.Scan(&result.ID, &result.ToolName, &success, &startedAt, &duration, &input, &output, &errText); err != nil {
+			return nil, fmt.Errorf("failed to scan tool execution: %w", err)
+		}
+		result.Success = success != 0
+		result.StartedAt = time.Unix(0, startedAt)
+		result.Duration = time.Duration(duration)
+		if input.String != "" {
+			result.Input = json.RawMessage(input.String)
+		}
+		if output.String != "" {
+			result.Output = json.RawMessage(output.String)
+		}
+		result.Error = errText.String
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tool history: %w", err)
+	}
+	return results, nil
+}
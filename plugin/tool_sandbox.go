@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Sentinel errors for tool limit violations, matched with errors.Is against a *ToolLimitError
+var (
+	ErrToolTimeout        = errors.New("tool execution timed out")
+	ErrToolOutputTooLarge = errors.New("tool output too large")
+	ErrToolPanic          = errors.New("tool panicked")
+)
+
+// ToolLimits bounds a single tool execution; zero values disable a limit. Memory is not limited:
+// Go only measures heap allocation process-wide, so a per-tool limit would fail tools for what
+// concurrent requests allocate.
+type ToolLimits struct {
+	Timeout        time.Duration `json:"timeout"`
+	MaxOutputBytes int           `json:"max_output_bytes"` // Size of the JSON-encoded output
+}
+
+// ToolSandboxConfig contains execution limits for registered tools
+type ToolSandboxConfig struct {
+	Enabled bool                  `json:"enabled"`
+	Default ToolLimits            `json:"default"`
+	Tools   map[string]ToolLimits `json:"tools,omitempty"` // Per-tool limits replacing the defaults
+}
+
+// ToolLimitError reports a tool execution stopped by a sandbox limit or a panic
+type ToolLimitError struct {
+	Tool   string `json:"tool"`
+	Kind   error  `json:"-"` // One of the ErrTool sentinels
+	Detail string `json:"detail"`
+	Stack  string `json:"stack,omitempty"` // Set for panics
+}
+
+// Error implements the error interface
+func (e *ToolLimitError) Error() string {
+	return fmt.Sprintf("tool %s: %v: %s", e.Tool, e.Kind, e.Detail)
+}
+
+// Unwrap returns the sentinel error for the violated limit
+func (e *ToolLimitError) Unwrap() error {
+	return e.Kind
+}
+
+// limitsFor returns the limits for a tool, preferring its own entry over the defaults
+func (c ToolSandboxConfig) limitsFor(name string) ToolLimits {
+	if limits, ok := c.Tools[name]; ok {
+		return limits
+	}
+	return c.Default
+}
+
+// guardedTool applies the sandbox limits to a tool function and records each execution in the tool history
+func guardedTool[In, Out any](config *AgenticRAGConfig, name string, fn func(*ai.ToolContext, In) (Out, error)) func(*ai.ToolContext, In) (Out, error) {
+	return recordedTool(config, name, sandboxedTool(config, name, fn))
+}
+
+// sandboxedTool wraps a tool function with the configured limits and panic recovery.
+// Timed-out executions are abandoned: the tool's context is cancelled and
+// the caller gets a *ToolLimitError, but a tool ignoring cancellation keeps running until it returns.
+func sandboxedTool[In, Out any](config *AgenticRAGConfig, name string, fn func(*ai.ToolContext, In) (Out, error)) func(*ai.ToolContext, In) (Out, error) {
+	return func(toolCtx *ai.ToolContext, input In) (Out, error) {
+		var zero Out
+		if !config.ToolSandbox.Enabled {
+			return fn(toolCtx, input)
+		}
+		limits := config.ToolSandbox.limitsFor(name)
+
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if limits.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(toolCtx.Context, limits.Timeout)
+		} else {
+			ctx, cancel = context.WithCancel(toolCtx.Context)
+		}
+		defer cancel()
+		sandboxed := *toolCtx
+		sandboxed.Context = ctx
+
+		type result struct {
+			output Out
+			err    error
+		}
+		done := make(chan result, 1)
+		go func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					done <- result{err: &ToolLimitError{Tool: name, Kind: ErrToolPanic, Detail: fmt.Sprint(recovered), Stack: string(debug.Stack())}}
+				}
+			}()
+			output, err := fn(&sandboxed, input)
+			done <- result{output: output, err: err}
+		}()
+
+		select {
+		case res := <-done:
+			if res.err != nil {
+				if errors.Is(res.err, ErrToolPanic) {
+					config.Metrics.IncCounter("agentic_rag_tool_panics_total", 1)
+				}
+				return zero, res.err
+			}
+			if limits.MaxOutputBytes > 0 {
+				encoded, err := json.Marshal(res.output)
+				if err != nil {
+					return zero, fmt.Errorf("failed to encode tool output: %w", err)
+				}
+				if len(encoded) > limits.MaxOutputBytes {
+					config.Metrics.IncCounter("agentic_rag_tool_limit_violations_total", 1)
+					return zero, &ToolLimitError{Tool: name, Kind: ErrToolOutputTooLarge, Detail: fmt.Sprintf("%d bytes exceeds limit of %d", len(encoded), limits.MaxOutputBytes)}
+				}
+			}
+			return res.output, nil
+		case <-ctx.Done():
+			if toolCtx.Context.Err() != nil {
+				return zero, toolCtx.Context.Err()
+			}
+			config.Metrics.IncCounter("agentic_rag_tool_limit_violations_total", 1)
+			return zero, &ToolLimitError{Tool: name, Kind: ErrToolTimeout, Detail: fmt.Sprintf("exceeded %s", limits.Timeout)}
+		}
+	}
+}
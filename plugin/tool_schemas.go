@@ -0,0 +1,165 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// GenerationToolsConfig contains configuration for letting the generation stage call tools
+type GenerationToolsConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Tools    []string `json:"tools,omitempty"` // Curated tool names; empty offers every tool registered by the plugin
+	MaxTurns int      `json:"max_turns"`       // Maximum tool call round trips per answer
+}
+
+// ToolSchema describes a registered tool for publication to models and clients
+type ToolSchema struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema,omitempty"`
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
+}
+
+// defineTool registers a guarded tool with Genkit and records it as a plugin tool
+func defineTool[In, Out any](p *AgenticRAGPlugin, g *genkit.Genkit, name, description string, fn func(*ai.ToolContext, In) (Out, error)) {
+	genkit.DefineTool(g, name, description, guardedTool(p.config, name, fn))
+	p.processor.addTool(name)
+}
+
+// addTool records the name of a tool available to the generation stage
+func (p *AgenticRAGProcessor) addTool(name string) {
+	p.toolsMu.Lock()
+	defer p.toolsMu.Unlock()
+	for _, existing := range p.tools {
+		if existing == name {
+			return
+		}
+	}
+	p.tools = append(p.tools, name)
+}
+
+// ToolNames returns the tools registered by the plugin, sorted by name
+func (p *AgenticRAGProcessor) ToolNames() []string {
+	p.toolsMu.Lock()
+	defer p.toolsMu.Unlock()
+	names := append([]string(nil), p.tools...)
+	sort.Strings(names)
+	return names
+}
+
+// ToolSchemas returns the JSON Schemas of the named tools, or of every plugin tool when no names are given
+func (p *AgenticRAGProcessor) ToolSchemas(names ...string) ([]ToolSchema, error) {
+	if p.config.Genkit == nil {
+		return nil, fmt.Errorf("genkit is not initialized")
+	}
+	if len(names) == 0 {
+		names = p.ToolNames()
+	}
+
+	schemas := make([]ToolSchema, 0, len(names))
+	for _, name := range names {
+		tool := genkit.LookupTool(p.config.Genkit, name)
+		if tool == nil {
+			return nil, fmt.Errorf("tool %q is not registered", name)
+		}
+		definition := tool.Definition()
+		schema := ToolSchema{
+			Name:         definition.Name,
+			Description:  definition.Description,
+			InputSchema:  completeSchema(definition.InputSchema, definition.Description),
+			OutputSchema: completeSchema(definition.OutputSchema, ""),
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// ToolSetOption returns a generation option offering the curated tool set to the model
+func (p *AgenticRAGProcessor) ToolSetOption(names ...string) (ai.CommonGenOption, error) {
+	schemas, err := p.ToolSchemas(names...)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]ai.ToolRef, len(schemas))
+	for i, schema := range schemas {
+		refs[i] = ai.ToolName(schema.Name)
+	}
+	return ai.WithTools(refs...), nil
+}
+
+// generationToolOptions returns the tool options for the generation stage, or nil when tool use is off.
+// Genkit runs the call-observe loop, so the model can reason, call tools, and answer in one request.
+func (p *AgenticRAGProcessor) generationToolOptions() []ai.CommonGenOption {
+	cfg := p.config.GenerationTools
+	if !cfg.Enabled || p.config.Genkit == nil {
+		return nil
+	}
+	tools, err := p.ToolSetOption(cfg.Tools...)
+	if err != nil {
+		p.config.Metrics.IncCounter("agentic_rag_tool_schema_errors_total", 1)
+		return nil
+	}
+	maxTurns := cfg.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = 5
+	}
+	return []ai.CommonGenOption{tools, ai.WithMaxTurns(maxTurns)}
+}
+
+// completeSchema inlines local "$ref" definitions so the schema stands alone and sets a
+// top-level description when the schema has none
+func completeSchema(schema map[string]any, description string) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	definitions := make(map[string]any)
+	for _, key := range []string{"$defs", "definitions"} {
+		if defs, ok := schema[key].(map[string]any); ok {
+			for name, definition := range defs {
+				definitions[name] = definition
+			}
+		}
+	}
+
+	resolved, _ := inlineSchemaRefs(schema, definitions, make(map[string]bool)).(map[string]any)
+	delete(resolved, "$defs")
+	delete(resolved, "definitions")
+	if _, ok := resolved["description"]; !ok && description != "" {
+		resolved["description"] = description
+	}
+	return resolved
+}
+
+// inlineSchemaRefs copies a schema value, replacing references with their definitions.
+// Recursive references are left in place to keep the result finite.
+func inlineSchemaRefs(value any, definitions map[string]any, expanding map[string]bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		if ref, ok := typed["$ref"].(string); ok {
+			name := ref[strings.LastIndex(ref, "/")+1:]
+			if definition, ok := definitions[name]; ok && !expanding[name] {
+				expanding[name] = true
+				inlined := inlineSchemaRefs(definition, definitions, expanding)
+				delete(expanding, name)
+				return inlined
+			}
+		}
+		copied := make(map[string]any, len(typed))
+		for key, item := range typed {
+			copied[key] = inlineSchemaRefs(item, definitions, expanding)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(typed))
+		for i, item := range typed {
+			copied[i] = inlineSchemaRefs(item, definitions, expanding)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,200 @@
+package plugin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// transcriptSegmentsMetadataKey is the document metadata key holding transcript timestamps
+const transcriptSegmentsMetadataKey = "transcript_segments"
+
+// TranscriptionConfig contains configuration for transcribing audio and video sources
+type TranscriptionConfig struct {
+	Enabled   bool   `json:"enabled"`
+	ModelName string `json:"model_name,omitempty"` // Transcription-capable model; defaults to the pipeline model
+	MaxBytes  int64  `json:"max_bytes"`            // Maximum media size sent inline to the model
+}
+
+// TranscriptSegment is a timed span of a transcript and its byte range in the document content
+type TranscriptSegment struct {
+	Start     float64 `json:"start"` // Seconds from the beginning of the recording
+	End       float64 `json:"end"`
+	Offset    int     `json:"offset"`
+	EndOffset int     `json:"end_offset"`
+}
+
+// mediaTypes maps audio and video file extensions to their media types
+var mediaTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/opus",
+	".flac": "audio/flac",
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".mkv":  "video/x-matroska",
+	".avi":  "video/x-msvideo",
+}
+
+// TranscriptionLoader transcribes local audio and video files with a Genkit model
+type TranscriptionLoader struct {
+	processor *AgenticRAGProcessor
+}
+
+// NewTranscriptionLoader creates a loader that transcribes media with the processor's models
+func NewTranscriptionLoader(processor *AgenticRAGProcessor) *TranscriptionLoader {
+	return &TranscriptionLoader{processor: processor}
+}
+
+// CanLoad reports whether transcription is enabled and the source is a local audio or video file
+func (l *TranscriptionLoader) CanLoad(source string) bool {
+	if !l.processor.config.Loading.Transcription.Enabled || l.processor.config.Genkit == nil {
+		return false
+	}
+	if _, ok := mediaTypes[strings.ToLower(filepath.Ext(source))]; !ok {
+		return false
+	}
+	info, err := os.Stat(source)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Load transcribes the file into a document whose metadata maps content offsets to timestamps
+func (l *TranscriptionLoader) Load(ctx context.Context, source string) ([]Document, error) {
+	cfg := l.processor.config.Loading.Transcription
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media file: %w", err)
+	}
+	defer file.Close()
+
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 20 << 20
+	}
+	data, err := readLimited(file, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(source))
+	mediaType := mediaTypes[extension]
+	if detected := mime.TypeByExtension(extension); strings.HasPrefix(detected, "audio/") || strings.HasPrefix(detected, "video/") {
+		mediaType = detected
+	}
+
+	segments, err := l.transcribe(ctx, mediaType, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe %s: %w", filepath.Base(source), err)
+	}
+
+	var builder strings.Builder
+	timed := make([]TranscriptSegment, 0, len(segments))
+	duration := 0.0
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		offset := builder.Len()
+		builder.WriteString(text)
+		timed = append(timed, TranscriptSegment{Start: segment.Start, End: segment.End, Offset: offset, EndOffset: builder.Len()})
+		if segment.End > duration {
+			duration = segment.End
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"title":        strings.TrimSuffix(filepath.Base(source), filepath.Ext(source)),
+		"content_type": mediaType,
+		"transcribed":  true,
+	}
+	if duration > 0 {
+		metadata["duration"] = duration
+	}
+	if len(timed) > 0 {
+		metadata[transcriptSegmentsMetadataKey] = timed
+	}
+	return []Document{{Content: builder.String(), Source: source, Metadata: metadata}}, nil
+}
+
+// transcriptionSegment is a segment as returned by the model
+type transcriptionSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// transcribe sends the media inline to the model and parses its timed segments.
+// A response that is not the requested JSON is kept as a single untimed segment.
+func (l *TranscriptionLoader) transcribe(ctx context.Context, mediaType string, data []byte) ([]transcriptionSegment, error) {
+	p := l.processor
+	modelOption := p.modelOption(ctx)
+	if endpointFromContext(ctx) == nil && p.config.Loading.Transcription.ModelName != "" {
+		modelOption = ai.WithModelName(p.config.Loading.Transcription.ModelName)
+	}
+
+	dataURL := fmt.Sprintf("data:%s;base64,%s", mediaType, base64.StdEncoding.EncodeToString(data))
+	response, err := genkit.Generate(ctx, p.config.Genkit,
+		modelOption,
+		p.modelMetricsOption(ctx),
+		ai.WithMessages(ai.NewUserMessage(
+			ai.NewMediaPart(mediaType, dataURL),
+			ai.NewTextPart(`Transcribe the speech in this recording verbatim. Split it into segments of one or a few sentences.
+
+Respond with a JSON array where each element has "start" and "end" (seconds from the beginning of the recording) and "text".
+
+Example: [{"start": 0.0, "end": 4.2, "text": "Welcome to the quarterly review."}]`),
+		)),
+		ai.WithConfig(&ai.GenerationCommonConfig{Temperature: 0.0}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []transcriptionSegment
+	if err := json.Unmarshal([]byte(extractJSON(response.Text())), &segments); err != nil {
+		return []transcriptionSegment{{Text: response.Text()}}, nil
+	}
+	return segments, nil
+}
+
+// annotateChunkTimestamps records the recording time span each chunk of a transcript covers.
+// Chunk offsets are approximate, so a segment counts only when most of it or most of the chunk overlaps.
+func annotateChunkTimestamps(chunks []DocumentChunk, segments []TranscriptSegment) {
+	for i := range chunks {
+		start, end := -1.0, -1.0
+		for _, segment := range segments {
+			overlap := min(segment.EndOffset, chunks[i].EndIndex) - max(segment.Offset, chunks[i].StartIndex)
+			if overlap <= 0 || (overlap*2 < segment.EndOffset-segment.Offset && overlap*2 < chunks[i].EndIndex-chunks[i].StartIndex) {
+				continue
+			}
+			if start < 0 || segment.Start < start {
+				start = segment.Start
+			}
+			if segment.End > end {
+				end = segment.End
+			}
+		}
+		if start >= 0 && end > 0 {
+			chunks[i].Metadata["start_time"] = start
+			chunks[i].Metadata["end_time"] = end
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TuningGrid lists the parameter values swept by the optimizer
+type TuningGrid struct {
+	ChunkSizes      []int `json:"chunk_sizes"`
+	MaxChunks       []int `json:"max_chunks"`
+	RecursiveDepths []int `json:"recursive_depths"`
+}
+
+// TuningTrial records the eval result of one parameter combination
+type TuningTrial struct {
+	Profile     PipelineProfile `json:"profile"`
+	MeanScore   float64         `json:"mean_score"`
+	MeanLatency time.Duration   `json:"mean_latency"`
+	Failures    int             `json:"failures"`
+}
+
+// TuningResult holds every trial and the recommended profile
+type TuningResult struct {
+	Trials []TuningTrial   `json:"trials"`
+	Best   PipelineProfile `json:"best"`
+	Score  float64         `json:"score"`
+}
+
+// DefaultTuningGrid returns a small grid around the default processing settings
+func DefaultTuningGrid() TuningGrid {
+	return TuningGrid{
+		ChunkSizes:      []int{128, 256, 384},
+		MaxChunks:       []int{10, 20},
+		RecursiveDepths: []int{1, 3},
+	}
+}
+
+// Tune sweeps the grid against the eval cases and recommends the best-scoring profile,
+// preferring the faster profile when scores tie
+func (p *AgenticRAGProcessor) Tune(ctx context.Context, cases []EvalCase, grid TuningGrid, base AgenticRAGOptions) (*TuningResult, error) {
+	if len(grid.ChunkSizes) == 0 || len(grid.MaxChunks) == 0 || len(grid.RecursiveDepths) == 0 {
+		return nil, fmt.Errorf("tuning grid must list at least one value per parameter")
+	}
+
+	result := &TuningResult{}
+	bestIndex := -1
+	for _, chunkSize := range grid.ChunkSizes {
+		for _, maxChunks := range grid.MaxChunks {
+			for _, depth := range grid.RecursiveDepths {
+				options := base
+				options.Profile = ""
+				options.ChunkSize = chunkSize
+				options.MaxChunks = maxChunks
+				options.RecursiveDepth = depth
+
+				report, err := p.Evaluate(ctx, cases, options)
+				if err != nil {
+					return nil, fmt.Errorf("failed to evaluate chunk size %d, max chunks %d, depth %d: %w", chunkSize, maxChunks, depth, err)
+				}
+
+				trial := TuningTrial{
+					Profile: PipelineProfile{
+						Description:                fmt.Sprintf("Tuned on %d eval cases (score %.3f)", len(cases), report.MeanScore),
+						ChunkSize:                  chunkSize,
+						MaxChunks:                  maxChunks,
+						RecursiveDepth:             depth,
+						EnableKnowledgeGraph:       base.EnableKnowledgeGraph,
+						EnableFactVerification:     base.EnableFactVerification,
+						EnableCitationVerification: base.EnableCitationVerification,
+					},
+					MeanScore:   report.MeanScore,
+					MeanLatency: report.MeanLatency,
+					Failures:    report.Failures,
+				}
+				result.Trials = append(result.Trials, trial)
+
+				if bestIndex < 0 || betterTrial(trial, result.Trials[bestIndex]) {
+					bestIndex = len(result.Trials) - 1
+				}
+			}
+		}
+	}
+
+	result.Best = result.Trials[bestIndex].Profile
+	result.Score = result.Trials[bestIndex].MeanScore
+	return result, nil
+}
+
+// betterTrial orders trials by score, then failures, then latency
+func betterTrial(a, b TuningTrial) bool {
+	const scoreEpsilon = 1e-6
+	if a.MeanScore > b.MeanScore+scoreEpsilon {
+		return true
+	}
+	if a.MeanScore < b.MeanScore-scoreEpsilon {
+		return false
+	}
+	if a.Failures != b.Failures {
+		return a.Failures < b.Failures
+	}
+	return a.MeanLatency < b.MeanLatency
+}
+
+// WriteProfile writes a named profile as JSON suitable for merging into AgenticRAGConfig.Profiles
+func WriteProfile(path, name string, profile PipelineProfile) error {
+	data, err := json.MarshalIndent(map[string]PipelineProfile{name: profile}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,617 @@
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// TursoConfig contains configuration for a Turso/libSQL vector store
+type TursoConfig struct {
+	Table          string `json:"table"`           // Records table (default: vector_records)
+	Dimensions     int    `json:"dimensions"`      // Embedding dimensions of the F32_BLOB column (required)
+	SkipEmbeddings bool   `json:"skip_embeddings"` // Leave embeddings out of read records, saving their extraction and transfer
+	BatchSize      int    `json:"batch_size"`      // Records inserted per multi-row statement (default: 100)
+}
+
+// tursoMaxBatchSize keeps a batch's bound parameters below SQLite's default limit of 32766
+const tursoMaxBatchSize = 32766 / 8
+
+// TursoVectorStore stores records in a libSQL table with an F32_BLOB embedding column and a
+// libSQL vector index. The same schema and queries serve a remote Turso database and a local
+// database file opened with an embedded libSQL driver; see NewLocalTursoVectorStore.
+type TursoVectorStore struct {
+	db     *sql.DB
+	config TursoConfig
+	schema *schemaInit
+	pruned *tursoPruneStats
+	guard  *embeddingGuard
+}
+
+// TursoStats reports the connection pool and the records removed by Prune since the store was opened
+type TursoStats struct {
+	sql.DBStats
+	Pruned       int64     `json:"pruned"`                  // Expired records removed in total
+	LastPruned   int64     `json:"last_pruned"`             // Expired records removed by the last Prune
+	LastPrunedAt time.Time `json:"last_pruned_at,omitzero"` // When Prune last completed
+}
+
+// tursoPruneStats counts a table's pruned records
+type tursoPruneStats struct {
+	mu           sync.Mutex
+	total        int64
+	last         int64
+	lastPrunedAt time.Time
+}
+
+// NewTursoVectorStore migrates the records table and its indexes to the latest schema version
+func NewTursoVectorStore(ctx context.Context, db *sql.DB, config TursoConfig) (*TursoVectorStore, error) {
+	if config.Table == "" {
+		config.Table = "vector_records"
+	}
+	if !pgIdentifier.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid table name %q", config.Table)
+	}
+	if config.Dimensions <= 0 {
+		return nil, fmt.Errorf("turso vector store needs the embedding dimensions")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.BatchSize > tursoMaxBatchSize {
+		config.BatchSize = tursoMaxBatchSize
+	}
+
+	store := &TursoVectorStore{db: db, config: config, schema: &schemaInit{}, pruned: &tursoPruneStats{}, guard: &embeddingGuard{}}
+	if err := store.schema.ensure(ctx, store.migrateLatest); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Stats returns the statistics of the database connection pool and of pruning the table
+func (s *TursoVectorStore) Stats() TursoStats {
+	s.pruned.mu.Lock()
+	defer s.pruned.mu.Unlock()
+	return TursoStats{
+		DBStats:      s.db.Stats(),
+		Pruned:       s.pruned.total,
+		LastPruned:   s.pruned.last,
+		LastPrunedAt: s.pruned.lastPrunedAt,
+	}
+}
+
+// WithCollection returns a view of the store keeping the collection's records in their own
+// table and vector index, named after the base table and the collection and migrated on first use
+func (s *TursoVectorStore) WithCollection(collection string) domain.VectorStore {
+	table, err := collectionTable(s.config.Table, collection)
+	if err != nil {
+		return invalidVectorStore{err: err}
+	}
+	config := s.config
+	config.Table = table
+	return &TursoVectorStore{db: s.db, config: config, schema: &schemaInit{}, pruned: &tursoPruneStats{}, guard: &embeddingGuard{}}
+}
+
+// tursoMigration is a reversible schema change
+type tursoMigration struct {
+	up   []string
+	down []string
+}
+
+// migrations returns the schema migrations in version order. Released migrations must never
+// change; schema changes are added as new migrations. The first one creates the table only if
+// missing, so tables created before versioning are adopted as version 1.
+func (s *TursoVectorStore) migrations() []tursoMigration {
+	table := s.config.Table
+	return []tursoMigration{
+		{
+			up: []string{
+				fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+					id TEXT PRIMARY KEY,
+					content TEXT NOT NULL,
+					embedding F32_BLOB(%d) NOT NULL,
+					metadata TEXT NOT NULL DEFAULT '{}',
+					updated_at INTEGER
+				)`, table, s.config.Dimensions),
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[1]s_embedding_idx ON %[1]s (libsql_vector_idx(embedding))", table),
+			},
+			down: []string{
+				fmt.Sprintf("DROP INDEX IF EXISTS %s_embedding_idx", table),
+				fmt.Sprintf("DROP TABLE IF EXISTS %s", table),
+			},
+		},
+		{
+			up: []string{
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN source TEXT", table),
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN chunk_index INTEGER", table),
+				fmt.Sprintf(`UPDATE %s SET source = COALESCE(json_extract(metadata, '$.source'), json_extract(metadata, '$.url')),
+					chunk_index = json_extract(metadata, '$.chunk_index')`, table),
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[1]s_source_idx ON %[1]s (source, chunk_index)", table),
+			},
+			down: []string{
+				fmt.Sprintf("DROP INDEX IF EXISTS %s_source_idx", table),
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN chunk_index", table),
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN source", table),
+			},
+		},
+		{
+			up: []string{
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN expires_at INTEGER", table),
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[1]s_expires_at_idx ON %[1]s (expires_at) WHERE expires_at IS NOT NULL", table),
+			},
+			down: []string{
+				fmt.Sprintf("DROP INDEX IF EXISTS %s_expires_at_idx", table),
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN expires_at", table),
+			},
+		},
+		{
+			up: []string{
+				fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_binding (
+					id INTEGER PRIMARY KEY CHECK (id = 1),
+					embedder TEXT NOT NULL,
+					dimensions INTEGER NOT NULL
+				)`, table),
+			},
+			down: []string{
+				fmt.Sprintf("DROP TABLE IF EXISTS %s_binding", table),
+			},
+		},
+	}
+}
+
+// migrateLatest migrates the schema to the latest version
+func (s *TursoVectorStore) migrateLatest(ctx context.Context) error {
+	return s.MigrateTo(ctx, len(s.migrations()))
+}
+
+// SchemaVersion returns the version of the latest applied migration, 0 before any
+func (s *TursoVectorStore) SchemaVersion(ctx context.Context) (int, error) {
+	if err := s.createVersionTable(ctx); err != nil {
+		return 0, err
+	}
+	return s.schemaVersion(ctx, s.db)
+}
+
+// MigrateTo applies migrations up, or reverts them down, until the schema is at the version.
+// Each step runs in its own transaction together with its version bookkeeping, so an
+// interrupted migration resumes from the last completed step.
+func (s *TursoVectorStore) MigrateTo(ctx context.Context, version int) error {
+	migrations := s.migrations()
+	if version < 0 || version > len(migrations) {
+		return fmt.Errorf("unknown turso schema version %d, latest is %d", version, len(migrations))
+	}
+	if err := s.createVersionTable(ctx); err != nil {
+		return err
+	}
+
+	for {
+		done, err := s.migrateStep(ctx, migrations, version)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// migrateStep moves the schema one version towards the target, reporting whether it was already there
+func (s *TursoVectorStore) migrateStep(ctx context.Context, migrations []tursoMigration, target int) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Read the version inside the transaction so concurrent processes do not apply a step twice
+	current, err := s.schemaVersion(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	versions := s.config.Table + "_schema_version"
+	var statements []string
+	var bookkeeping string
+	var args []interface{}
+	switch {
+	case current < target:
+		statements = migrations[current].up
+		bookkeeping = fmt.Sprintf("INSERT INTO %s (version, applied_at) VALUES (?, ?)", versions)
+		args = []interface{}{current + 1, time.Now().Unix()}
+	case current > target:
+		statements = migrations[current-1].down
+		bookkeeping = fmt.Sprintf("DELETE FROM %s WHERE version = ?", versions)
+		args = []interface{}{current}
+	default:
+		return true, nil
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return false, fmt.Errorf("failed to migrate turso schema from version %d: %w", current, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return false, fmt.Errorf("failed to record turso schema version: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit turso migration: %w", err)
+	}
+	return false, nil
+}
+
+// createVersionTable creates the table recording applied migrations
+func (s *TursoVectorStore) createVersionTable(ctx context.Context) error {
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_schema_version (
+		version INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`, s.config.Table)
+	if _, err := s.db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to create schema version table: %w", err)
+	}
+	return nil
+}
+
+// rowQuerier is a database or transaction that queries single rows
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// schemaVersion reads the latest applied migration version
+func (s *TursoVectorStore) schemaVersion(ctx context.Context, q rowQuerier) (int, error) {
+	var version int
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s_schema_version", s.config.Table)
+	if err := q.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read turso schema version: %w", err)
+	}
+	return version, nil
+}
+
+// NewLocalTursoVectorStore opens a vector store in a local database file, for offline and desktop
+// use without a Turso account or auth token. The driver must be an embedded libSQL driver, such as
+// go-libsql's "libsql", since plain SQLite lacks the vector functions.
+func NewLocalTursoVectorStore(ctx context.Context, driver, path string, config TursoConfig) (*TursoVectorStore, error) {
+	if driver == "" {
+		driver = "libsql"
+	}
+	db, err := sql.Open(driver, "file:"+path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	store, err := NewTursoVectorStore(ctx, db, config)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// BindEmbedding binds the table to the embedding binding unless it is already bound, keeping the
+// binding in a single-row table beside the records table
+func (s *TursoVectorStore) BindEmbedding(ctx context.Context, binding domain.EmbeddingBinding) (domain.EmbeddingBinding, error) {
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return domain.EmbeddingBinding{}, err
+	}
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %s_binding (id, embedder, dimensions) VALUES (1, ?, ?)", s.config.Table)
+	if _, err := s.db.ExecContext(ctx, insert, binding.Embedder, binding.Dimensions); err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: %w", err)
+	}
+	bound, _, err := s.embeddingBinding(ctx)
+	return bound, err
+}
+
+// embeddingBinding returns the table's binding, if it is bound
+func (s *TursoVectorStore) embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error) {
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return domain.EmbeddingBinding{}, false, err
+	}
+	var bound domain.EmbeddingBinding
+	query := fmt.Sprintf("SELECT embedder, dimensions FROM %s_binding WHERE id = 1", s.config.Table)
+	err := s.db.QueryRowContext(ctx, query).Scan(&bound.Embedder, &bound.Dimensions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.EmbeddingBinding{}, false, nil
+	}
+	if err != nil {
+		return domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: %w", err)
+	}
+	return bound, true, nil
+}
+
+// Store inserts records, replacing existing records with the same ID. Records are written in
+// multi-row statements of the configured batch size within one transaction.
+func (s *TursoVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return err
+	}
+	if err := s.guard.checkRecords(ctx, s, records); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(records); start += s.config.BatchSize {
+		end := min(start+s.config.BatchSize, len(records))
+		if err := s.insertBatch(ctx, tx, records[start:end]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit records: %w", err)
+	}
+	return nil
+}
+
+// insertBatch upserts records with a single multi-row INSERT
+func (s *TursoVectorStore) insertBatch(ctx context.Context, tx *sql.Tx, records []domain.VectorRecord) error {
+	args := make([]interface{}, 0, len(records)*8)
+	for _, record := range records {
+		metadata := record.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of record %s: %w", record.ID, err)
+		}
+		var updatedAt sql.NullInt64
+		if !record.UpdatedAt.IsZero() {
+			updatedAt = sql.NullInt64{Int64: record.UpdatedAt.UnixNano(), Valid: true}
+		}
+		var expiresAt sql.NullInt64
+		if !record.ExpiresAt.IsZero() {
+			expiresAt = sql.NullInt64{Int64: record.ExpiresAt.UnixNano(), Valid: true}
+		}
+		var source sql.NullString
+		if value := metadataString(metadata, "source"); value != "" {
+			source = sql.NullString{String: value, Valid: true}
+		} else if value := metadataString(metadata, "url"); value != "" {
+			source = sql.NullString{String: value, Valid: true}
+		}
+		var chunkIndex sql.NullInt64
+		if _, ok := metadata["chunk_index"]; ok {
+			chunkIndex = sql.NullInt64{Int64: int64(metadataInt(metadata, "chunk_index")), Valid: true}
+		}
+		args = append(args, record.ID, record.Content, vectorLiteral(record.Embedding), string(encoded), updatedAt, source, chunkIndex, expiresAt)
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?, ?, vector32(?), ?, ?, ?, ?, ?), ", len(records)), ", ")
+	query := fmt.Sprintf(`INSERT INTO %s (id, content, embedding, metadata, updated_at, source, chunk_index, expires_at) VALUES %s
+		ON CONFLICT(id) DO UPDATE SET content = excluded.content, embedding = excluded.embedding, metadata = excluded.metadata,
+		updated_at = excluded.updated_at, source = excluded.source, chunk_index = excluded.chunk_index, expires_at = excluded.expires_at`, s.config.Table, values)
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to store records %s to %s: %w", records[0].ID, records[len(records)-1].ID, err)
+	}
+	return nil
+}
+
+// Search returns the k records nearest by cosine distance that match the filters. Unfiltered
+// searches use the vector index; filtered searches scan the matching rows so the filters cannot
+// leave fewer than k results.
+func (s *TursoVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return nil, err
+	}
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	args := []interface{}{vectorLiteral(embedding)}
+	var query string
+	if len(filters) == 0 {
+		args = append(args, vectorLiteral(embedding), k)
+		query = fmt.Sprintf(`SELECT r.id, r.content, r.metadata, r.updated_at, %[2]s, 1 - vector_distance_cos(r.embedding, vector32(?)) AS score
+			FROM vector_top_k('%[1]s_embedding_idx', vector32(?), ?) AS v JOIN %[1]s AS r ON r.rowid = v.id
+			ORDER BY score DESC`, s.config.Table, s.embeddingColumn("r.embedding"))
+	} else {
+		conditions, err := sqliteFilterConditions(filters, &args)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, k)
+		query = fmt.Sprintf(`SELECT id, content, metadata, updated_at, %s, 1 - vector_distance_cos(embedding, vector32(?)) AS score
+			FROM %s WHERE %s ORDER BY score DESC LIMIT ?`, s.embeddingColumn("embedding"), s.config.Table, strings.Join(conditions, " AND "))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search records: %w", err)
+	}
+	defer rows.Close()
+	return parseSearchResults(rows)
+}
+
+// Get returns the records with the given IDs; unknown IDs are skipped
+func (s *TursoVectorStore) Get(ctx context.Context, ids []string) ([]domain.VectorRecord, error) {
+	if len(ids) == 0 {
+		return []domain.VectorRecord{}, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := fmt.Sprintf("SELECT id, content, metadata, updated_at, %s, 0 FROM %s WHERE id IN (%s)",
+		s.embeddingColumn("embedding"), s.config.Table, strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "))
+	return s.queryRecords(ctx, query, args...)
+}
+
+// List returns a page of records ordered by ID
+func (s *TursoVectorStore) List(ctx context.Context, offset, limit int) ([]domain.VectorRecord, error) {
+	query := fmt.Sprintf("SELECT id, content, metadata, updated_at, %s, 0 FROM %s ORDER BY id LIMIT ? OFFSET ?",
+		s.embeddingColumn("embedding"), s.config.Table)
+	return s.queryRecords(ctx, query, limit, offset)
+}
+
+// queryRecords runs a query selecting the columns parseSearchResults reads and returns the records
+func (s *TursoVectorStore) queryRecords(ctx context.Context, query string, args ...interface{}) ([]domain.VectorRecord, error) {
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	defer rows.Close()
+
+	results, err := parseSearchResults(rows)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]domain.VectorRecord, len(results))
+	for i, result := range results {
+		records[i] = result.Record
+	}
+	return records, nil
+}
+
+// embeddingColumn selects a column's embedding as text, or NULL when embeddings are skipped
+func (s *TursoVectorStore) embeddingColumn(column string) string {
+	if s.config.SkipEmbeddings {
+		return "NULL"
+	}
+	return "vector_extract(" + column + ")"
+}
+
+// parseSearchResults reads rows of id, content, metadata, updated_at, extracted embedding, and score
+func parseSearchResults(rows *sql.Rows) ([]domain.SearchResult, error) {
+	results := make([]domain.SearchResult, 0)
+	for rows.Next() {
+		var (
+			result    domain.SearchResult
+			metadata  string
+			updatedAt sql.NullInt64
+			embedding sql.NullString
+		)
+		if err := rows.Scan(&result.Record.ID, &result.Record.Content, &metadata, &updatedAt, &embedding, &result.Score); err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+		if err := json.Unmarshal([]byte(metadata), &result.Record.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of record %s: %w", result.Record.ID, err)
+		}
+		if updatedAt.Valid {
+			result.Record.UpdatedAt = time.Unix(0, updatedAt.Int64)
+		}
+		if embedding.Valid {
+			// vector_extract returns the "[1,2,3]" text form, which is a JSON array
+			if err := json.Unmarshal([]byte(embedding.String), &result.Record.Embedding); err != nil {
+				return nil, fmt.Errorf("failed to decode embedding of record %s: %w", result.Record.ID, err)
+			}
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs
+func (s *TursoVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return err
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", s.config.Table, strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "))
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+	return nil
+}
+
+// Prune removes the records whose expiry has passed and counts them in Stats
+func (s *TursoVectorStore) Prune(ctx context.Context) (int, error) {
+	if err := s.schema.ensure(ctx, s.migrateLatest); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?", s.config.Table)
+	result, err := s.db.ExecContext(ctx, query, time.Now().UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune records: %w", err)
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pruned records: %w", err)
+	}
+
+	s.pruned.mu.Lock()
+	s.pruned.total += pruned
+	s.pruned.last = pruned
+	s.pruned.lastPrunedAt = time.Now()
+	s.pruned.mu.Unlock()
+	return int(pruned), nil
+}
+
+// sqliteFilterConditions translates filters into SQLite JSON conditions, appending their
+// arguments. json_each yields a scalar as its only row and a list's elements, matching
+// domain.Filters semantics; ranges only compare values of the bound's type.
+func sqliteFilterConditions(filters domain.Filters, args *[]interface{}) ([]string, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+
+	conditions := make([]string, 0, len(filters))
+	for key, want := range filters {
+		quotedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filter key %q: %w", key, err)
+		}
+		predicates := make([]string, 0)
+		switch want := want.(type) {
+		case map[string]interface{}:
+			operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+			bounds := make([]string, 0, len(want))
+			for operator, bound := range want {
+				value := pgFilterValue(bound)
+				valueType := "type IN ('integer', 'real')"
+				if _, ok := value.(string); ok {
+					valueType = "type = 'text'"
+				}
+				bounds = append(bounds, fmt.Sprintf("(%s AND value %s ?)", valueType, operators[operator]))
+				*args = append(*args, value)
+			}
+			predicates = append(predicates, strings.Join(bounds, " AND "))
+		default:
+			options, ok := filterOptions(want)
+			if !ok {
+				options = []interface{}{want}
+			}
+			for _, option := range options {
+				predicates = append(predicates, "value = ?")
+				*args = append(*args, sqliteFilterValue(option))
+			}
+		}
+		if len(predicates) == 0 {
+			// An empty any-of list matches nothing
+			conditions = append(conditions, "0")
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(metadata, '$.%s') WHERE %s)",
+			strings.ReplaceAll(string(quotedKey), "'", "''"), strings.Join(predicates, " OR ")))
+	}
+	return conditions, nil
+}
+
+// sqliteFilterValue converts a filter value to the form json_each reports, where booleans are 0 or 1
+func sqliteFilterValue(value interface{}) interface{} {
+	if b, ok := value.(bool); ok {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	return pgFilterValue(value)
+}
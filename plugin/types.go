@@ -3,6 +3,8 @@ package plugin
 import (
 	"time"
 
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 )
@@ -10,55 +12,90 @@ import (
 // Core request/response types for agentic RAG flow
 
 // AgenticRAGRequest represents a request for the agentic RAG flow
+//
+// Deprecated: external callers should use v1.Request, whose JSON encoding is covered by the
+// v1 compatibility guarantees; this type tracks the pipeline and may change between releases.
 type AgenticRAGRequest struct {
-	Query     string            `json:"query" jsonschema_description:"The user's query or question"`
-	Documents []string          `json:"documents,omitempty" jsonschema_description:"Documents to process (URLs, file paths, or raw text)"`
-	Options   AgenticRAGOptions `json:"options,omitempty" jsonschema_description:"Processing options"`
+	Query        string            `json:"query" jsonschema_description:"The user's query or question"`
+	Documents    []string          `json:"documents,omitempty" jsonschema_description:"Documents to process (URLs, file paths, or raw text)"`
+	UserID       string            `json:"user_id,omitempty" jsonschema_description:"Identity of the caller, recorded in the audit log"`
+	TenantID     string            `json:"tenant_id,omitempty" jsonschema_description:"Tenant of the caller, used for data residency routing"`
+	SessionID    string            `json:"session_id,omitempty" jsonschema_description:"Conversation session the query and answer are recorded in"`
+	CheckpointID string            `json:"checkpoint_id,omitempty" jsonschema_description:"Saves intermediate state under this ID and resumes from it if present"`
+	ResumeToken  string            `json:"resume_token,omitempty" jsonschema_description:"Continues an interrupted answer stream instead of starting a new request"`
+	ResumeAfter  int               `json:"resume_after,omitempty" jsonschema_description:"Sequence number of the last stream chunk received before the interruption"`
+	Filters      domain.Filters    `json:"filters,omitempty" jsonschema_description:"Restricts retrieval to chunks whose metadata matches every entry: a value, a list of allowed values, or an object of gt/gte/lt/lte bounds"`
+	Options      AgenticRAGOptions `json:"options,omitempty" jsonschema_description:"Processing options"`
 }
 
 // AgenticRAGOptions contains processing options
 type AgenticRAGOptions struct {
-	MaxChunks              int     `json:"max_chunks,omitempty" jsonschema_description:"Maximum number of chunks to process (default: 20)"`
-	RecursiveDepth         int     `json:"recursive_depth,omitempty" jsonschema_description:"Maximum recursive processing depth (default: 3)"`
-	EnableKnowledgeGraph   bool    `json:"enable_knowledge_graph,omitempty" jsonschema_description:"Whether to build knowledge graph"`
-	EnableFactVerification bool    `json:"enable_fact_verification,omitempty" jsonschema_description:"Whether to verify facts in response"`
-	Temperature            float32 `json:"temperature,omitempty" jsonschema_description:"Temperature for generation (default: 0.7)"`
+	Profile                    string                 `json:"profile,omitempty" jsonschema_description:"Pipeline preset: fast, balanced, or thorough"`
+	ChunkSize                  int                    `json:"chunk_size,omitempty" jsonschema_description:"Maximum chunk size in model tokens, or characters when so configured (default: 256)"`
+	Chunker                    string                 `json:"chunker,omitempty" jsonschema_description:"Chunker to use: sentence, token, semantic, markdown, recursive, or a configured custom chunker"`
+	Retrieval                  string                 `json:"retrieval,omitempty" jsonschema_description:"Retrieval mode: standard, small_to_big (score small chunks, generate from their parents), embedding (score only the top-k chunks by embedding similarity), hybrid (top-k by fused BM25 and embedding rankings), or hyde (top-k by similarity to a generated hypothetical answer)"`
+	MaxChunks                  int                    `json:"max_chunks,omitempty" jsonschema_description:"Maximum number of chunks to process (default: 20)"`
+	RecursiveDepth             int                    `json:"recursive_depth,omitempty" jsonschema_description:"Maximum recursive processing depth (default: 3)"`
+	MMRLambda                  *float64               `json:"mmr_lambda,omitempty" jsonschema_description:"Select up to max_chunks final chunks by maximal marginal relevance: 1 favors relevance, 0 favors diversity (default: disabled)"`
+	EnableKnowledgeGraph       bool                   `json:"enable_knowledge_graph,omitempty" jsonschema_description:"Whether to build knowledge graph"`
+	EnableFactVerification     bool                   `json:"enable_fact_verification,omitempty" jsonschema_description:"Whether to verify facts in response"`
+	EnableCitationVerification bool                   `json:"enable_citation_verification,omitempty" jsonschema_description:"Whether to check that cited chunks support the citing sentences"`
+	Temperature                float32                `json:"temperature,omitempty" jsonschema_description:"Temperature for generation (default: 0.7)"`
+	Generation                 *GenerationParams      `json:"generation,omitempty" jsonschema_description:"Advanced sampling parameters (top_p, top_k, penalties, stop sequences, safety settings)"`
+	Persona                    string                 `json:"persona,omitempty" jsonschema_description:"Answer style profile (e.g. technical_writer, support_agent, executive_summary)"`
+	OutputFormat               string                 `json:"output_format,omitempty" jsonschema_description:"Render the answer with citations as markdown, html, or text"`
+	Blocklist                  *BlocklistConfig       `json:"blocklist,omitempty" jsonschema_description:"Documents to exclude from retrieval and citation for this request"`
+	MetadataFilter             map[string]interface{} `json:"metadata_filter,omitempty" jsonschema_description:"Only retrieve documents whose metadata equals these values (a list matches any of its values)"`
+	Collections                []string               `json:"collections,omitempty" jsonschema_description:"Named collections to search instead of routing automatically"`
+	CommercialUse              bool                   `json:"commercial_use,omitempty" jsonschema_description:"Exclude sources whose license forbids commercial use"`
+	SignAnswer                 bool                   `json:"sign_answer,omitempty" jsonschema_description:"Return a signed bundle proving the answer's provenance"`
+	Priority                   string                 `json:"priority,omitempty" jsonschema_description:"Admission priority: interactive (default) or batch"`
+	IncludeEmbeddings          bool                   `json:"include_embeddings,omitempty" jsonschema_description:"Return chunk embeddings (or URLs to them) with the relevant chunks"`
+	EntityMode                 bool                   `json:"entity_mode,omitempty" jsonschema_description:"Answer queries naming a knowledge graph entity with an entity card built from its subgraph and source chunks"`
 }
 
 // AgenticRAGResponse represents the response from agentic RAG flow
+//
+// Deprecated: external callers should use v1.Response, whose JSON encoding is covered by the
+// v1 compatibility guarantees; this type tracks the pipeline and may change between releases.
 type AgenticRAGResponse struct {
 	Answer             string             `json:"answer" jsonschema_description:"The generated answer"`
+	FormattedAnswer    string             `json:"formatted_answer,omitempty" jsonschema_description:"The answer rendered in the requested output format"`
+	Citations          []Citation         `json:"citations,omitempty" jsonschema_description:"Sources cited in the answer"`
+	CitationChecks     []CitationCheck    `json:"citation_checks,omitempty" jsonschema_description:"Per-sentence citation verification results if enabled"`
+	Highlights         []Highlight        `json:"highlights,omitempty" jsonschema_description:"Supporting span of each cited chunk in its original document"`
+	Bundle             *AnswerBundle      `json:"bundle,omitempty" jsonschema_description:"Signed provenance bundle if requested"`
 	RelevantChunks     []ProcessedChunk   `json:"relevant_chunks" jsonschema_description:"Chunks used to generate answer"`
 	KnowledgeGraph     *KnowledgeGraph    `json:"knowledge_graph,omitempty" jsonschema_description:"Knowledge graph if enabled"`
+	EntityCard         *EntityCard        `json:"entity_card,omitempty" jsonschema_description:"Entity card the answer was assembled from in entity mode"`
 	FactVerification   *FactVerification  `json:"fact_verification,omitempty" jsonschema_description:"Fact verification results if enabled"`
 	ProcessingMetadata ProcessingMetadata `json:"processing_metadata" jsonschema_description:"Processing metadata"`
 }
 
 // Document represents a document to be processed
-type Document struct {
-	ID       string                 `json:"id"`
-	Content  string                 `json:"content"`
-	Source   string                 `json:"source"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
-}
+type Document = domain.Document
 
 // DocumentChunk represents a chunk of a document
 type DocumentChunk struct {
-	ID             string  `json:"id"`
-	Content        string  `json:"content"`
-	DocumentID     string  `json:"document_id"`
-	ChunkIndex     int     `json:"chunk_index"`
-	StartIndex     int     `json:"start_index"`
-	EndIndex       int     `json:"end_index"`
-	RelevanceScore float64 `json:"relevance_score,omitempty"`
+	ID             string                 `json:"id"`
+	Content        string                 `json:"content"`
+	DocumentID     string                 `json:"document_id"`
+	ChunkIndex     int                    `json:"chunk_index"`
+	StartIndex     int                    `json:"start_index"`
+	EndIndex       int                    `json:"end_index"`
+	RelevanceScore float64                `json:"relevance_score,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // ProcessedChunk represents a chunk that has been processed and scored
 type ProcessedChunk struct {
-	Chunk     DocumentChunk          `json:"chunk"`
-	Entities  []Entity               `json:"entities,omitempty"`
-	Relations []Relation             `json:"relations,omitempty"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Chunk          DocumentChunk          `json:"chunk"`
+	Entities       []Entity               `json:"entities,omitempty"`
+	Relations      []Relation             `json:"relations,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Embedding      []float32              `json:"embedding,omitempty"`       // Chunk embedding if requested
+	EmbeddingURL   string                 `json:"embedding_url,omitempty"`   // URL of the stored embedding when an embedding store is configured
+	EmbeddingModel string                 `json:"embedding_model,omitempty"` // Embedder that produced the embedding
 }
 
 // Entity represents an extracted entity
@@ -90,7 +127,7 @@ type KnowledgeGraph struct {
 // FactVerification represents fact verification results
 type FactVerification struct {
 	Claims   []Claim                `json:"claims"`
-	Overall  string                 `json:"overall"` // "verified", "partially_verified", "unverified"
+	Overall  string                 `json:"overall"` // "verified", "partially_verified", "unverified", or "skipped" when verification failed
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -104,22 +141,87 @@ type Claim struct {
 
 // ProcessingMetadata contains metadata about the processing
 type ProcessingMetadata struct {
-	ProcessingTime  time.Duration `json:"processing_time"`
-	ChunksProcessed int           `json:"chunks_processed"`
-	RecursiveLevels int           `json:"recursive_levels"`
-	ModelCalls      int           `json:"model_calls"`
-	TokensUsed      int           `json:"tokens_used"`
+	ProcessingTime     time.Duration              `json:"processing_time"`
+	ChunksProcessed    int                        `json:"chunks_processed"`
+	RecursiveLevels    int                        `json:"recursive_levels"`
+	ModelCalls         int                        `json:"model_calls"`
+	TokensUsed         int                        `json:"tokens_used"`
+	QueryNormalization *QueryNormalization        `json:"query_normalization,omitempty"`
+	ExamplesUsed       int                        `json:"examples_used,omitempty"`
+	SourceConflicts    []SourceConflict           `json:"source_conflicts,omitempty"`
+	PinnedChunks       int                        `json:"pinned_chunks,omitempty"`
+	ExcludedDocuments  int                        `json:"excluded_documents,omitempty"`
+	Routing            *RoutingDecision           `json:"routing,omitempty"`
+	CollectionRouting  *CollectionRoutingDecision `json:"collection_routing,omitempty"`
+	SecretFindings     []SecretFinding            `json:"secret_findings,omitempty"`
+	Degraded           bool                       `json:"degraded,omitempty"`     // Set when load shedding ran a cheaper pipeline
+	Cached             bool                       `json:"cached,omitempty"`       // Set when the answer was served from the answer cache
+	FAQEntryID         string                     `json:"faq_entry_id,omitempty"` // FAQ entry the answer was served from
+	Degradations       []DegradationEvent         `json:"degradations,omitempty"` // Subsystem failures the request continued past
+	Canary             *CanaryAssignment          `json:"canary,omitempty"`       // Variant that served the request while a canary runs
+	Partial            bool                       `json:"partial,omitempty"`      // Set when sources timed out and the answer uses the rest
+	TimedOutSources    []string                   `json:"timed_out_sources,omitempty"`
 }
 
 // AgenticRAGConfig contains configuration for the agentic RAG system
 type AgenticRAGConfig struct {
-	Genkit           *genkit.Genkit         `json:"-"`          // GenKit instance (not serialized)
-	Model            ai.Model               `json:"-"`          // Model instance (not serialized)
-	ModelName        string                 `json:"model_name"` // Model name for serialization
-	Processing       ProcessingConfig       `json:"processing"`
-	KnowledgeGraph   KnowledgeGraphConfig   `json:"knowledge_graph"`
-	FactVerification FactVerificationConfig `json:"fact_verification"`
-	Prompts          PromptsConfig          `json:"prompts"`
+	Genkit               *genkit.Genkit              `json:"-"`                          // GenKit instance (not serialized)
+	Model                ai.Model                    `json:"-"`                          // Model instance (not serialized)
+	ModelName            string                      `json:"model_name"`                 // Model name for serialization
+	EmbedderName         string                      `json:"embedder_name,omitempty"`    // Default embedder ("provider/name") for similarity search
+	Embedders            map[string]domain.Embedder  `json:"-"`                          // Embedders by "provider/name", registered with GenKit at plugin initialization (not serialized)
+	OpenAIEmbedders      []OpenAIEmbedderConfig      `json:"openai_embedders,omitempty"` // OpenAI embedders registered as "openai/<name>" at plugin initialization
+	ExampleBank          *ExampleBank                `json:"-"`                          // Few-shot demonstrations (not serialized)
+	Overrides            *RetrievalOverrides         `json:"-"`                          // Pinned content and static document boosts (not serialized)
+	Metrics              *Metrics                    `json:"-"`                          // Counters and gauges for monitoring (not serialized)
+	MetricsExport        MetricsConfig               `json:"metrics"`
+	Providers            *ProviderManager            `json:"-"` // Region-aware model endpoints and residency rules (not serialized)
+	Sessions             *SessionStore               `json:"-"` // Recorded conversation sessions (not serialized)
+	ToolHistory          ToolHistoryStore            `json:"-"` // Persisted tool executions (not serialized)
+	Checkpoints          CheckpointStore             `json:"-"` // Saved intermediate pipeline state for resuming requests (not serialized)
+	Tokenizer            Tokenizer                   `json:"-"` // Counts model tokens for chunk sizes; approximate when unset (not serialized)
+	Chunkers             map[string]Chunker          `json:"-"` // Custom chunkers by name, selectable like the built-in ones (not serialized)
+	ToolSandbox          ToolSandboxConfig           `json:"tool_sandbox"`
+	GenerationTools      GenerationToolsConfig       `json:"generation_tools"`
+	MCPServers           []MCPServerConfig           `json:"mcp_servers,omitempty"` // External MCP servers whose tools are imported at startup
+	Processing           ProcessingConfig            `json:"processing"`
+	Admission            AdmissionConfig             `json:"admission"`
+	Loading              LoaderConfig                `json:"loading"`
+	SemanticChunking     SemanticChunkingConfig      `json:"semantic_chunking"`
+	TableChunking        TableChunkingConfig         `json:"table_chunking"`
+	Hierarchical         HierarchicalChunkingConfig  `json:"hierarchical"`
+	VectorRetrieval      VectorRetrievalConfig       `json:"vector_retrieval"`
+	HybridSearch         HybridSearchConfig          `json:"hybrid_search"`
+	AnswerCache          AnswerCacheConfig           `json:"answer_cache"`
+	FAQ                  FAQConfig                   `json:"faq"`
+	Degradation          DegradationConfig           `json:"degradation"`
+	VectorStore          domain.VectorStore          `json:"-"` // Stores chunk embeddings for embedding retrieval; in-memory search when unset (not serialized)
+	VectorStores         VectorStoresConfig          `json:"vector_store"`
+	Loaders              *domain.LoaderRegistry      `json:"-"` // Custom document loaders, tried before the built-in ones (not serialized)
+	Analysis             AnalysisConfig              `json:"analysis"`
+	QueryNormalization   QueryNormalizationConfig    `json:"query_normalization"`
+	Routing              RoutingConfig               `json:"routing"`
+	Collections          map[string]CollectionConfig `json:"collections,omitempty"` // Named document collections
+	CollectionRouting    CollectionRoutingConfig     `json:"collection_routing"`
+	KnowledgeGraph       KnowledgeGraphConfig        `json:"knowledge_graph"`
+	FactVerification     FactVerificationConfig      `json:"fact_verification"`
+	Credibility          CredibilityConfig           `json:"credibility"`
+	Freshness            FreshnessConfig             `json:"freshness"`
+	CitationVerification CitationVerificationConfig  `json:"citation_verification"`
+	Blocklist            *BlocklistConfig            `json:"blocklist,omitempty"` // Content never retrieved or cited
+	SecretScanning       SecretScanningConfig        `json:"secret_scanning"`
+	Licensing            LicensingConfig             `json:"licensing"`
+	FewShot              FewShotConfig               `json:"few_shot"`
+	HealthCheck          HealthCheckConfig           `json:"health_check"`
+	Admin                AdminConfig                 `json:"admin"`
+	Audit                AuditConfig                 `json:"audit"`
+	Signing              SigningConfig               `json:"signing"`
+	ChunkEmbeddings      ChunkEmbeddingConfig        `json:"chunk_embeddings"`
+	Personas             map[string]PersonaConfig    `json:"personas,omitempty"`
+	Profiles             map[string]PipelineProfile  `json:"profiles,omitempty"`
+	Canary               CanaryConfig                `json:"canary"`
+	Streaming            StreamingConfig             `json:"streaming"`
+	Prompts              PromptsConfig               `json:"prompts"`
 }
 
 // ModelConfig contains model configuration
@@ -133,10 +235,21 @@ type ModelConfig struct {
 
 // ProcessingConfig contains processing configuration
 type ProcessingConfig struct {
-	DefaultChunkSize      int  `json:"default_chunk_size"`
-	DefaultMaxChunks      int  `json:"default_max_chunks"`
-	DefaultRecursiveDepth int  `json:"default_recursive_depth"`
-	RespectSentences      bool `json:"respect_sentences"`
+	DefaultChunkSize      int             `json:"default_chunk_size"`
+	DefaultMaxChunks      int             `json:"default_max_chunks"`
+	DefaultRecursiveDepth int             `json:"default_recursive_depth"`
+	RespectSentences      bool            `json:"respect_sentences"`
+	ChunkUnit             string          `json:"chunk_unit"`           // ChunkUnitTokens (default) or ChunkUnitCharacters
+	Chunker               string          `json:"chunker"`              // Default chunker: sentence, token, semantic, markdown (default), recursive, or a custom one
+	Separators            []string        `json:"separators"`           // Separators tried in order by the recursive chunker ("" splits characters)
+	Retrieval             string          `json:"retrieval"`            // Default retrieval mode: standard (default), small_to_big, embedding, hybrid, or hyde
+	ContextWindow         int             `json:"context_window"`       // Model context window in tokens; relevance scoring is batched to fit (default: 32768)
+	Concurrency           int             `json:"concurrency"`          // Scoring batches, embedding batches, and sources loaded at once per request (default: 4)
+	EmbeddingBatchSize    int             `json:"embedding_batch_size"` // Texts per embedder call (default: 100)
+	EmbeddingCacheSize    int             `json:"embedding_cache_size"` // Embeddings memoized in memory, least recently used evicted first (default: 10000)
+	GenerationReserve     time.Duration   `json:"generation_reserve"`   // Time kept before the request deadline for answering; sources still loading then are skipped (default: 10s)
+	Reranking             RerankingConfig `json:"reranking"`
+	Scoring               ScoringConfig   `json:"scoring"`
 }
 
 // KnowledgeGraphConfig contains knowledge graph configuration
@@ -161,6 +274,7 @@ type PromptsConfig struct {
 	ResponseGenerationPrompt  string            `json:"response_generation_prompt"`  // Name of response generation prompt
 	KnowledgeExtractionPrompt string            `json:"knowledge_extraction_prompt"` // Name of knowledge extraction prompt
 	FactVerificationPrompt    string            `json:"fact_verification_prompt"`    // Name of fact verification prompt
+	RerankingPrompt           string            `json:"reranking_prompt"`            // Name of reranking prompt
 	Variants                  map[string]string `json:"variants,omitempty"`          // Prompt variants for A/B testing
 	CustomHelpers             bool              `json:"custom_helpers"`              // Whether to register custom helpers
 }
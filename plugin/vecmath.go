@@ -0,0 +1,29 @@
+package plugin
+
+import "math"
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if they are incomparable
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	dot, normA, normB := dotNorms(a, b)
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// dotNormsGeneric returns the dot product of two equal-length vectors and their squared norms,
+// accumulated in float64
+func dotNormsGeneric(a, b []float32) (dot, normA, normB float64) {
+	b = b[:len(a)]
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+	}
+	return dot, normA, normB
+}
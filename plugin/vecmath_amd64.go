@@ -0,0 +1,31 @@
+//go:build amd64 && !purego
+
+package plugin
+
+import "golang.org/x/sys/cpu"
+
+// useAVX2 selects the assembly kernel on CPUs with AVX2 and FMA
+var useAVX2 = cpu.X86.HasAVX2 && cpu.X86.HasFMA
+
+// dotNormsAVX2 computes dotNorms over the first n elements, where n is a multiple of 4
+//
+//go:noescape
+func dotNormsAVX2(a, b *float32, n int) (dot, normA, normB float64)
+
+// dotNorms returns the dot product of two equal-length vectors and their squared norms
+func dotNorms(a, b []float32) (dot, normA, normB float64) {
+	if !useAVX2 || len(a) < 8 {
+		return dotNormsGeneric(a, b)
+	}
+	b = b[:len(a)]
+
+	n := len(a) &^ 3
+	dot, normA, normB = dotNormsAVX2(&a[0], &b[0], n)
+	for i := n; i < len(a); i++ {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+	}
+	return dot, normA, normB
+}
@@ -0,0 +1,70 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func dotNormsAVX2(a, b *float32, n int) (dot, normA, normB float64)
+//
+// Widens four float32 lanes at a time to float64 and accumulates a·b, a·a, and b·b with FMA,
+// eight elements per iteration in two accumulator sets. n must be a multiple of 4.
+TEXT ·dotNormsAVX2(SB), NOSPLIT, $0-48
+	MOVQ a+0(FP), SI
+	MOVQ b+8(FP), DI
+	MOVQ n+16(FP), CX
+
+	VXORPD Y0, Y0, Y0 // dot
+	VXORPD Y1, Y1, Y1 // normA
+	VXORPD Y2, Y2, Y2 // normB
+	VXORPD Y3, Y3, Y3 // dot, second set
+	VXORPD Y4, Y4, Y4 // normA, second set
+	VXORPD Y5, Y5, Y5 // normB, second set
+	XORQ   AX, AX
+
+loop8:
+	MOVQ CX, DX
+	SUBQ AX, DX
+	CMPQ DX, $8
+	JL   tail4
+
+	VCVTPS2PD   (SI)(AX*4), Y6
+	VCVTPS2PD   (DI)(AX*4), Y7
+	VCVTPS2PD   16(SI)(AX*4), Y8
+	VCVTPS2PD   16(DI)(AX*4), Y9
+	VFMADD231PD Y7, Y6, Y0
+	VFMADD231PD Y6, Y6, Y1
+	VFMADD231PD Y7, Y7, Y2
+	VFMADD231PD Y9, Y8, Y3
+	VFMADD231PD Y8, Y8, Y4
+	VFMADD231PD Y9, Y9, Y5
+	ADDQ        $8, AX
+	JMP         loop8
+
+tail4:
+	CMPQ DX, $4
+	JL   reduce
+
+	VCVTPS2PD   (SI)(AX*4), Y6
+	VCVTPS2PD   (DI)(AX*4), Y7
+	VFMADD231PD Y7, Y6, Y0
+	VFMADD231PD Y6, Y6, Y1
+	VFMADD231PD Y7, Y7, Y2
+
+reduce:
+	VADDPD Y3, Y0, Y0
+	VADDPD Y4, Y1, Y1
+	VADDPD Y5, Y2, Y2
+
+	VEXTRACTF128 $1, Y0, X6
+	VADDPD       X6, X0, X0
+	VHADDPD      X0, X0, X0
+	VEXTRACTF128 $1, Y1, X6
+	VADDPD       X6, X1, X1
+	VHADDPD      X1, X1, X1
+	VEXTRACTF128 $1, Y2, X6
+	VADDPD       X6, X2, X2
+	VHADDPD      X2, X2, X2
+
+	VMOVSD X0, dot+24(FP)
+	VMOVSD X1, normA+32(FP)
+	VMOVSD X2, normB+40(FP)
+	VZEROUPPER
+	RET
@@ -0,0 +1,8 @@
+//go:build !amd64 || purego
+
+package plugin
+
+// dotNorms returns the dot product of two equal-length vectors and their squared norms
+func dotNorms(a, b []float32) (dot, normA, normB float64) {
+	return dotNormsGeneric(a, b)
+}
@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// randomVector returns a vector of n values in [-1, 1)
+func randomVector(rng *rand.Rand, n int) []float32 {
+	v := make([]float32, n)
+	for i := range v {
+		v[i] = rng.Float32()*2 - 1
+	}
+	return v
+}
+
+// closeTo reports whether got matches want up to float64 rounding differences from summing in
+// another order, relative to the magnitude of the summed terms
+func closeTo(got, want, magnitude float64) bool {
+	return math.Abs(got-want) <= 1e-9*math.Max(magnitude, 1)
+}
+
+// TestDotNormsMatchesGeneric compares the dispatched kernel, assembly where available, with the
+// generic loop for every length up to several vector widths, covering the scalar tails
+func TestDotNormsMatchesGeneric(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n <= 67; n++ {
+		a, b := randomVector(rng, n), randomVector(rng, n)
+		dot, normA, normB := dotNorms(a, b)
+		wantDot, wantNormA, wantNormB := dotNormsGeneric(a, b)
+		magnitude := wantNormA + wantNormB
+		if !closeTo(dot, wantDot, magnitude) || !closeTo(normA, wantNormA, magnitude) || !closeTo(normB, wantNormB, magnitude) {
+			t.Errorf("n=%d: got (%v, %v, %v), want (%v, %v, %v)", n, dot, normA, normB, wantDot, wantNormA, wantNormB)
+		}
+	}
+}
+
+// TestDotNormsLongerSecondVector checks that both paths read only the first vector's length of the second
+func TestDotNormsLongerSecondVector(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range []int{3, 8, 13, 768} {
+		a, b := randomVector(rng, n), randomVector(rng, n+5)
+		dot, _, normB := dotNorms(a, b)
+		wantDot, _, wantNormB := dotNormsGeneric(a, b[:n])
+		if !closeTo(dot, wantDot, float64(n)) || !closeTo(normB, wantNormB, float64(n)) {
+			t.Errorf("n=%d: got (%v, %v), want (%v, %v)", n, dot, normB, wantDot, wantNormB)
+		}
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	v := randomVector(rng, 1536)
+	negated := make([]float32, len(v))
+	for i := range v {
+		negated[i] = -v[i]
+	}
+
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"empty", nil, nil, 0},
+		{"mismatched lengths", []float32{1, 0, 0}, []float32{1, 0}, 0},
+		{"mismatched lengths reversed", v[:9], v[:10], 0},
+		{"zero vector", make([]float32, 16), v[:16], 0},
+		{"identical", v, v, 1},
+		{"opposite", v, negated, -1},
+		{"orthogonal", []float32{1, 0, 0, 0, 0, 0, 0, 0, 0}, []float32{0, 1, 0, 0, 0, 0, 0, 0, 0}, 0},
+		{"tail only", []float32{0, 0, 0, 0, 0, 0, 0, 0, 3}, []float32{0, 0, 0, 0, 0, 0, 0, 0, 5}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); !closeTo(got, tt.want, 1) {
+				t.Errorf("cosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkDotNorms measures a kernel on the dimensions of common embedding models
+func benchmarkDotNorms(b *testing.B, kernel func(a, b []float32) (float64, float64, float64)) {
+	rng := rand.New(rand.NewSource(4))
+	for _, n := range []int{768, 1536} {
+		x, y := randomVector(rng, n), randomVector(rng, n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.SetBytes(int64(8 * n))
+			for i := 0; i < b.N; i++ {
+				kernel(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkDotNorms(b *testing.B) {
+	benchmarkDotNorms(b, dotNorms)
+}
+
+func BenchmarkDotNormsGeneric(b *testing.B) {
+	benchmarkDotNorms(b, dotNormsGeneric)
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// collectionName matches vector store collection names, which become part of table names
+var collectionName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// collectionTable returns the table holding a collection's records: the base table for the default
+// collection, otherwise the base table name suffixed with the collection
+func collectionTable(table, collection string) (string, error) {
+	if collection == "" {
+		return table, nil
+	}
+	if !collectionName.MatchString(collection) {
+		return "", fmt.Errorf("invalid vector store collection %q", collection)
+	}
+	return table + "_" + collection, nil
+}
+
+// schemaInit runs a store's schema setup once, retrying on the next use after a failure, so
+// collection views can create their tables on first use
+type schemaInit struct {
+	mu   sync.Mutex
+	done bool
+}
+
+// ensure runs setup unless it already succeeded
+func (i *schemaInit) ensure(ctx context.Context, setup func(ctx context.Context) error) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.done {
+		return nil
+	}
+	if err := setup(ctx); err != nil {
+		return err
+	}
+	i.done = true
+	return nil
+}
+
+// invalidVectorStore is returned for a collection that cannot be opened; every operation fails
+type invalidVectorStore struct {
+	err error
+}
+
+func (s invalidVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	return s.err
+}
+
+func (s invalidVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	return nil, s.err
+}
+
+func (s invalidVectorStore) Delete(ctx context.Context, ids []string) error {
+	return s.err
+}
+
+func (s invalidVectorStore) Prune(ctx context.Context) (int, error) {
+	return 0, s.err
+}
+
+func (s invalidVectorStore) WithCollection(collection string) domain.VectorStore {
+	return s
+}
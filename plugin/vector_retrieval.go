@@ -0,0 +1,459 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// VectorRetrievalConfig contains configuration for the embedding retrieval mode
+type VectorRetrievalConfig struct {
+	TopK                int     `json:"top_k"`                   // Candidates passed on to model relevance scoring
+	SimilarityThreshold float64 `json:"similarity_threshold"`    // Minimum cosine similarity of a candidate to the query; 0 keeps all. Not applied to store hybrid search scores
+	EmbedderName        string  `json:"embedder_name,omitempty"` // Embedder override; defaults to the query language's embedder
+}
+
+// Vector store types that can be opened from configuration
+const (
+	VectorStorePgVector      = "pgvector"      // PostgreSQL + pgvector, see PgVectorStore
+	VectorStoreMemory        = "memory"        // In-process store, see MemoryVectorStore
+	VectorStoreTurso         = "turso"         // Remote Turso/libSQL database, see TursoVectorStore
+	VectorStoreLocal         = "local"         // Local libSQL database file, see NewLocalTursoVectorStore
+	VectorStoreWeaviate      = "weaviate"      // Weaviate instance, see WeaviateVectorStore
+	VectorStoreMilvus        = "milvus"        // Milvus or Zilliz Cloud instance, see MilvusVectorStore
+	VectorStoreElasticsearch = "elasticsearch" // Elasticsearch or OpenSearch cluster, see ElasticsearchVectorStore
+)
+
+// VectorStoresConfig contains named vector stores opened from configuration
+type VectorStoresConfig struct {
+	Default string                       `json:"default"`          // Store used by embedding retrieval when VectorStore is unset
+	Stores  map[string]VectorStoreConfig `json:"stores,omitempty"` // Stores by name
+}
+
+// VectorStoreConfig configures a vector store opened from configuration
+type VectorStoreConfig struct {
+	Type          string                 `json:"type"`                 // One of the VectorStore types
+	Driver        string                 `json:"driver"`               // database/sql driver registered by the application, e.g. "pgx" or "libsql"
+	DSN           string                 `json:"dsn"`                  // Connection string of database-backed stores
+	Path          string                 `json:"path,omitempty"`       // Database file of a local store
+	Collection    string                 `json:"collection,omitempty"` // Collection of the store holding this index; the default collection when empty
+	PgVector      PgVectorConfig         `json:"pgvector"`
+	Turso         TursoConfig            `json:"turso"` // Schema of turso and local stores
+	Weaviate      WeaviateConfig         `json:"weaviate"`
+	Milvus        MilvusConfig           `json:"milvus"`
+	Elasticsearch ElasticsearchConfig    `json:"elasticsearch"`
+	Cache         VectorStoreCacheConfig `json:"cache"` // Read-through cache of hot records in front of the store
+}
+
+// defaultVectorStore returns the store embedding retrieval searches, or nil to search in memory
+func (p *AgenticRAGProcessor) defaultVectorStore(ctx context.Context) (domain.VectorStore, error) {
+	if p.config.VectorStore != nil {
+		return p.config.VectorStore, nil
+	}
+	if p.config.VectorStores.Default == "" {
+		return nil, nil
+	}
+	return p.namedVectorStore(ctx, p.config.VectorStores.Default)
+}
+
+// namedVectorStore returns a configured store, opening it on first use. Stores that fail to open
+// are retried on the next call.
+func (p *AgenticRAGProcessor) namedVectorStore(ctx context.Context, name string) (domain.VectorStore, error) {
+	p.vectorStoresMu.Lock()
+	defer p.vectorStoresMu.Unlock()
+
+	if store, ok := p.vectorStores[name]; ok {
+		return store, nil
+	}
+	config, ok := p.config.VectorStores.Stores[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown vector store %q", name)
+	}
+
+	var store domain.VectorStore
+	switch config.Type {
+	case VectorStorePgVector:
+		db, err := sql.Open(config.Driver, config.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		pgStore, err := NewPgVectorStore(ctx, db, config.PgVector)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		store = pgStore
+	case VectorStoreMemory:
+		store = NewMemoryVectorStore()
+	case VectorStoreTurso:
+		db, err := sql.Open(config.Driver, config.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		tursoStore, err := NewTursoVectorStore(ctx, db, config.Turso)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		store = tursoStore
+	case VectorStoreLocal:
+		localStore, err := NewLocalTursoVectorStore(ctx, config.Driver, config.Path, config.Turso)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		store = localStore
+	case VectorStoreWeaviate:
+		weaviateStore, err := NewWeaviateVectorStore(ctx, config.Weaviate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		store = weaviateStore
+	case VectorStoreMilvus:
+		milvusStore, err := NewMilvusVectorStore(ctx, config.Milvus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		store = milvusStore
+	case VectorStoreElasticsearch:
+		elasticStore, err := NewElasticsearchVectorStore(ctx, config.Elasticsearch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vector store %q: %w", name, err)
+		}
+		store = elasticStore
+	default:
+		return nil, fmt.Errorf("vector store %q has unsupported type %q", name, config.Type)
+	}
+	if config.Collection != "" {
+		store = store.WithCollection(config.Collection)
+	}
+	if config.Cache.Enabled {
+		store = NewCachedVectorStore(store, config.Cache)
+	}
+	p.vectorStores[name] = store
+	return store, nil
+}
+
+// retrieveCandidates returns the configured top-k chunks by embedding similarity, so only those are
+// scored by the model
+func (p *AgenticRAGProcessor) retrieveCandidates(ctx context.Context, query string, chunks []DocumentChunk, filters domain.Filters) ([]DocumentChunk, error) {
+	return p.vectorSearch(ctx, query, chunks, p.vectorTopK(), filters, "")
+}
+
+// vectorTopK returns the number of candidates embedding and hybrid retrieval pass on to scoring
+func (p *AgenticRAGProcessor) vectorTopK() int {
+	if p.config.VectorRetrieval.TopK <= 0 {
+		return 20
+	}
+	return p.config.VectorRetrieval.TopK
+}
+
+// vectorSearch embeds the query and chunks and returns the topK chunks most similar to the query,
+// dropping those below the configured similarity threshold.
+// With a vector store configured the chunks are indexed in it and the search runs against the
+// store, which may also return chunks indexed by earlier requests of the same tenant that match the
+// filters and pass the request's blocklist, license, and metadata filter. A
+// non-empty keywordQuery runs a hybrid search instead on stores implementing domain.HybridSearcher,
+// whose fused scores are not cosine similarities and so are not held to the threshold.
+func (p *AgenticRAGProcessor) vectorSearch(ctx context.Context, query string, chunks []DocumentChunk, topK int, filters domain.Filters, keywordQuery string) ([]DocumentChunk, error) {
+	embedderName, err := p.retrievalEmbedder(query)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, len(chunks)+1)
+	texts[0] = query
+	for i, chunk := range chunks {
+		texts[i+1] = chunk.Content
+	}
+	embeddings, err := p.cachedEmbeddings(ctx, embedderName, texts)
+	if err != nil {
+		return nil, err
+	}
+	queryEmbedding, chunkEmbeddings := embeddings[0], embeddings[1:]
+
+	store, err := p.defaultVectorStore(ctx)
+	if err == nil && store == nil {
+		return p.aboveSimilarityThreshold(rankBySimilarity(queryEmbedding, chunks, chunkEmbeddings, topK)), nil
+	}
+	var candidates []DocumentChunk
+	if err == nil {
+		candidates, err = p.searchVectorStore(ctx, store, embedderName, queryEmbedding, chunks, chunkEmbeddings, topK, filters, keywordQuery)
+		if err == nil && searchesHybrid(store, keywordQuery) {
+			return candidates, nil
+		}
+	}
+	// A mismatched embedder is a configuration error rather than an outage, so it is not degraded
+	var mismatch *domain.EmbeddingMismatchError
+	if errors.As(err, &mismatch) {
+		return nil, err
+	}
+	if err != nil {
+		// Fall back to searching the request's chunks in memory when the policy allows it
+		if err := p.degrade(ctx, SubsystemVectorStore, err); err != nil {
+			return nil, err
+		}
+		candidates = rankBySimilarity(queryEmbedding, chunks, chunkEmbeddings, topK)
+	}
+	return p.aboveSimilarityThreshold(candidates), nil
+}
+
+// retrievalEmbedder returns the embedder for vector retrieval of the query
+func (p *AgenticRAGProcessor) retrievalEmbedder(query string) (string, error) {
+	embedderName := p.config.VectorRetrieval.EmbedderName
+	if embedderName == "" {
+		embedderName = p.embedderNameFor(p.detectLanguage(query))
+	}
+	if embedderName == "" {
+		return "", fmt.Errorf("no embedder configured")
+	}
+	return embedderName, nil
+}
+
+// aboveSimilarityThreshold drops ranked candidates less similar to the query than the configured threshold
+func (p *AgenticRAGProcessor) aboveSimilarityThreshold(candidates []DocumentChunk) []DocumentChunk {
+	threshold := p.config.VectorRetrieval.SimilarityThreshold
+	for i, candidate := range candidates {
+		if candidate.RelevanceScore < threshold {
+			return candidates[:i]
+		}
+	}
+	return candidates
+}
+
+// rankBySimilarity returns the topK chunks most similar to the query embedding
+func rankBySimilarity(queryEmbedding []float32, chunks []DocumentChunk, chunkEmbeddings [][]float32, topK int) []DocumentChunk {
+	candidates := make([]DocumentChunk, len(chunks))
+	for i, chunk := range chunks {
+		chunk.RelevanceScore = cosineSimilarity(queryEmbedding, chunkEmbeddings[i])
+		candidates[i] = chunk
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].RelevanceScore > candidates[j].RelevanceScore
+	})
+	if len(candidates) > topK {
+		candidates = candidates[:topK]
+	}
+	return candidates
+}
+
+// searchVectorStore indexes the chunks in the vector store, then searches it with the filters
+// pushed down, by hybrid search when a keyword query is given and the store supports it. The
+// search is limited to the request's tenant, and records indexed by earlier requests are held to
+// the request's blocklist, license, and metadata filter like its own documents.
+func (p *AgenticRAGProcessor) searchVectorStore(ctx context.Context, store domain.VectorStore, embedderName string, queryEmbedding []float32, chunks []DocumentChunk, chunkEmbeddings [][]float32, topK int, filters domain.Filters, keywordQuery string) ([]DocumentChunk, error) {
+	ctx = withEmbedder(ctx, embedderName, len(queryEmbedding))
+	scope := retrievalScopeFrom(ctx)
+	records := make([]domain.VectorRecord, len(chunks))
+	indexed := make(map[string]DocumentChunk, len(chunks))
+	startTime := time.Now()
+	for i, chunk := range chunks {
+		records[i] = vectorRecord(embedderName, scope.TenantID, scope.Sources[chunk.DocumentID], chunk, chunkEmbeddings[i])
+		if updatedAt, ok := p.chunkDate(chunk); ok {
+			records[i].UpdatedAt = updatedAt
+		}
+		if expiresAt, ok := chunkExpiry(chunk, startTime); ok {
+			records[i].ExpiresAt = expiresAt
+		}
+		indexed[records[i].ID] = chunk
+	}
+	startTime = time.Now()
+	err := store.Store(ctx, records)
+	p.observeVectorStore(ctx, "store", err, startTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store chunk embeddings: %w", err)
+	}
+
+	scoped := make(domain.Filters, len(filters)+1)
+	for key, value := range filters {
+		scoped[key] = value
+	}
+	scoped[tenantMetadataKey] = scope.TenantID
+	filters = scoped
+
+	startTime = time.Now()
+	var results []domain.SearchResult
+	if searchesHybrid(store, keywordQuery) {
+		results, err = store.(domain.HybridSearcher).HybridSearch(ctx, keywordQuery, queryEmbedding, topK, filters)
+		p.observeVectorStore(ctx, "hybrid_search", err, startTime)
+	} else {
+		results, err = store.Search(ctx, queryEmbedding, topK, filters)
+		p.observeVectorStore(ctx, "search", err, startTime)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vector store: %w", err)
+	}
+	candidates := make([]DocumentChunk, 0, len(results))
+	for _, result := range results {
+		chunk, ok := indexed[result.Record.ID]
+		if !ok {
+			if !p.admitsRecord(scope, result.Record) {
+				continue
+			}
+			chunk = chunkFromRecord(result.Record)
+		}
+		chunk.RelevanceScore = result.Score
+		candidates = append(candidates, chunk)
+	}
+	return candidates, nil
+}
+
+// searchesHybrid reports whether searchVectorStore runs a hybrid search on the store
+func searchesHybrid(store domain.VectorStore, keywordQuery string) bool {
+	_, ok := store.(domain.HybridSearcher)
+	return ok && keywordQuery != ""
+}
+
+// chunkExpiry returns when a chunk's record expires, from an "expires_at" date or a "ttl" in
+// document metadata, given as a duration string such as "72h" or a number of seconds
+func chunkExpiry(chunk DocumentChunk, now time.Time) (time.Time, bool) {
+	switch value := chunk.Metadata["expires_at"].(type) {
+	case time.Time:
+		return value, true
+	case string:
+		for _, layout := range sourceDateLayouts {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	if value, ok := chunk.Metadata["ttl"].(string); ok {
+		if ttl, err := time.ParseDuration(value); err == nil && ttl > 0 {
+			return now.Add(ttl), true
+		}
+	}
+	if seconds, ok := metadataFloat(chunk.Metadata, "ttl"); ok && seconds > 0 {
+		return now.Add(time.Duration(seconds * float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
+
+// vectorRecord converts a chunk into a vector store record keyed by its tenant, source document,
+// embedder, and content, so identical text from different documents or tenants is kept apart
+func vectorRecord(embedderName, tenantID, source string, chunk DocumentChunk, embedding []float32) domain.VectorRecord {
+	sum := sha256.Sum256([]byte(tenantID + "|" + source + "|" + embedderName + "|" + chunk.Content))
+
+	metadata := make(map[string]interface{}, len(chunk.Metadata)+7)
+	for key, value := range chunk.Metadata {
+		metadata[key] = value
+	}
+	metadata[tenantMetadataKey] = tenantID
+	metadata[sourceMetadataKey] = source
+	metadata["chunk_id"] = chunk.ID
+	metadata["document_id"] = chunk.DocumentID
+	metadata["chunk_index"] = chunk.ChunkIndex
+	metadata["start_index"] = chunk.StartIndex
+	metadata["end_index"] = chunk.EndIndex
+
+	return domain.VectorRecord{
+		ID:        hex.EncodeToString(sum[:]),
+		Content:   chunk.Content,
+		Embedding: embedding,
+		Metadata:  metadata,
+	}
+}
+
+// Record metadata keys identifying who indexed a record and from which document source
+const (
+	tenantMetadataKey = "tenant_id"
+	sourceMetadataKey = "document_source"
+)
+
+// retrievalScope is what a request may retrieve from a shared vector store: its tenant's records
+// that pass its blocklist, license, and metadata filter
+type retrievalScope struct {
+	TenantID       string
+	Sources        map[string]string // Source of each of the request's documents by document ID
+	Blocklist      *BlocklistConfig
+	CommercialUse  bool
+	MetadataFilter map[string]interface{}
+}
+
+// retrievalScopeContextKey is the context key of the request's retrieval scope
+type retrievalScopeContextKey struct{}
+
+// withRetrievalScope attaches the request's retrieval scope to the context
+func withRetrievalScope(ctx context.Context, request AgenticRAGRequest, documents []Document) context.Context {
+	scope := retrievalScope{
+		TenantID:       request.TenantID,
+		Sources:        make(map[string]string, len(documents)),
+		Blocklist:      request.Options.Blocklist,
+		CommercialUse:  request.Options.CommercialUse,
+		MetadataFilter: request.Options.MetadataFilter,
+	}
+	for _, doc := range documents {
+		scope.Sources[doc.ID] = doc.Source
+	}
+	return context.WithValue(ctx, retrievalScopeContextKey{}, scope)
+}
+
+// retrievalScopeFrom returns the context's retrieval scope; without one only records indexed
+// without a tenant are retrieved
+func retrievalScopeFrom(ctx context.Context) retrievalScope {
+	scope, _ := ctx.Value(retrievalScopeContextKey{}).(retrievalScope)
+	return scope
+}
+
+// admitsRecord reports whether a record indexed by another request passes the request's blocklist,
+// license, and metadata filter. Document IDs are only unique within a request, so blocklisted
+// document IDs do not apply to it.
+func (p *AgenticRAGProcessor) admitsRecord(scope retrievalScope, record domain.VectorRecord) bool {
+	documents := []Document{{Source: metadataString(record.Metadata, sourceMetadataKey), Metadata: record.Metadata}}
+	documents, _ = p.filterBlockedDocuments(documents, scope.Blocklist)
+	documents, _ = p.filterLicensedDocuments(documents, scope.CommercialUse)
+	documents, _ = filterDocumentsByMetadata(documents, scope.MetadataFilter)
+	return len(documents) == 1
+}
+
+// filterChunks returns the chunks whose metadata matches the filters
+func filterChunks(chunks []DocumentChunk, filters domain.Filters) []DocumentChunk {
+	if len(filters) == 0 {
+		return chunks
+	}
+	matching := make([]DocumentChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if filters.Matches(chunk.Metadata) {
+			matching = append(matching, chunk)
+		}
+	}
+	return matching
+}
+
+// storedDocumentPrefix marks the document IDs of chunks retrieved from records indexed by earlier
+// requests, which are not among the request's documents
+const storedDocumentPrefix = "store:"
+
+// isStoredDocument reports whether a document ID belongs to a chunk from an earlier request
+func isStoredDocument(documentID string) bool {
+	return strings.HasPrefix(documentID, storedDocumentPrefix)
+}
+
+// chunkFromRecord converts a vector store record indexed by an earlier request back into a chunk.
+// Chunk and document IDs are only unique within one request, so the record ID is used as the chunk
+// ID and, namespaced, as the document ID; the original document ID stays in the metadata. The
+// record's update time becomes the chunk's updated_at metadata for recency scoring.
+func chunkFromRecord(record domain.VectorRecord) DocumentChunk {
+	metadata := make(map[string]interface{}, len(record.Metadata)+1)
+	for key, value := range record.Metadata {
+		metadata[key] = value
+	}
+	if _, ok := metadata["updated_at"]; !ok && !record.UpdatedAt.IsZero() {
+		metadata["updated_at"] = record.UpdatedAt
+	}
+	return DocumentChunk{
+		ID:         record.ID,
+		Content:    record.Content,
+		DocumentID: storedDocumentPrefix + record.ID,
+		ChunkIndex: metadataInt(record.Metadata, "chunk_index"),
+		StartIndex: metadataInt(record.Metadata, "start_index"),
+		EndIndex:   metadataInt(record.Metadata, "end_index"),
+		Metadata:   metadata,
+	}
+}
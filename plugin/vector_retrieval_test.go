@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStoredChunkKeepsDocumentsApart retrieves a chunk stored by an earlier request's doc_0
+// alongside the current request's doc_0, and checks that the stored chunk is neither mistaken for
+// the current document nor highlighted in its text
+func TestStoredChunkKeepsDocumentsApart(t *testing.T) {
+	p := NewAgenticRAGProcessor(DefaultConfig())
+	store := NewMemoryVectorStore()
+	const embedder = "test/embedder"
+
+	earlier := Document{ID: "doc_0", Source: "earlier.txt", Content: "Paris is the capital of France."}
+	earlierChunk := DocumentChunk{ID: "doc_0_chunk_0", DocumentID: "doc_0", Content: earlier.Content, EndIndex: len(earlier.Content), Metadata: map[string]interface{}{}}
+	ctx := withRetrievalScope(context.Background(), AgenticRAGRequest{Query: "capital"}, []Document{earlier})
+	if _, err := p.searchVectorStore(ctx, store, embedder, []float32{1, 0}, []DocumentChunk{earlierChunk}, [][]float32{{1, 0}}, 5, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	current := Document{ID: "doc_0", Source: "current.txt", Content: "Berlin is the capital of Germany."}
+	currentChunk := DocumentChunk{ID: "doc_0_chunk_0", DocumentID: "doc_0", Content: current.Content, EndIndex: len(current.Content), Metadata: map[string]interface{}{}}
+	ctx = withRetrievalScope(context.Background(), AgenticRAGRequest{Query: "capital"}, []Document{current})
+	chunks, err := p.searchVectorStore(ctx, store, embedder, []float32{1, 1}, []DocumentChunk{currentChunk}, [][]float32{{0, 1}}, 5, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want the current and the stored one", len(chunks))
+	}
+
+	var own, stored DocumentChunk
+	for _, chunk := range chunks {
+		if chunk.Content == current.Content {
+			own = chunk
+		} else {
+			stored = chunk
+		}
+	}
+	if own.DocumentID != "doc_0" {
+		t.Errorf("current chunk document ID = %q, want doc_0", own.DocumentID)
+	}
+	if !isStoredDocument(stored.DocumentID) || stored.ID == own.ID {
+		t.Errorf("stored chunk IDs = %q/%q, want a namespaced document ID and a distinct chunk ID", stored.DocumentID, stored.ID)
+	}
+	if got := metadataString(stored.Metadata, "document_id"); got != "doc_0" {
+		t.Errorf("stored chunk metadata document_id = %q, want the original doc_0", got)
+	}
+
+	ordered := []DocumentChunk{stored, own}
+	citations := []Citation{
+		{Number: 1, ChunkID: stored.ID, DocumentID: stored.DocumentID},
+		{Number: 2, ChunkID: own.ID, DocumentID: own.DocumentID},
+	}
+	highlights := p.extractHighlights("Paris is the capital [1]. Berlin is the capital [2].", citations, ordered, []Document{current})
+	if len(highlights) != 1 || highlights[0].Number != 2 || highlights[0].Text != current.Content {
+		t.Errorf("highlights = %+v, want only the current document's sentence for citation 2", highlights)
+	}
+
+	// Without a source to locate it in, a stored chunk's sub-chunks keep its own span
+	long := stored
+	long.Content = "Paris is the capital of France. It lies on the Seine."
+	for _, sub := range p.breakdownChunk(long, "") {
+		if sub.StartIndex != long.StartIndex || sub.EndIndex != long.EndIndex || sub.DocumentID != stored.DocumentID {
+			t.Errorf("sub-chunk %q spans %d-%d of %q", sub.Content, sub.StartIndex, sub.EndIndex, sub.DocumentID)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package plugin
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// VectorStoreCacheConfig contains configuration for the read-through cache in front of a vector store
+type VectorStoreCacheConfig struct {
+	Enabled     bool          `json:"enabled"`
+	MaxRecords  int           `json:"max_records"`  // Records (content and metadata) kept (default: 10000)
+	MaxSearches int           `json:"max_searches"` // Search results kept as record IDs and scores (default: 1000)
+	TTL         time.Duration `json:"ttl"`          // How long entries are served, bounding staleness from other writers (default: 5m)
+}
+
+// VectorStoreCacheStats counts the cache's contents and lookups
+type VectorStoreCacheStats struct {
+	Records  int    `json:"records"`
+	Searches int    `json:"searches"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+}
+
+// CachedVectorStore is a read-through LRU cache of recently stored and retrieved records in front of
+// a vector store, kept without their embeddings. A repeated search whose records are all cached is
+// answered without a round trip.
+// Storing a record not cached before invalidates every cached search, since it may rank in any of
+// them; storing a cached record again and deleting records invalidate only the searches holding them.
+type CachedVectorStore struct {
+	store  domain.VectorStore
+	config VectorStoreCacheConfig
+
+	mu       sync.Mutex
+	records  *lruCache[string, domain.VectorRecord]
+	searches *lruCache[string, []cachedHit]
+	hits     uint64
+	misses   uint64
+}
+
+// cachedHit is a search result kept by record ID
+type cachedHit struct {
+	id    string
+	score float64
+}
+
+// cachedHybridVectorStore is a CachedVectorStore in front of a store that also searches hybrid
+type cachedHybridVectorStore struct {
+	*CachedVectorStore
+}
+
+// NewCachedVectorStore puts a read-through cache in front of a store. The cached store is a
+// domain.HybridSearcher when the store is.
+func NewCachedVectorStore(store domain.VectorStore, config VectorStoreCacheConfig) domain.VectorStore {
+	cached := newCachedVectorStore(store, config)
+	if _, ok := store.(domain.HybridSearcher); ok {
+		return cachedHybridVectorStore{cached}
+	}
+	return cached
+}
+
+// newCachedVectorStore creates the cache with defaults applied
+func newCachedVectorStore(store domain.VectorStore, config VectorStoreCacheConfig) *CachedVectorStore {
+	if config.MaxRecords <= 0 {
+		config.MaxRecords = 10000
+	}
+	if config.MaxSearches <= 0 {
+		config.MaxSearches = 1000
+	}
+	if config.TTL <= 0 {
+		config.TTL = 5 * time.Minute
+	}
+	return &CachedVectorStore{
+		store:    store,
+		config:   config,
+		records:  newLRUCache[string, domain.VectorRecord](config.MaxRecords, config.TTL),
+		searches: newLRUCache[string, []cachedHit](config.MaxSearches, config.TTL),
+	}
+}
+
+// WithCollection returns a cached view of the collection with its own cache
+func (s *CachedVectorStore) WithCollection(collection string) domain.VectorStore {
+	return NewCachedVectorStore(s.store.WithCollection(collection), s.config)
+}
+
+// WithCollection returns a cached view of the collection with its own cache
+func (s cachedHybridVectorStore) WithCollection(collection string) domain.VectorStore {
+	return s.CachedVectorStore.WithCollection(collection)
+}
+
+// Unwrap returns the store behind the cache
+func (s *CachedVectorStore) Unwrap() domain.VectorStore {
+	return s.store
+}
+
+// CacheStats returns the cache's sizes and hit counts
+func (s *CachedVectorStore) CacheStats() VectorStoreCacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return VectorStoreCacheStats{Records: s.records.len(), Searches: s.searches.len(), Hits: s.hits, Misses: s.misses}
+}
+
+// Store writes the records through to the store and caches them
+func (s *CachedVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if err := s.store.Store(ctx, records); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := make(map[string]bool, len(records))
+	for _, record := range records {
+		if _, ok := s.records.get(record.ID); !ok {
+			s.searches.clear()
+		} else {
+			changed[record.ID] = true
+		}
+		record.Embedding = nil
+		s.records.set(record.ID, record)
+	}
+	s.invalidateSearches(changed)
+	return nil
+}
+
+// Search answers from the cache when the same search was cached and all its records still are,
+// otherwise searches the store and caches the results
+func (s *CachedVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	key, keyed := searchCacheKey(ctx, "", embedding, k, filters)
+	return s.readThrough(key, keyed, func() ([]domain.SearchResult, error) {
+		return s.store.Search(ctx, embedding, k, filters)
+	})
+}
+
+// HybridSearch answers a hybrid search from the cache like Search, keyed by the query as well
+func (s cachedHybridVectorStore) HybridSearch(ctx context.Context, query string, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	key, keyed := searchCacheKey(ctx, query, embedding, k, filters)
+	return s.readThrough(key, keyed, func() ([]domain.SearchResult, error) {
+		return s.store.(domain.HybridSearcher).HybridSearch(ctx, query, embedding, k, filters)
+	})
+}
+
+// readThrough returns the cached search under the key, or runs the search and caches its results
+func (s *CachedVectorStore) readThrough(key string, keyed bool, search func() ([]domain.SearchResult, error)) ([]domain.SearchResult, error) {
+	if keyed {
+		if results, ok := s.cachedSearch(key); ok {
+			return results, nil
+		}
+	}
+
+	results, err := search()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.misses++
+	hits := make([]cachedHit, len(results))
+	for i, result := range results {
+		hits[i] = cachedHit{id: result.Record.ID, score: result.Score}
+		record := result.Record
+		record.Embedding = nil
+		s.records.set(record.ID, record)
+	}
+	if keyed {
+		s.searches.set(key, hits)
+	}
+	return results, nil
+}
+
+// cachedSearch rebuilds a cached search's results from the cached records
+func (s *CachedVectorStore) cachedSearch(key string) ([]domain.SearchResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hits, ok := s.searches.get(key)
+	if !ok {
+		return nil, false
+	}
+	results := make([]domain.SearchResult, len(hits))
+	for i, hit := range hits {
+		record, ok := s.records.get(hit.id)
+		if !ok {
+			return nil, false
+		}
+		results[i] = domain.SearchResult{Record: record, Score: hit.score}
+	}
+	s.hits++
+	return results, true
+}
+
+// Delete deletes the records from the store and the cache
+func (s *CachedVectorStore) Delete(ctx context.Context, ids []string) error {
+	if err := s.store.Delete(ctx, ids); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		s.records.remove(id)
+		deleted[id] = true
+	}
+	s.invalidateSearches(deleted)
+	return nil
+}
+
+// Prune prunes the store. Pruned records are not known by ID, so the whole cache is dropped.
+func (s *CachedVectorStore) Prune(ctx context.Context) (int, error) {
+	pruned, err := s.store.Prune(ctx)
+	if pruned > 0 {
+		s.mu.Lock()
+		s.records.clear()
+		s.searches.clear()
+		s.mu.Unlock()
+	}
+	return pruned, err
+}
+
+// invalidateSearches drops the cached searches holding any of the records; callers hold mu
+func (s *CachedVectorStore) invalidateSearches(ids map[string]bool) {
+	if len(ids) == 0 {
+		return
+	}
+	s.searches.removeIf(func(hits []cachedHit) bool {
+		for _, hit := range hits {
+			if ids[hit.id] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// searchCacheKey hashes a search's declared embedder, keyword query, embedding, k, and filters, so
+// searches declaring another embedder miss rather than bypass the store's binding check. Filters
+// that cannot be encoded are not cached.
+func searchCacheKey(ctx context.Context, query string, embedding []float32, k int, filters domain.Filters) (string, bool) {
+	encodedFilters, err := json.Marshal(filters)
+	if err != nil {
+		return "", false
+	}
+	hash := sha256.New()
+	if declared, ok := domain.EmbeddingBindingFrom(ctx); ok {
+		hash.Write([]byte(declared.Embedder))
+	}
+	hash.Write([]byte{0})
+	hash.Write([]byte(query))
+	hash.Write([]byte{0})
+	buffer := make([]byte, 4)
+	for _, value := range embedding {
+		binary.LittleEndian.PutUint32(buffer, math.Float32bits(value))
+		hash.Write(buffer)
+	}
+	binary.LittleEndian.PutUint32(buffer, uint32(k))
+	hash.Write(buffer)
+	hash.Write(encodedFilters)
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// lruCache is a size-bounded map evicting the least recently used entries, whose entries expire
+// after a TTL unless it is zero. It is not safe for concurrent use.
+type lruCache[K comparable, V any] struct {
+	maxSize int
+	ttl     time.Duration
+	order   *list.List // Most recently used first
+	entries map[K]*list.Element
+}
+
+// lruEntry is a cached value with its key and expiry
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// newLRUCache creates an empty cache
+func newLRUCache[K comparable, V any](maxSize int, ttl time.Duration) *lruCache[K, V] {
+	return &lruCache[K, V]{maxSize: maxSize, ttl: ttl, order: list.New(), entries: make(map[K]*list.Element)}
+}
+
+// get returns an unexpired value and marks it recently used
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	entry := element.Value.(*lruEntry[K, V])
+	if c.ttl > 0 && time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set stores a value, evicting the least recently used entry when full
+func (c *lruCache[K, V]) set(key K, value V) {
+	entry := &lruEntry[K, V]{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+// remove drops a key
+func (c *lruCache[K, V]) remove(key K) {
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// removeIf drops the entries whose values match
+func (c *lruCache[K, V]) removeIf(match func(V) bool) {
+	for key, element := range c.entries {
+		if match(element.Value.(*lruEntry[K, V]).value) {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// clear drops every entry
+func (c *lruCache[K, V]) clear() {
+	c.order.Init()
+	clear(c.entries)
+}
+
+// len returns the number of entries, including expired ones not yet evicted
+func (c *lruCache[K, V]) len() int {
+	return c.order.Len()
+}
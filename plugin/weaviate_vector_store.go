@@ -0,0 +1,598 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZanzyTHEbar/genkit-agentic-rag/pkg/domain"
+)
+
+// WeaviateConfig contains configuration for a Weaviate vector store
+type WeaviateConfig struct {
+	URL       string        `json:"url"`                   // Base URL of the Weaviate instance, e.g. http://localhost:8080
+	Class     string        `json:"class"`                 // Collection class holding the records (default: VectorRecord)
+	APIKeyEnv string        `json:"api_key_env,omitempty"` // Environment variable holding the API key, if authentication is enabled
+	Alpha     float64       `json:"alpha"`                 // Weight of the vector ranking against BM25 in hybrid search, from 0 to 1 (default: 0.75)
+	BatchSize int           `json:"batch_size"`            // Records per batch import request (default: 100)
+	Timeout   time.Duration `json:"timeout"`               // Timeout of each request (default: 30s)
+}
+
+// WeaviateVectorStore stores records as objects of a Weaviate class with client-supplied vectors.
+// Metadata is kept whole as JSON and also flattened into meta_-prefixed properties, typed by
+// Weaviate's auto-schema, which filters are translated into where filters on. Metadata keys that
+// are not valid property names are stored but cannot be filtered on.
+type WeaviateVectorStore struct {
+	client *http.Client
+	config WeaviateConfig
+	schema *schemaInit
+	guard  *embeddingGuard
+}
+
+// weaviateClassName matches class names, which Weaviate requires to start with a capital letter
+var weaviateClassName = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+// weaviateMetadataPrefix prefixes the flattened metadata properties, keeping them apart from the
+// record's own properties
+const weaviateMetadataPrefix = "meta_"
+
+// NewWeaviateVectorStore creates the store's class unless it already exists
+func NewWeaviateVectorStore(ctx context.Context, config WeaviateConfig) (*WeaviateVectorStore, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("weaviate URL is required")
+	}
+	if config.Class == "" {
+		config.Class = "VectorRecord"
+	}
+	if config.Alpha <= 0 || config.Alpha > 1 {
+		config.Alpha = 0.75
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	if !weaviateClassName.MatchString(config.Class) {
+		return nil, fmt.Errorf("invalid weaviate class name %q", config.Class)
+	}
+
+	store := &WeaviateVectorStore{client: &http.Client{Timeout: config.Timeout}, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+	if err := store.schema.ensure(ctx, store.bootstrap); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// WithCollection returns a view of the store keeping the collection's records in their own class,
+// named after the base class and the collection and created on first use
+func (s *WeaviateVectorStore) WithCollection(collection string) domain.VectorStore {
+	class, err := collectionTable(s.config.Class, collection)
+	if err != nil {
+		return invalidVectorStore{err: err}
+	}
+	config := s.config
+	config.Class = class
+	return &WeaviateVectorStore{client: s.client, config: config, schema: &schemaInit{}, guard: &embeddingGuard{}}
+}
+
+// bootstrap creates the class with the record properties unless it exists. Metadata properties
+// are added by auto-schema as records carrying them are imported.
+func (s *WeaviateVectorStore) bootstrap(ctx context.Context) error {
+	status, err := s.do(ctx, http.MethodGet, "/v1/schema/"+s.config.Class, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read weaviate schema: %w", err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	class := map[string]interface{}{
+		"class":             s.config.Class,
+		"vectorizer":        "none",
+		"vectorIndexConfig": map[string]interface{}{"distance": "cosine"},
+		"properties": []map[string]interface{}{
+			{"name": "record_id", "dataType": []string{"text"}, "tokenization": "field", "indexSearchable": false},
+			{"name": "content", "dataType": []string{"text"}},
+			{"name": "metadata", "dataType": []string{"text"}, "indexFilterable": false, "indexSearchable": false},
+			{"name": "updated_at", "dataType": []string{"date"}},
+			{"name": "expires_at", "dataType": []string{"date"}},
+		},
+	}
+	status, err = s.do(ctx, http.MethodPost, "/v1/schema", class, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create weaviate class %s: %w", s.config.Class, err)
+	}
+	if status == http.StatusUnprocessableEntity {
+		// Another process created the class first
+		if status, err = s.do(ctx, http.MethodGet, "/v1/schema/"+s.config.Class, nil, nil); err == nil && status == http.StatusOK {
+			return nil
+		}
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to create weaviate class %s: status %d", s.config.Class, status)
+	}
+	return nil
+}
+
+// weaviateBindingDescription is the class description holding the class's embedding binding, since
+// Weaviate classes have no other place for custom metadata
+type weaviateBindingDescription struct {
+	Embedding *domain.EmbeddingBinding `json:"embedding"`
+}
+
+// BindEmbedding binds the class to the embedding binding unless it is already bound, keeping the
+// binding as JSON in the class description
+func (s *WeaviateVectorStore) BindEmbedding(ctx context.Context, binding domain.EmbeddingBinding) (domain.EmbeddingBinding, error) {
+	class, bound, ok, err := s.classBinding(ctx)
+	if err != nil || ok {
+		return bound, err
+	}
+
+	description, err := json.Marshal(weaviateBindingDescription{Embedding: &binding})
+	if err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to encode embedding binding: %w", err)
+	}
+	class["description"] = string(description)
+	status, err := s.do(ctx, http.MethodPut, "/v1/schema/"+s.config.Class, class, nil)
+	if err != nil {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: %w", err)
+	}
+	if status != http.StatusOK {
+		return domain.EmbeddingBinding{}, fmt.Errorf("failed to store embedding binding: status %d", status)
+	}
+	// Another process may have bound the class concurrently; the last update wins
+	bound, _, err = s.embeddingBinding(ctx)
+	return bound, err
+}
+
+// embeddingBinding returns the class's binding, if it is bound
+func (s *WeaviateVectorStore) embeddingBinding(ctx context.Context) (domain.EmbeddingBinding, bool, error) {
+	_, bound, ok, err := s.classBinding(ctx)
+	return bound, ok, err
+}
+
+// classBinding returns the class definition and the binding in its description, if any
+func (s *WeaviateVectorStore) classBinding(ctx context.Context) (map[string]interface{}, domain.EmbeddingBinding, bool, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return nil, domain.EmbeddingBinding{}, false, err
+	}
+	var class map[string]interface{}
+	status, err := s.do(ctx, http.MethodGet, "/v1/schema/"+s.config.Class, nil, &class)
+	if err != nil {
+		return nil, domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, domain.EmbeddingBinding{}, false, fmt.Errorf("failed to read embedding binding: status %d", status)
+	}
+	description, _ := class["description"].(string)
+	var decoded weaviateBindingDescription
+	if json.Unmarshal([]byte(description), &decoded) != nil || decoded.Embedding == nil {
+		return class, domain.EmbeddingBinding{}, false, nil
+	}
+	return class, *decoded.Embedding, true, nil
+}
+
+// Store imports records in batches, replacing existing records with the same ID
+func (s *WeaviateVectorStore) Store(ctx context.Context, records []domain.VectorRecord) error {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return err
+	}
+	if err := s.guard.checkRecords(ctx, s, records); err != nil {
+		return err
+	}
+	for start := 0; start < len(records); start += s.config.BatchSize {
+		end := min(start+s.config.BatchSize, len(records))
+		objects := make([]map[string]interface{}, 0, end-start)
+		for _, record := range records[start:end] {
+			object, err := s.object(record)
+			if err != nil {
+				return err
+			}
+			objects = append(objects, object)
+		}
+
+		var results []struct {
+			ID     string `json:"id"`
+			Result struct {
+				Errors *struct {
+					Error []struct {
+						Message string `json:"message"`
+					} `json:"error"`
+				} `json:"errors"`
+			} `json:"result"`
+		}
+		status, err := s.do(ctx, http.MethodPost, "/v1/batch/objects", map[string]interface{}{"objects": objects}, &results)
+		if err != nil {
+			return fmt.Errorf("failed to store records: %w", err)
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("failed to store records: status %d", status)
+		}
+		for _, result := range results {
+			if result.Result.Errors != nil && len(result.Result.Errors.Error) > 0 {
+				return fmt.Errorf("failed to store object %s: %s", result.ID, result.Result.Errors.Error[0].Message)
+			}
+		}
+	}
+	return nil
+}
+
+// object converts a record into a Weaviate object with its metadata flattened into properties
+func (s *WeaviateVectorStore) object(record domain.VectorRecord) (map[string]interface{}, error) {
+	metadata := record.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata of record %s: %w", record.ID, err)
+	}
+
+	properties := map[string]interface{}{
+		"record_id": record.ID,
+		"content":   record.Content,
+		"metadata":  string(encoded),
+	}
+	if !record.UpdatedAt.IsZero() {
+		properties["updated_at"] = record.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if !record.ExpiresAt.IsZero() {
+		properties["expires_at"] = record.ExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	for key, value := range metadata {
+		if value, ok := weaviatePropertyValue(value); ok && pgIdentifier.MatchString(key) {
+			properties[weaviateMetadataPrefix+key] = value
+		}
+	}
+	return map[string]interface{}{
+		"class":      s.config.Class,
+		"id":         weaviateUUID(record.ID),
+		"vector":     record.Embedding,
+		"properties": properties,
+	}, nil
+}
+
+// Search returns the k nearest records matching the filters, scored by cosine similarity
+func (s *WeaviateVectorStore) Search(ctx context.Context, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	return s.search(ctx, fmt.Sprintf("nearVector: {vector: %s}", graphqlValue(embedding)), k, filters, false)
+}
+
+// HybridSearch returns the k records ranking best by Weaviate's fusion of BM25 over the content
+// with vector similarity, weighted by the configured alpha. Scores are the fused scores from 0 to 1
+// rather than cosine similarities.
+func (s *WeaviateVectorStore) HybridSearch(ctx context.Context, query string, embedding []float32, k int, filters domain.Filters) ([]domain.SearchResult, error) {
+	if err := s.guard.check(ctx, s, len(embedding)); err != nil {
+		return nil, err
+	}
+	operator := fmt.Sprintf("hybrid: {query: %s, vector: %s, alpha: %s, properties: [\"content\"], fusionType: relativeScoreFusion}",
+		graphqlValue(query), graphqlValue(embedding), strconv.FormatFloat(s.config.Alpha, 'g', -1, 64))
+	return s.search(ctx, operator, k, filters, true)
+}
+
+// search runs a Get query with the search operator and filters, reading the fused score of hybrid
+// queries and the distance of vector queries
+func (s *WeaviateVectorStore) search(ctx context.Context, operator string, k int, filters domain.Filters, hybrid bool) ([]domain.SearchResult, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return nil, err
+	}
+	where, empty, err := weaviateWhere(filters)
+	if err != nil {
+		return nil, err
+	}
+	if empty {
+		return []domain.SearchResult{}, nil
+	}
+	arguments := []string{operator, "limit: " + strconv.Itoa(k)}
+	if where != nil {
+		arguments = append(arguments, "where: "+graphqlValue(where))
+	}
+	additional := "distance"
+	if hybrid {
+		additional = "score"
+	}
+	query := fmt.Sprintf("{ Get { %s(%s) { record_id content metadata updated_at _additional { %s } } } }",
+		s.config.Class, strings.Join(arguments, ", "), additional)
+
+	var response struct {
+		Data struct {
+			Get map[string][]struct {
+				RecordID   string `json:"record_id"`
+				Content    string `json:"content"`
+				Metadata   string `json:"metadata"`
+				UpdatedAt  string `json:"updated_at"`
+				Additional struct {
+					Distance *float64 `json:"distance"`
+					Score    string   `json:"score"`
+				} `json:"_additional"`
+			} `json:"Get"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	status, err := s.do(ctx, http.MethodPost, "/v1/graphql", map[string]string{"query": query}, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search records: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to search records: status %d", status)
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("failed to search records: %s", response.Errors[0].Message)
+	}
+
+	objects := response.Data.Get[s.config.Class]
+	results := make([]domain.SearchResult, 0, len(objects))
+	for _, object := range objects {
+		result := domain.SearchResult{Record: domain.VectorRecord{ID: object.RecordID, Content: object.Content}}
+		if object.Metadata != "" {
+			if err := json.Unmarshal([]byte(object.Metadata), &result.Record.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode metadata of record %s: %w", object.RecordID, err)
+			}
+		}
+		if object.UpdatedAt != "" {
+			if updatedAt, err := time.Parse(time.RFC3339Nano, object.UpdatedAt); err == nil {
+				result.Record.UpdatedAt = updatedAt
+			}
+		}
+		switch {
+		case hybrid:
+			result.Score, _ = strconv.ParseFloat(object.Additional.Score, 64)
+		case object.Additional.Distance != nil:
+			result.Score = 1 - *object.Additional.Distance
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs
+func (s *WeaviateVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"match": map[string]interface{}{
+			"class": s.config.Class,
+			"where": map[string]interface{}{"path": []string{"record_id"}, "operator": "ContainsAny", "valueTextArray": ids},
+		},
+	}
+	status, err := s.do(ctx, http.MethodDelete, "/v1/batch/objects", body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("failed to delete records: status %d", status)
+	}
+	return nil
+}
+
+// Prune removes the records whose expiry has passed, up to the server's batch delete limit per call
+func (s *WeaviateVectorStore) Prune(ctx context.Context) (int, error) {
+	if err := s.schema.ensure(ctx, s.bootstrap); err != nil {
+		return 0, err
+	}
+	body := map[string]interface{}{
+		"match": map[string]interface{}{
+			"class": s.config.Class,
+			"where": map[string]interface{}{
+				"path":      []string{"expires_at"},
+				"operator":  "LessThanEqual",
+				"valueDate": time.Now().UTC().Format(time.RFC3339Nano),
+			},
+		},
+	}
+	var response struct {
+		Results struct {
+			Successful int `json:"successful"`
+		} `json:"results"`
+	}
+	status, err := s.do(ctx, http.MethodDelete, "/v1/batch/objects", body, &response)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune records: %w", err)
+	}
+	if status != http.StatusOK {
+		return 0, fmt.Errorf("failed to prune records: status %d", status)
+	}
+	return response.Results.Successful, nil
+}
+
+// do sends a JSON request to the Weaviate API and decodes a successful response into out,
+// returning the status so callers can handle expected failures such as a missing class
+func (s *WeaviateVectorStore) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.config.URL+path, payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.config.APIKeyEnv != "" {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv(s.config.APIKeyEnv))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// weaviateUUID derives the object UUID of a record ID, since Weaviate requires UUID object IDs.
+// It is a name-based (version 5 layout) UUID, so re-importing a record replaces its object.
+func weaviateUUID(id string) string {
+	sum := sha1.Sum([]byte(id))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	encoded := hex.EncodeToString(sum[:16])
+	return encoded[0:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:32]
+}
+
+// weaviatePropertyValue returns a metadata value as a property value: scalars, times as RFC 3339
+// dates, and lists of scalars. Nested objects are only kept in the metadata JSON.
+func weaviatePropertyValue(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string, bool, float32, float64, int, int32, int64:
+		return value, true
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano), true
+	case []string:
+		return value, len(value) > 0
+	case []interface{}:
+		for _, element := range value {
+			switch element.(type) {
+			case string, bool, float64, int:
+			default:
+				return nil, false
+			}
+		}
+		return value, len(value) > 0
+	}
+	return nil, false
+}
+
+// weaviateWhere translates filters into a where filter on the flattened metadata properties. It
+// reports empty for a filter that matches nothing, an empty any-of list, which where filters
+// cannot express.
+func weaviateWhere(filters domain.Filters) (where map[string]interface{}, empty bool, err error) {
+	if err := filters.Validate(); err != nil {
+		return nil, false, fmt.Errorf("invalid filters: %w", err)
+	}
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	operands := make([]interface{}, 0, len(filters))
+	for _, key := range keys {
+		if !pgIdentifier.MatchString(key) {
+			return nil, false, fmt.Errorf("filter key %q is not a valid weaviate property name", key)
+		}
+		path := []string{weaviateMetadataPrefix + key}
+		switch want := filters[key].(type) {
+		case map[string]interface{}:
+			operators := map[string]string{"gt": "GreaterThan", "gte": "GreaterThanEqual", "lt": "LessThan", "lte": "LessThanEqual"}
+			bounds := make([]string, 0, len(want))
+			for operator := range want {
+				bounds = append(bounds, operator)
+			}
+			sort.Strings(bounds)
+			for _, operator := range bounds {
+				operands = append(operands, weaviateCondition(path, operators[operator], want[operator]))
+			}
+		default:
+			options, ok := filterOptions(want)
+			if !ok {
+				operands = append(operands, weaviateCondition(path, "Equal", want))
+				continue
+			}
+			if len(options) == 0 {
+				return nil, true, nil
+			}
+			alternatives := make([]interface{}, len(options))
+			for i, option := range options {
+				alternatives[i] = weaviateCondition(path, "Equal", option)
+			}
+			operands = append(operands, map[string]interface{}{"operator": graphqlEnum("Or"), "operands": alternatives})
+		}
+	}
+
+	switch len(operands) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return operands[0].(map[string]interface{}), false, nil
+	}
+	return map[string]interface{}{"operator": graphqlEnum("And"), "operands": operands}, false, nil
+}
+
+// weaviateCondition compares a property with a value, typed the way auto-schema types the
+// property: RFC 3339 strings and times as dates, other strings as text, and numbers as numbers
+func weaviateCondition(path []string, operator string, value interface{}) map[string]interface{} {
+	condition := map[string]interface{}{"path": path, "operator": graphqlEnum(operator)}
+	switch value := value.(type) {
+	case time.Time:
+		condition["valueDate"] = value.UTC().Format(time.RFC3339Nano)
+	case string:
+		if _, err := time.Parse(time.RFC3339, value); err == nil {
+			condition["valueDate"] = value
+		} else {
+			condition["valueText"] = value
+		}
+	case bool:
+		condition["valueBoolean"] = value
+	default:
+		condition["valueNumber"] = value
+	}
+	return condition
+}
+
+// graphqlEnum is a GraphQL enum value, written unquoted
+type graphqlEnum string
+
+// graphqlValue writes a value as a GraphQL input literal: JSON with unquoted object keys and enums
+func graphqlValue(value interface{}) string {
+	switch value := value.(type) {
+	case graphqlEnum:
+		return string(value)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = key + ": " + graphqlValue(value[key])
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []interface{}:
+		elements := make([]string, len(value))
+		for i, element := range value {
+			elements[i] = graphqlValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "null"
+	}
+	return string(encoded)
+}